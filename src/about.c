@@ -0,0 +1,86 @@
+#define _DEFAULT_SOURCE
+#include "about.h"
+#include "keyhandler.h"
+#include "utils.h"
+#include <SDL3/SDL.h>
+#include <SDL3_image/SDL_image.h>
+#include <SDL3_ttf/SDL_ttf.h>
+#include <stdarg.h>
+#include <stdbool.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
+
+#define ABOUT_MAX 2048
+
+/* Check whether an executable called name is on $PATH */
+static bool in_path(const char *name)
+{
+    const char *path = getenv("PATH");
+    if (!path) return false;
+    char dir[4096];
+    while (*path) {
+        size_t len = strcspn(path, ":");
+        if (len > 0 && len < sizeof(dir) - strlen(name) - 2) {
+            snprintf(dir, sizeof(dir), "%.*s/%s", (int)len, path, name);
+            if (access(dir, X_OK) == 0) return true;
+        }
+        path += len;
+        if (*path == ':') path++;
+    }
+    return false;
+}
+
+static void append(char *buf, size_t *n, const char *fmt, ...)
+{
+    if (*n >= ABOUT_MAX) return;
+    va_list ap;
+    va_start(ap, fmt);
+    int ret = vsnprintf(buf + *n, ABOUT_MAX - *n, fmt, ap);
+    va_end(ap);
+    if (ret > 0) *n += (size_t)ret;
+}
+
+static void append_version(char *buf, size_t *n, const char *name, int version)
+{
+    append(buf, n, "%s: %d.%d.%d\n", name, SDL_VERSIONNUM_MAJOR(version),
+           SDL_VERSIONNUM_MINOR(version), SDL_VERSIONNUM_MICRO(version));
+}
+
+char *about_text(void)
+{
+    char *buf = malloc(ABOUT_MAX);
+    if (!buf) return NULL;
+    size_t n = 0;
+
+    append(buf, &n, "Version: %s\n", FRAME_VERSION);
+    append_version(buf, &n, "SDL", SDL_GetVersion());
+    append_version(buf, &n, "SDL_image", IMG_Version());
+    append_version(buf, &n, "SDL_ttf", TTF_Version());
+    const char *driver = SDL_GetCurrentVideoDriver();
+    append(buf, &n, "Video driver: %s\n", driver ? driver : "none");
+
+#ifdef HAVE_TURBOJPEG
+    append(buf, &n, "JPEG decoder: libjpeg-turbo (scaled decoding)\n");
+#else
+    append(buf, &n, "JPEG decoder: SDL_image (built without libjpeg-turbo)\n");
+#endif
+    append(buf, &n, "EXIF: libexif\n");
+
+    /* Each format once, in table order (.jpg and .jpeg are both JPEG) */
+    append(buf, &n, "Formats:");
+    for (int i = 0; image_formats[i].ext; i++) {
+        bool seen = false;
+        for (int j = 0; j < i && !seen; j++) {
+            seen = strcmp(image_formats[j].name, image_formats[i].name) == 0;
+        }
+        if (!seen) append(buf, &n, "%s %s", i == 0 ? "" : ",", image_formats[i].name);
+    }
+    append(buf, &n, "\n");
+
+    append(buf, &n, "curl (uploads): %s\n", in_path("curl") ? "found" : "not found");
+    append(buf, &n, "Key handler: %s\n", keyhandler_available() ? "installed" : "not installed");
+    if (n >= ABOUT_MAX) buf[ABOUT_MAX - 1] = '\0';
+    return buf;
+}
@@ -0,0 +1,14 @@
+#ifndef FRAME_ABOUT_H
+#define FRAME_ABOUT_H
+
+/* Version when the build system does not pass one */
+#ifndef FRAME_VERSION
+#define FRAME_VERSION "1.3.3"
+#endif
+
+/* Text of the About box: the version, the versions of the libraries Frame
+   runs with, which optional decoders were built in, the supported formats
+   and which external helpers are present. The caller must free it. */
+char *about_text(void);
+
+#endif /* FRAME_ABOUT_H */
@@ -1,5 +1,10 @@
-#define _DEFAULT_SOURCE
+#define _GNU_SOURCE
 #include "app.h"
+#include "config.h"
+#include "netfs.h"
+#include "utils.h"
+#include <ctype.h>
+#include <fnmatch.h>
 #include <stdlib.h>
 #include <string.h>
 #include <strings.h>
@@ -7,22 +12,43 @@
 #include <dirent.h>
 #include <sys/stat.h>
 #include <libgen.h>
-
-/* Supported image extensions for directory scanning */
-static const char *supported_extensions[] = {
-    ".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp",
-    ".tiff", ".tif", ".ico", ".apng", NULL
-};
+#include <pthread.h>
 
 struct AppState {
     char **images;       /* NULL-terminated array of full paths (dynamically allocated) */
     int count;           /* number of entries */
     int current_index;   /* 0-based index of currently displayed image, -1 if none */
     char *initial_path;  /* from CLI, may be NULL */
+    char *dir;           /* directory the list was scanned from, NULL before the first load */
+    char **marked;       /* full paths of marked images (kept across directory loads) */
+    int marked_count;
+    struct ScanJob *scan; /* background scan feeding the list, NULL when idle */
+    bool remote;         /* dir is on a network mount (see netfs.h) */
+    bool follow_first;   /* opened without a target file: stay on the first
+                            image while scan batches arrive, until the user moves */
 };
 
+typedef struct ScanJob ScanJob;
+
+static void cancel_scan(AppState *app);
+
 /* ---- helpers ---- */
 
+/* Find a path in the marked list. Returns its position or -1. */
+static int find_mark(const AppState *app, const char *path) {
+    for (int i = 0; i < app->marked_count; i++) {
+        if (strcmp(app->marked[i], path) == 0) return i;
+    }
+    return -1;
+}
+
+/* Drop the mark at position `pos` (if any). */
+static void remove_mark(AppState *app, int pos) {
+    if (pos < 0) return;
+    free(app->marked[pos]);
+    app->marked[pos] = app->marked[--app->marked_count];
+}
+
 /* Safely extract the directory name from a path.
    dirname() on Linux may modify its argument and return a static buffer,
    so we operate on a strdup'd copy. The caller must free the result. */
@@ -35,20 +61,54 @@ static char *get_dirname(const char *path) {
     return result;
 }
 
-/* Check whether a file path has a supported image extension.
-   Performs case-insensitive comparison. */
-static bool is_supported_extension(const char *path) {
-    if (!path) return false;
-    const char *dot = strrchr(path, '.');
-    if (!dot || dot == path) return false;
+/* Check whether a name without a supported extension may still be an
+   image worth sniffing: one without an extension ("photo") or with a
+   supported one followed by another ("IMG_1.JPG.bak"). Any other extension
+   belongs to some other format, so those files are never opened. */
+static bool may_be_misnamed(const char *name) {
+    const char *last = strrchr(name, '.');
+    if (!last || last == name) return true;
+
+    for (const char *dot = strchr(name + 1, '.'); dot && dot < last; dot = strchr(dot + 1, '.')) {
+        char ext[16];
+        size_t len = strcspn(dot + 1, ".") + 1;
+        if (len >= sizeof(ext)) continue;
+        memcpy(ext, dot, len);
+        ext[len] = '\0';
+        if (image_format_from_ext(ext)) return true;
+    }
+    return false;
+}
 
-    for (int i = 0; supported_extensions[i] != NULL; i++) {
-        if (strcasecmp(dot, supported_extensions[i]) == 0)
-            return true;
+/* Check a file name against the comma-separated glob patterns of the
+   exclude setting (case-insensitive). */
+static bool is_excluded(const char *name, const char *patterns) {
+    const char *p = patterns;
+    while (*p) {
+        while (*p == ',' || isspace((unsigned char)*p)) p++;
+        const char *end = p;
+        while (*end && *end != ',') end++;
+        size_t len = (size_t)(end - p);
+        while (len > 0 && isspace((unsigned char)p[len - 1])) len--;
+
+        char pattern[256];
+        if (len > 0 && len < sizeof(pattern)) {
+            memcpy(pattern, p, len);
+            pattern[len] = '\0';
+            if (fnmatch(pattern, name, FNM_CASEFOLD) == 0) return true;
+        }
+        p = end;
     }
     return false;
 }
 
+/* Apply the hidden-file and exclude settings to a file name. */
+static bool passes_filters(const char *name) {
+    const FrameConfig *cfg = config_get();
+    if (!cfg->show_hidden && name[0] == '.') return false;
+    return !is_excluded(name, cfg->exclude);
+}
+
 /* qsort comparison: standard strcmp for file paths. */
 static int compare_paths(const void *a, const void *b) {
     const char * const *pa = (const char * const *)a;
@@ -56,6 +116,65 @@ static int compare_paths(const void *a, const void *b) {
     return strcmp(*pa, *pb);
 }
 
+/* qsort comparison for directory names */
+static int compare_names(const void *a, const void *b) {
+    return strcmp(*(const char * const *)a, *(const char * const *)b);
+}
+
+/* Check whether a directory contains at least one supported image. */
+static bool dir_has_images(const char *dir) {
+    DIR *dp = opendir(dir);
+    if (!dp) return false;
+
+    bool found = false;
+    struct dirent *entry;
+    while (!found && (entry = readdir(dp)) != NULL) {
+        if (entry->d_type == DT_DIR) continue;
+        found = app_accepts_name(entry->d_name);
+    }
+    closedir(dp);
+    return found;
+}
+
+/* List the visible subdirectory names of `parent`, sorted.
+   Returns a malloc'd array of malloc'd names (NULL if there are none). */
+static char **list_subdirs(const char *parent, int *out_count) {
+    *out_count = 0;
+    DIR *dp = opendir(parent);
+    if (!dp) return NULL;
+
+    char **names = NULL;
+    int count = 0, capacity = 0;
+    struct dirent *entry;
+    while ((entry = readdir(dp)) != NULL) {
+        if (entry->d_name[0] == '.') continue;   /* ".", ".." and hidden folders */
+        if (entry->d_type != DT_DIR) {
+            if (entry->d_type != DT_LNK && entry->d_type != DT_UNKNOWN) continue;
+            char full[4096];
+            struct stat st;
+            snprintf(full, sizeof(full), "%s/%s", parent, entry->d_name);
+            if (stat(full, &st) != 0 || !S_ISDIR(st.st_mode)) continue;
+        }
+
+        if (count >= capacity) {
+            int new_cap = capacity ? capacity * 2 : 32;
+            char **tmp = (char **)realloc(names, (size_t)new_cap * sizeof(char *));
+            if (!tmp) break;
+            names = tmp;
+            capacity = new_cap;
+        }
+        char *name = strdup(entry->d_name);
+        if (name) names[count++] = name;
+    }
+    closedir(dp);
+
+    if (count > 0) {
+        qsort(names, (size_t)count, sizeof(char *), compare_names);
+    }
+    *out_count = count;
+    return names;
+}
+
 /* ---- public API ---- */
 
 AppState *app_create(const char *initial_path) {
@@ -82,7 +201,10 @@ AppState *app_create(const char *initial_path) {
 void app_destroy(AppState *app) {
     if (!app) return;
 
+    cancel_scan(app);
     free(app->initial_path);
+    free(app->dir);
+    app_clear_marks(app);
 
     if (app->images) {
         for (int i = 0; i < app->count; i++) {
@@ -94,48 +216,202 @@ void app_destroy(AppState *app) {
     free(app);
 }
 
-void app_load_directory(AppState *app, const char *path) {
-    if (!app || !path) return;
+/* Resolve a path given to app_load_directory into the folder to scan and,
+   when it names a file, the file to show. Both are malloc'd; *target is
+   NULL for a folder. Returns false (with a message) if the path is unusable. */
+static bool resolve_load_path(const char *path, char **out_dir, char **out_target) {
+    *out_dir = NULL;
+    *out_target = NULL;
 
     /* Resolve to an absolute canonical path so relative paths like "image3.png"
        match the full paths we build during directory scanning. */
     char *resolved = realpath(path, NULL);
     if (!resolved) {
         fprintf(stderr, "app_load_directory: cannot resolve '%s'\n", path);
-        return;
+        return false;
     }
 
     struct stat path_stat;
     if (stat(resolved, &path_stat) != 0) {
         fprintf(stderr, "app_load_directory: cannot stat '%s'\n", resolved);
         free(resolved);
-        return;
+        return false;
     }
 
-    char *dir = NULL;
-    char *target_file = NULL;
-
     if (S_ISDIR(path_stat.st_mode)) {
         /* path is a directory */
-        dir = strdup(resolved);
-        target_file = NULL;
-    } else if (S_ISREG(path_stat.st_mode)) {
+        *out_dir = resolved;
+        return true;
+    }
+    if (S_ISREG(path_stat.st_mode)) {
         /* path is a regular file — extract directory and remember target */
-        dir = get_dirname(resolved);
-        target_file = strdup(resolved);
-        if (!dir || !target_file) {
-            free(dir);
-            free(target_file);
+        *out_dir = get_dirname(resolved);
+        *out_target = resolved;
+        if (!*out_dir) {
             free(resolved);
-            return;
+            *out_target = NULL;
+            return false;
         }
-    } else {
-        fprintf(stderr, "app_load_directory: not a file or directory: '%s'\n", resolved);
-        free(resolved);
-        return;
+        return true;
     }
 
+    fprintf(stderr, "app_load_directory: not a file or directory: '%s'\n", resolved);
     free(resolved);
+    return false;
+}
+
+/* Build the full path of a directory entry if it is a supported image that
+   passes the list settings. `keep` names a file listed whatever the
+   settings say (the one the user opened), may be NULL. In a `remote`
+   folder the name is trusted: files are neither stat'ed nor sniffed, as
+   each would cost a round trip.
+   Returns a malloc'd path or NULL for anything to skip. */
+static char *image_entry_path(const char *dir, const struct dirent *entry, const char *keep,
+                              bool remote) {
+    /* Skip directories and non-relevant file types */
+    if (entry->d_type == DT_DIR) return NULL;
+    if (entry->d_type != DT_REG && entry->d_type != DT_LNK && entry->d_type != DT_UNKNOWN) return NULL;
+
+    const char *name = entry->d_name;
+    bool kept = keep && strcmp(name, keep) == 0;
+
+    /* Check extension, hidden files and exclude patterns; misnamed files
+       are sniffed once the path is known */
+    bool by_name = image_is_supported(name);
+    if (!by_name && (remote || !may_be_misnamed(name))) return NULL;
+    if (!kept && !passes_filters(name)) return NULL;
+
+    /* Build full path: dir + "/" + name */
+    size_t dir_len = strlen(dir);
+    size_t name_len = strlen(name);
+    char *full_path = (char *)malloc(dir_len + 1 + name_len + 1);
+    if (!full_path) return NULL;
+    memcpy(full_path, dir, dir_len);
+    full_path[dir_len] = '/';
+    memcpy(full_path + dir_len + 1, name, name_len + 1);
+
+    /* Double-check via stat only for symlinks and unknown file types */
+    if (!remote && (entry->d_type == DT_LNK || entry->d_type == DT_UNKNOWN)) {
+        struct stat st;
+        bool is_link = entry->d_type == DT_LNK ||
+                       (lstat(full_path, &st) == 0 && S_ISLNK(st.st_mode));
+        if ((is_link && !kept && !config_get()->follow_symlinks) ||
+            stat(full_path, &st) != 0 || !S_ISREG(st.st_mode)) {
+            free(full_path);
+            return NULL;
+        }
+    }
+    if (!by_name && !image_sniff_file(full_path)) {
+        free(full_path);
+        return NULL;
+    }
+    return full_path;
+}
+
+/* Append a path to a growable array. Returns false (path not taken) if
+   memory runs out. */
+static bool append_path(char ***list, int *count, int *capacity, char *path) {
+    if (*count >= *capacity) {
+        int new_cap = *capacity ? *capacity * 2 : 64;
+        char **tmp = (char **)realloc(*list, (size_t)new_cap * sizeof(char *));
+        if (!tmp) return false;
+        *list = tmp;
+        *capacity = new_cap;
+    }
+    (*list)[(*count)++] = path;
+    return true;
+}
+
+/* Free the image list and leave it empty. */
+static void clear_images(AppState *app) {
+    if (app->images) {
+        for (int i = 0; i < app->count; i++) {
+            free(app->images[i]);
+        }
+        free(app->images);
+    }
+    app->images = NULL;
+    app->count = 0;
+    app->current_index = -1;
+}
+
+/* ---- background scanning ---- */
+
+/* A folder being read on a worker thread. Paths pile up in `found` until
+   app_poll_scan() takes them; the list itself is only touched on the main
+   thread. */
+struct ScanJob {
+    pthread_mutex_t mutex;
+    int refs;            /* the worker thread and the AppState */
+    bool cancelled;
+    bool done;
+    bool remote;         /* set before the thread starts */
+    char *dir;
+    char **found;        /* paths not yet merged, unsorted */
+    int found_count;
+    int found_capacity;
+};
+
+static void scan_unref(ScanJob *job) {
+    pthread_mutex_lock(&job->mutex);
+    int refs = --job->refs;
+    pthread_mutex_unlock(&job->mutex);
+    if (refs > 0) return;
+
+    for (int i = 0; i < job->found_count; i++) free(job->found[i]);
+    free(job->found);
+    free(job->dir);
+    pthread_mutex_destroy(&job->mutex);
+    free(job);
+}
+
+static void *scan_thread(void *arg) {
+    ScanJob *job = (ScanJob *)arg;
+
+    DIR *dp = opendir(job->dir);
+    if (!dp) {
+        fprintf(stderr, "app_load_directory: cannot open directory '%s'\n", job->dir);
+    }
+
+    bool cancelled = false;
+    struct dirent *entry;
+    while (dp && !cancelled && (entry = readdir(dp)) != NULL) {
+        char *full_path = image_entry_path(job->dir, entry, NULL, job->remote);
+
+        pthread_mutex_lock(&job->mutex);
+        cancelled = job->cancelled;
+        if (full_path && (cancelled ||
+                          !append_path(&job->found, &job->found_count, &job->found_capacity, full_path))) {
+            free(full_path);
+        }
+        pthread_mutex_unlock(&job->mutex);
+    }
+    if (dp) closedir(dp);
+
+    pthread_mutex_lock(&job->mutex);
+    job->done = true;
+    pthread_mutex_unlock(&job->mutex);
+
+    scan_unref(job);
+    return NULL;
+}
+
+/* Stop a running scan; whatever it has not delivered yet is dropped. */
+static void cancel_scan(AppState *app) {
+    if (!app->scan) return;
+    pthread_mutex_lock(&app->scan->mutex);
+    app->scan->cancelled = true;
+    pthread_mutex_unlock(&app->scan->mutex);
+    scan_unref(app->scan);
+    app->scan = NULL;
+}
+
+void app_load_directory(AppState *app, const char *path) {
+    if (!app || !path) return;
+
+    char *dir = NULL;
+    char *target_file = NULL;
+    if (!resolve_load_path(path, &dir, &target_file)) return;
 
     /* Scan the directory */
     DIR *dp = opendir(dir);
@@ -145,57 +421,27 @@ void app_load_directory(AppState *app, const char *path) {
         free(target_file);
         return;
     }
+    cancel_scan(app);
 
     /* Temporary dynamic array for collected paths */
     char **new_images = NULL;
     int new_count = 0;
     int new_capacity = 0;
 
+    const char *keep = target_file ? strrchr(target_file, '/') + 1 : NULL;
+    bool remote = netfs_is_remote(dir);
     struct dirent *entry;
     while ((entry = readdir(dp)) != NULL) {
-        /* Skip directories and non-relevant file types */
-        if (entry->d_type == DT_DIR) continue;
-        if (entry->d_type != DT_REG && entry->d_type != DT_LNK && entry->d_type != DT_UNKNOWN) continue;
-
-        const char *name = entry->d_name;
-
-        /* Check extension */
-        if (!is_supported_extension(name)) continue;
-
-        /* Build full path: dir + "/" + name */
-        size_t dir_len = strlen(dir);
-        size_t name_len = strlen(name);
-        char *full_path = (char *)malloc(dir_len + 1 + name_len + 1);
-        if (!full_path) continue;
-        memcpy(full_path, dir, dir_len);
-        full_path[dir_len] = '/';
-        memcpy(full_path + dir_len + 1, name, name_len + 1);
-
-        /* Double-check via stat only for symlinks and unknown file types */
-        if (entry->d_type == DT_LNK || entry->d_type == DT_UNKNOWN) {
-            struct stat st;
-            if (stat(full_path, &st) != 0 || !S_ISREG(st.st_mode)) {
-                free(full_path);
-                continue;
-            }
+        char *full_path = image_entry_path(dir, entry, keep, remote);
+        if (full_path && !append_path(&new_images, &new_count, &new_capacity, full_path)) {
+            free(full_path);
         }
-
-        /* Append to dynamic array */
-        if (new_count >= new_capacity) {
-            int new_cap = new_capacity ? new_capacity * 2 : 64;
-            char **tmp = (char **)realloc(new_images, (size_t)new_cap * sizeof(char *));
-            if (!tmp) {
-                free(full_path);
-                continue;
-            }
-            new_images = tmp;
-            new_capacity = new_cap;
-        }
-        new_images[new_count++] = full_path;
     }
 
     closedir(dp);
-    free(dir);
+    free(app->dir);
+    app->dir = dir;
+    app->remote = remote;
 
     /* Sort the collected paths */
     if (new_count > 0) {
@@ -214,18 +460,11 @@ void app_load_directory(AppState *app, const char *path) {
     }
 
     /* Replace old state */
-    if (app->images) {
-        for (int i = 0; i < app->count; i++) {
-            free(app->images[i]);
-        }
-        free(app->images);
-    }
+    clear_images(app);
+    app->follow_first = false;
 
     if (new_count == 0) {
         free(new_images);
-        app->images = NULL;
-        app->count = 0;
-        app->current_index = -1;
     } else {
         /* NULL-terminate for safety */
         char **final_images = (char **)realloc(new_images, (size_t)(new_count + 1) * sizeof(char *));
@@ -242,6 +481,127 @@ void app_load_directory(AppState *app, const char *path) {
     free(target_file);
 }
 
+void app_load_directory_async(AppState *app, const char *path) {
+    if (!app || !path) return;
+
+    char *dir = NULL;
+    char *target_file = NULL;
+    if (!resolve_load_path(path, &dir, &target_file)) return;
+
+    ScanJob *job = (ScanJob *)calloc(1, sizeof(ScanJob));
+    if (!job) {
+        free(dir);
+        free(target_file);
+        return;
+    }
+    job->dir = strdup(dir);
+    if (!job->dir || pthread_mutex_init(&job->mutex, NULL) != 0) {
+        free(job->dir);
+        free(job);
+        free(dir);
+        free(target_file);
+        return;
+    }
+    job->refs = 2;
+    job->remote = netfs_is_remote(dir);
+
+    pthread_t thread;
+    if (pthread_create(&thread, NULL, scan_thread, job) != 0) {
+        /* No thread: fall back to reading the folder here */
+        pthread_mutex_destroy(&job->mutex);
+        free(job->dir);
+        free(job);
+        free(dir);
+        app_load_directory(app, target_file ? target_file : path);
+        free(target_file);
+        return;
+    }
+    pthread_detach(thread);
+
+    cancel_scan(app);
+    app->scan = job;
+    free(app->dir);
+    app->dir = dir;
+    app->remote = job->remote;
+
+    /* Show the requested file straight away; its neighbours follow */
+    clear_images(app);
+    app->follow_first = !target_file;
+    if (target_file) {
+        app->images = (char **)calloc(2, sizeof(char *));
+        if (app->images) {
+            app->images[0] = target_file;
+            app->count = 1;
+            app->current_index = 0;
+            return;
+        }
+    }
+    free(target_file);
+}
+
+bool app_poll_scan(AppState *app) {
+    if (!app || !app->scan) return false;
+
+    pthread_mutex_lock(&app->scan->mutex);
+    char **batch = app->scan->found;
+    int batch_count = app->scan->found_count;
+    bool done = app->scan->done;
+    app->scan->found = NULL;
+    app->scan->found_count = 0;
+    app->scan->found_capacity = 0;
+    pthread_mutex_unlock(&app->scan->mutex);
+
+    if (done) {
+        scan_unref(app->scan);
+        app->scan = NULL;
+    }
+    if (batch_count == 0) {
+        free(batch);
+        return done;
+    }
+
+    /* Sort the batch and merge it into the sorted list, dropping paths
+       already there (the file shown at startup) */
+    qsort(batch, (size_t)batch_count, sizeof(char *), compare_paths);
+    char **merged = (char **)malloc((size_t)(app->count + batch_count + 1) * sizeof(char *));
+    if (!merged) {
+        for (int i = 0; i < batch_count; i++) free(batch[i]);
+        free(batch);
+        return done;
+    }
+
+    const char *current = app_current_path(app);
+    int n = 0, i = 0, j = 0;
+    int new_current = -1;
+    while (i < app->count || j < batch_count) {
+        int cmp = i >= app->count ? 1 : j >= batch_count ? -1 : strcmp(app->images[i], batch[j]);
+        if (cmp == 0) {
+            free(batch[j++]);
+            continue;
+        }
+        char *next = cmp < 0 ? app->images[i++] : batch[j++];
+        if (next == current) new_current = n;
+        merged[n++] = next;
+    }
+    merged[n] = NULL;
+
+    free(batch);
+    free(app->images);
+    app->images = merged;
+    app->count = n;
+    /* Batches arrive in readdir order, so the first image so far can change */
+    app->current_index = new_current >= 0 && !app->follow_first ? new_current : 0;
+    return true;
+}
+
+bool app_scan_active(const AppState *app) {
+    return app && app->scan;
+}
+
+bool app_accepts_name(const char *name) {
+    return name && image_is_supported(name) && passes_filters(name);
+}
+
 int app_image_count(const AppState *app) {
     return app ? app->count : 0;
 }
@@ -285,13 +645,14 @@ bool app_display_image(AppState *app, int index) {
     if (index >= app->count) index = app->count - 1;
 
     app->current_index = index;
+    app->follow_first = false;
     return true;
 }
 
-bool app_remove_current(AppState *app) {
-    if (!app || app->count == 0 || app->current_index < 0) return false;
-
-    int idx = app->current_index;
+/* Drop entry idx from the list, keeping the current image current (or
+   moving on to the next one if it is the entry removed) */
+static void remove_at(AppState *app, int idx) {
+    remove_mark(app, find_mark(app, app->images[idx]));
 
     /* Free the path string */
     free(app->images[idx]);
@@ -305,36 +666,139 @@ bool app_remove_current(AppState *app) {
 
     if (app->count == 0) {
         app->current_index = -1;
-    } else if (idx >= app->count) {
+    } else if (idx < app->current_index) {
+        app->current_index--;
+    } else if (app->current_index >= app->count) {
         /* We removed the last item */
         app->current_index = app->count - 1;
     }
     /* Otherwise, current_index stays at idx (which now holds the next image) */
+}
+
+bool app_remove_current(AppState *app) {
+    if (!app || app->count == 0 || app->current_index < 0) return false;
 
+    remove_at(app, app->current_index);
     return true;
 }
 
+bool app_remove_path(AppState *app, const char *path) {
+    if (!app || !path) return false;
+
+    for (int i = 0; i < app->count; i++) {
+        if (strcmp(app->images[i], path) == 0) {
+            remove_at(app, i);
+            return true;
+        }
+    }
+    return false;
+}
+
 void app_rename_current(AppState *app, const char *new_path) {
     if (!app || app->current_index < 0 || !new_path) return;
+    app_rename_path(app, app->images[app->current_index], new_path);
+}
 
-    /* Free the old path and replace */
-    free(app->images[app->current_index]);
-    app->images[app->current_index] = strdup(new_path);
-    if (!app->images[app->current_index]) return;
+bool app_rename_path(AppState *app, const char *old_path, const char *new_path) {
+    if (!app || !old_path || !new_path) return false;
 
-    /* Re-sort */
-    qsort(app->images, (size_t)app->count, sizeof(char *), compare_paths);
+    int idx = -1;
+    for (int i = 0; i < app->count; i++) {
+        if (strcmp(app->images[i], old_path) == 0) {
+            idx = i;
+            break;
+        }
+    }
+    if (idx < 0) return false;
+
+    char *copy = strdup(new_path);
+    if (!copy) return false;
+
+    /* Carry a mark over to the new name */
+    int mark = find_mark(app, old_path);
+    if (mark >= 0) {
+        char *mark_copy = strdup(new_path);
+        if (mark_copy) {
+            free(app->marked[mark]);
+            app->marked[mark] = mark_copy;
+        } else {
+            remove_mark(app, mark);
+        }
+    }
+
+    /* Replace the old path (old_path may be this very string) */
+    const char *current = app->current_index >= 0 ? app->images[app->current_index] : NULL;
+    bool was_current = idx == app->current_index;
+    free(app->images[idx]);
+    app->images[idx] = copy;
+    if (was_current) current = copy;
 
-    /* Find the renamed file's new index */
+    /* Re-sort and find the current image's new index */
+    qsort(app->images, (size_t)app->count, sizeof(char *), compare_paths);
     for (int i = 0; i < app->count; i++) {
-        if (strcmp(app->images[i], new_path) == 0) {
+        if (app->images[i] == current) {
             app->current_index = i;
-            return;
+            break;
         }
     }
+    return true;
+}
+
+const char *app_current_dir(const AppState *app) {
+    return app ? app->dir : NULL;
+}
 
-    /* Should not happen, but if not found, pin to 0 */
-    app->current_index = 0;
+bool app_dir_is_remote(const AppState *app) {
+    return app && app->remote;
+}
+
+bool app_load_sibling(AppState *app, int direction) {
+    if (!app || !app->dir || direction == 0) return false;
+
+    char *parent = get_dirname(app->dir);
+    if (!parent) return false;
+    const char *name = strrchr(app->dir, '/');
+    name = name ? name + 1 : app->dir;
+
+    int count = 0;
+    char **names = list_subdirs(parent, &count);
+
+    /* Position of the current folder; if it has gone, start from where it
+       would sort */
+    int pos = 0;
+    while (pos < count && strcmp(names[pos], name) < 0) pos++;
+    bool present = pos < count && strcmp(names[pos], name) == 0;
+    if (!present && direction > 0) pos--;
+
+    /* Step over folders without images (e.g. DCIM's empty "MISC"). On a
+       network mount that would read each folder in full, so the next one
+       is taken as is and read in the background. */
+    bool remote = app->remote;
+    char *target = NULL;
+    for (int i = pos + direction; i >= 0 && i < count && !target; i += direction) {
+        size_t len = strlen(parent) + 1 + strlen(names[i]) + 1;
+        char *candidate = (char *)malloc(len);
+        if (!candidate) break;
+        snprintf(candidate, len, "%s/%s", strcmp(parent, "/") == 0 ? "" : parent, names[i]);
+        if (remote || dir_has_images(candidate)) {
+            target = candidate;
+        } else {
+            free(candidate);
+        }
+    }
+
+    for (int i = 0; i < count; i++) free(names[i]);
+    free(names);
+    free(parent);
+
+    if (!target) return false;
+    if (remote) {
+        app_load_directory_async(app, target);
+    } else {
+        app_load_directory(app, target);
+    }
+    free(target);
+    return true;
 }
 
 const char *app_initial_path(const AppState *app) {
@@ -345,3 +809,49 @@ const char *app_image_path(const AppState *app, int index) {
     if (!app || index < 0 || index >= app->count) return NULL;
     return app->images[index];
 }
+
+bool app_toggle_mark(AppState *app) {
+    const char *path = app_current_path(app);
+    if (!path) return false;
+
+    int pos = find_mark(app, path);
+    if (pos >= 0) {
+        remove_mark(app, pos);
+        return false;
+    }
+
+    char *copy = strdup(path);
+    if (!copy) return false;
+    char **tmp = (char **)realloc(app->marked, (size_t)(app->marked_count + 1) * sizeof(char *));
+    if (!tmp) {
+        free(copy);
+        return false;
+    }
+    app->marked = tmp;
+    app->marked[app->marked_count++] = copy;
+    return true;
+}
+
+bool app_is_marked(const AppState *app, int index) {
+    if (!app || index < 0 || index >= app->count) return false;
+    return find_mark(app, app->images[index]) >= 0;
+}
+
+int app_marked_count(const AppState *app) {
+    return app ? app->marked_count : 0;
+}
+
+const char *app_marked_path(const AppState *app, int i) {
+    if (!app || i < 0 || i >= app->marked_count) return NULL;
+    return app->marked[i];
+}
+
+void app_clear_marks(AppState *app) {
+    if (!app) return;
+    for (int i = 0; i < app->marked_count; i++) {
+        free(app->marked[i]);
+    }
+    free(app->marked);
+    app->marked = NULL;
+    app->marked_count = 0;
+}
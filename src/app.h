@@ -15,9 +15,29 @@ void app_destroy(AppState *app);
 
 /* Load images from the given directory (or extract the directory from a file path).
    Sorts the image list alphabetically. If the path points to a file, the directory
-   containing that file is scanned and the specific file becomes the current image. */
+   containing that file is scanned and the specific file becomes the current image
+   (even if the show_hidden, follow_symlinks or exclude settings would skip it). */
 void app_load_directory(AppState *app, const char *path);
 
+/* Like app_load_directory, but the folder is read on a background thread so
+   huge folders don't stall the window. A file path becomes the only entry
+   (and the current image) right away; everything else arrives through
+   app_poll_scan(). Loading another folder cancels the scan. */
+void app_load_directory_async(AppState *app, const char *path);
+
+/* Merge the images a background scan has found since the last call into the
+   sorted list, keeping the current image. A folder opened without a target
+   file stays on its first image (in sorted order) until the user navigates.
+   Returns true if the list changed or the scan finished. */
+bool app_poll_scan(AppState *app);
+
+/* Check whether a background scan is still running. */
+bool app_scan_active(const AppState *app);
+
+/* Check whether a file name would be listed: a supported extension that
+   passes the show_hidden and exclude settings. */
+bool app_accepts_name(const char *name);
+
 /* Get the number of images in the current list. */
 int app_image_count(const AppState *app);
 
@@ -47,14 +67,56 @@ bool app_display_image(AppState *app, int index);
    Returns true if the image was removed, false if the list is empty. */
 bool app_remove_current(AppState *app);
 
+/* Remove any image from the list (its file was already deleted). The
+   current image stays current; if it is the one removed, the next image
+   becomes current. Returns false if path is not in the list. */
+bool app_remove_path(AppState *app, const char *path);
+
 /* Rename the current image path (the file was already renamed by fileops module).
    Updates the internal path string and re-sorts the list. */
 void app_rename_current(AppState *app, const char *new_path);
 
+/* Rename any image in the list (the file was already renamed). Marks follow
+   the file and the current image stays current. Returns false if old_path
+   is not in the list. */
+bool app_rename_path(AppState *app, const char *old_path, const char *new_path);
+
+/* Get the directory the image list was loaded from (NULL before the first load). */
+const char *app_current_dir(const AppState *app);
+
+/* Check if that directory is on a network mount, where Frame prefetches
+   less and reads folders only in the background. */
+bool app_dir_is_remote(const AppState *app);
+
+/* Load the next (direction > 0) or previous (direction < 0) sibling folder,
+   in name order, that contains images; hidden and image-less folders are
+   skipped. On a network mount the next folder is taken without looking
+   inside and scanned in the background. Returns false, leaving the list
+   alone, if there is none. */
+bool app_load_sibling(AppState *app, int direction);
+
 /* Get the initial path that was passed on the command line (may be NULL). */
 const char *app_initial_path(const AppState *app);
 
 /* Get the path at a specific 0-based index. Returns NULL if out of range. */
 const char *app_image_path(const AppState *app, int index);
 
+/* --- Marks --- */
+
+/* Toggle the mark on the current image. Returns true if it is now marked.
+   Marks are stored by path, so they survive renames and directory changes. */
+bool app_toggle_mark(AppState *app);
+
+/* Check whether the image at a 0-based index is marked. */
+bool app_is_marked(const AppState *app, int index);
+
+/* Get the number of marked images. */
+int app_marked_count(const AppState *app);
+
+/* Get the i-th marked path (order is unspecified). Returns NULL if out of range. */
+const char *app_marked_path(const AppState *app, int i);
+
+/* Unmark all images. */
+void app_clear_marks(AppState *app);
+
 #endif /* FRAME_APP_H */
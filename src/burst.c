@@ -0,0 +1,209 @@
+#define _DEFAULT_SOURCE
+#include "burst.h"
+#include "exif.h"
+#include <pthread.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+
+/* Parse an EXIF date ("2024:05:17 14:03:22"). Returns 0 if malformed. */
+static time_t parse_exif_date(const char *date)
+{
+    struct tm tm;
+    memset(&tm, 0, sizeof(tm));
+    if (sscanf(date, "%d:%d:%d %d:%d:%d", &tm.tm_year, &tm.tm_mon, &tm.tm_mday,
+               &tm.tm_hour, &tm.tm_min, &tm.tm_sec) != 6 || tm.tm_year < 1900) {
+        return 0;
+    }
+    tm.tm_year -= 1900;
+    tm.tm_mon -= 1;
+
+    /* The camera's wall clock, read as UTC so no DST change falls
+       between two shots */
+    time_t t = timegm(&tm);
+    return t == (time_t)-1 ? 0 : t;
+}
+
+time_t burst_capture_time(const char *path)
+{
+    if (!path) return 0;
+
+    /* The camera clock is what matters: copying a card resets file times */
+    ExifInfo exif;
+    if (exif_read(path, &exif) && exif.date[0]) {
+        time_t t = parse_exif_date(exif.date);
+        if (t) return t;
+    }
+
+    struct stat st;
+    if (stat(path, &st) != 0) return 0;
+    return st.st_mtime;
+}
+
+void burst_group(const time_t *times, int count, int *first, int *length)
+{
+    int start = 0;
+    for (int i = 1; i <= count; i++) {
+        bool joins = i < count && times[i] && times[i - 1] &&
+                     times[i] >= times[i - 1] - BURST_GAP_SECONDS &&
+                     times[i] <= times[i - 1] + BURST_GAP_SECONDS;
+        if (joins) continue;
+
+        /* Close the run [start, i) */
+        for (int j = start; j < i; j++) {
+            first[j] = start;
+            length[j] = i - start;
+        }
+        start = i;
+    }
+}
+
+/* ---- background reading ---- */
+
+typedef struct {
+    char *path;
+    time_t time;
+    bool known;     /* read; otherwise still queued */
+} TimeEntry;
+
+static pthread_mutex_t times_mutex = PTHREAD_MUTEX_INITIALIZER;
+static TimeEntry *entries = NULL;
+static int entry_count = 0;
+static int entry_capacity = 0;
+static int *hash_table = NULL;      /* maps path to entry index, -1 if free */
+static int hash_capacity = 0;
+static int next_queued = 0;         /* entries before this one have been read */
+static bool reader_running = false;
+static bool times_updated = false;
+static unsigned int generation = 0; /* bumped by burst_forget() */
+
+static uint32_t fnv1a_hash(const char *str)
+{
+    uint32_t hash = 2166136261u;
+    while (*str) {
+        hash ^= (unsigned char)*str++;
+        hash *= 16777619u;
+    }
+    return hash;
+}
+
+/* Index of the entry for path, or -1. Call with times_mutex held. */
+static int find_entry(const char *path)
+{
+    if (hash_capacity == 0) return -1;
+    int mask = hash_capacity - 1;
+    for (int idx = (int)(fnv1a_hash(path) & (uint32_t)mask); hash_table[idx] != -1; idx = (idx + 1) & mask) {
+        if (strcmp(entries[hash_table[idx]].path, path) == 0) return hash_table[idx];
+    }
+    return -1;
+}
+
+/* Queue path for reading. Call with times_mutex held. */
+static bool add_entry(const char *path)
+{
+    if (entry_count == entry_capacity) {
+        int capacity = entry_capacity ? entry_capacity * 2 : 256;
+        TimeEntry *grown = realloc(entries, sizeof(TimeEntry) * (size_t)capacity);
+        if (!grown) return false;
+        entries = grown;
+        entry_capacity = capacity;
+    }
+    if ((entry_count + 1) * 2 > hash_capacity) {
+        int capacity = hash_capacity ? hash_capacity * 2 : 512;
+        int *table = malloc(sizeof(int) * (size_t)capacity);
+        if (!table) return false;
+        memset(table, -1, sizeof(int) * (size_t)capacity);
+        free(hash_table);
+        hash_table = table;
+        hash_capacity = capacity;
+        for (int i = 0; i < entry_count; i++) {
+            int mask = hash_capacity - 1;
+            int idx = (int)(fnv1a_hash(entries[i].path) & (uint32_t)mask);
+            while (hash_table[idx] != -1) idx = (idx + 1) & mask;
+            hash_table[idx] = i;
+        }
+    }
+
+    char *copy = strdup(path);
+    if (!copy) return false;
+    int mask = hash_capacity - 1;
+    int idx = (int)(fnv1a_hash(copy) & (uint32_t)mask);
+    while (hash_table[idx] != -1) idx = (idx + 1) & mask;
+    hash_table[idx] = entry_count;
+    entries[entry_count++] = (TimeEntry){ .path = copy, .time = 0, .known = false };
+    return true;
+}
+
+/* Read queued paths until none are left */
+static void *reader_thread(void *arg)
+{
+    (void)arg;
+    pthread_mutex_lock(&times_mutex);
+    while (next_queued < entry_count) {
+        char *path = strdup(entries[next_queued++].path);
+        unsigned int gen = generation;
+        pthread_mutex_unlock(&times_mutex);
+
+        time_t t = path ? burst_capture_time(path) : 0;
+
+        pthread_mutex_lock(&times_mutex);
+        int i = path && gen == generation ? find_entry(path) : -1;
+        if (i >= 0) {
+            entries[i].time = t;
+            entries[i].known = true;
+            times_updated = true;
+        }
+        free(path);
+    }
+    reader_running = false;
+    pthread_mutex_unlock(&times_mutex);
+    return NULL;
+}
+
+bool burst_lookup_time(const char *path, time_t *out)
+{
+    if (!path) return false;
+
+    pthread_mutex_lock(&times_mutex);
+    int i = find_entry(path);
+    bool known = i >= 0 && entries[i].known;
+    if (known) {
+        *out = entries[i].time;
+    } else if ((i >= 0 || add_entry(path)) && !reader_running) {
+        pthread_t thread;
+        if (pthread_create(&thread, NULL, reader_thread, NULL) == 0) {
+            pthread_detach(thread);
+            reader_running = true;
+        }
+    }
+    pthread_mutex_unlock(&times_mutex);
+    return known;
+}
+
+bool burst_times_updated(void)
+{
+    pthread_mutex_lock(&times_mutex);
+    bool updated = times_updated;
+    times_updated = false;
+    pthread_mutex_unlock(&times_mutex);
+    return updated;
+}
+
+void burst_forget(void)
+{
+    pthread_mutex_lock(&times_mutex);
+    for (int i = 0; i < entry_count; i++) {
+        free(entries[i].path);
+    }
+    free(entries);
+    free(hash_table);
+    entries = NULL;
+    hash_table = NULL;
+    entry_count = entry_capacity = hash_capacity = 0;
+    next_queued = 0;
+    times_updated = false;
+    generation++;
+    pthread_mutex_unlock(&times_mutex);
+}
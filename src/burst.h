@@ -0,0 +1,36 @@
+#ifndef FRAME_BURST_H
+#define FRAME_BURST_H
+
+#include <stdbool.h>
+#include <time.h>
+
+/* Shots taken at most this many seconds apart belong to one burst */
+#define BURST_GAP_SECONDS 1
+
+/* Capture time of an image: the EXIF DateTimeOriginal tag, or the file's
+   modification time when there is none. Returns 0 if neither can be read. */
+time_t burst_capture_time(const char *path);
+
+/* Split `count` capture times, in list order, into bursts: runs of images
+   each taken within BURST_GAP_SECONDS of the one before. For every image,
+   first[i] receives the index of the first shot of its burst and length[i]
+   the number of shots in it (1 for an image on its own). Unknown times
+   (0) never join a burst. */
+void burst_group(const time_t *times, int count, int *first, int *length);
+
+/* Capture times for the grid are read on a background thread, one file at
+   a time, and remembered per path until burst_forget(), so grouping a big
+   or remote folder neither blocks the window nor re-reads files as the
+   list grows. All three are called from the main thread. */
+
+/* Look up the capture time of path. Returns false, queuing the path for
+   reading if it is not already, while the time is not known yet. */
+bool burst_lookup_time(const char *path, time_t *out);
+
+/* Check whether queued times have been read since the last call. */
+bool burst_times_updated(void);
+
+/* Forget every remembered time; reads under way are discarded. */
+void burst_forget(void);
+
+#endif /* FRAME_BURST_H */
@@ -443,3 +443,50 @@ void cache_pin(ImageCache *cache, const char *path)
     }
     pthread_mutex_unlock(&cache->mutex);
 }
+
+void cache_get_usage(ImageCache *cache, int *out_count, size_t *out_bytes)
+{
+    int count = 0;
+    size_t bytes = 0;
+    if (cache) {
+        pthread_mutex_lock(&cache->mutex);
+        count = cache->len;
+        bytes = cache->total_bytes;
+        pthread_mutex_unlock(&cache->mutex);
+    }
+    if (out_count) *out_count = count;
+    if (out_bytes) *out_bytes = bytes;
+}
+
+void cache_trim(ImageCache *cache, size_t max_bytes)
+{
+    if (!cache) return;
+
+    pthread_mutex_lock(&cache->mutex);
+    bool evicted = false;
+    while (cache->len > 0 && cache->total_bytes > max_bytes) {
+        if (!evict_one_locked(cache))
+            break;  /* only the pinned entry is left */
+        evicted = true;
+    }
+    pthread_mutex_unlock(&cache->mutex);
+
+#ifdef __linux__
+    if (evicted)
+        malloc_trim(0);
+#else
+    (void)evicted;
+#endif
+}
+
+void cache_set_max_bytes(ImageCache *cache, size_t max_bytes)
+{
+    if (!cache) return;
+
+    pthread_mutex_lock(&cache->mutex);
+    cache->max_bytes = max_bytes;
+    pthread_mutex_unlock(&cache->mutex);
+
+    if (max_bytes > 0)
+        cache_trim(cache, max_bytes);
+}
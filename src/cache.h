@@ -32,4 +32,15 @@ void cache_invalidate(ImageCache *cache, const char *path);
 /* Pin a path to prevent it from being evicted. Pass NULL to unpin. */
 void cache_pin(ImageCache *cache, const char *path);
 
+/* Get the number of entries and the total size of the cached surfaces.
+   Either output pointer may be NULL. */
+void cache_get_usage(ImageCache *cache, int *out_count, size_t *out_bytes);
+
+/* Evict least-recently-used entries until at most max_bytes are cached.
+   The pinned entry is never evicted. Pass 0 to drop everything else. */
+void cache_trim(ImageCache *cache, size_t max_bytes);
+
+/* Change the memory budget (0 = unlimited), trimming if already over it. */
+void cache_set_max_bytes(ImageCache *cache, size_t max_bytes);
+
 #endif /* FRAME_CACHE_H */
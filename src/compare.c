@@ -0,0 +1,115 @@
+#define _DEFAULT_SOURCE
+#include "compare.h"
+#include "viewer.h"
+#include "loader.h"
+#include "overlay.h"
+#include "theme.h"
+#include <SDL3_ttf/SDL_ttf.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define LABEL_PADDING 8.0f
+
+static char *reference_path = NULL;
+static SDL_Texture *reference = NULL;
+
+bool compare_set_reference(SDL_Renderer *renderer, const char *path)
+{
+    if (!path) return false;
+    SDL_Texture *tex = loader_load_texture(path, renderer);
+    char *copy = strdup(path);
+    if (!tex || !copy) {
+        fprintf(stderr, "compare: cannot load %s\n", path);
+        SDL_DestroyTexture(tex);
+        free(copy);
+        return false;
+    }
+    compare_clear();
+    reference = tex;
+    reference_path = copy;
+    return true;
+}
+
+void compare_clear(void)
+{
+    SDL_DestroyTexture(reference);
+    reference = NULL;
+    free(reference_path);
+    reference_path = NULL;
+}
+
+bool compare_is_active(void)
+{
+    return reference != NULL;
+}
+
+const char *compare_reference_path(void)
+{
+    return reference_path;
+}
+
+int compare_pane_width(int w)
+{
+    return reference ? w / 2 : w;
+}
+
+/* Name of the reference in the top-left corner of its pane */
+static void render_label(SDL_Renderer *renderer, const SDL_FRect *pane)
+{
+    TTF_Font *font = overlay_ui_font();
+    if (!font) return;
+    const char *name = strrchr(reference_path, '/');
+    name = name ? name + 1 : reference_path;
+    char text[300];
+    snprintf(text, sizeof(text), "Reference: %s", name);
+
+    SDL_Surface *surf = TTF_RenderText_Blended(font, text, 0, theme_get()->text);
+    if (!surf) return;
+    SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+    if (tex) {
+        float w = surf->w < pane->w - 4 * LABEL_PADDING ? (float)surf->w : pane->w - 4 * LABEL_PADDING;
+        SDL_FRect bg = {pane->x + LABEL_PADDING, pane->y + LABEL_PADDING,
+                        w + 2 * LABEL_PADDING, surf->h + LABEL_PADDING};
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+        theme_set_color(renderer, theme_get()->panel, 180);
+        SDL_RenderFillRect(renderer, &bg);
+        SDL_FRect src = {0, 0, w, (float)surf->h};
+        SDL_FRect dst = {bg.x + LABEL_PADDING, bg.y + LABEL_PADDING / 2.0f, w, (float)surf->h};
+        SDL_RenderTexture(renderer, tex, &src, &dst);
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+        SDL_DestroyTexture(tex);
+    }
+    SDL_DestroySurface(surf);
+}
+
+void compare_render(SDL_Renderer *renderer, const struct Viewer *viewer)
+{
+    if (!reference) return;
+
+    int out_w, out_h;
+    if (!SDL_GetRenderOutputSize(renderer, &out_w, &out_h)) return;
+    int left = compare_pane_width(out_w);
+    SDL_FRect pane = {(float)left, 0, (float)(out_w - left), (float)out_h};
+
+    theme_set_color(renderer, theme_get()->background, 255);
+    SDL_RenderFillRect(renderer, &pane);
+
+    /* Same scale and offset as the current image in the left pane */
+    float scale = viewer_get_zoom(viewer);
+    float offset_x = 0.0f, offset_y = 0.0f;
+    viewer_get_offset(viewer, &offset_x, &offset_y);
+    if (scale > 0.0f) {
+        float w, h;
+        SDL_GetTextureSize(reference, &w, &h);
+        SDL_Rect clip = {left, 0, out_w - left, out_h};
+        SDL_SetRenderClipRect(renderer, &clip);
+        SDL_FRect dst = {pane.x + offset_x, offset_y, w * scale, h * scale};
+        SDL_RenderTexture(renderer, reference, NULL, &dst);
+        SDL_SetRenderClipRect(renderer, NULL);
+    }
+
+    theme_set_color(renderer, theme_get()->border, 255);
+    SDL_RenderLine(renderer, pane.x, 0, pane.x, (float)out_h);
+    render_label(renderer, &pane);
+}
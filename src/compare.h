@@ -0,0 +1,35 @@
+#ifndef FRAME_COMPARE_H
+#define FRAME_COMPARE_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+struct Viewer;
+
+/*
+ * Compare mode: an image pinned as the reference is shown in the right half
+ * of the window while the left half shows the current image as usual. The
+ * reference follows the viewer's zoom and pan, so the same pixels of both
+ * line up (images of the same size match exactly).
+ */
+
+/* Pin the image at path as the reference, loading it now. Returns false
+   (leaving compare mode as it was) if it cannot be loaded. */
+bool compare_set_reference(SDL_Renderer *renderer, const char *path);
+
+/* Leave compare mode and free the reference. */
+void compare_clear(void);
+
+bool compare_is_active(void);
+
+/* Path of the reference, or NULL outside compare mode. */
+const char *compare_reference_path(void);
+
+/* Width of the viewer's pane in a window whose output is w pixels wide. */
+int compare_pane_width(int w);
+
+/* Draw the reference in the right half of the window, with the viewer's
+   zoom and pan. Call after viewer_render(). */
+void compare_render(SDL_Renderer *renderer, const struct Viewer *viewer);
+
+#endif /* FRAME_COMPARE_H */
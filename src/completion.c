@@ -0,0 +1,214 @@
+#include "completion.h"
+#include "utils.h"
+#include <ctype.h>
+#include <string.h>
+
+/* Subcommands and top-level flags offered by every shell */
+typedef struct {
+    const char *name;
+    const char *desc;
+} CliEntry;
+
+static const CliEntry subcommands[] = {
+    {"info", "Print image metadata"},
+    {"completion", "Print a shell completion script"},
+    {NULL, NULL}
+};
+
+static const CliEntry flags[] = {
+    {"--version", "Print version information"},
+    {"--read-only", "Disable delete, rename and other file changes"},
+    {"--ipc-server=", "Listen for control commands on a Unix socket"},
+    {"--verbose", "Log the decoder used for each image"},
+    {NULL, NULL}
+};
+
+static const char *shells[] = { "bash", "zsh", "fish", NULL };
+
+/* Flags ending in '=' take a value (e.g. --ipc-server=PATH) */
+static bool takes_value(const char *flag)
+{
+    size_t len = strlen(flag);
+    return len > 0 && flag[len - 1] == '=';
+}
+
+/* Print the supported extensions (without dots) joined by `sep`,
+   optionally followed by their upper-case variants. */
+static void write_extensions(FILE *out, const char *sep, bool with_upper)
+{
+    int passes = with_upper ? 2 : 1;
+    bool first = true;
+    for (int pass = 0; pass < passes; pass++) {
+        for (int i = 0; image_formats[i].ext != NULL; i++) {
+            if (!first) fputs(sep, out);
+            first = false;
+            for (const char *c = image_formats[i].ext + 1; *c; c++) {
+                fputc(pass ? toupper((unsigned char)*c) : *c, out);
+            }
+        }
+    }
+}
+
+static void write_bash(FILE *out)
+{
+    fputs("# bash completion for frame\n"
+          "_frame_images() {\n"
+          "    local IFS=$'\\n'\n"
+          "    COMPREPLY+=( $(compgen -d -- \"$cur\") )\n"
+          "    COMPREPLY+=( $(compgen -f -X '!*.@(", out);
+    write_extensions(out, "|", true);
+    fputs(")' -- \"$cur\") )\n"
+          "}\n"
+          "\n"
+          "_frame() {\n"
+          "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n"
+          "    COMPREPLY=()\n"
+          "    shopt -s extglob\n"
+          "\n"
+          "    if [[ $COMP_CWORD -gt 1 ]]; then\n"
+          "        case \"${COMP_WORDS[1]}\" in\n"
+          "        completion)\n"
+          "            [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=( $(compgen -W \"", out);
+    for (int i = 0; shells[i]; i++) {
+        fprintf(out, "%s%s", i ? " " : "", shells[i]);
+    }
+    fputs("\" -- \"$cur\") )\n"
+          "            return ;;\n"
+          "        info)\n"
+          "            if [[ $cur == -* ]]; then\n"
+          "                COMPREPLY=( $(compgen -W \"--json\" -- \"$cur\") )\n"
+          "            else\n"
+          "                _frame_images\n"
+          "            fi\n"
+          "            return ;;\n"
+          "        esac\n"
+          "    fi\n"
+          "\n"
+          "    if [[ $cur == --*=* ]]; then\n"
+          "        COMPREPLY=( $(compgen -f -- \"${cur#*=}\") )\n"
+          "        return\n"
+          "    fi\n"
+          "\n"
+          "    if [[ $cur == -* ]]; then\n"
+          "        COMPREPLY=( $(compgen -W \"-v", out);
+    for (int i = 0; flags[i].name; i++) {
+        fprintf(out, " %s", flags[i].name);
+    }
+    fputs("\" -- \"$cur\") )\n"
+          "        [[ ${COMPREPLY[0]} == *= ]] && compopt -o nospace\n"
+          "        return\n"
+          "    fi\n"
+          "\n"
+          "    [[ $COMP_CWORD -eq 1 ]] && COMPREPLY=( $(compgen -W \"", out);
+    for (int i = 0; subcommands[i].name; i++) {
+        fprintf(out, "%s%s", i ? " " : "", subcommands[i].name);
+    }
+    fputs("\" -- \"$cur\") )\n"
+          "    _frame_images\n"
+          "}\n"
+          "complete -o filenames -F _frame frame\n", out);
+}
+
+static void write_zsh(FILE *out)
+{
+    fputs("#compdef frame\n"
+          "\n"
+          "_frame() {\n"
+          "    local images='*.(#i)(", out);
+    write_extensions(out, "|", false);
+    fputs(")(-.)'\n"
+          "    local -a commands\n"
+          "    commands=(\n", out);
+    for (int i = 0; subcommands[i].name; i++) {
+        fprintf(out, "        '%s:%s'\n", subcommands[i].name, subcommands[i].desc);
+    }
+    fputs("    )\n"
+          "\n"
+          "    _arguments -C \\\n"
+          "        '(- *)'{-v,--version}'[Print version information]' \\\n", out);
+    for (int i = 0; flags[i].name; i++) {
+        if (strcmp(flags[i].name, "--version") == 0) continue;
+        if (takes_value(flags[i].name)) {
+            fprintf(out, "        '%s[%s]:file:_files' \\\n", flags[i].name, flags[i].desc);
+        } else {
+            fprintf(out, "        '%s[%s]' \\\n", flags[i].name, flags[i].desc);
+        }
+    }
+    fputs("        '1: :->first' \\\n"
+          "        '*:: :->rest'\n"
+          "\n"
+          "    case $state in\n"
+          "    first)\n"
+          "        _describe -t commands 'frame command' commands\n"
+          "        _files -g \"$images\"\n"
+          "        ;;\n"
+          "    rest)\n"
+          "        case $words[1] in\n"
+          "        info)\n"
+          "            _arguments '--json[Print metadata as JSON]' \"*:image:_files -g '$images'\"\n"
+          "            ;;\n"
+          "        completion)\n"
+          "            _values 'shell'", out);
+    for (int i = 0; shells[i]; i++) {
+        fprintf(out, " %s", shells[i]);
+    }
+    fputs("\n"
+          "            ;;\n"
+          "        *)\n"
+          "            _files -g \"$images\"\n"
+          "            ;;\n"
+          "        esac\n"
+          "        ;;\n"
+          "    esac\n"
+          "}\n"
+          "\n"
+          "_frame \"$@\"\n", out);
+}
+
+static void write_fish(FILE *out)
+{
+    fputs("# fish completion for frame\n"
+          "complete -c frame -f\n"
+          "complete -c frame -s v -l version -d 'Print version information'\n", out);
+    for (int i = 0; flags[i].name; i++) {
+        if (strcmp(flags[i].name, "--version") == 0) continue;
+        if (takes_value(flags[i].name)) {
+            int len = (int)strlen(flags[i].name) - 3;
+            fprintf(out, "complete -c frame -l %.*s -r -F -d '%s'\n",
+                    len, flags[i].name + 2, flags[i].desc);
+        } else {
+            fprintf(out, "complete -c frame -l %s -d '%s'\n", flags[i].name + 2, flags[i].desc);
+        }
+    }
+    for (int i = 0; subcommands[i].name; i++) {
+        fprintf(out, "complete -c frame -n '__fish_use_subcommand' -a %s -d '%s'\n",
+                subcommands[i].name, subcommands[i].desc);
+    }
+    fputs("complete -c frame -n '__fish_seen_subcommand_from completion' -a '", out);
+    for (int i = 0; shells[i]; i++) {
+        fprintf(out, "%s%s", i ? " " : "", shells[i]);
+    }
+    fputs("'\n"
+          "complete -c frame -n '__fish_seen_subcommand_from info' -l json -d 'Print metadata as JSON'\n"
+          "complete -c frame -n 'not __fish_seen_subcommand_from completion' -a '(__fish_complete_suffix ", out);
+    for (int i = 0; image_formats[i].ext != NULL; i++) {
+        fprintf(out, "%s%s", i ? " " : "", image_formats[i].ext);
+    }
+    fputs(")'\n", out);
+}
+
+bool completion_write(const char *shell, FILE *out)
+{
+    if (!shell || !out) return false;
+
+    if (strcmp(shell, "bash") == 0) {
+        write_bash(out);
+    } else if (strcmp(shell, "zsh") == 0) {
+        write_zsh(out);
+    } else if (strcmp(shell, "fish") == 0) {
+        write_fish(out);
+    } else {
+        return false;
+    }
+    return true;
+}
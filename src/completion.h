@@ -0,0 +1,12 @@
+#ifndef FRAME_COMPLETION_H
+#define FRAME_COMPLETION_H
+
+#include <stdbool.h>
+#include <stdio.h>
+
+/* Write a completion script for the given shell ("bash", "zsh" or "fish")
+   to `out`. Covers subcommands, flags and image-file path completion.
+   Returns false if the shell is not supported. */
+bool completion_write(const char *shell, FILE *out);
+
+#endif /* FRAME_COMPLETION_H */
@@ -0,0 +1,402 @@
+#define _DEFAULT_SOURCE
+#include "config.h"
+#include "theme.h"
+#include <ctype.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <strings.h>
+#include <unistd.h>
+#include <pwd.h>
+
+static FrameConfig config = {
+    .read_only = false,
+    .theme = THEME_SYSTEM,
+    .cache_size_mb = 128,
+    .zoom_min = 10,
+    .zoom_max = 1000,
+    .zoom_step = 5,
+    .show_hidden = true,
+    .follow_symlinks = true,
+    .rename_sidecars = true,
+    .minimap = true,
+    .contact_sheet_columns = 5,
+    .animation_delay_ms = 100,
+    .animation_size = 480,
+    .double_click = CLICK_ACTION_FULLSCREEN,
+    .middle_click = CLICK_ACTION_ZOOM,
+};
+
+/* ---- helpers ---- */
+
+/* Trim leading and trailing whitespace in place. Returns the trimmed start. */
+static char *trim(char *s)
+{
+    while (isspace((unsigned char)*s)) s++;
+    size_t len = strlen(s);
+    while (len > 0 && isspace((unsigned char)s[len - 1])) {
+        s[--len] = '\0';
+    }
+    return s;
+}
+
+/* Parse a boolean value. Returns false (and leaves *out untouched) if invalid. */
+static bool parse_bool(const char *value, bool *out)
+{
+    if (strcasecmp(value, "true") == 0 || strcasecmp(value, "yes") == 0 ||
+        strcasecmp(value, "on") == 0 || strcmp(value, "1") == 0) {
+        *out = true;
+        return true;
+    }
+    if (strcasecmp(value, "false") == 0 || strcasecmp(value, "no") == 0 ||
+        strcasecmp(value, "off") == 0 || strcmp(value, "0") == 0) {
+        *out = false;
+        return true;
+    }
+    return false;
+}
+
+/* Parse a click action ("fullscreen", "zoom" or "none"). Returns false
+   (and leaves *out untouched) if invalid. */
+static bool parse_click_action(const char *value, ClickAction *out)
+{
+    if (strcasecmp(value, "fullscreen") == 0) {
+        *out = CLICK_ACTION_FULLSCREEN;
+    } else if (strcasecmp(value, "zoom") == 0) {
+        *out = CLICK_ACTION_ZOOM;
+    } else if (strcasecmp(value, "none") == 0) {
+        *out = CLICK_ACTION_NONE;
+    } else {
+        return false;
+    }
+    return true;
+}
+
+/* Parse an integer in [min, max]. Returns false (and leaves *out untouched) if invalid. */
+static bool parse_int(const char *value, int min, int max, int *out)
+{
+    char *end = NULL;
+    long n = strtol(value, &end, 10);
+    if (end == value || *end != '\0' || n < min || n > max) return false;
+    *out = (int)n;
+    return true;
+}
+
+/* Copy a string value. Returns false if it does not fit. */
+static bool parse_string(const char *value, char *out, size_t size)
+{
+    int ret = snprintf(out, size, "%s", value);
+    return ret >= 0 && (size_t)ret < size;
+}
+
+/* Build the config file path. Returns false if no location can be determined. */
+static bool get_config_path(char *buf, size_t size)
+{
+    int ret;
+    const char *xdg = getenv("XDG_CONFIG_HOME");
+    if (xdg && xdg[0] == '/') {
+        ret = snprintf(buf, size, "%s/frame/config", xdg);
+    } else {
+        const char *home = getenv("HOME");
+        if (!home) {
+            struct passwd *pw = getpwuid(getuid());
+            home = pw ? pw->pw_dir : NULL;
+        }
+        if (!home) return false;
+        ret = snprintf(buf, size, "%s/.config/frame/config", home);
+    }
+    return ret > 0 && (size_t)ret < size;
+}
+
+/* Apply a single key/value pair. Returns false for unknown keys or bad values. */
+static bool apply_option(const char *key, const char *value)
+{
+    if (strcmp(key, "read_only") == 0) {
+        return parse_bool(value, &config.read_only);
+    }
+    if (strcmp(key, "theme") == 0) {
+        if (strcasecmp(value, "system") == 0) {
+            config.theme = THEME_SYSTEM;
+        } else if (strcasecmp(value, "dark") == 0) {
+            config.theme = THEME_DARK;
+        } else if (strcasecmp(value, "light") == 0) {
+            config.theme = THEME_LIGHT;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "background") == 0) {
+        SDL_Color color;
+        if (strcasecmp(value, "theme") != 0 && !theme_parse_color(value, &color)) return false;
+        return parse_string(value, config.background, sizeof(config.background));
+    }
+    if (strcmp(key, "cache_size_mb") == 0) {
+        return parse_int(value, 16, 65536, &config.cache_size_mb);
+    }
+    if (strcmp(key, "zoom_min") == 0) {
+        return parse_int(value, 1, 100, &config.zoom_min);
+    }
+    if (strcmp(key, "zoom_max") == 0) {
+        return parse_int(value, 100, 10000, &config.zoom_max);
+    }
+    if (strcmp(key, "zoom_step") == 0) {
+        return parse_int(value, 1, 100, &config.zoom_step);
+    }
+    if (strcmp(key, "interpolation") == 0) {
+        if (strcasecmp(value, "linear") == 0) {
+            config.interpolation = INTERP_LINEAR;
+        } else if (strcasecmp(value, "nearest") == 0) {
+            config.interpolation = INTERP_NEAREST;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "initial_view") == 0) {
+        if (strcasecmp(value, "fit") == 0) {
+            config.initial_view = INITIAL_VIEW_FIT;
+        } else if (strcasecmp(value, "shrink") == 0) {
+            config.initial_view = INITIAL_VIEW_SHRINK;
+        } else if (strcasecmp(value, "original") == 0) {
+            config.initial_view = INITIAL_VIEW_ORIGINAL;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "never_upscale") == 0) {
+        return parse_bool(value, &config.never_upscale);
+    }
+    if (strcmp(key, "camera_overlay") == 0) {
+        return parse_bool(value, &config.camera_overlay);
+    }
+    if (strcmp(key, "filmstrip") == 0) {
+        return parse_bool(value, &config.filmstrip);
+    }
+    if (strcmp(key, "status_bar") == 0) {
+        return parse_bool(value, &config.status_bar);
+    }
+    if (strcmp(key, "minimap") == 0) {
+        return parse_bool(value, &config.minimap);
+    }
+    if (strcmp(key, "pixel_art") == 0) {
+        return parse_bool(value, &config.pixel_art);
+    }
+    if (strcmp(key, "ambient_background") == 0) {
+        return parse_bool(value, &config.ambient_background);
+    }
+    if (strcmp(key, "unsaved_rotation") == 0) {
+        if (strcasecmp(value, "ask") == 0) {
+            config.unsaved_rotation = UNSAVED_ROTATION_ASK;
+        } else if (strcasecmp(value, "save") == 0) {
+            config.unsaved_rotation = UNSAVED_ROTATION_SAVE;
+        } else if (strcasecmp(value, "discard") == 0) {
+            config.unsaved_rotation = UNSAVED_ROTATION_DISCARD;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "escape") == 0) {
+        if (strcasecmp(value, "fullscreen") == 0) {
+            config.escape = ESCAPE_FULLSCREEN;
+        } else if (strcasecmp(value, "quit") == 0) {
+            config.escape = ESCAPE_QUIT;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "scroll_wheel") == 0) {
+        if (strcasecmp(value, "zoom") == 0) {
+            config.scroll_wheel = WHEEL_ZOOM;
+        } else if (strcasecmp(value, "navigate") == 0) {
+            config.scroll_wheel = WHEEL_NAVIGATE;
+        } else if (strcasecmp(value, "pan") == 0) {
+            config.scroll_wheel = WHEEL_PAN;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "double_click") == 0) {
+        return parse_click_action(value, &config.double_click);
+    }
+    if (strcmp(key, "middle_click") == 0) {
+        return parse_click_action(value, &config.middle_click);
+    }
+    if (strcmp(key, "pdf_page_size") == 0) {
+        if (strcasecmp(value, "a4") == 0) {
+            config.pdf_page_size = PDF_PAGE_A4;
+        } else if (strcasecmp(value, "letter") == 0) {
+            config.pdf_page_size = PDF_PAGE_LETTER;
+        } else if (strcasecmp(value, "image") == 0) {
+            config.pdf_page_size = PDF_PAGE_IMAGE;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "pdf_fit") == 0) {
+        if (strcasecmp(value, "fit") == 0) {
+            config.pdf_fit = PDF_FIT_CONTAIN;
+        } else if (strcasecmp(value, "fill") == 0) {
+            config.pdf_fit = PDF_FIT_COVER;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "pdf_layout") == 0) {
+        if (strcasecmp(value, "single") == 0 || strcmp(value, "1") == 0) {
+            config.pdf_layout = PDF_LAYOUT_SINGLE;
+        } else if (strcasecmp(value, "2up") == 0 || strcmp(value, "2") == 0) {
+            config.pdf_layout = PDF_LAYOUT_2UP;
+        } else if (strcasecmp(value, "4up") == 0 || strcmp(value, "4") == 0) {
+            config.pdf_layout = PDF_LAYOUT_4UP;
+        } else if (strcasecmp(value, "index") == 0) {
+            config.pdf_layout = PDF_LAYOUT_INDEX;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "contact_sheet_columns") == 0) {
+        return parse_int(value, 1, 32, &config.contact_sheet_columns);
+    }
+    if (strcmp(key, "contact_sheet_captions") == 0) {
+        if (strcasecmp(value, "name") == 0) {
+            config.contact_sheet_captions = CONTACT_CAPTION_NAME;
+        } else if (strcasecmp(value, "date") == 0) {
+            config.contact_sheet_captions = CONTACT_CAPTION_DATE;
+        } else if (strcasecmp(value, "both") == 0) {
+            config.contact_sheet_captions = CONTACT_CAPTION_BOTH;
+        } else if (strcasecmp(value, "none") == 0) {
+            config.contact_sheet_captions = CONTACT_CAPTION_NONE;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "lossless_rotation") == 0) {
+        return parse_bool(value, &config.lossless_rotation);
+    }
+    if (strcmp(key, "raw_pairs") == 0) {
+        return parse_bool(value, &config.raw_pairs);
+    }
+    if (strcmp(key, "group_bursts") == 0) {
+        return parse_bool(value, &config.group_bursts);
+    }
+    if (strcmp(key, "rename_sidecars") == 0) {
+        return parse_bool(value, &config.rename_sidecars);
+    }
+    if (strcmp(key, "show_hidden") == 0) {
+        return parse_bool(value, &config.show_hidden);
+    }
+    if (strcmp(key, "follow_symlinks") == 0) {
+        return parse_bool(value, &config.follow_symlinks);
+    }
+    if (strcmp(key, "exclude") == 0) {
+        return parse_string(value, config.exclude, sizeof(config.exclude));
+    }
+    if (strcmp(key, "start_directory") == 0) {
+        return parse_string(value, config.start_directory, sizeof(config.start_directory));
+    }
+    if (strcmp(key, "export_directory") == 0) {
+        return parse_string(value, config.export_directory, sizeof(config.export_directory));
+    }
+    if (strcmp(key, "export_resampler") == 0) {
+        if (strcasecmp(value, "linear") == 0) {
+            config.export_resampler = RESAMPLE_LINEAR;
+        } else if (strcasecmp(value, "mitchell") == 0) {
+            config.export_resampler = RESAMPLE_MITCHELL;
+        } else if (strcasecmp(value, "lanczos") == 0) {
+            config.export_resampler = RESAMPLE_LANCZOS;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "animation_delay_ms") == 0) {
+        return parse_int(value, 20, 60000, &config.animation_delay_ms);
+    }
+    if (strcmp(key, "animation_size") == 0) {
+        return parse_int(value, 16, 4096, &config.animation_size);
+    }
+    if (strcmp(key, "uploader") == 0) {
+        if (strcasecmp(value, "none") == 0) {
+            config.uploader = UPLOADER_NONE;
+        } else if (strcasecmp(value, "0x0.st") == 0) {
+            config.uploader = UPLOADER_0X0;
+        } else if (strcasecmp(value, "imgur") == 0) {
+            config.uploader = UPLOADER_IMGUR;
+        } else if (strcasecmp(value, "command") == 0) {
+            config.uploader = UPLOADER_COMMAND;
+        } else {
+            return false;
+        }
+        return true;
+    }
+    if (strcmp(key, "upload_command") == 0) {
+        return parse_string(value, config.upload_command, sizeof(config.upload_command));
+    }
+    if (strcmp(key, "imgur_client_id") == 0) {
+        return parse_string(value, config.imgur_client_id, sizeof(config.imgur_client_id));
+    }
+    if (strcmp(key, "hook_image_changed") == 0) {
+        return parse_string(value, config.hook_image_changed, sizeof(config.hook_image_changed));
+    }
+    if (strcmp(key, "hook_image_deleted") == 0) {
+        return parse_string(value, config.hook_image_deleted, sizeof(config.hook_image_deleted));
+    }
+    if (strcmp(key, "hook_image_saved") == 0) {
+        return parse_string(value, config.hook_image_saved, sizeof(config.hook_image_saved));
+    }
+    return false;
+}
+
+/* ---- public API ---- */
+
+void config_load(void)
+{
+    char path[4096];
+    if (!get_config_path(path, sizeof(path))) return;
+
+    FILE *fp = fopen(path, "r");
+    if (!fp) return; /* no config file — keep defaults */
+
+    char line[1024];
+    int line_no = 0;
+    while (fgets(line, sizeof(line), fp)) {
+        line_no++;
+        char *s = trim(line);
+        if (s[0] == '\0' || s[0] == '#') continue;
+
+        char *eq = strchr(s, '=');
+        if (!eq) {
+            fprintf(stderr, "config: %s:%d: expected 'key = value'\n", path, line_no);
+            continue;
+        }
+        *eq = '\0';
+        char *key = trim(s);
+        char *value = trim(eq + 1);
+
+        if (!apply_option(key, value)) {
+            fprintf(stderr, "config: %s:%d: invalid setting '%s = %s'\n",
+                    path, line_no, key, value);
+        }
+    }
+
+    fclose(fp);
+}
+
+const FrameConfig *config_get(void)
+{
+    return &config;
+}
+
+void config_set_read_only(bool read_only)
+{
+    config.read_only = read_only;
+}
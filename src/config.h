@@ -0,0 +1,171 @@
+#ifndef FRAME_CONFIG_H
+#define FRAME_CONFIG_H
+
+#include <stdbool.h>
+
+/* Colour scheme selection */
+typedef enum {
+    THEME_SYSTEM,         /* follow the desktop's light/dark preference */
+    THEME_DARK,
+    THEME_LIGHT,
+} ThemeMode;
+
+/* Where `u` uploads images */
+typedef enum {
+    UPLOADER_NONE,
+    UPLOADER_0X0,         /* https://0x0.st */
+    UPLOADER_IMGUR,       /* anonymous imgur upload, needs imgur_client_id */
+    UPLOADER_COMMAND,     /* upload_command */
+} UploaderKind;
+
+/* PDF export page size */
+typedef enum {
+    PDF_PAGE_A4,
+    PDF_PAGE_LETTER,
+    PDF_PAGE_IMAGE,       /* page is the image's size at 96 dpi */
+} PdfPageSize;
+
+/* How images are placed on PDF pages */
+typedef enum {
+    PDF_FIT_CONTAIN,      /* whole image inside a half-inch margin */
+    PDF_FIT_COVER,        /* fill the page, cropping the overflow */
+} PdfFit;
+
+/* How many images go on each PDF page */
+typedef enum {
+    PDF_LAYOUT_SINGLE,    /* one image per page */
+    PDF_LAYOUT_2UP,       /* two images stacked */
+    PDF_LAYOUT_4UP,       /* 2x2 grid */
+    PDF_LAYOUT_INDEX,     /* captioned thumbnails, like a contact sheet */
+} PdfLayout;
+
+/* Captions under contact sheet thumbnails */
+typedef enum {
+    CONTACT_CAPTION_NAME,
+    CONTACT_CAPTION_DATE,
+    CONTACT_CAPTION_BOTH,
+    CONTACT_CAPTION_NONE,
+} ContactCaption;
+
+/* What happens to an unsaved rotation when leaving the image */
+typedef enum {
+    UNSAVED_ROTATION_ASK,     /* ask whether to save it */
+    UNSAVED_ROTATION_SAVE,    /* save without asking */
+    UNSAVED_ROTATION_DISCARD, /* drop it silently */
+} UnsavedRotation;
+
+/* What a double click or a middle click on the image does */
+typedef enum {
+    CLICK_ACTION_NONE,
+    CLICK_ACTION_FULLSCREEN,  /* toggle fullscreen */
+    CLICK_ACTION_ZOOM,        /* toggle between 100% and fit */
+} ClickAction;
+
+/* What Esc does once nothing is loading */
+typedef enum {
+    ESCAPE_FULLSCREEN,    /* leave fullscreen first, quit from a window */
+    ESCAPE_QUIT,          /* always quit */
+} EscapeAction;
+
+/* What the scroll wheel does without Ctrl (Ctrl+scroll always zooms) */
+typedef enum {
+    WHEEL_ZOOM,           /* zoom toward the cursor */
+    WHEEL_NAVIGATE,       /* previous / next image */
+    WHEEL_PAN,            /* move a zoomed-in image up and down */
+} WheelMode;
+
+/* Texture filtering used when an image is drawn scaled */
+typedef enum {
+    INTERP_LINEAR,        /* bilinear: smooth, the default */
+    INTERP_NEAREST,       /* nearest neighbour: hard pixel edges */
+    INTERP_COUNT
+} InterpolationMode;
+
+/* How each image is first shown (and re-shown after a window resize) */
+typedef enum {
+    INITIAL_VIEW_FIT,     /* scaled to fit the window, small images enlarged */
+    INITIAL_VIEW_SHRINK,  /* fitted only when larger than the window, else 100% */
+    INITIAL_VIEW_ORIGINAL,/* 100%, centered */
+} InitialView;
+
+/* Filter used when exports scale images (contact sheets, PDF index prints,
+   animations). The interactive view always uses InterpolationMode. */
+typedef enum {
+    RESAMPLE_LINEAR,      /* bilinear: fast, the default */
+    RESAMPLE_MITCHELL,    /* Mitchell-Netravali cubic: smooth, no ringing */
+    RESAMPLE_LANCZOS,     /* Lanczos-3: sharpest, slight ringing on edges */
+} ResampleFilter;
+
+/* User settings. Defaults apply for anything not set in the config file. */
+typedef struct {
+    bool read_only;       /* disable delete, rename and any other file modification */
+    ThemeMode theme;
+    int cache_size_mb;    /* memory ceiling for decoded images */
+    int zoom_min;         /* smallest zoom, percent */
+    int zoom_max;         /* largest zoom, percent */
+    int zoom_step;        /* change per zoom in/out key press, percent */
+    InterpolationMode interpolation;
+    InitialView initial_view;
+    bool never_upscale;   /* fitting stops at 100% for small images */
+    bool camera_overlay;  /* shutter, aperture, ISO and focal length in a corner */
+    bool filmstrip;       /* row of thumbnails below the image */
+    bool status_bar;      /* position, size and zoom under the image */
+    bool minimap;         /* navigator in the corner while zoomed in */
+    bool pixel_art;       /* nearest neighbour with integer zoom steps */
+    bool ambient_background; /* blurred image behind letterboxed images */
+    char background[16];  /* behind images: "theme", black, gray, white or #rrggbb */
+    UnsavedRotation unsaved_rotation;
+    EscapeAction escape;
+    ClickAction double_click;
+    WheelMode scroll_wheel;
+    ClickAction middle_click;
+    bool rename_sidecars;     /* rename .xmp and other sidecars along with the image */
+    bool raw_pairs;           /* delete and rename a JPEG's raw file along with it */
+    bool group_bursts;        /* stack burst shots in the search grid */
+    bool lossless_rotation;   /* save JPEG rotation as an EXIF orientation tag */
+    ResampleFilter export_resampler;
+
+    /* Navigation list */
+    bool show_hidden;           /* list dotfiles */
+    bool follow_symlinks;       /* list symlinks to images */
+    char exclude[1024];         /* comma-separated glob patterns of names to skip */
+    char start_directory[1024]; /* where open dialogs start without an image ("~/" allowed) */
+    char export_directory[1024]; /* default folder for exports, empty = next to the image */
+
+    /* PDF export */
+    PdfPageSize pdf_page_size;
+    PdfFit pdf_fit;
+    PdfLayout pdf_layout;
+
+    /* Contact sheets */
+    int contact_sheet_columns;
+    ContactCaption contact_sheet_captions;
+
+    /* Animation builder */
+    int animation_delay_ms;     /* time each frame is shown */
+    int animation_size;         /* longest edge of the output in pixels */
+
+    /* Image upload */
+    UploaderKind uploader;
+    char upload_command[1024];  /* file is $1, prints the URL */
+    char imgur_client_id[128];
+
+    /* Shell commands run on events (empty = disabled), see hooks.h */
+    char hook_image_changed[1024];
+    char hook_image_deleted[1024];
+    char hook_image_saved[1024];
+} FrameConfig;
+
+/* Load settings from $XDG_CONFIG_HOME/frame/config (falling back to
+   ~/.config/frame/config). The file is a list of `key = value` lines;
+   blank lines and lines starting with '#' are ignored. A missing file is
+   not an error — defaults are used. Unknown keys are reported on stderr. */
+void config_load(void);
+
+/* Get the active configuration. Never returns NULL. */
+const FrameConfig *config_get(void);
+
+/* Command-line overrides (applied after config_load). */
+void config_set_read_only(bool read_only);
+
+#endif /* FRAME_CONFIG_H */
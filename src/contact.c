@@ -0,0 +1,139 @@
+#define _GNU_SOURCE
+#include "contact.h"
+#include "loader.h"
+#include "exif.h"
+#include "resample.h"
+#include <SDL3_ttf/SDL_ttf.h>
+#include <stdio.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <time.h>
+
+#define PADDING 16
+#define CAPTION_LINE 22
+
+/* Height of the caption area below each thumbnail */
+static int caption_height(ContactCaption captions)
+{
+    switch (captions) {
+    case CONTACT_CAPTION_NONE: return 0;
+    case CONTACT_CAPTION_BOTH: return 2 * CAPTION_LINE;
+    default:                   return CAPTION_LINE;
+    }
+}
+
+int contact_sheet_capacity(int columns, int thumb_size)
+{
+    int cell_h = thumb_size + caption_height(CONTACT_CAPTION_BOTH) + PADDING;
+    int rows = (MAX_IMAGE_DIMENSION - PADDING) / cell_h;
+    return rows * columns;
+}
+
+/* Date for the caption: EXIF capture date if present, else the file's mtime */
+static void caption_date(const char *path, char *buf, size_t size)
+{
+    ExifInfo exif;
+    buf[0] = '\0';
+    if (exif_read(path, &exif) && exif.date[0]) {
+        /* "2024:06:01 14:03:22" -> "2024-06-01 14:03" */
+        snprintf(buf, size, "%.16s", exif.date);
+        if (strlen(buf) >= 10) {
+            buf[4] = '-';
+            buf[7] = '-';
+        }
+        return;
+    }
+
+    struct stat st;
+    if (stat(path, &st) == 0) {
+        struct tm *tm = localtime(&st.st_mtime);
+        if (tm) strftime(buf, size, "%Y-%m-%d %H:%M", tm);
+    }
+}
+
+/* Draw one caption line centred in the cell, clipped to its width */
+static void draw_caption(SDL_Surface *sheet, TTF_Font *font, const char *text,
+                         int cell_x, int y, int thumb_size)
+{
+    if (!text[0]) return;
+    SDL_Color color = { 40, 40, 40, 255 };
+    SDL_Surface *surf = TTF_RenderText_Blended(font, text, 0, color);
+    if (!surf) return;
+
+    SDL_Rect src = { 0, 0, surf->w < thumb_size ? surf->w : thumb_size, surf->h };
+    SDL_Rect dst = { cell_x + (thumb_size - src.w) / 2, y, src.w, src.h };
+    SDL_BlitSurface(surf, &src, sheet, &dst);
+    SDL_DestroySurface(surf);
+}
+
+SDL_Surface *contact_sheet_render(const char *const *paths, int count, int columns,
+                                  int thumb_size, ContactCaption captions, struct TTF_Font *font,
+                                  ResampleFilter filter, int *skipped)
+{
+    *skipped = 0;
+    if (count <= 0 || columns <= 0 || thumb_size <= 0) return NULL;
+    if (!font) captions = CONTACT_CAPTION_NONE;
+    if (columns > count) columns = count;
+
+    int cap_h = caption_height(captions);
+    int cell_w = thumb_size + PADDING;
+    int cell_h = thumb_size + cap_h + PADDING;
+    int rows = (count + columns - 1) / columns;
+    int sheet_w = columns * cell_w + PADDING;
+    int sheet_h = rows * cell_h + PADDING;
+    if (sheet_w > MAX_IMAGE_DIMENSION || sheet_h > MAX_IMAGE_DIMENSION) {
+        fprintf(stderr, "contact: %d images do not fit on one sheet\n", count);
+        return NULL;
+    }
+
+    SDL_Surface *sheet = SDL_CreateSurface(sheet_w, sheet_h, SDL_PIXELFORMAT_RGBA8888);
+    if (!sheet) return NULL;
+    SDL_FillSurfaceRect(sheet, NULL, SDL_MapSurfaceRGB(sheet, 255, 255, 255));
+
+    int empty = 0;
+    for (int i = 0; i < count; i++) {
+        if (!paths[i]) {
+            empty++;
+            continue;
+        }
+
+        int cell_x = PADDING + (i % columns) * cell_w;
+        int cell_y = PADDING + (i / columns) * cell_h;
+
+        SDL_Surface *image = loader_load_scaled(paths[i], thumb_size);
+        if (!image) {
+            (*skipped)++;
+            continue;
+        }
+
+        /* Fit the image into the square, centred */
+        float scale = (float)thumb_size / (float)(image->w > image->h ? image->w : image->h);
+        int tw = (int)(image->w * scale + 0.5f);
+        int th = (int)(image->h * scale + 0.5f);
+        if (tw < 1) tw = 1;
+        if (th < 1) th = 1;
+        SDL_Rect dst = { cell_x + (thumb_size - tw) / 2, cell_y + (thumb_size - th) / 2, tw, th };
+        resample_blit(image, sheet, &dst, filter);
+        SDL_DestroySurface(image);
+
+        if (captions == CONTACT_CAPTION_NONE) continue;
+
+        int y = cell_y + thumb_size + 2;
+        if (captions == CONTACT_CAPTION_NAME || captions == CONTACT_CAPTION_BOTH) {
+            const char *name = strrchr(paths[i], '/');
+            draw_caption(sheet, font, name ? name + 1 : paths[i], cell_x, y, thumb_size);
+            y += CAPTION_LINE;
+        }
+        if (captions == CONTACT_CAPTION_DATE || captions == CONTACT_CAPTION_BOTH) {
+            char date[32];
+            caption_date(paths[i], date, sizeof(date));
+            draw_caption(sheet, font, date, cell_x, y, thumb_size);
+        }
+    }
+
+    if (*skipped + empty == count) {
+        SDL_DestroySurface(sheet);
+        return NULL;
+    }
+    return sheet;
+}
@@ -0,0 +1,26 @@
+#ifndef FRAME_CONTACT_H
+#define FRAME_CONTACT_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+#include "config.h"
+
+struct TTF_Font;
+
+/* Default thumbnail edge length in pixels */
+#define CONTACT_THUMB_SIZE 256
+
+/* Tile thumbnails of the images into one sheet: `columns` per row, each
+   fitted into a thumb_size square with an optional caption below (file name
+   and/or date), scaled with the given filter. Captions need a font;
+   with font NULL they are left out. Images that fail to load are counted in
+   *skipped; NULL entries leave their cell blank. Returns a new RGBA surface the caller owns, or NULL if the sheet
+   would be too large or nothing could be drawn. */
+SDL_Surface *contact_sheet_render(const char *const *paths, int count, int columns,
+                                  int thumb_size, ContactCaption captions, struct TTF_Font *font,
+                                  ResampleFilter filter, int *skipped);
+
+/* Largest number of images that fit on one sheet with this many columns. */
+int contact_sheet_capacity(int columns, int thumb_size);
+
+#endif /* FRAME_CONTACT_H */
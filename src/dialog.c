@@ -0,0 +1,108 @@
+#define _GNU_SOURCE
+#include "dialog.h"
+#include "utils.h"
+#include "config.h"
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+/* The dialog callback may run on another thread, so the choice is handed
+   to the main loop under a lock. */
+static pthread_mutex_t selection_mutex = PTHREAD_MUTEX_INITIALIZER;
+static char *selection = NULL;
+
+/* "jpg;jpeg;png;..." built from image_formats */
+static char image_pattern[256];
+
+static void SDLCALL on_dialog_done(void *userdata, const char * const *filelist, int filter)
+{
+    (void)userdata;
+    (void)filter;
+
+    if (!filelist) {
+        fprintf(stderr, "dialog: %s\n", SDL_GetError());
+        return;
+    }
+    if (!filelist[0]) return; /* cancelled */
+
+    char *path = strdup(filelist[0]);
+    if (!path) return;
+
+    pthread_mutex_lock(&selection_mutex);
+    free(selection);
+    selection = path;
+    pthread_mutex_unlock(&selection_mutex);
+
+    /* Wake the main loop, which may be blocked waiting for events */
+    SDL_Event event;
+    memset(&event, 0, sizeof(event));
+    event.type = SDL_EVENT_USER;
+    SDL_PushEvent(&event);
+}
+
+/* Copy the start_directory setting into buf, expanding a leading "~/".
+   Returns NULL if it is not set. */
+static const char *configured_folder(char *buf, size_t size)
+{
+    const char *dir = config_get()->start_directory;
+    if (!dir[0] || !expand_home(dir, buf, size - 1)) return NULL;
+
+    /* A trailing slash marks it as a folder to start in */
+    strcat(buf, "/");
+    return buf;
+}
+
+/* Copy the folder of `current` into buf, or without an image the
+   configured start directory. Returns NULL if there is neither. */
+static const char *start_folder(const char *current, char *buf, size_t size)
+{
+    if (!current) return configured_folder(buf, size);
+    const char *slash = strrchr(current, '/');
+    if (!slash || slash == current) return NULL;
+
+    size_t len = (size_t)(slash - current);
+    if (len + 2 > size) return NULL;
+    memcpy(buf, current, len);
+    buf[len] = '/';
+    buf[len + 1] = '\0';
+    return buf;
+}
+
+void dialog_open_file(SDL_Window *window, const char *current)
+{
+    if (!image_pattern[0]) {
+        size_t len = 0;
+        for (int i = 0; image_formats[i].ext != NULL; i++) {
+            int n = snprintf(image_pattern + len, sizeof(image_pattern) - len, "%s%s",
+                             len ? ";" : "", image_formats[i].ext + 1);
+            if (n < 0 || (size_t)n >= sizeof(image_pattern) - len) break;
+            len += (size_t)n;
+        }
+    }
+
+    static const SDL_DialogFileFilter filters[] = {
+        { "Images", image_pattern },
+        { "All files", "*" },
+    };
+
+    char folder[4096];
+    SDL_ShowOpenFileDialog(on_dialog_done, NULL, window, filters, 2,
+                           start_folder(current, folder, sizeof(folder)), false);
+}
+
+void dialog_open_folder(SDL_Window *window, const char *current)
+{
+    char folder[4096];
+    SDL_ShowOpenFolderDialog(on_dialog_done, NULL, window,
+                             start_folder(current, folder, sizeof(folder)), false);
+}
+
+char *dialog_take_selection(void)
+{
+    pthread_mutex_lock(&selection_mutex);
+    char *path = selection;
+    selection = NULL;
+    pthread_mutex_unlock(&selection_mutex);
+    return path;
+}
@@ -0,0 +1,19 @@
+#ifndef FRAME_DIALOG_H
+#define FRAME_DIALOG_H
+
+#include <SDL3/SDL.h>
+
+/* Show the desktop's file chooser for picking an image. `current` (may be
+   NULL) is the image on screen; its folder is where the dialog starts,
+   otherwise the start_directory setting.
+   Returns immediately; the choice is collected with dialog_take_selection(). */
+void dialog_open_file(SDL_Window *window, const char *current);
+
+/* Same, but choose a folder to open as a whole. */
+void dialog_open_folder(SDL_Window *window, const char *current);
+
+/* Get the path chosen in a dialog since the last call, or NULL.
+   The caller must free the returned string. */
+char *dialog_take_selection(void);
+
+#endif /* FRAME_DIALOG_H */
@@ -4,113 +4,724 @@
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
+#include <strings.h>
+#include <sys/stat.h>
+#include <time.h>
+#include <unistd.h>
 
-char *exif_get_data(const char *path)
+/* Copy the formatted value of a tag into dst. Returns true if non-empty. */
+static bool read_tag(ExifData *ed, ExifIfd ifd, ExifTag tag, char *dst, size_t size)
+{
+    dst[0] = '\0';
+    ExifEntry *entry = exif_content_get_entry(ed->ifd[ifd], tag);
+    if (!entry) return false;
+    exif_entry_get_value(entry, dst, (unsigned int)size);
+    return dst[0] != '\0';
+}
+
+/* Read a GPS coordinate (degrees, minutes, seconds) as decimal degrees,
+   negative for a south or west reference. Returns false if it is missing. */
+static bool read_gps_coord(ExifData *ed, ExifTag tag, ExifTag ref_tag, double *out)
+{
+    ExifEntry *entry = exif_content_get_entry(ed->ifd[EXIF_IFD_GPS], tag);
+    if (!entry || entry->format != EXIF_FORMAT_RATIONAL || entry->components < 3 ||
+        entry->size < 24) {
+        return false;
+    }
+
+    ExifByteOrder order = exif_data_get_byte_order(ed);
+    double value = 0.0;
+    double unit = 1.0;
+    for (int i = 0; i < 3; i++) {
+        ExifRational r = exif_get_rational(entry->data + 8 * i, order);
+        if (r.denominator != 0) value += (double)r.numerator / r.denominator / unit;
+        unit *= 60.0;
+    }
+
+    ExifEntry *ref = exif_content_get_entry(ed->ifd[EXIF_IFD_GPS], ref_tag);
+    if (ref && ref->size > 0 && (ref->data[0] == 'S' || ref->data[0] == 'W')) value = -value;
+    *out = value;
+    return true;
+}
+
+bool exif_read(const char *path, ExifInfo *out)
 {
+    if (!out) return false;
+    memset(out, 0, sizeof(*out));
+    if (!path) return false;
+
     ExifData *ed = exif_data_new_from_file(path);
-    if (!ed) return NULL;
+    if (!ed) return false;
+
+    bool has_data = false;
+
+    /* IFD_0: camera make/model and orientation */
+    has_data |= read_tag(ed, EXIF_IFD_0, EXIF_TAG_MAKE, out->make, sizeof(out->make));
+    has_data |= read_tag(ed, EXIF_IFD_0, EXIF_TAG_MODEL, out->model, sizeof(out->model));
+    has_data |= read_tag(ed, EXIF_IFD_0, EXIF_TAG_ORIENTATION, out->orientation, sizeof(out->orientation));
+
+    /* EXIF sub-IFD for photo settings */
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_LENS_MODEL, out->lens, sizeof(out->lens));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_DATE_TIME_ORIGINAL, out->date, sizeof(out->date));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_EXPOSURE_TIME, out->exposure, sizeof(out->exposure));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_FNUMBER, out->aperture, sizeof(out->aperture));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_ISO_SPEED_RATINGS, out->iso, sizeof(out->iso));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_FOCAL_LENGTH, out->focal_length, sizeof(out->focal_length));
+    has_data |= read_tag(ed, EXIF_IFD_EXIF, EXIF_TAG_FLASH, out->flash, sizeof(out->flash));
+
+    /* GPS IFD */
+    out->has_gps = read_gps_coord(ed, EXIF_TAG_GPS_LATITUDE, EXIF_TAG_GPS_LATITUDE_REF, &out->latitude) &&
+                   read_gps_coord(ed, EXIF_TAG_GPS_LONGITUDE, EXIF_TAG_GPS_LONGITUDE_REF, &out->longitude);
+    has_data |= out->has_gps;
+
+    exif_data_unref(ed);
+    return has_data;
+}
+
+char *exif_format(const ExifInfo *info)
+{
+    if (!info) return NULL;
 
     char result[2048] = {0};
-    char value[256];
-    int has_data = 0;
-
-    ExifEntry *entry;
-
-    /* IFD_0: camera make/model */
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_0], EXIF_TAG_MAKE);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Make: %s\n", value);
-            has_data = 1;
+
+#define APPEND(fmt, field) \
+    if (info->field[0]) \
+        snprintf(result + strlen(result), sizeof(result) - strlen(result), fmt, info->field)
+
+    APPEND("Make: %s\n", make);
+    APPEND("Model: %s\n", model);
+    APPEND("Lens: %s\n", lens);
+    APPEND("Date: %s\n", date);
+    APPEND("Exposure: %ss\n", exposure);
+    APPEND("Aperture: f/%s\n", aperture);
+    APPEND("ISO: %s\n", iso);
+    APPEND("Orientation: %s\n", orientation);
+    APPEND("Focal Length: %s\n", focal_length);
+    APPEND("Flash: %s\n", flash);
+
+#undef APPEND
+
+    if (info->has_gps) {
+        snprintf(result + strlen(result), sizeof(result) - strlen(result),
+                 "Location: %.6f, %.6f\n", info->latitude, info->longitude);
+    }
+
+    if (!result[0]) return NULL;
+    return strdup(result);
+}
+
+/* Append text to buf, after sep unless buf is empty */
+static void summary_add(char *buf, size_t size, const char *sep, const char *text)
+{
+    size_t len = strlen(buf);
+    if (!text[0] || len >= size) return;
+    snprintf(buf + len, size - len, "%s%s", len ? sep : "", text);
+}
+
+/* Append shutter, aperture, ISO and focal length. libexif writes
+   "1/250 sec.", "f/4.0" and "50.0 mm" (sometimes with a 35mm equivalent
+   in brackets); keep them short. */
+static void settings_add(char *buf, size_t size, const char *sep, const ExifInfo *info)
+{
+    char part[96];
+
+    if (info->exposure[0]) {
+        size_t n = strcspn(info->exposure, " s");
+        snprintf(part, sizeof(part), "%.*s s", (int)n, info->exposure);
+        summary_add(buf, size, sep, part);
+    }
+    if (info->aperture[0]) {
+        snprintf(part, sizeof(part), "%s%s",
+                 strncasecmp(info->aperture, "f/", 2) == 0 ? "" : "f/", info->aperture);
+        summary_add(buf, size, sep, part);
+    }
+    if (info->iso[0]) {
+        snprintf(part, sizeof(part), "ISO %s", info->iso);
+        summary_add(buf, size, sep, part);
+    }
+    if (info->focal_length[0]) {
+        double mm = atof(info->focal_length);
+        if (mm > 0) {
+            snprintf(part, sizeof(part), "%g mm", mm);
+            summary_add(buf, size, sep, part);
         }
     }
+}
+
+char *exif_summary(const ExifInfo *info)
+{
+    if (!info) return NULL;
+
+    char result[512] = {0};
+    char part[96];
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_0], EXIF_TAG_MODEL);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Model: %s\n", value);
-            has_data = 1;
+    /* Most models already start with the brand ("Canon EOS R6"); others
+       need it ("ILCE-7M3" from "SONY"). Compare the brand's first word. */
+    if (info->model[0]) {
+        size_t brand = strcspn(info->make, " ");
+        if (brand > 0 && strncasecmp(info->model, info->make, brand) != 0) {
+            snprintf(part, sizeof(part), "%.*s %s", (int)brand, info->make, info->model);
+        } else {
+            snprintf(part, sizeof(part), "%s", info->model);
         }
+        summary_add(result, sizeof(result), ", ", part);
+    } else {
+        summary_add(result, sizeof(result), ", ", info->make);
     }
+    summary_add(result, sizeof(result), ", ", info->lens);
+    settings_add(result, sizeof(result), ", ", info);
 
-    /* EXIF sub-IFD for photo settings */
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_DATE_TIME_ORIGINAL);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Date: %s\n", value);
-            has_data = 1;
+    if (!result[0]) return NULL;
+    return strdup(result);
+}
+
+char *exif_settings(const ExifInfo *info, const char *separator)
+{
+    if (!info) return NULL;
+
+    char result[256] = {0};
+    settings_add(result, sizeof(result), separator ? separator : ", ", info);
+
+    if (!result[0]) return NULL;
+    return strdup(result);
+}
+
+char *exif_get_data(const char *path)
+{
+    ExifInfo info;
+    if (!exif_read(path, &info)) return NULL;
+    return exif_format(&info);
+}
+
+/* ---- orientation ---- */
+
+static int orientation_of(ExifData *ed)
+{
+    int orientation = 1;
+    ExifEntry *entry = exif_content_get_entry(ed->ifd[EXIF_IFD_0], EXIF_TAG_ORIENTATION);
+    if (entry && entry->format == EXIF_FORMAT_SHORT && entry->size >= 2) {
+        orientation = exif_get_short(entry->data, exif_data_get_byte_order(ed));
+    }
+    return orientation >= 1 && orientation <= 8 ? orientation : 1;
+}
+
+int exif_read_orientation(const char *path)
+{
+    ExifData *ed = path ? exif_data_new_from_file(path) : NULL;
+    if (!ed) return 1;
+    int orientation = orientation_of(ed);
+    exif_data_unref(ed);
+    return orientation;
+}
+
+int exif_orientation_from_data(const void *data, size_t size)
+{
+    ExifData *ed = data ? exif_data_new_from_data(data, (unsigned int)size) : NULL;
+    if (!ed) return 1;
+    int orientation = orientation_of(ed);
+    exif_data_unref(ed);
+    return orientation;
+}
+
+/* Orientation 1-8 as a horizontal mirror followed by a clockwise rotation */
+static const struct { bool mirror; int degrees; } orientation_steps[9] = {
+    { false, 0 },
+    { false, 0 }, { true, 0 }, { false, 180 }, { true, 180 },
+    { true, 270 }, { false, 90 }, { true, 90 }, { false, 270 },
+};
+
+int exif_orientation_rotate(int orientation, int degrees)
+{
+    if (orientation < 1 || orientation > 8) orientation = 1;
+    bool mirror = orientation_steps[orientation].mirror;
+    int total = ((orientation_steps[orientation].degrees + degrees) % 360 + 360) % 360;
+    for (int i = 1; i <= 8; i++) {
+        if (orientation_steps[i].mirror == mirror && orientation_steps[i].degrees == total) return i;
+    }
+    return 1;
+}
+
+static unsigned int get16(const unsigned char *p, bool le)
+{
+    return le ? (unsigned int)p[1] << 8 | p[0] : (unsigned int)p[0] << 8 | p[1];
+}
+
+static unsigned long get32(const unsigned char *p, bool le)
+{
+    return le ? (unsigned long)get16(p + 2, true) << 16 | get16(p, true)
+              : (unsigned long)get16(p, false) << 16 | get16(p + 2, false);
+}
+
+/* Find the file offset of the orientation value in an Exif APP1 segment
+   (TIFF data of `size` bytes at file offset `base`). Returns -1 if the tag
+   is missing, *le is set to the byte order. */
+static long find_orientation_value(const unsigned char *tiff, size_t size, long base, bool *le)
+{
+    if (size < 8) return -1;
+    if (memcmp(tiff, "II*\0", 4) == 0) {
+        *le = true;
+    } else if (memcmp(tiff, "MM\0*", 4) == 0) {
+        *le = false;
+    } else {
+        return -1;
+    }
+
+    unsigned long ifd = get32(tiff + 4, *le);
+    if (ifd + 2 > size) return -1;
+    unsigned int count = get16(tiff + ifd, *le);
+    for (unsigned int i = 0; i < count; i++) {
+        unsigned long entry = ifd + 2 + 12UL * i;
+        if (entry + 12 > size) return -1;
+        if (get16(tiff + entry, *le) == 0x0112) {
+            if (get16(tiff + entry + 2, *le) != 3) return -1;  /* SHORT */
+            return base + (long)entry + 8;
+        }
+    }
+    return -1;
+}
+
+/* Write data over path via a temporary file, keeping the permissions */
+static bool replace_file(const char *path, const unsigned char *data, size_t size)
+{
+    char tmp[4096];
+    const char *slash = strrchr(path, '/');
+    int dir_len = slash ? (int)(slash - path) : 1;
+    int ret = snprintf(tmp, sizeof(tmp), "%.*s/.%s.frame-save", dir_len, slash ? path : ".",
+                       slash ? slash + 1 : path);
+    if (ret < 0 || (size_t)ret >= sizeof(tmp)) return false;
+
+    FILE *fp = fopen(tmp, "wb");
+    if (!fp) return false;
+    bool ok = fwrite(data, 1, size, fp) == size;
+    ok = fclose(fp) == 0 && ok;
+
+    struct stat st;
+    if (ok && stat(path, &st) == 0) chmod(tmp, st.st_mode & 07777);
+    if (!ok || rename(tmp, path) != 0) {
+        unlink(tmp);
+        return false;
+    }
+    return true;
+}
+
+/* Read a whole JPEG file. Returns NULL if it cannot be read or is not a JPEG. */
+static unsigned char *read_jpeg(const char *path, long *out_size)
+{
+    FILE *fp = fopen(path, "rb");
+    if (!fp) return NULL;
+    unsigned char *data = NULL;
+    long size = -1;
+    if (fseek(fp, 0, SEEK_END) == 0) size = ftell(fp);
+    if (size > 4 && fseek(fp, 0, SEEK_SET) == 0) {
+        data = malloc((size_t)size);
+        if (data && fread(data, 1, (size_t)size, fp) != (size_t)size) {
+            free(data);
+            data = NULL;
         }
     }
+    fclose(fp);
+    if (!data || data[0] != 0xFF || data[1] != 0xD8) {
+        free(data);
+        return NULL;
+    }
+    *out_size = size;
+    return data;
+}
+
+bool exif_write_orientation(const char *path, int orientation)
+{
+    if (!path || orientation < 1 || orientation > 8) return false;
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_EXPOSURE_TIME);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Exposure: %ss\n", value);
-            has_data = 1;
+    long size = 0;
+    unsigned char *data = read_jpeg(path, &size);
+    if (!data) return false;
+
+    /* Walk the segments before the image data looking for Exif APP1 */
+    long insert_at = 2;
+    long pos = 2;
+    bool ok = false, has_exif = false;
+    while (pos + 4 <= size && data[pos] == 0xFF) {
+        unsigned char marker = data[pos + 1];
+        if (marker == 0xDA || marker == 0xD9) break;  /* start of scan, end */
+        long len = (long)get16(data + pos + 2, false);
+        if (len < 2 || pos + 2 + len > size) break;
+
+        if (marker == 0xE0 && insert_at == pos) {
+            insert_at = pos + 2 + len;  /* new EXIF goes after JFIF APP0 */
+        } else if (marker == 0xE1 && len >= 8 && memcmp(data + pos + 4, "Exif\0\0", 6) == 0) {
+            has_exif = true;
+            bool le = false;
+            long value = find_orientation_value(data + pos + 10, (size_t)(len - 8), pos + 10, &le);
+            if (value >= 0) {
+                /* Two bytes change; the rest of the file is left alone */
+                FILE *out = fopen(path, "r+b");
+                if (out) {
+                    unsigned char v[2] = {
+                        (unsigned char)(le ? orientation : 0),
+                        (unsigned char)(le ? 0 : orientation),
+                    };
+                    ok = fseek(out, value, SEEK_SET) == 0 && fwrite(v, 1, 2, out) == 2;
+                    ok = fclose(out) == 0 && ok;
+                }
+            }
+            break;
         }
+        pos += 2 + len;
     }
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_FNUMBER);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Aperture: f/%s\n", value);
-            has_data = 1;
+    if (!has_exif) {
+        /* Exif header, little-endian TIFF with IFD0 holding only the tag */
+        static const unsigned char segment_head[] = {
+            0xFF, 0xE1, 0x00, 0x22, 'E', 'x', 'i', 'f', 0, 0,
+            'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00,
+            0x01, 0x00, 0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00,
+        };
+        size_t extra = sizeof(segment_head) + 8;
+        unsigned char *out = malloc((size_t)size + extra);
+        if (out) {
+            unsigned char *p = out;
+            memcpy(p, data, (size_t)insert_at);
+            p += insert_at;
+            memcpy(p, segment_head, sizeof(segment_head));
+            p += sizeof(segment_head);
+            const unsigned char tail[8] = { (unsigned char)orientation, 0, 0, 0, 0, 0, 0, 0 };
+            memcpy(p, tail, sizeof(tail));
+            p += sizeof(tail);
+            memcpy(p, data + insert_at, (size_t)(size - insert_at));
+            ok = replace_file(path, out, (size_t)size + extra);
+            free(out);
         }
     }
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_ISO_SPEED_RATINGS);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "ISO: %s\n", value);
-            has_data = 1;
+    free(data);
+    return ok;
+}
+
+/* Copy the segments of a JPEG worth keeping across a re-encode: Exif and
+   XMP (APP1) and the ICC profile (APP2). Returns the number of bytes
+   written to out, or -1 if the segments are malformed. */
+static long copy_metadata_segments(const unsigned char *data, long size, unsigned char *out)
+{
+    long n = 0;
+    long pos = 2;
+    while (pos + 4 <= size && data[pos] == 0xFF) {
+        unsigned char marker = data[pos + 1];
+        if (marker == 0xDA || marker == 0xD9) break;
+        long len = (long)get16(data + pos + 2, false);
+        if (len < 2 || pos + 2 + len > size) return -1;
+
+        if (marker == 0xE1 || marker == 0xE2) {
+            unsigned char *seg = out + n;
+            memcpy(seg, data + pos, (size_t)(len + 2));
+            if (marker == 0xE1 && len >= 8 && memcmp(seg + 4, "Exif\0\0", 6) == 0) {
+                /* The pixels are stored upright now */
+                bool le = false;
+                long value = find_orientation_value(seg + 10, (size_t)(len - 8), 10, &le);
+                if (value >= 0) {
+                    seg[value] = le ? 1 : 0;
+                    seg[value + 1] = le ? 0 : 1;
+                }
+            }
+            n += 2 + len;
         }
+        pos += 2 + len;
     }
+    return n;
+}
 
-    /* IFD_0: orientation */
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_0], EXIF_TAG_ORIENTATION);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Orientation: %s\n", value);
-            has_data = 1;
+bool exif_copy_metadata(const char *source, const char *target)
+{
+    long src_size = 0, dst_size = 0;
+    unsigned char *src = read_jpeg(source, &src_size);
+    unsigned char *dst = src ? read_jpeg(target, &dst_size) : NULL;
+    unsigned char *out = dst ? malloc((size_t)(src_size + dst_size)) : NULL;
+    if (!out) {
+        free(src);
+        free(dst);
+        return false;
+    }
+
+    /* SOI and the encoder's JFIF APP0 stay first */
+    long n = 2;
+    long pos = 2;
+    memcpy(out, dst, 2);
+    if (pos + 4 <= dst_size && dst[pos] == 0xFF && dst[pos + 1] == 0xE0) {
+        long len = (long)get16(dst + pos + 2, false);
+        if (len >= 2 && pos + 2 + len <= dst_size) {
+            memcpy(out + n, dst + pos, (size_t)(len + 2));
+            n += 2 + len;
+            pos += 2 + len;
         }
     }
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_FOCAL_LENGTH);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Focal Length: %s\n", value);
-            has_data = 1;
+    long copied = copy_metadata_segments(src, src_size, out + n);
+    bool ok = copied >= 0;
+    n += copied;
+
+    /* The rest of the new file, minus whatever metadata the encoder wrote */
+    while (ok && pos + 4 <= dst_size && dst[pos] == 0xFF) {
+        unsigned char marker = dst[pos + 1];
+        if (marker == 0xDA || marker == 0xD9) break;
+        long len = (long)get16(dst + pos + 2, false);
+        if (len < 2 || pos + 2 + len > dst_size) {
+            ok = false;
+            break;
         }
+        if (marker != 0xE1 && marker != 0xE2) {
+            memcpy(out + n, dst + pos, (size_t)(len + 2));
+            n += 2 + len;
+        }
+        pos += 2 + len;
+    }
+    if (ok) {
+        memcpy(out + n, dst + pos, (size_t)(dst_size - pos));
+        n += dst_size - pos;
+        ok = replace_file(target, out, (size_t)n);
     }
 
-    entry = exif_content_get_entry(ed->ifd[EXIF_IFD_EXIF], EXIF_TAG_FLASH);
-    if (entry) {
-        exif_entry_get_value(entry, value, sizeof(value));
-        if (value[0]) {
-            snprintf(result + strlen(result), sizeof(result) - strlen(result),
-                     "Flash: %s\n", value);
-            has_data = 1;
+    free(out);
+    free(dst);
+    free(src);
+    return ok;
+}
+
+/* ---- GPS ---- */
+
+#define TAG_GPS_IFD_POINTER 0x8825
+#define TAG_EXIF_IFD_POINTER 0x8769
+#define TAG_DATE_TIME 0x0132
+#define TAG_DATE_TIME_ORIGINAL 0x9003
+#define TAG_DATE_TIME_DIGITIZED 0x9004
+
+static void put16(unsigned char *p, unsigned int v, bool le)
+{
+    p[le ? 0 : 1] = (unsigned char)(v & 0xFF);
+    p[le ? 1 : 0] = (unsigned char)(v >> 8);
+}
+
+static void put32(unsigned char *p, unsigned long v, bool le)
+{
+    put16(p + (le ? 0 : 2), (unsigned int)(v & 0xFFFF), le);
+    put16(p + (le ? 2 : 0), (unsigned int)(v >> 16), le);
+}
+
+/* Find the TIFF data of the Exif APP1 segment of a JPEG in memory. Returns
+   NULL if there is none; *le is set to its byte order. */
+static unsigned char *find_tiff(unsigned char *data, long size, size_t *tiff_size, bool *le)
+{
+    long pos = 2;
+    while (pos + 4 <= size && data[pos] == 0xFF) {
+        unsigned char marker = data[pos + 1];
+        if (marker == 0xDA || marker == 0xD9) break;
+        long len = (long)get16(data + pos + 2, false);
+        if (len < 2 || pos + 2 + len > size) break;
+
+        if (marker == 0xE1 && len >= 16 && memcmp(data + pos + 4, "Exif\0\0", 6) == 0) {
+            unsigned char *tiff = data + pos + 10;
+            if (memcmp(tiff, "II*\0", 4) == 0) {
+                *le = true;
+            } else if (memcmp(tiff, "MM\0*", 4) == 0) {
+                *le = false;
+            } else {
+                return NULL;
+            }
+            *tiff_size = (size_t)(len - 8);
+            return tiff;
         }
+        pos += 2 + len;
     }
+    return NULL;
+}
 
-    exif_data_unref(ed);
+/* Find entry `tag` of the IFD at offset ifd. Returns its offset in the TIFF
+   data and the entry's position in *index, or -1 if it is not there. */
+static long find_entry(const unsigned char *tiff, size_t size, unsigned long ifd,
+                       unsigned int tag, bool le, int *index)
+{
+    if (ifd + 2 > size) return -1;
+    unsigned int count = get16(tiff + ifd, le);
+    for (unsigned int i = 0; i < count; i++) {
+        unsigned long entry = ifd + 2 + 12UL * i;
+        if (entry + 12 > size) return -1;
+        if (get16(tiff + entry, le) == tag) {
+            if (index) *index = (int)i;
+            return (long)entry;
+        }
+    }
+    return -1;
+}
 
-    if (!has_data) return NULL;
-    return strdup(result);
+/* Offset of the GPS IFD, or 0 if the image has none */
+static unsigned long gps_ifd(const unsigned char *tiff, size_t size, bool le, long *pointer, int *index)
+{
+    long entry = find_entry(tiff, size, get32(tiff + 4, le), TAG_GPS_IFD_POINTER, le, index);
+    if (entry < 0) return 0;
+    if (pointer) *pointer = entry;
+    unsigned long ifd = get32(tiff + entry + 8, le);
+    return ifd + 2 <= size ? ifd : 0;
+}
+
+int exif_strip_gps(const char *path)
+{
+    if (!path) return -1;
+
+    long size = 0;
+    unsigned char *data = read_jpeg(path, &size);
+    if (!data) return -1;
+
+    size_t tsize = 0;
+    bool le = false;
+    unsigned char *tiff = find_tiff(data, size, &tsize, &le);
+    long pointer = -1;
+    int index = 0;
+    unsigned long gps = tiff ? gps_ifd(tiff, tsize, le, &pointer, &index) : 0;
+    if (!gps) {
+        free(data);
+        return 0;
+    }
+
+    /* Zero every value stored outside the GPS IFD, then the IFD itself */
+    static const int type_size[] = { 1, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8 };
+    unsigned int count = get16(tiff + gps, le);
+    unsigned long ifd_end = gps + 2 + 12UL * count + 4;
+    if (ifd_end > tsize) {
+        free(data);
+        return -1;
+    }
+    for (unsigned int i = 0; i < count; i++) {
+        const unsigned char *entry = tiff + gps + 2 + 12UL * i;
+        unsigned int type = get16(entry + 2, le);
+        unsigned long bytes = get32(entry + 4, le) * (unsigned long)type_size[type < 13 ? type : 0];
+        unsigned long offset = get32(entry + 8, le);
+        if (bytes > 4 && offset < tsize && bytes <= tsize - offset) {
+            memset(tiff + offset, 0, bytes);
+        }
+    }
+    memset(tiff + gps, 0, ifd_end - gps);
+
+    /* Drop the pointer from IFD0: later entries and the next-IFD offset move
+       up one slot. Nothing else points into IFD0, so the file stays valid. */
+    unsigned long ifd0 = get32(tiff + 4, le);
+    unsigned int entries = get16(tiff + ifd0, le);
+    if (ifd0 + 2 + 12UL * entries + 4 > tsize) {
+        free(data);
+        return -1;
+    }
+    unsigned long after = (unsigned long)pointer + 12;
+    unsigned long tail = 12UL * (entries - 1 - (unsigned int)index) + 4;
+    memmove(tiff + pointer, tiff + after, tail);
+    memset(tiff + pointer + tail, 0, 12);
+    put16(tiff + ifd0, entries - 1, le);
+
+    bool ok = replace_file(path, data, (size_t)size);
+    free(data);
+    return ok ? 1 : -1;
+}
+
+/* Write decimal degrees over a GPS coordinate entry (three RATIONALs) and
+   its reference letter. Returns false if either entry is unusable. */
+static bool patch_coord(unsigned char *tiff, size_t size, unsigned long gps, bool le,
+                        unsigned int tag, unsigned int ref_tag, double value, char pos, char neg)
+{
+    long entry = find_entry(tiff, size, gps, tag, le, NULL);
+    long ref = find_entry(tiff, size, gps, ref_tag, le, NULL);
+    if (entry < 0 || ref < 0) return false;
+    if (get16(tiff + entry + 2, le) != 5 || get32(tiff + entry + 4, le) < 3) return false;
+    if (get16(tiff + ref + 2, le) != 2 || get32(tiff + ref + 4, le) > 4) return false;
+    unsigned long offset = get32(tiff + entry + 8, le);
+    if (offset >= size || size - offset < 24) return false;
+
+    double a = value < 0 ? -value : value;
+    unsigned long deg = (unsigned long)a;
+    unsigned long min = (unsigned long)((a - deg) * 60.0);
+    unsigned long sec = (unsigned long)(((a - deg) * 60.0 - min) * 60.0 * 10000.0 + 0.5);
+    const unsigned long parts[6] = { deg, 1, min, 1, sec, 10000 };
+    for (int i = 0; i < 6; i++) put32(tiff + offset + 4 * i, parts[i], le);
+
+    tiff[ref + 8] = (unsigned char)(value < 0 ? neg : pos);
+    tiff[ref + 9] = 0;
+    return true;
+}
+
+bool exif_write_gps(const char *path, double latitude, double longitude)
+{
+    if (!path || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180) return false;
+
+    long size = 0;
+    unsigned char *data = read_jpeg(path, &size);
+    if (!data) return false;
+
+    size_t tsize = 0;
+    bool le = false;
+    unsigned char *tiff = find_tiff(data, size, &tsize, &le);
+    unsigned long gps = tiff ? gps_ifd(tiff, tsize, le, NULL, NULL) : 0;
+    bool ok = gps &&
+              patch_coord(tiff, tsize, gps, le, 0x0002, 0x0001, latitude, 'N', 'S') &&
+              patch_coord(tiff, tsize, gps, le, 0x0004, 0x0003, longitude, 'E', 'W') &&
+              replace_file(path, data, (size_t)size);
+    free(data);
+    return ok;
+}
+
+/* Move the "YYYY:MM:DD HH:MM:SS" value of a date entry by `seconds`.
+   Returns false if the entry is missing or not a date. */
+static bool shift_date(unsigned char *tiff, size_t size, unsigned long ifd, unsigned int tag,
+                       bool le, long seconds)
+{
+    long entry = find_entry(tiff, size, ifd, tag, le, NULL);
+    if (entry < 0) return false;
+    if (get16(tiff + entry + 2, le) != 2 || get32(tiff + entry + 4, le) < 20) return false;
+    unsigned long offset = get32(tiff + entry + 8, le);
+    if (offset >= size || size - offset < 20) return false;
+
+    char text[20];
+    memcpy(text, tiff + offset, 19);
+    text[19] = '\0';
+    struct tm tm;
+    memset(&tm, 0, sizeof(tm));
+    if (sscanf(text, "%4d:%2d:%2d %2d:%2d:%2d", &tm.tm_year, &tm.tm_mon, &tm.tm_mday,
+               &tm.tm_hour, &tm.tm_min, &tm.tm_sec) != 6 || tm.tm_year < 1900) {
+        return false;
+    }
+    tm.tm_year -= 1900;
+    tm.tm_mon -= 1;
+
+    /* Camera clocks have no time zone: do the arithmetic in UTC so daylight
+       saving changes in between do not add or lose an hour */
+    time_t t = timegm(&tm) + seconds;
+    if (!gmtime_r(&t, &tm)) return false;
+    int len = snprintf(text, sizeof(text), "%04d:%02d:%02d %02d:%02d:%02d", tm.tm_year + 1900,
+                       tm.tm_mon + 1, tm.tm_mday, tm.tm_hour, tm.tm_min, tm.tm_sec);
+    if (len != 19) return false;
+    memcpy(tiff + offset, text, 20);
+    return true;
+}
+
+int exif_shift_dates(const char *path, long seconds)
+{
+    if (!path) return -1;
+
+    long size = 0;
+    unsigned char *data = read_jpeg(path, &size);
+    if (!data) return -1;
+
+    size_t tsize = 0;
+    bool le = false;
+    unsigned char *tiff = find_tiff(data, size, &tsize, &le);
+    int shifted = 0;
+    if (tiff) {
+        unsigned long ifd0 = get32(tiff + 4, le);
+        shifted += shift_date(tiff, tsize, ifd0, TAG_DATE_TIME, le, seconds);
+
+        long pointer = find_entry(tiff, tsize, ifd0, TAG_EXIF_IFD_POINTER, le, NULL);
+        unsigned long exif_ifd = pointer >= 0 ? get32(tiff + pointer + 8, le) : 0;
+        if (exif_ifd && exif_ifd + 2 <= tsize) {
+            shifted += shift_date(tiff, tsize, exif_ifd, TAG_DATE_TIME_ORIGINAL, le, seconds);
+            shifted += shift_date(tiff, tsize, exif_ifd, TAG_DATE_TIME_DIGITIZED, le, seconds);
+        }
+    }
+
+    if (shifted > 0 && !replace_file(path, data, (size_t)size)) shifted = -1;
+    free(data);
+    return shifted;
 }
@@ -1,6 +1,84 @@
 #ifndef FRAME_EXIF_H
 #define FRAME_EXIF_H
 
+#include <stdbool.h>
+#include <stddef.h>
+
+/* Selected EXIF fields as formatted by libexif.
+   Empty strings mean the tag is absent. */
+typedef struct {
+    char make[64];
+    char model[64];
+    char lens[64];
+    char date[32];
+    char exposure[32];
+    char aperture[32];
+    char iso[16];
+    char orientation[32];
+    char focal_length[32];
+    char flash[64];
+    bool has_gps;           /* latitude and longitude are set */
+    double latitude;        /* decimal degrees, negative south */
+    double longitude;       /* decimal degrees, negative west */
+} ExifInfo;
+
+/* Read the EXIF fields of an image file into out (always cleared first).
+   Returns true if at least one field was found. */
+bool exif_read(const char *path, ExifInfo *out);
+
+/* Format previously read EXIF fields as "Key: value" lines.
+   Returns NULL if no field is set. The caller must free the returned string. */
+char *exif_format(const ExifInfo *info);
+
+/* One line describing how a photo was taken, for sharing settings:
+   "Canon EOS R6, RF24-105mm F4 L IS USM, 1/250 s, f/4.0, ISO 800, 50 mm".
+   Missing fields are left out; returns NULL if none is known.
+   The caller must free the returned string. */
+char *exif_summary(const ExifInfo *info);
+
+/* Just the exposure settings and focal length ("1/250 s", "f/4.0",
+   "ISO 800", "50 mm") joined by separator. Returns NULL if none is known.
+   The caller must free the returned string. */
+char *exif_settings(const ExifInfo *info, const char *separator);
+
+/* Get the EXIF orientation (1-8) of an image file or of a JPEG in memory.
+   Returns 1 (upright) if there is no valid tag. */
+int exif_read_orientation(const char *path);
+int exif_orientation_from_data(const void *data, size_t size);
+
+/* Combine an orientation with a further clockwise rotation of 0, 90, 180
+   or 270 degrees. Returns the orientation showing the result. */
+int exif_orientation_rotate(int orientation, int degrees);
+
+/* Set the orientation tag of a JPEG file without re-encoding it: the tag
+   is patched in place, or a minimal EXIF segment is added to files that
+   have none. Returns false if the file is not a JPEG or has EXIF data
+   without an orientation tag (the caller may re-encode instead). */
+bool exif_write_orientation(const char *path, int orientation);
+
+/* Carry the Exif, XMP and ICC profile segments of the JPEG source over
+   into the freshly encoded JPEG target, replacing any the encoder wrote,
+   with the orientation tag reset to 1. Returns false (target untouched)
+   if either file is not a readable JPEG or could not be written. */
+bool exif_copy_metadata(const char *source, const char *target);
+
+/* Remove the GPS data of a JPEG: the GPS IFD and every value it points to
+   are zeroed and the pointer to it is dropped, without re-encoding.
+   Returns 1 if location data was removed, 0 if there was none, -1 if the
+   file is not a JPEG or could not be written. */
+int exif_strip_gps(const char *path);
+
+/* Change the coordinates of a JPEG that already has GPS latitude and
+   longitude tags, in place. Returns false (leaving the file alone) if
+   there are no such tags to overwrite; the caller may use a sidecar. */
+bool exif_write_gps(const char *path, double latitude, double longitude);
+
+/* Move the capture dates of a JPEG (DateTime, DateTimeOriginal and
+   DateTimeDigitized) by `seconds`, in place, to correct a camera clock
+   that was set wrong. Returns the number of dates changed (0 if the file
+   has none), or -1 if it is not a JPEG or could not be written. */
+int exif_shift_dates(const char *path, long seconds);
+
 /* Extract EXIF metadata from an image file.
    Returns a dynamically allocated string with formatted EXIF data,
    or NULL if no EXIF data is present or extraction fails.
@@ -1,13 +1,17 @@
 #define _DEFAULT_SOURCE
 #include "fileops.h"
+#include "utils.h"
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
+#include <strings.h>
+#include <ctype.h>
 #include <sys/stat.h>
 #include <unistd.h>
 #include <time.h>
 #include <libgen.h>
-#include <pwd.h>
+#include <errno.h>
+#include <stdbool.h>
 
 /* ---- helpers ---- */
 
@@ -23,57 +27,99 @@ static char *get_dirname_safe(const char *path) {
     return result;
 }
 
-/* Get the home directory. Returns a pointer to a static string (do not free)
-   or NULL on failure. */
-static const char *get_home_dir(void) {
-    const char *home = getenv("HOME");
-    if (home) return home;
-
-    struct passwd *pw = getpwuid(getuid());
-    if (pw) return pw->pw_dir;
-
-    return NULL;
-}
-
-/* Ensure a directory exists. Returns 0 on success, -1 on failure.
-   Does NOT fail if the directory already exists. */
+/* Ensure a directory exists, creating missing parents (like mkdir -p).
+   Returns 0 on success, -1 on failure. */
 static int ensure_dir(const char *path) {
     struct stat st;
-    if (stat(path, &st) == 0) {
-        if (S_ISDIR(st.st_mode)) return 0;
+    if (stat(path, &st) == 0 && !S_ISDIR(st.st_mode)) {
         fprintf(stderr, "trash: '%s' exists but is not a directory\n", path);
         return -1;
     }
-    if (mkdir(path, 0700) != 0) {
+    if (!make_dirs(path)) {
         perror("trash: mkdir");
         return -1;
     }
     return 0;
 }
 
-/* ---- public API ---- */
+/* Check whether we run inside a Flatpak sandbox. */
+static bool is_sandboxed(void) {
+    return access("/.flatpak-info", F_OK) == 0;
+}
 
-int fileops_trash(const char *path) {
-    if (!path) {
-        fprintf(stderr, "trash: path is NULL\n");
-        return -1;
+/* Build the home trash directory: $XDG_DATA_HOME/Trash, or
+   ~/.local/share/Trash. Inside Flatpak, XDG_DATA_HOME points at the
+   app's private data directory, so the host location is used instead. */
+static bool get_home_trash(char *buf, size_t size) {
+    int ret;
+    const char *xdg = getenv("XDG_DATA_HOME");
+    if (xdg && xdg[0] == '/' && !is_sandboxed()) {
+        ret = snprintf(buf, size, "%s/Trash", xdg);
+    } else {
+        const char *home = home_dir();
+        if (!home) {
+            fprintf(stderr, "trash: cannot determine home directory\n");
+            return false;
+        }
+        ret = snprintf(buf, size, "%s/.local/share/Trash", home);
+    }
+    if (ret < 0 || (size_t)ret >= size) {
+        fprintf(stderr, "trash: trash path too long\n");
+        return false;
     }
+    return true;
+}
 
-    const char *home = get_home_dir();
-    if (!home) {
-        fprintf(stderr, "trash: cannot determine home directory\n");
-        return -1;
+/* Find the top directory of the mount point containing `path`. */
+static char *get_mount_top(const char *path) {
+    struct stat st;
+    if (stat(path, &st) != 0) return NULL;
+    dev_t dev = st.st_dev;
+
+    char *top = get_dirname_safe(path);
+    while (top && strcmp(top, "/") != 0) {
+        char *parent = get_dirname_safe(top);
+        if (!parent) break;
+        if (stat(parent, &st) != 0 || st.st_dev != dev) {
+            free(parent);
+            break;
+        }
+        free(top);
+        top = parent;
+    }
+    return top;
+}
+
+/* Pick the trash directory on the volume mounted at `top`: $top/.Trash/$uid
+   if the administrator set up a shared .Trash (sticky, not a symlink),
+   otherwise $top/.Trash-$uid. */
+static bool get_volume_trash(const char *top, char *buf, size_t size) {
+    char shared[4096];
+    struct stat st;
+    int ret = snprintf(shared, sizeof(shared), "%s/.Trash", top);
+    if (ret > 0 && (size_t)ret < sizeof(shared) &&
+        lstat(shared, &st) == 0 && S_ISDIR(st.st_mode) && (st.st_mode & S_ISVTX)) {
+        ret = snprintf(buf, size, "%s/%u", shared, (unsigned)getuid());
+    } else {
+        ret = snprintf(buf, size, "%s/.Trash-%u", top, (unsigned)getuid());
     }
+    return ret > 0 && (size_t)ret < size;
+}
 
+/* Move `path` into the trash directory `trash_dir`, recording `info_path`
+   (the Path= value) in its .trashinfo file.
+   Returns 0 on success, -1 on error. errno is EXDEV if the trash is on
+   another filesystem, so the caller can try a different trash. */
+static int trash_into(const char *trash_dir, const char *path, const char *info_value) {
     /* Build trash directory paths */
     char trash_files[4096];
     char trash_info[4096];
-    int ret = snprintf(trash_files, sizeof(trash_files), "%s/.local/share/Trash/files", home);
+    int ret = snprintf(trash_files, sizeof(trash_files), "%s/files", trash_dir);
     if (ret < 0 || (size_t)ret >= sizeof(trash_files)) {
         fprintf(stderr, "trash: trash files path too long\n");
         return -1;
     }
-    ret = snprintf(trash_info, sizeof(trash_info), "%s/.local/share/Trash/info", home);
+    ret = snprintf(trash_info, sizeof(trash_info), "%s/info", trash_dir);
     if (ret < 0 || (size_t)ret >= sizeof(trash_info)) {
         fprintf(stderr, "trash: trash info path too long\n");
         return -1;
@@ -169,7 +215,7 @@ int fileops_trash(const char *path) {
         return -1;
     }
     fprintf(fp, "[Trash Info]\n");
-    fprintf(fp, "Path=%s\n", path);
+    fprintf(fp, "Path=%s\n", info_value);
     fprintf(fp, "DeletionDate=%s\n", time_buf);
     fclose(fp);
 
@@ -183,10 +229,12 @@ int fileops_trash(const char *path) {
 
     /* Move the file to the trash */
     if (rename(path, dest) != 0) {
-        perror("trash: rename file");
+        int saved = errno;
+        if (saved != EXDEV) perror("trash: rename file");
         /* Clean up the orphaned .trashinfo */
         unlink(info_path);
         free(base);
+        errno = saved;
         return -1;
     }
 
@@ -194,6 +242,47 @@ int fileops_trash(const char *path) {
     return 0;
 }
 
+/* ---- public API ---- */
+
+int fileops_trash(const char *path) {
+    if (!path) {
+        fprintf(stderr, "trash: path is NULL\n");
+        return -1;
+    }
+
+    char trash_dir[4096];
+    if (!get_home_trash(trash_dir, sizeof(trash_dir))) return -1;
+
+    if (trash_into(trash_dir, path, path) == 0) return 0;
+    if (errno != EXDEV) return -1;
+
+    /* The file lives on another filesystem (USB stick, second disk):
+       use that volume's own trash, with Path= relative to its top. */
+    char *top = get_mount_top(path);
+    if (!top) {
+        fprintf(stderr, "trash: cannot find mount point of '%s'\n", path);
+        return -1;
+    }
+
+    int result = -1;
+    size_t top_len = strlen(top);
+    const char *relative = path;
+    if (strncmp(path, top, top_len) == 0 && path[top_len] == '/') {
+        relative = path + top_len + 1;
+    } else if (strcmp(top, "/") == 0 && path[0] == '/') {
+        relative = path + 1;
+    }
+
+    if (get_volume_trash(top, trash_dir, sizeof(trash_dir))) {
+        result = trash_into(trash_dir, path, relative);
+        if (result != 0 && errno == EXDEV) {
+            fprintf(stderr, "trash: '%s' cannot be moved to any trash\n", path);
+        }
+    }
+    free(top);
+    return result;
+}
+
 char *fileops_rename(const char *old_path, const char *new_name) {
     if (!old_path || !new_name) {
         fprintf(stderr, "rename: NULL argument\n");
@@ -238,3 +327,165 @@ char *fileops_rename(const char *old_path, const char *new_name) {
 
     return new_path; /* caller must free */
 }
+
+const char *fileops_check_name(const char *dir, const char *name, bool *fatal) {
+    *fatal = true;
+    if (!name || name[0] == '\0') return "Name is empty";
+    if (strcmp(name, ".") == 0 || strcmp(name, "..") == 0) return "Name is reserved";
+    if (strlen(name) > 255) return "Name is too long";
+
+    for (const unsigned char *c = (const unsigned char *)name; *c; c++) {
+        if (*c == '/') return "Name cannot contain '/'";
+        if (*c < 0x20 || *c == 0x7F) return "Name cannot contain control characters";
+    }
+
+    if (dir) {
+        char path[4096];
+        struct stat st;
+        int ret = snprintf(path, sizeof(path), "%s/%s", dir, name);
+        if (ret < 0 || (size_t)ret >= sizeof(path)) return "Name is too long";
+        if (lstat(path, &st) == 0) return "A file with this name already exists";
+    }
+
+    /* Legal here, but not on Windows or FAT drives */
+    *fatal = false;
+    if (strpbrk(name, "\\:*?\"<>|")) return "Contains \\ : * ? \" < > | (not allowed on Windows/FAT)";
+    char last = name[strlen(name) - 1];
+    if (last == '.' || last == ' ') return "Ends with a dot or space (not allowed on Windows/FAT)";
+    return NULL;
+}
+
+/* Sidecar extensions written by raw developers and photo managers */
+static const char *sidecar_extensions[] = {
+    ".xmp", ".XMP", ".pp3", ".dop", ".aae", ".AAE", NULL
+};
+
+/* Rename old_base + ext to new_base + ext if it exists and the target is
+   free. Returns true if a file was renamed. */
+static bool rename_with_suffix(const char *old_base, size_t old_len,
+                               const char *new_base, size_t new_len, const char *ext) {
+    char from[4096], to[4096];
+    struct stat st;
+    int a = snprintf(from, sizeof(from), "%.*s%s", (int)old_len, old_base, ext);
+    int b = snprintf(to, sizeof(to), "%.*s%s", (int)new_len, new_base, ext);
+    if (a < 0 || b < 0 || (size_t)a >= sizeof(from) || (size_t)b >= sizeof(to)) return false;
+    if (lstat(from, &st) != 0) return false;
+    if (lstat(to, &st) == 0) {
+        fprintf(stderr, "rename: sidecar target already exists: %s\n", to);
+        return false;
+    }
+    if (rename(from, to) != 0) {
+        perror("rename");
+        return false;
+    }
+    return true;
+}
+
+/* Length of a path without the extension of its last component */
+static size_t stem_length(const char *path) {
+    const char *slash = strrchr(path, '/');
+    const char *dot = strrchr(path, '.');
+    if (!dot || (slash && dot < slash) || dot == (slash ? slash + 1 : path)) return strlen(path);
+    return (size_t)(dot - path);
+}
+
+int fileops_rename_sidecars(const char *old_path, const char *new_path) {
+    if (!old_path || !new_path) return 0;
+
+    size_t old_full = strlen(old_path), new_full = strlen(new_path);
+    size_t old_stem = stem_length(old_path), new_stem = stem_length(new_path);
+    int renamed = 0;
+    for (int i = 0; sidecar_extensions[i]; i++) {
+        /* IMG_1.jpg.xmp (darktable, digiKam, RawTherapee) */
+        if (rename_with_suffix(old_path, old_full, new_path, new_full, sidecar_extensions[i])) {
+            renamed++;
+        }
+        /* IMG_1.xmp (Lightroom, Capture One) */
+        if (old_stem != old_full &&
+            rename_with_suffix(old_path, old_stem, new_path, new_stem, sidecar_extensions[i])) {
+            renamed++;
+        }
+    }
+    return renamed;
+}
+
+bool fileops_has_sidecar(const char *path) {
+    if (!path) return false;
+
+    size_t full = strlen(path), stem = stem_length(path);
+    char buf[4096];
+    struct stat st;
+    for (int i = 0; sidecar_extensions[i]; i++) {
+        int n = snprintf(buf, sizeof(buf), "%s%s", path, sidecar_extensions[i]);
+        if (n > 0 && (size_t)n < sizeof(buf) && stat(buf, &st) == 0) return true;
+        if (stem == full) continue;
+        n = snprintf(buf, sizeof(buf), "%.*s%s", (int)stem, path, sidecar_extensions[i]);
+        if (n > 0 && (size_t)n < sizeof(buf) && stat(buf, &st) == 0) return true;
+    }
+    return false;
+}
+
+/* Raw formats of common cameras; the JPEG shot with one shares its name */
+static const char *raw_extensions[] = {
+    ".CR2", ".CR3", ".NEF", ".NRW", ".ARW", ".SR2", ".DNG", ".RAF", ".ORF",
+    ".RW2", ".PEF", ".SRW", ".X3F", ".3FR", ".IIQ", ".RWL", NULL
+};
+
+char *fileops_raw_companion(const char *path) {
+    if (!path) return NULL;
+
+    /* Only JPEGs: other images with a raw beside them are exports */
+    const char *ext = path + stem_length(path);
+    if (strcasecmp(ext, ".jpg") != 0 && strcasecmp(ext, ".jpeg") != 0) return NULL;
+
+    size_t stem = (size_t)(ext - path);
+    char buf[4096];
+    struct stat st;
+    for (int i = 0; raw_extensions[i]; i++) {
+        /* Cameras write upper case; some import tools lower it */
+        char lower[8];
+        size_t len = strlen(raw_extensions[i]);
+        for (size_t j = 0; j <= len; j++) lower[j] = (char)tolower((unsigned char)raw_extensions[i][j]);
+
+        const char *variants[] = { raw_extensions[i], lower };
+        for (int v = 0; v < 2; v++) {
+            int n = snprintf(buf, sizeof(buf), "%.*s%s", (int)stem, path, variants[v]);
+            if (n > 0 && (size_t)n < sizeof(buf) && stat(buf, &st) == 0 && S_ISREG(st.st_mode)) {
+                return strdup(buf);
+            }
+        }
+    }
+    return NULL;
+}
+
+int fileops_rename_raw_companion(const char *old_path, const char *new_path, bool sidecars) {
+    char *raw = fileops_raw_companion(old_path);
+    if (!raw || !new_path) {
+        free(raw);
+        return 0;
+    }
+
+    /* New name: the image's new stem with the raw's own extension */
+    const char *slash = strrchr(new_path, '/');
+    const char *new_name = slash ? slash + 1 : new_path;
+    size_t new_stem = stem_length(new_path) - (size_t)(new_name - new_path);
+    const char *raw_ext = raw + stem_length(raw);
+
+    char name[1024];
+    int n = snprintf(name, sizeof(name), "%.*s%s", (int)new_stem, new_name, raw_ext);
+    const char *raw_name = strrchr(raw, '/');
+    if (n <= 0 || (size_t)n >= sizeof(name) || strcmp(name, raw_name ? raw_name + 1 : raw) == 0) {
+        free(raw);
+        return 0;
+    }
+
+    int renamed = 0;
+    char *new_raw = fileops_rename(raw, name);
+    if (new_raw) {
+        renamed = 1;
+        if (sidecars) renamed += fileops_rename_sidecars(raw, new_raw);
+        free(new_raw);
+    }
+    free(raw);
+    return renamed;
+}
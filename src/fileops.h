@@ -1,10 +1,14 @@
 #ifndef FRAME_FILEOPS_H
 #define FRAME_FILEOPS_H
 
-/* Move a file to the XDG trash directory (~/.local/share/Trash/files/).
-   Follows the freedesktop.org Trash specification:
-   - Creates ~/.local/share/Trash/files/ and ~/.local/share/Trash/info/ if needed.
-   - Handles name collisions by appending _2, _3, etc.
+#include <stdbool.h>
+
+/* Move a file to the trash, following the freedesktop.org Trash specification:
+   - Uses $XDG_DATA_HOME/Trash (~/.local/share/Trash; always the host
+     location when running inside Flatpak), creating files/ and info/ if needed.
+   - Files on other filesystems go to that volume's $topdir/.Trash/$uid or
+     $topdir/.Trash-$uid instead, since they cannot be renamed across devices.
+   - Handles name collisions by appending _1, _2, etc.
    - Writes a .trashinfo file with original path and deletion date.
    Returns 0 on success, -1 on error. */
 int fileops_trash(const char *path);
@@ -14,4 +18,30 @@ int fileops_trash(const char *path);
    or NULL on error. */
 char *fileops_rename(const char *old_path, const char *new_name);
 
+/* Check a new file name for the folder `dir`. Returns NULL if it is fine,
+   otherwise a short description of the problem. *fatal is set when the
+   rename cannot work (empty, '/', control characters, too long, taken);
+   otherwise the name only breaks on other systems (Windows/FAT characters,
+   trailing dot or space). */
+const char *fileops_check_name(const char *dir, const char *name, bool *fatal);
+
+/* Rename the sidecars of a file that was renamed from old_path to new_path:
+   edit files such as "IMG_1.xmp" and "IMG_1.jpg.xmp" (also .pp3, .dop,
+   .aae), keeping each naming style. Returns the number of files renamed. */
+int fileops_rename_sidecars(const char *old_path, const char *new_path);
+
+/* Check whether a file has any of those sidecars, i.e. has been edited in a
+   raw developer or photo manager. */
+bool fileops_has_sidecar(const char *path);
+
+/* Find the raw file shot together with a JPEG: the same name with a camera
+   raw extension (IMG_1.jpg and IMG_1.CR2). Returns a malloc'd path, or NULL
+   if there is none or path is not a JPEG. */
+char *fileops_raw_companion(const char *path);
+
+/* After a JPEG was renamed from old_path to new_path, give its raw
+   companion the same new name (keeping the raw extension), with its
+   sidecars if `sidecars` is set. Returns the number of files renamed. */
+int fileops_rename_raw_companion(const char *old_path, const char *new_path, bool sidecars);
+
 #endif /* FRAME_FILEOPS_H */
@@ -0,0 +1,231 @@
+#define _DEFAULT_SOURCE
+#include "filmstrip.h"
+#include "app.h"
+#include "viewer.h"
+#include "cache.h"
+#include "theme.h"
+#include "statusbar.h"
+#include <stdlib.h>
+#include <string.h>
+
+#define STRIP_PADDING 8
+#define MAX_CELLS 42     /* three times this fits one thumbnail request */
+
+static bool visible = false;
+
+/* Cells drawn last time: the path of each and its texture (NULL while the
+   thumbnail is being made). Textures are carried over between draws. */
+static char *cell_path[MAX_CELLS];
+static SDL_Texture *cell_tex[MAX_CELLS];
+static int cell_index[MAX_CELLS];
+static SDL_FRect cell_rect[MAX_CELLS];
+static int cell_count = 0;
+
+/* Range the thumbnails were last requested for */
+static int requested_first = -1;
+static int requested_count = 0;
+static int requested_total = 0;
+
+static void clear_cells(void)
+{
+    for (int i = 0; i < cell_count; i++) {
+        free(cell_path[i]);
+        if (cell_tex[i]) SDL_DestroyTexture(cell_tex[i]);
+        cell_path[i] = NULL;
+        cell_tex[i] = NULL;
+    }
+    cell_count = 0;
+}
+
+void filmstrip_set_visible(bool show)
+{
+    visible = show;
+    if (!visible) {
+        clear_cells();
+        filmstrip_invalidate();
+    }
+}
+
+bool filmstrip_is_visible(void)
+{
+    return visible;
+}
+
+int filmstrip_height(void)
+{
+    return visible ? FILMSTRIP_HEIGHT : 0;
+}
+
+void filmstrip_invalidate(void)
+{
+    requested_first = -1;
+}
+
+/* Queue the thumbnails of the cells, the current image first and then
+   outwards, followed by a strip's width either side for scrolling on */
+static void request_thumbnails(struct AppState *app, struct Viewer *viewer, int first, int count,
+                               int current)
+{
+    const char *paths[MAX_CELLS * 3];
+    int n = 0;
+    int total = app_image_count(app);
+    int lo = first - count;
+    int hi = first + count * 2 - 1;
+
+    for (int step = 0; n < MAX_CELLS * 3 && (current - step >= lo || current + step <= hi); step++) {
+        int before = current - step;
+        int after = current + step;
+        if (before >= lo && before >= 0 && before < total) {
+            paths[n++] = app_image_path(app, before);
+        }
+        if (step > 0 && after <= hi && after < total && n < MAX_CELLS * 3) {
+            paths[n++] = app_image_path(app, after);
+        }
+    }
+    viewer_request_thumbnails(viewer, paths, n);
+}
+
+void filmstrip_render(SDL_Renderer *renderer, struct AppState *app, struct Viewer *viewer)
+{
+    if (!visible || !app || !viewer) return;
+
+    int vp_w, vp_h;
+    if (!SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) return;
+
+    const ThemePalette *pal = theme_get();
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+    theme_set_color(renderer, pal->panel, 255);
+    /* Above the status bar, if that is shown */
+    float top = (float)(vp_h - statusbar_height() - FILMSTRIP_HEIGHT);
+    SDL_FRect strip = {0, top, (float)vp_w, (float)FILMSTRIP_HEIGHT};
+    SDL_RenderFillRect(renderer, &strip);
+    theme_set_color(renderer, pal->border, 255);
+    SDL_RenderLine(renderer, 0, strip.y, (float)vp_w, strip.y);
+
+    int total = app_image_count(app);
+    int current = app_current_index(app) - 1;
+    if (total == 0 || current < 0) {
+        clear_cells();
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+        return;
+    }
+
+    /* As many cells as fit, with the current image in the middle */
+    float cell_h = FILMSTRIP_HEIGHT - STRIP_PADDING * 2;
+    float cell_w = cell_h * 4.0f / 3.0f;
+    int fit = (int)((vp_w - STRIP_PADDING) / (cell_w + STRIP_PADDING));
+    if (fit < 1) fit = 1;
+    if (fit > MAX_CELLS) fit = MAX_CELLS;
+    int first = current - fit / 2;
+    if (first > total - fit) first = total - fit;
+    if (first < 0) first = 0;
+    int count = total - first < fit ? total - first : fit;
+
+    if (first != requested_first || count != requested_count || total != requested_total) {
+        request_thumbnails(app, viewer, first, count, current);
+        requested_first = first;
+        requested_count = count;
+        requested_total = total;
+    }
+
+    /* Keep the textures of images still on the strip */
+    char *paths[MAX_CELLS];
+    SDL_Texture *texs[MAX_CELLS];
+    struct ImageCache *thumb_cache = viewer_get_thumb_cache(viewer);
+    for (int i = 0; i < count; i++) {
+        const char *path = app_image_path(app, first + i);
+        paths[i] = path ? strdup(path) : NULL;
+        texs[i] = NULL;
+        for (int j = 0; paths[i] && j < cell_count; j++) {
+            if (cell_tex[j] && cell_path[j] && strcmp(cell_path[j], paths[i]) == 0) {
+                texs[i] = cell_tex[j];
+                cell_tex[j] = NULL;
+                break;
+            }
+        }
+        if (!texs[i] && paths[i] && thumb_cache) {
+            SDL_Surface *surf = cache_get(thumb_cache, paths[i]);
+            if (surf) texs[i] = SDL_CreateTextureFromSurface(renderer, surf);
+        }
+    }
+    clear_cells();
+
+    float x = (vp_w - (count * (cell_w + STRIP_PADDING) - STRIP_PADDING)) / 2.0f;
+    float y = strip.y + STRIP_PADDING;
+    for (int i = 0; i < count; i++) {
+        cell_path[i] = paths[i];
+        cell_tex[i] = texs[i];
+        cell_index[i] = first + i;
+        cell_rect[i] = (SDL_FRect){x + i * (cell_w + STRIP_PADDING), y, cell_w, cell_h};
+        SDL_FRect r = cell_rect[i];
+
+        theme_set_color(renderer, pal->surface, 255);
+        SDL_RenderFillRect(renderer, &r);
+
+        if (texs[i]) {
+            float tw, th;
+            SDL_GetTextureSize(texs[i], &tw, &th);
+            float scale = r.w / tw < r.h / th ? r.w / tw : r.h / th;
+            SDL_FRect dst = {r.x + (r.w - tw * scale) / 2.0f, r.y + (r.h - th * scale) / 2.0f,
+                             tw * scale, th * scale};
+            SDL_RenderTexture(renderer, texs[i], NULL, &dst);
+        } else {
+            theme_set_color(renderer, pal->separator, 255);
+            SDL_FRect placeholder = {r.x + r.w / 4.0f, r.y + r.h / 4.0f, r.w / 2.0f, r.h / 2.0f};
+            SDL_RenderFillRect(renderer, &placeholder);
+        }
+
+        if (first + i == current) {
+            theme_set_color(renderer, pal->accent, 255);
+            SDL_FRect outer = {r.x - 2.0f, r.y - 2.0f, r.w + 4.0f, r.h + 4.0f};
+            SDL_RenderRect(renderer, &r);
+            SDL_RenderRect(renderer, &outer);
+        } else {
+            theme_set_color(renderer, pal->border, 255);
+            SDL_RenderRect(renderer, &r);
+        }
+    }
+    cell_count = count;
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+}
+
+int filmstrip_hit(float x, float y)
+{
+    if (!visible) return -1;
+    for (int i = 0; i < cell_count; i++) {
+        SDL_FRect r = cell_rect[i];
+        if (x >= r.x && x < r.x + r.w && y >= r.y && y < r.y + r.h) {
+            return cell_index[i];
+        }
+    }
+    return -1;
+}
+
+bool filmstrip_check_dirty(struct Viewer *viewer)
+{
+    if (!visible || !viewer) return false;
+
+    struct ImageCache *thumb_cache = viewer_get_thumb_cache(viewer);
+    if (!thumb_cache) return false;
+    for (int i = 0; i < cell_count; i++) {
+        if (!cell_tex[i] && cell_path[i] && cache_get(thumb_cache, cell_path[i])) {
+            return true;
+        }
+    }
+    return false;
+}
+
+bool filmstrip_waiting(void)
+{
+    if (!visible) return false;
+    for (int i = 0; i < cell_count; i++) {
+        if (!cell_tex[i]) return true;
+    }
+    return false;
+}
+
+void filmstrip_shutdown(void)
+{
+    clear_cells();
+}
@@ -0,0 +1,51 @@
+#ifndef FRAME_FILMSTRIP_H
+#define FRAME_FILMSTRIP_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+struct AppState;
+struct Viewer;
+
+/* Height of the strip along the bottom of the window */
+#define FILMSTRIP_HEIGHT 96
+
+/*
+ * Filmstrip: a row of thumbnails below the image, centred on the current
+ * one, which is highlighted. Clicking a thumbnail jumps to that image.
+ * Thumbnails are made by the viewer's background thumbnail workers, so a
+ * large folder never stalls the window; cells show a placeholder until
+ * theirs arrives.
+ */
+
+void filmstrip_set_visible(bool visible);
+bool filmstrip_is_visible(void);
+
+/* Pixels the strip takes from the bottom of the window (0 when hidden).
+   The viewer is given the window height minus this. */
+int filmstrip_height(void);
+
+/* Draw the strip over the bottom of the window and ask for the thumbnails
+   around the current image that are not cached yet. */
+void filmstrip_render(SDL_Renderer *renderer, struct AppState *app, struct Viewer *viewer);
+
+/* Index (0-based) of the image whose thumbnail is at window position
+   (x, y), or -1 if there is none (or the strip is hidden). */
+int filmstrip_hit(float x, float y);
+
+/* Check whether a thumbnail that was missing on the last draw is now
+   cached, so the strip should be drawn again. */
+bool filmstrip_check_dirty(struct Viewer *viewer);
+
+/* Check whether the strip is still waiting for thumbnails (the main loop
+   keeps polling while it is). */
+bool filmstrip_waiting(void);
+
+/* Ask for the thumbnails again on the next draw, after something else
+   (the search grid) replaced the thumbnail request. */
+void filmstrip_invalidate(void);
+
+/* Free the thumbnail textures. */
+void filmstrip_shutdown(void);
+
+#endif /* FRAME_FILMSTRIP_H */
@@ -0,0 +1,182 @@
+#include "filter.h"
+#include <math.h>
+
+/* Machado, Oliveira & Fernandes (2009) simulation matrices at full
+   severity. They operate on linear RGB. */
+static const float cvd_matrices[CVD_COUNT][9] = {
+    [CVD_NONE] = {
+        1.0f, 0.0f, 0.0f,
+        0.0f, 1.0f, 0.0f,
+        0.0f, 0.0f, 1.0f,
+    },
+    [CVD_PROTANOPIA] = {
+         0.152286f,  1.052583f, -0.204868f,
+         0.114503f,  0.786281f,  0.099216f,
+        -0.003882f, -0.048116f,  1.051998f,
+    },
+    [CVD_DEUTERANOPIA] = {
+         0.367322f,  0.860646f, -0.227968f,
+         0.280085f,  0.672501f,  0.047413f,
+        -0.011820f,  0.042940f,  0.968881f,
+    },
+    [CVD_TRITANOPIA] = {
+         1.255528f, -0.076749f, -0.178779f,
+        -0.078411f,  0.930809f,  0.147602f,
+         0.004733f,  0.691367f,  0.303900f,
+    },
+};
+
+#define LINEAR_STEPS 4096
+
+/* sRGB <-> linear lookup tables, built on first use */
+static float to_linear[256];
+static Uint8 to_srgb[LINEAR_STEPS];
+static bool tables_ready = false;
+
+static void build_tables(void)
+{
+    if (tables_ready) return;
+    for (int i = 0; i < 256; i++) {
+        float c = i / 255.0f;
+        to_linear[i] = c <= 0.04045f ? c / 12.92f : powf((c + 0.055f) / 1.055f, 2.4f);
+    }
+    for (int i = 0; i < LINEAR_STEPS; i++) {
+        float l = i / (float)(LINEAR_STEPS - 1);
+        float c = l <= 0.0031308f ? l * 12.92f : 1.055f * powf(l, 1.0f / 2.4f) - 0.055f;
+        to_srgb[i] = (Uint8)(c * 255.0f + 0.5f);
+    }
+    tables_ready = true;
+}
+
+static Uint8 encode(float linear)
+{
+    if (linear <= 0.0f) return 0;
+    if (linear >= 1.0f) return 255;
+    return to_srgb[(int)(linear * (LINEAR_STEPS - 1) + 0.5f)];
+}
+
+void filter_init(ViewFilter *f)
+{
+    if (!f) return;
+    f->cvd = CVD_NONE;
+    f->exposure = 0.0f;
+    f->gamma = 1.0f;
+}
+
+bool filter_is_identity(const ViewFilter *f)
+{
+    return !f || (f->cvd == CVD_NONE && f->exposure == 0.0f && f->gamma == 1.0f);
+}
+
+SDL_Surface *filter_apply(SDL_Surface *src, const ViewFilter *f)
+{
+    if (!src) return NULL;
+
+    /* RGBA32 is R, G, B, A in memory order regardless of endianness */
+    SDL_Surface *dst = SDL_ConvertSurface(src, SDL_PIXELFORMAT_RGBA32);
+    if (!dst || filter_is_identity(f)) return dst;
+
+    build_tables();
+
+    /* Exposure scales linear light; gamma is applied to the encoded result */
+    float gain = powf(2.0f, f->exposure);
+    float inv_gamma = 1.0f / (f->gamma > 0.0f ? f->gamma : 1.0f);
+    Uint8 gamma_lut[256];
+    for (int i = 0; i < 256; i++) {
+        gamma_lut[i] = (Uint8)(powf(i / 255.0f, inv_gamma) * 255.0f + 0.5f);
+    }
+
+    /* Without simulation each channel is independent: one table does it all */
+    Uint8 channel_lut[256];
+    bool per_channel = f->cvd == CVD_NONE;
+    if (per_channel) {
+        for (int i = 0; i < 256; i++) {
+            channel_lut[i] = gamma_lut[encode(to_linear[i] * gain)];
+        }
+    }
+    const float *m = cvd_matrices[f->cvd];
+
+    if (!SDL_LockSurface(dst)) {
+        SDL_DestroySurface(dst);
+        return NULL;
+    }
+    for (int y = 0; y < dst->h; y++) {
+        Uint8 *p = (Uint8 *)dst->pixels + (size_t)y * dst->pitch;
+        for (int x = 0; x < dst->w; x++, p += 4) {
+            if (per_channel) {
+                p[0] = channel_lut[p[0]];
+                p[1] = channel_lut[p[1]];
+                p[2] = channel_lut[p[2]];
+                continue;
+            }
+            float r = to_linear[p[0]];
+            float g = to_linear[p[1]];
+            float b = to_linear[p[2]];
+            p[0] = gamma_lut[encode((m[0] * r + m[1] * g + m[2] * b) * gain)];
+            p[1] = gamma_lut[encode((m[3] * r + m[4] * g + m[5] * b) * gain)];
+            p[2] = gamma_lut[encode((m[6] * r + m[7] * g + m[8] * b) * gain)];
+        }
+    }
+    SDL_UnlockSurface(dst);
+    return dst;
+}
+
+SDL_Surface *filter_clipping_mask(SDL_Surface *src, float *out_high, float *out_low)
+{
+    *out_high = 0.0f;
+    *out_low = 0.0f;
+    if (!src || src->w <= 0 || src->h <= 0) return NULL;
+
+    SDL_Surface *rgba = SDL_ConvertSurface(src, SDL_PIXELFORMAT_RGBA32);
+    if (!rgba) return NULL;
+    SDL_Surface *mask = SDL_CreateSurface(src->w, src->h, SDL_PIXELFORMAT_RGBA32);
+    if (!mask || !SDL_LockSurface(rgba) || !SDL_LockSurface(mask)) {
+        SDL_DestroySurface(mask);
+        SDL_DestroySurface(rgba);
+        return NULL;
+    }
+
+    /* Fully transparent pixels show the background, so they never count */
+    long long high = 0, low = 0, counted = 0;
+    for (int y = 0; y < rgba->h; y++) {
+        const Uint8 *p = (const Uint8 *)rgba->pixels + (size_t)y * rgba->pitch;
+        Uint8 *m = (Uint8 *)mask->pixels + (size_t)y * mask->pitch;
+        for (int x = 0; x < rgba->w; x++, p += 4, m += 4) {
+            m[0] = m[1] = m[2] = m[3] = 0;
+            if (p[3] == 0) continue;
+            counted++;
+
+            Uint8 hi = p[0] > p[1] ? p[0] : p[1];
+            if (p[2] > hi) hi = p[2];
+            if (hi >= FILTER_CLIP_HIGH) {
+                m[0] = 255;
+                m[3] = 255;
+                high++;
+            } else if (hi <= FILTER_CLIP_LOW) {
+                m[1] = 64;
+                m[2] = 255;
+                m[3] = 255;
+                low++;
+            }
+        }
+    }
+    SDL_UnlockSurface(mask);
+    SDL_UnlockSurface(rgba);
+    SDL_DestroySurface(rgba);
+
+    if (counted > 0) {
+        *out_high = (float)((double)high / (double)counted);
+        *out_low = (float)((double)low / (double)counted);
+    }
+    return mask;
+}
+
+const char *filter_cvd_name(CvdMode mode)
+{
+    switch (mode) {
+    case CVD_PROTANOPIA:   return "Protanopia";
+    case CVD_DEUTERANOPIA: return "Deuteranopia";
+    case CVD_TRITANOPIA:   return "Tritanopia";
+    default:               return "Normal vision";
+    }
+}
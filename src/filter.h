@@ -0,0 +1,58 @@
+#ifndef FRAME_FILTER_H
+#define FRAME_FILTER_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+/*
+ * View filters: display-only pixel transforms applied when an image is
+ * uploaded to the GPU. They never touch the decoded surfaces in the cache
+ * or the file on disk.
+ */
+
+/* Colour vision deficiency simulation */
+typedef enum {
+    CVD_NONE,
+    CVD_PROTANOPIA,     /* no red cones */
+    CVD_DEUTERANOPIA,   /* no green cones */
+    CVD_TRITANOPIA,     /* no blue cones */
+    CVD_COUNT
+} CvdMode;
+
+typedef struct ViewFilter {
+    CvdMode cvd;
+    float exposure;     /* brightness change in stops (EV), 0 = unchanged */
+    float gamma;        /* display gamma, 1 = unchanged; > 1 lifts shadows */
+} ViewFilter;
+
+/* Limits for the adjustments */
+#define FILTER_EXPOSURE_MIN -5.0f
+#define FILTER_EXPOSURE_MAX 5.0f
+#define FILTER_GAMMA_MIN 0.2f
+#define FILTER_GAMMA_MAX 5.0f
+
+/* Reset a filter to the identity (no simulation, no adjustments). */
+void filter_init(ViewFilter *f);
+
+/* Check whether the filter leaves pixels unchanged (nothing to apply). */
+bool filter_is_identity(const ViewFilter *f);
+
+/* Return a filtered RGBA copy of `src` (caller frees), or NULL on failure. */
+SDL_Surface *filter_apply(SDL_Surface *src, const ViewFilter *f);
+
+/* Channel values treated as clipped: JPEG noise keeps blown-out areas
+   from sitting exactly at 255 (or 0) */
+#define FILTER_CLIP_HIGH 254
+#define FILTER_CLIP_LOW 1
+
+/* Build an overlay for the exposure warning: pixels with a clipped channel
+   are red, pixels black in all channels blue, the rest transparent. The
+   fractions of clipped highlights and shadows (0-1) are stored in
+   *out_high and *out_low. Returns an RGBA surface (caller frees), or NULL
+   on failure. */
+SDL_Surface *filter_clipping_mask(SDL_Surface *src, float *out_high, float *out_low);
+
+/* Human-readable name of a simulation mode, e.g. "Protanopia". */
+const char *filter_cvd_name(CvdMode mode);
+
+#endif /* FRAME_FILTER_H */
@@ -0,0 +1,375 @@
+#define _GNU_SOURCE
+#include "gif.h"
+#include "resample.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define PALETTE_SIZE 256
+#define HIST_SIZE 32768     /* 5 bits per channel */
+#define LZW_MIN_CODE_SIZE 8
+#define LZW_MAX_CODES 4096
+
+struct GifWriter {
+    FILE *fp;
+    char *path;
+    int width, height;
+    ResampleFilter filter;
+    SDL_Surface *canvas;    /* RGB24 frame being encoded */
+    unsigned char *indices; /* palette index per pixel */
+    int frame_count;
+    bool failed;
+};
+
+/* A range of histogram colours that becomes one palette entry */
+typedef struct {
+    int start, count;
+} ColorBox;
+
+static int sort_shift;
+
+static int channel_of(int color, int shift)
+{
+    return (color >> shift) & 31;
+}
+
+static int compare_channel(const void *a, const void *b)
+{
+    int ca = channel_of(*(const unsigned short *)a, sort_shift);
+    int cb = channel_of(*(const unsigned short *)b, sort_shift);
+    return ca - cb;
+}
+
+/* Longest channel of a box: returns the bit shift and sets *range */
+static int longest_channel(const unsigned short *colors, ColorBox box, int *range)
+{
+    int best_shift = 0;
+    *range = -1;
+    for (int shift = 0; shift <= 10; shift += 5) {
+        int lo = 31, hi = 0;
+        for (int i = box.start; i < box.start + box.count; i++) {
+            int v = channel_of(colors[i], shift);
+            if (v < lo) lo = v;
+            if (v > hi) hi = v;
+        }
+        if (hi - lo > *range) {
+            *range = hi - lo;
+            best_shift = shift;
+        }
+    }
+    return best_shift;
+}
+
+/* Median cut over the 15-bit histogram. Fills the palette (RGB triples) and
+   the colour -> index map. Returns the number of palette entries. */
+static int build_palette(const unsigned int *hist, unsigned char *palette,
+                         unsigned char *map)
+{
+    static unsigned short colors[HIST_SIZE];
+    int n = 0;
+    for (int c = 0; c < HIST_SIZE; c++) {
+        if (hist[c]) colors[n++] = (unsigned short)c;
+    }
+
+    ColorBox boxes[PALETTE_SIZE];
+    int box_count = 1;
+    boxes[0] = (ColorBox){ 0, n };
+
+    while (box_count < PALETTE_SIZE) {
+        /* Split the box with the widest spread, weighted by its pixels */
+        int pick = -1, pick_shift = 0;
+        double best = 0.0;
+        for (int b = 0; b < box_count; b++) {
+            if (boxes[b].count < 2) continue;
+            int range;
+            int shift = longest_channel(colors, boxes[b], &range);
+            double pixels = 0.0;
+            for (int i = boxes[b].start; i < boxes[b].start + boxes[b].count; i++) {
+                pixels += hist[colors[i]];
+            }
+            double score = (double)range * pixels;
+            if (score > best) {
+                best = score;
+                pick = b;
+                pick_shift = shift;
+            }
+        }
+        if (pick < 0) break;
+
+        ColorBox *box = &boxes[pick];
+        sort_shift = pick_shift;
+        qsort(colors + box->start, (size_t)box->count, sizeof(colors[0]), compare_channel);
+
+        double total = 0.0, half = 0.0;
+        for (int i = box->start; i < box->start + box->count; i++) total += hist[colors[i]];
+        int split = box->start + 1;
+        for (int i = box->start; i < box->start + box->count - 1; i++) {
+            half += hist[colors[i]];
+            if (half >= total / 2.0) {
+                split = i + 1;
+                break;
+            }
+        }
+
+        boxes[box_count++] = (ColorBox){ split, box->start + box->count - split };
+        box->count = split - box->start;
+    }
+
+    for (int b = 0; b < box_count; b++) {
+        double r = 0, g = 0, bl = 0, w = 0;
+        for (int i = boxes[b].start; i < boxes[b].start + boxes[b].count; i++) {
+            int c = colors[i];
+            double weight = hist[c];
+            r += weight * channel_of(c, 10);
+            g += weight * channel_of(c, 5);
+            bl += weight * channel_of(c, 0);
+            w += weight;
+            map[c] = (unsigned char)b;
+        }
+        if (w <= 0) w = 1;
+        /* Expand 5-bit averages back to 8 bits */
+        palette[b * 3 + 0] = (unsigned char)(r / w * 255.0 / 31.0 + 0.5);
+        palette[b * 3 + 1] = (unsigned char)(g / w * 255.0 / 31.0 + 0.5);
+        palette[b * 3 + 2] = (unsigned char)(bl / w * 255.0 / 31.0 + 0.5);
+    }
+    return box_count;
+}
+
+/* LZW output: variable-width codes packed LSB first into 255-byte sub-blocks */
+typedef struct {
+    FILE *fp;
+    unsigned char block[255];
+    int block_len;
+    unsigned int bits;
+    int bit_count;
+} BitWriter;
+
+static void put_byte(BitWriter *w, unsigned char byte)
+{
+    w->block[w->block_len++] = byte;
+    if (w->block_len == 255) {
+        fputc(255, w->fp);
+        fwrite(w->block, 1, 255, w->fp);
+        w->block_len = 0;
+    }
+}
+
+static void put_code(BitWriter *w, int code, int size)
+{
+    w->bits |= (unsigned int)code << w->bit_count;
+    w->bit_count += size;
+    while (w->bit_count >= 8) {
+        put_byte(w, (unsigned char)(w->bits & 0xFF));
+        w->bits >>= 8;
+        w->bit_count -= 8;
+    }
+}
+
+static void flush_bits(BitWriter *w)
+{
+    if (w->bit_count > 0) put_byte(w, (unsigned char)(w->bits & 0xFF));
+    if (w->block_len > 0) {
+        fputc(w->block_len, w->fp);
+        fwrite(w->block, 1, (size_t)w->block_len, w->fp);
+    }
+    fputc(0, w->fp);    /* block terminator */
+}
+
+/* LZW-compress the frame's palette indices */
+static bool write_lzw(FILE *fp, const unsigned char *indices, size_t count)
+{
+    /* Dictionary as a trie: child code for (prefix, next index), 0 = none */
+    unsigned short *child = calloc((size_t)LZW_MAX_CODES * PALETTE_SIZE, sizeof(unsigned short));
+    if (!child) return false;
+
+    const int clear = 1 << LZW_MIN_CODE_SIZE;
+    const int eoi = clear + 1;
+    int size = LZW_MIN_CODE_SIZE + 1;
+    int next = eoi + 1;
+
+    BitWriter w = { .fp = fp };
+    fputc(LZW_MIN_CODE_SIZE, fp);
+    put_code(&w, clear, size);
+
+    int prefix = indices[0];
+    for (size_t i = 1; i < count; i++) {
+        int k = indices[i];
+        int code = child[prefix * PALETTE_SIZE + k];
+        if (code) {
+            prefix = code;
+            continue;
+        }
+
+        put_code(&w, prefix, size);
+        if (next < LZW_MAX_CODES) {
+            child[prefix * PALETTE_SIZE + k] = (unsigned short)next++;
+            /* The decoder adds its entry one code later, so widen after it */
+            if (next > (1 << size) && size < 12) size++;
+        } else {
+            put_code(&w, clear, size);
+            memset(child, 0, (size_t)LZW_MAX_CODES * PALETTE_SIZE * sizeof(unsigned short));
+            size = LZW_MIN_CODE_SIZE + 1;
+            next = eoi + 1;
+        }
+        prefix = k;
+    }
+    put_code(&w, prefix, size);
+    put_code(&w, eoi, size);
+    flush_bits(&w);
+
+    free(child);
+    return true;
+}
+
+static void put_u16(FILE *fp, int v)
+{
+    fputc(v & 0xFF, fp);
+    fputc((v >> 8) & 0xFF, fp);
+}
+
+GifWriter *gif_open(const char *path, int width, int height, ResampleFilter filter)
+{
+    if (width <= 0 || height <= 0 || width > 65535 || height > 65535) return NULL;
+
+    GifWriter *gif = calloc(1, sizeof(GifWriter));
+    if (!gif) return NULL;
+
+    gif->width = width;
+    gif->height = height;
+    gif->filter = filter;
+    gif->canvas = SDL_CreateSurface(width, height, SDL_PIXELFORMAT_RGB24);
+    gif->indices = malloc((size_t)width * (size_t)height);
+    gif->path = strdup(path);
+    gif->fp = gif->canvas && gif->indices && gif->path ? fopen(path, "wb") : NULL;
+    if (!gif->fp) {
+        fprintf(stderr, "gif: cannot create '%s'\n", path);
+        SDL_DestroySurface(gif->canvas);
+        free(gif->indices);
+        free(gif->path);
+        free(gif);
+        return NULL;
+    }
+
+    FILE *fp = gif->fp;
+    fputs("GIF89a", fp);
+    put_u16(fp, width);
+    put_u16(fp, height);
+    fputc(0x00, fp);    /* no global colour table */
+    fputc(0, fp);       /* background colour */
+    fputc(0, fp);       /* square pixels */
+
+    /* NETSCAPE2.0 application extension: loop forever */
+    fputc(0x21, fp);
+    fputc(0xFF, fp);
+    fputc(11, fp);
+    fputs("NETSCAPE2.0", fp);
+    fputc(3, fp);
+    fputc(1, fp);
+    put_u16(fp, 0);
+    fputc(0, fp);
+    return gif;
+}
+
+bool gif_add_frame(GifWriter *gif, SDL_Surface *image, int delay_ms)
+{
+    if (!gif || !image || gif->failed) return false;
+
+    /* Fit the image into the canvas */
+    SDL_Surface *canvas = gif->canvas;
+    memset(canvas->pixels, 0, (size_t)canvas->pitch * (size_t)canvas->h);
+    float sx = (float)gif->width / (float)image->w;
+    float sy = (float)gif->height / (float)image->h;
+    float s = sx < sy ? sx : sy;
+    int dw = (int)(image->w * s + 0.5f);
+    int dh = (int)(image->h * s + 0.5f);
+    if (dw < 1) dw = 1;
+    if (dh < 1) dh = 1;
+    SDL_Rect dst = { (gif->width - dw) / 2, (gif->height - dh) / 2, dw, dh };
+    if (!resample_blit(image, canvas, &dst, gif->filter)) {
+        fprintf(stderr, "gif: cannot scale frame: %s\n", SDL_GetError());
+        return false;
+    }
+
+    unsigned int *hist = calloc(HIST_SIZE, sizeof(unsigned int));
+    unsigned char *map = malloc(HIST_SIZE);
+    if (!hist || !map) {
+        free(hist);
+        free(map);
+        return false;
+    }
+
+    for (int y = 0; y < gif->height; y++) {
+        const unsigned char *row = (const unsigned char *)canvas->pixels + (size_t)y * (size_t)canvas->pitch;
+        for (int x = 0; x < gif->width; x++) {
+            const unsigned char *p = row + x * 3;
+            hist[(p[0] >> 3) << 10 | (p[1] >> 3) << 5 | p[2] >> 3]++;
+        }
+    }
+
+    unsigned char palette[PALETTE_SIZE * 3] = {0};
+    build_palette(hist, palette, map);
+
+    unsigned char *out = gif->indices;
+    for (int y = 0; y < gif->height; y++) {
+        const unsigned char *row = (const unsigned char *)canvas->pixels + (size_t)y * (size_t)canvas->pitch;
+        for (int x = 0; x < gif->width; x++) {
+            const unsigned char *p = row + x * 3;
+            *out++ = map[(p[0] >> 3) << 10 | (p[1] >> 3) << 5 | p[2] >> 3];
+        }
+    }
+    free(hist);
+    free(map);
+
+    FILE *fp = gif->fp;
+
+    /* Graphic control extension: delay in hundredths of a second */
+    int delay_cs = (delay_ms + 5) / 10;
+    if (delay_cs < 2) delay_cs = 2;     /* browsers treat 0-1 as 10 */
+    if (delay_cs > 65535) delay_cs = 65535;
+    fputc(0x21, fp);
+    fputc(0xF9, fp);
+    fputc(4, fp);
+    fputc(0x04, fp);    /* disposal: leave in place, no transparency */
+    put_u16(fp, delay_cs);
+    fputc(0, fp);
+    fputc(0, fp);
+
+    /* Image descriptor with a full 256-entry local colour table */
+    fputc(0x2C, fp);
+    put_u16(fp, 0);
+    put_u16(fp, 0);
+    put_u16(fp, gif->width);
+    put_u16(fp, gif->height);
+    fputc(0x80 | 7, fp);
+    fwrite(palette, 1, sizeof(palette), fp);
+
+    if (!write_lzw(fp, gif->indices, (size_t)gif->width * (size_t)gif->height)) {
+        gif->failed = true;
+        return false;
+    }
+    gif->frame_count++;
+    return !ferror(fp);
+}
+
+int gif_frame_count(const GifWriter *gif)
+{
+    return gif ? gif->frame_count : 0;
+}
+
+bool gif_close(GifWriter *gif)
+{
+    if (!gif) return false;
+
+    fputc(0x3B, gif->fp);   /* trailer */
+    bool ok = !gif->failed && !ferror(gif->fp);
+    if (fclose(gif->fp) != 0) ok = false;
+    if (!ok) {
+        fprintf(stderr, "gif: failed writing '%s'\n", gif->path);
+    }
+
+    SDL_DestroySurface(gif->canvas);
+    free(gif->indices);
+    free(gif->path);
+    free(gif);
+    return ok;
+}
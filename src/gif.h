@@ -0,0 +1,28 @@
+#ifndef FRAME_GIF_H
+#define FRAME_GIF_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+#include "config.h"
+
+/* Minimal animated GIF writer. Every frame gets its own 256-colour palette
+   (median cut), and the animation loops forever. */
+
+typedef struct GifWriter GifWriter;
+
+/* Create a GIF file at path with a fixed canvas size; frames are scaled to
+   it with filter. Returns NULL (with a message on stderr) on error. */
+GifWriter *gif_open(const char *path, int width, int height, ResampleFilter filter);
+
+/* Append a frame shown for delay_ms. The image is scaled to fit the canvas
+   and centred on black; transparent areas are drawn on black too. */
+bool gif_add_frame(GifWriter *gif, SDL_Surface *image, int delay_ms);
+
+/* Get the number of frames added so far. */
+int gif_frame_count(const GifWriter *gif);
+
+/* Finish and close the file. Returns false if anything failed to write;
+   the writer is freed either way. */
+bool gif_close(GifWriter *gif);
+
+#endif /* FRAME_GIF_H */
@@ -0,0 +1,189 @@
+#include "hdr.h"
+#include "loader.h"
+#include <math.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <strings.h>
+
+/* Middle grey the average scene luminance is mapped to */
+#define HDR_KEY 0.18f
+
+typedef struct {
+    const unsigned char *p;
+    const unsigned char *end;
+} Reader;
+
+/* Read one header line (without the newline) into buf. */
+static bool read_line(Reader *r, char *buf, size_t size)
+{
+    size_t n = 0;
+    while (r->p < r->end && *r->p != '\n') {
+        if (n + 1 < size) buf[n++] = (char)*r->p;
+        r->p++;
+    }
+    if (r->p >= r->end) return false;
+    r->p++; /* skip '\n' */
+    buf[n] = '\0';
+    return true;
+}
+
+/* Decode one scanline of `width` RGBE pixels into out (4 bytes each). */
+static bool read_scanline(Reader *r, unsigned char *out, int width)
+{
+    if (r->end - r->p < 4) return false;
+
+    /* New-style RLE: 2, 2, width high byte, width low byte, then each
+       channel separately run-length encoded. */
+    bool rle = width >= 8 && width < 0x8000 &&
+               r->p[0] == 2 && r->p[1] == 2 && !(r->p[2] & 0x80);
+    if (!rle) {
+        size_t bytes = (size_t)width * 4;
+        if ((size_t)(r->end - r->p) < bytes) return false;
+        memcpy(out, r->p, bytes);
+        r->p += bytes;
+        return true;
+    }
+
+    if (((r->p[2] << 8) | r->p[3]) != width) return false;
+    r->p += 4;
+
+    for (int ch = 0; ch < 4; ch++) {
+        int x = 0;
+        while (x < width) {
+            if (r->p >= r->end) return false;
+            int count = *r->p++;
+            if (count > 128) {
+                count -= 128;
+                if (count > width - x || r->p >= r->end) return false;
+                unsigned char value = *r->p++;
+                for (int i = 0; i < count; i++) out[(x++) * 4 + ch] = value;
+            } else {
+                if (count == 0 || count > width - x || r->end - r->p < count) return false;
+                for (int i = 0; i < count; i++) out[(x++) * 4 + ch] = *r->p++;
+            }
+        }
+    }
+    return true;
+}
+
+static void rgbe_to_float(const unsigned char *rgbe, float *rgb)
+{
+    if (rgbe[3] == 0) {
+        rgb[0] = rgb[1] = rgb[2] = 0.0f;
+        return;
+    }
+    float f = ldexpf(1.0f, (int)rgbe[3] - (128 + 8));
+    rgb[0] = (rgbe[0] + 0.5f) * f;
+    rgb[1] = (rgbe[1] + 0.5f) * f;
+    rgb[2] = (rgbe[2] + 0.5f) * f;
+}
+
+static float luminance(const float *rgb)
+{
+    return 0.2126f * rgb[0] + 0.7152f * rgb[1] + 0.0722f * rgb[2];
+}
+
+static Uint8 srgb_encode(float c)
+{
+    if (c <= 0.0f) return 0;
+    if (c >= 1.0f) return 255;
+    c = c <= 0.0031308f ? c * 12.92f : 1.055f * powf(c, 1.0f / 2.4f) - 0.055f;
+    return (Uint8)(c * 255.0f + 0.5f);
+}
+
+bool hdr_is_radiance(const char *path)
+{
+    const char *dot = path ? strrchr(path, '.') : NULL;
+    return dot && strcasecmp(dot, ".hdr") == 0;
+}
+
+SDL_Surface *hdr_decode(const void *data, size_t size)
+{
+    Reader r = { data, (const unsigned char *)data + size };
+    char line[256];
+
+    /* Header: magic, key=value lines, blank line, then the resolution */
+    if (!read_line(&r, line, sizeof(line)) ||
+        (strcmp(line, "#?RADIANCE") != 0 && strcmp(line, "#?RGBE") != 0)) {
+        fprintf(stderr, "hdr: not a Radiance HDR file\n");
+        return NULL;
+    }
+    for (;;) {
+        if (!read_line(&r, line, sizeof(line))) return NULL;
+        if (line[0] == '\0') break;
+        if (strncmp(line, "FORMAT=", 7) == 0 && strcmp(line + 7, "32-bit_rle_rgbe") != 0) {
+            fprintf(stderr, "hdr: unsupported pixel format '%s'\n", line + 7);
+            return NULL;
+        }
+    }
+
+    char ysign, xsign;
+    int width, height;
+    if (!read_line(&r, line, sizeof(line)) ||
+        sscanf(line, "%cY %d %cX %d", &ysign, &height, &xsign, &width) != 4 ||
+        xsign != '+' || (ysign != '-' && ysign != '+')) {
+        fprintf(stderr, "hdr: unsupported image orientation\n");
+        return NULL;
+    }
+    if (width <= 0 || height <= 0 ||
+        width > MAX_IMAGE_DIMENSION || height > MAX_IMAGE_DIMENSION) {
+        fprintf(stderr, "hdr: invalid dimensions %dx%d\n", width, height);
+        return NULL;
+    }
+
+    unsigned char *rgbe = malloc((size_t)width * (size_t)height * 4);
+    if (!rgbe) return NULL;
+    for (int y = 0; y < height; y++) {
+        /* "+Y" files store the bottom row first */
+        int row = ysign == '-' ? y : height - 1 - y;
+        if (!read_scanline(&r, rgbe + (size_t)row * width * 4, width)) {
+            fprintf(stderr, "hdr: truncated or corrupt scanline %d\n", y);
+            free(rgbe);
+            return NULL;
+        }
+    }
+
+    /* Log-average and peak luminance drive the tone curve */
+    size_t pixels = (size_t)width * (size_t)height;
+    double log_sum = 0.0;
+    float peak = 0.0f;
+    for (size_t i = 0; i < pixels; i++) {
+        float rgb[3];
+        rgbe_to_float(rgbe + i * 4, rgb);
+        float lum = luminance(rgb);
+        log_sum += log(1e-4 + lum);
+        if (lum > peak) peak = lum;
+    }
+    float avg = (float)exp(log_sum / (double)pixels);
+    float scale = HDR_KEY / (avg > 0.0f ? avg : 1.0f);
+    float white = peak * scale;
+    float white_sq = white > 1.0f ? white * white : 1.0f;
+
+    SDL_Surface *surface = SDL_CreateSurface(width, height, SDL_PIXELFORMAT_RGBA32);
+    if (!surface) {
+        free(rgbe);
+        return NULL;
+    }
+    for (int y = 0; y < height; y++) {
+        Uint8 *dst = (Uint8 *)surface->pixels + (size_t)y * surface->pitch;
+        const unsigned char *src = rgbe + (size_t)y * width * 4;
+        for (int x = 0; x < width; x++, dst += 4, src += 4) {
+            float rgb[3];
+            rgbe_to_float(src, rgb);
+            float lum = luminance(rgb);
+            float ratio = 0.0f;
+            if (lum > 0.0f) {
+                float l = lum * scale;
+                ratio = (l * (1.0f + l / white_sq) / (1.0f + l)) / lum;
+            }
+            dst[0] = srgb_encode(rgb[0] * ratio);
+            dst[1] = srgb_encode(rgb[1] * ratio);
+            dst[2] = srgb_encode(rgb[2] * ratio);
+            dst[3] = 255;
+        }
+    }
+
+    free(rgbe);
+    return surface;
+}
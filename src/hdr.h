@@ -0,0 +1,16 @@
+#ifndef FRAME_HDR_H
+#define FRAME_HDR_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+#include <stddef.h>
+
+/* Check whether the path names a Radiance HDR (RGBE) image. */
+bool hdr_is_radiance(const char *path);
+
+/* Decode a Radiance HDR image from memory and tone-map it for an SDR
+   display (Reinhard global operator, sRGB encoding).
+   Returns an RGBA surface the caller owns, or NULL on error. */
+SDL_Surface *hdr_decode(const void *data, size_t size);
+
+#endif /* FRAME_HDR_H */
@@ -0,0 +1,60 @@
+#define _GNU_SOURCE
+#include "hooks.h"
+#include "config.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <sys/types.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+static const char *event_name(HookEvent event)
+{
+    switch (event) {
+    case HOOK_IMAGE_CHANGED: return "image-changed";
+    case HOOK_IMAGE_DELETED: return "image-deleted";
+    case HOOK_IMAGE_SAVED:   return "image-saved";
+    }
+    return "unknown";
+}
+
+static const char *event_command(HookEvent event)
+{
+    const FrameConfig *cfg = config_get();
+    switch (event) {
+    case HOOK_IMAGE_CHANGED: return cfg->hook_image_changed;
+    case HOOK_IMAGE_DELETED: return cfg->hook_image_deleted;
+    case HOOK_IMAGE_SAVED:   return cfg->hook_image_saved;
+    }
+    return NULL;
+}
+
+void hooks_run(HookEvent event, const char *path, int index, int count)
+{
+    const char *cmd = event_command(event);
+    if (!cmd || cmd[0] == '\0' || !path) return;
+
+    /* Double fork so the hook is reparented to init and never becomes a
+       zombie, without Frame having to reap it later. */
+    pid_t pid = fork();
+    if (pid < 0) {
+        perror("hooks: fork");
+        return;
+    }
+
+    if (pid == 0) {
+        if (fork() != 0) _exit(0);
+
+        char num[32];
+        setenv("FRAME_EVENT", event_name(event), 1);
+        setenv("FRAME_PATH", path, 1);
+        snprintf(num, sizeof(num), "%d", index);
+        setenv("FRAME_INDEX", num, 1);
+        snprintf(num, sizeof(num), "%d", count);
+        setenv("FRAME_COUNT", num, 1);
+
+        execl("/bin/sh", "sh", "-c", cmd, "frame-hook", path, (char *)NULL);
+        _exit(127);
+    }
+
+    waitpid(pid, NULL, 0);
+}
@@ -0,0 +1,24 @@
+#ifndef FRAME_HOOKS_H
+#define FRAME_HOOKS_H
+
+/*
+ * User hooks: shell commands from the config file that run on viewer events.
+ *
+ * Each command is run with `sh -c` in the background (Frame never waits for
+ * it). The image path is passed as $1 and in the environment:
+ *   FRAME_EVENT  event name (image-changed, image-deleted, image-saved)
+ *   FRAME_PATH   absolute path of the image
+ *   FRAME_INDEX  1-based position in the current list
+ *   FRAME_COUNT  number of images in the list
+ */
+
+typedef enum {
+    HOOK_IMAGE_CHANGED,   /* a new image is displayed */
+    HOOK_IMAGE_DELETED,   /* the image was moved to trash */
+    HOOK_IMAGE_SAVED,     /* Frame wrote the image to disk */
+} HookEvent;
+
+/* Run the command configured for `event`, if any. */
+void hooks_run(HookEvent event, const char *path, int index, int count);
+
+#endif /* FRAME_HOOKS_H */
@@ -0,0 +1,532 @@
+#define _DEFAULT_SOURCE
+#include "info.h"
+#include "loader.h"
+#include "utils.h"
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+
+/* Results of the slower readers (content sniffing, libexif, header
+   probing), remembered per file so moving back and forth through a folder
+   or hovering the grid does not parse the same files again. An entry is
+   only used while the file's mtime and size are unchanged. */
+#define INFO_CACHE_SIZE 128
+
+typedef struct {
+    char *path;            /* canonical path, NULL for a free slot */
+    time_t modified;
+    long long file_size;
+    const char *format;
+    bool has_exif;
+    ExifInfo exif;
+    ImageColor color;
+    int width, height;     /* 0 until probed or decoded */
+    unsigned int last_used;
+} InfoCacheEntry;
+
+static InfoCacheEntry info_cache[INFO_CACHE_SIZE];
+static unsigned int info_cache_clock = 0;
+
+static void probe_color(const char *path, ImageColor *out);
+
+/* Find the entry for a file as it is now, or NULL */
+static InfoCacheEntry *info_cache_find(const ImageInfo *info)
+{
+    for (int i = 0; i < INFO_CACHE_SIZE; i++) {
+        InfoCacheEntry *e = &info_cache[i];
+        if (e->path && e->modified == info->modified && e->file_size == info->file_size &&
+            strcmp(e->path, info->path) == 0) {
+            e->last_used = ++info_cache_clock;
+            return e;
+        }
+    }
+    return NULL;
+}
+
+/* Store the metadata of info, replacing an outdated entry for the same
+   file or else the least recently used one */
+static void info_cache_store(const ImageInfo *info)
+{
+    InfoCacheEntry *slot = &info_cache[0];
+    for (int i = 0; i < INFO_CACHE_SIZE; i++) {
+        InfoCacheEntry *e = &info_cache[i];
+        if (!e->path || strcmp(e->path, info->path) == 0) {
+            slot = e;
+            break;
+        }
+        if (e->last_used < slot->last_used) slot = e;
+    }
+
+    char *path = slot->path && strcmp(slot->path, info->path) == 0 ? slot->path : strdup(info->path);
+    if (!path) return;
+    if (path != slot->path) free(slot->path);
+
+    slot->path = path;
+    slot->modified = info->modified;
+    slot->file_size = info->file_size;
+    slot->format = info->format;
+    slot->has_exif = info->has_exif;
+    slot->exif = info->exif;
+    slot->color = info->color;
+    slot->width = info->width;
+    slot->height = info->height;
+    slot->last_used = ++info_cache_clock;
+}
+
+/* Remember dimensions found for an already cached file */
+static void info_cache_set_dimensions(const ImageInfo *info)
+{
+    InfoCacheEntry *e = info_cache_find(info);
+    if (e) {
+        e->width = info->width;
+        e->height = info->height;
+    }
+}
+
+bool info_read(const char *path, ImageInfo *out)
+{
+    if (!path || !out) return false;
+    memset(out, 0, sizeof(*out));
+
+    struct stat st;
+    if (stat(path, &st) != 0) return false;
+
+    out->path = realpath(path, NULL);
+    if (!out->path) out->path = strdup(path);
+    if (!out->path) return false;
+
+    const char *name = strrchr(out->path, '/');
+    out->name = name ? name + 1 : out->path;
+    out->file_size = (long long)st.st_size;
+    out->modified = st.st_mtime;
+
+    const InfoCacheEntry *cached = info_cache_find(out);
+    if (cached) {
+        out->format = cached->format;
+        out->has_exif = cached->has_exif;
+        out->exif = cached->exif;
+        out->color = cached->color;
+        return true;
+    }
+
+    /* The content decides; the extension may be wrong or missing */
+    const char *ext = image_sniff_file(path);
+    if (!ext) ext = strrchr(out->name, '.');
+    out->format = ext ? format_from_ext(ext) : "Unknown";
+
+    out->has_exif = exif_read(path, &out->exif);
+    probe_color(path, &out->color);
+    info_cache_store(out);
+    return true;
+}
+
+bool info_read_dimensions(ImageInfo *info)
+{
+    if (!info || !info->path) return false;
+
+    SDL_Surface *surface = loader_load_static(info->path);
+    if (!surface) return false;
+
+    info->width = surface->w;
+    info->height = surface->h;
+    SDL_DestroySurface(surface);
+    info_cache_set_dimensions(info);
+    return true;
+}
+
+static unsigned int be16(const unsigned char *p) { return (unsigned int)p[0] << 8 | p[1]; }
+static unsigned int le16(const unsigned char *p) { return (unsigned int)p[1] << 8 | p[0]; }
+static unsigned int le24(const unsigned char *p) { return le16(p) | (unsigned int)p[2] << 16; }
+
+static unsigned int be32(const unsigned char *p)
+{
+    return (unsigned int)p[0] << 24 | (unsigned int)p[1] << 16 | (unsigned int)p[2] << 8 | p[3];
+}
+
+/* Walk the JPEG markers up to the first start-of-frame */
+static bool probe_jpeg(FILE *fp, int *w, int *h)
+{
+    if (fseek(fp, 2, SEEK_SET) != 0) return false;
+
+    unsigned char seg[7];
+    for (;;) {
+        int c = fgetc(fp);
+        if (c != 0xFF) return false;
+        int marker;
+        do {
+            marker = fgetc(fp);
+        } while (marker == 0xFF);
+        if (marker == EOF || marker == 0xD9 || marker == 0xDA) return false;
+        if (marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7)) continue;
+
+        if (fread(seg, 1, 2, fp) != 2) return false;
+        unsigned int len = be16(seg);
+        if (len < 2) return false;
+
+        /* SOF0-SOF15, except DHT (C4), JPG (C8) and DAC (CC) */
+        if (marker >= 0xC0 && marker <= 0xCF &&
+            marker != 0xC4 && marker != 0xC8 && marker != 0xCC) {
+            if (fread(seg, 1, 5, fp) != 5) return false;
+            *h = (int)be16(seg + 1);
+            *w = (int)be16(seg + 3);
+            return true;
+        }
+        if (fseek(fp, (long)len - 2, SEEK_CUR) != 0) return false;
+    }
+}
+
+bool info_probe_dimensions(ImageInfo *info)
+{
+    if (!info || !info->path) return false;
+
+    const InfoCacheEntry *cached = info_cache_find(info);
+    if (cached && cached->width > 0) {
+        info->width = cached->width;
+        info->height = cached->height;
+        return true;
+    }
+
+    FILE *fp = fopen(info->path, "rb");
+    if (!fp) return false;
+
+    unsigned char hdr[32] = {0};
+    size_t got = fread(hdr, 1, sizeof(hdr), fp);
+    int w = 0, h = 0;
+
+    if (got >= 24 && memcmp(hdr, "\x89PNG\r\n\x1a\n", 8) == 0 && memcmp(hdr + 12, "IHDR", 4) == 0) {
+        w = (int)be32(hdr + 16);
+        h = (int)be32(hdr + 20);
+    } else if (got >= 10 && memcmp(hdr, "GIF8", 4) == 0) {
+        w = (int)le16(hdr + 6);
+        h = (int)le16(hdr + 8);
+    } else if (got >= 26 && hdr[0] == 'B' && hdr[1] == 'M') {
+        w = (int)(le16(hdr + 18) | le16(hdr + 20) << 16);
+        h = (int)(le16(hdr + 22) | le16(hdr + 24) << 16);
+        if (h < 0) h = -h;  /* top-down bitmap */
+    } else if (got >= 30 && memcmp(hdr, "RIFF", 4) == 0 && memcmp(hdr + 8, "WEBP", 4) == 0) {
+        if (memcmp(hdr + 12, "VP8 ", 4) == 0) {
+            w = (int)(le16(hdr + 26) & 0x3FFF);
+            h = (int)(le16(hdr + 28) & 0x3FFF);
+        } else if (memcmp(hdr + 12, "VP8L", 4) == 0) {
+            const unsigned char *b = hdr + 21;
+            w = 1 + (int)(((b[1] & 0x3F) << 8) | b[0]);
+            h = 1 + (int)(((b[3] & 0x0F) << 10) | (b[2] << 2) | ((b[1] & 0xC0) >> 6));
+        } else if (memcmp(hdr + 12, "VP8X", 4) == 0) {
+            w = 1 + (int)le24(hdr + 24);
+            h = 1 + (int)le24(hdr + 27);
+        }
+    } else if (got >= 2 && hdr[0] == 0xFF && hdr[1] == 0xD8) {
+        probe_jpeg(fp, &w, &h);
+    }
+    fclose(fp);
+
+    if (w <= 0 || h <= 0) return false;
+    info->width = w;
+    info->height = h;
+    info_cache_set_dimensions(info);
+    return true;
+}
+
+/* ---- colour metadata ---- */
+
+/* Headers, ICC profiles and JFIF/EXIF segments sit in the first bytes */
+#define COLOR_PROBE_BYTES (256 * 1024)
+
+/* Pixels per metre to dots per inch */
+static int ppm_to_dpi(unsigned int ppm)
+{
+    return (int)((double)ppm * 0.0254 + 0.5);
+}
+
+/* Copy the description ('desc' tag) of an ICC profile as ASCII. Handles
+   v2 text descriptions and the first record of v4 multi-language ones. */
+static void icc_description(const unsigned char *p, size_t n, char *out, size_t size)
+{
+    out[0] = '\0';
+    if (n < 132 || size == 0) return;
+
+    unsigned int count = be32(p + 128);
+    for (unsigned int i = 0; i < count && 132 + (size_t)(i + 1) * 12 <= n; i++) {
+        const unsigned char *tag = p + 132 + (size_t)i * 12;
+        if (memcmp(tag, "desc", 4) != 0) continue;
+
+        size_t off = be32(tag + 4), len = be32(tag + 8);
+        if (off >= n || len > n - off || len < 12) return;
+        const unsigned char *t = p + off;
+        size_t o = 0;
+        if (memcmp(t, "desc", 4) == 0) {
+            size_t chars = be32(t + 8);
+            if (chars > len - 12) chars = len - 12;
+            for (size_t c = 0; c < chars && t[12 + c] && o + 1 < size; c++) {
+                out[o++] = t[12 + c] < 0x80 ? (char)t[12 + c] : '?';
+            }
+        } else if (memcmp(t, "mluc", 4) == 0 && len >= 28 && be32(t + 8) > 0) {
+            size_t str_len = be32(t + 20), str_off = be32(t + 24);
+            if (str_off >= len || str_len > len - str_off) return;
+            for (size_t c = 0; c + 1 < str_len && o + 1 < size; c += 2) {
+                unsigned int ch = be16(t + str_off + c);
+                if (ch == 0) break;
+                out[o++] = ch < 0x80 ? (char)ch : '?';
+            }
+        }
+        out[o] = '\0';
+        return;
+    }
+}
+
+static void color_png(const unsigned char *p, size_t n, ImageColor *out)
+{
+    for (size_t off = 8; off + 12 <= n; ) {
+        size_t len = be32(p + off);
+        const unsigned char *type = p + off + 4;
+        const unsigned char *data = p + off + 8;
+        if (len > n - off - 12) break;
+
+        if (memcmp(type, "IHDR", 4) == 0 && len >= 13) {
+            static const char *const spaces[] = {
+                "Grayscale", NULL, "RGB", "Indexed", "Grayscale", NULL, "RGB"
+            };
+            out->bit_depth = data[8];
+            out->color_space = data[9] < 7 ? spaces[data[9]] : NULL;
+            out->has_alpha = data[9] == 4 || data[9] == 6;
+        } else if (memcmp(type, "tRNS", 4) == 0) {
+            out->has_alpha = true;
+        } else if (memcmp(type, "sRGB", 4) == 0 && !out->icc_profile[0]) {
+            snprintf(out->icc_profile, sizeof(out->icc_profile), "sRGB");
+        } else if (memcmp(type, "iCCP", 4) == 0) {
+            /* The profile itself is compressed; its name comes first */
+            size_t name_len = strnlen((const char *)data, len < 80 ? len : 80);
+            snprintf(out->icc_profile, sizeof(out->icc_profile), "%.*s", (int)name_len, data);
+        } else if (memcmp(type, "pHYs", 4) == 0 && len >= 9 && data[8] == 1) {
+            out->dpi_x = ppm_to_dpi(be32(data));
+            out->dpi_y = ppm_to_dpi(be32(data + 4));
+        } else if (memcmp(type, "IDAT", 4) == 0) {
+            break;
+        }
+        off += len + 12;
+    }
+}
+
+static void color_jpeg(const unsigned char *p, size_t n, ImageColor *out)
+{
+    size_t off = 2;
+    while (off + 4 <= n && p[off] == 0xFF) {
+        unsigned int marker = p[off + 1];
+        if (marker == 0xFF) { off++; continue; }
+        if (marker == 0xD9 || marker == 0xDA) break;
+        if (marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7)) { off += 2; continue; }
+
+        size_t len = be16(p + off + 2);
+        if (len < 2 || off + 2 + len > n) break;
+        const unsigned char *seg = p + off + 4;
+        size_t seg_len = len - 2;
+
+        if (marker == 0xE0 && seg_len >= 12 && memcmp(seg, "JFIF\0", 5) == 0) {
+            unsigned int units = seg[7], xd = be16(seg + 8), yd = be16(seg + 10);
+            if (units == 1) {
+                out->dpi_x = (int)xd;
+                out->dpi_y = (int)yd;
+            } else if (units == 2) {
+                out->dpi_x = (int)(xd * 2.54 + 0.5);
+                out->dpi_y = (int)(yd * 2.54 + 0.5);
+            }
+        } else if (marker == 0xE2 && seg_len > 14 && memcmp(seg, "ICC_PROFILE\0", 12) == 0 &&
+                   seg[12] == 1) {
+            /* Only the first chunk; the description is near the start */
+            icc_description(seg + 14, seg_len - 14, out->icc_profile, sizeof(out->icc_profile));
+            if (!out->icc_profile[0])
+                snprintf(out->icc_profile, sizeof(out->icc_profile), "embedded");
+        } else if (marker >= 0xC0 && marker <= 0xCF &&
+                   marker != 0xC4 && marker != 0xC8 && marker != 0xCC && seg_len >= 6) {
+            out->bit_depth = seg[0];
+            out->color_space = seg[5] == 1 ? "Grayscale" : seg[5] == 4 ? "CMYK" : "RGB";
+            break;
+        }
+        off += 2 + len;
+    }
+}
+
+static void color_gif(const unsigned char *p, size_t n, ImageColor *out)
+{
+    out->color_space = "Indexed";
+    out->bit_depth = (p[10] & 0x07) + 1;
+
+    /* A graphic control extension before the first frame may set a
+       transparent colour */
+    size_t off = 13;
+    if (p[10] & 0x80) off += (size_t)3 << ((p[10] & 0x07) + 1);
+    while (off + 2 < n && p[off] == 0x21) {
+        if (p[off + 1] == 0xF9 && off + 3 < n && (p[off + 3] & 0x01)) {
+            out->has_alpha = true;
+            return;
+        }
+        off += 2;
+        while (off < n && p[off] != 0) off += p[off] + 1;
+        off++;
+    }
+}
+
+static void color_bmp(const unsigned char *p, size_t n, ImageColor *out)
+{
+    unsigned int header = le16(p + 14) | le16(p + 16) << 16;
+    unsigned int bpp = le16(p + 28);
+    out->color_space = bpp <= 8 ? "Indexed" : "RGB";
+    out->bit_depth = bpp <= 8 ? (int)bpp : bpp == 16 ? 5 : 8;
+    if (n >= 54 && header >= 40) {
+        unsigned int xppm = le16(p + 38) | le16(p + 40) << 16;
+        unsigned int yppm = le16(p + 42) | le16(p + 44) << 16;
+        out->dpi_x = ppm_to_dpi(xppm);
+        out->dpi_y = ppm_to_dpi(yppm);
+    }
+    /* V3 headers and later carry an alpha mask */
+    if (bpp == 32 && header >= 56 && n >= 70) {
+        out->has_alpha = (le16(p + 66) | le16(p + 68)) != 0;
+    }
+}
+
+static void color_webp(const unsigned char *p, size_t n, ImageColor *out)
+{
+    out->color_space = "RGB";
+    out->bit_depth = 8;
+    if (memcmp(p + 12, "VP8L", 4) == 0 && n >= 25) {
+        out->has_alpha = (p[24] & 0x10) != 0;
+    } else if (memcmp(p + 12, "VP8X", 4) == 0 && n >= 21) {
+        out->has_alpha = (p[20] & 0x10) != 0;
+
+        /* The ICCP chunk follows VP8X when flagged */
+        size_t off = 30;
+        if ((p[20] & 0x20) && off + 8 <= n && memcmp(p + off, "ICCP", 4) == 0) {
+            size_t len = le16(p + off + 4) | (size_t)le16(p + off + 6) << 16;
+            if (len > n - off - 8) len = n - off - 8;
+            icc_description(p + off + 8, len, out->icc_profile, sizeof(out->icc_profile));
+            if (!out->icc_profile[0])
+                snprintf(out->icc_profile, sizeof(out->icc_profile), "embedded");
+        }
+    }
+}
+
+/* Read the colour metadata from the start of the file (PNG, JPEG, GIF,
+   BMP, WebP); other formats leave `out` empty. */
+static void probe_color(const char *path, ImageColor *out)
+{
+    memset(out, 0, sizeof(*out));
+
+    FILE *fp = fopen(path, "rb");
+    if (!fp) return;
+    unsigned char *p = malloc(COLOR_PROBE_BYTES);
+    size_t n = p ? fread(p, 1, COLOR_PROBE_BYTES, fp) : 0;
+    fclose(fp);
+
+    if (n >= 33 && memcmp(p, "\x89PNG\r\n\x1a\n", 8) == 0) {
+        color_png(p, n, out);
+    } else if (n >= 4 && p[0] == 0xFF && p[1] == 0xD8) {
+        color_jpeg(p, n, out);
+    } else if (n >= 13 && memcmp(p, "GIF8", 4) == 0) {
+        color_gif(p, n, out);
+    } else if (n >= 30 && p[0] == 'B' && p[1] == 'M') {
+        color_bmp(p, n, out);
+    } else if (n >= 21 && memcmp(p, "RIFF", 4) == 0 && memcmp(p + 8, "WEBP", 4) == 0) {
+        color_webp(p, n, out);
+    }
+    free(p);
+}
+
+char *info_format_color(const ImageInfo *info)
+{
+    if (!info) return NULL;
+    const ImageColor *c = &info->color;
+
+    char buf[512];
+    size_t len = 0;
+    buf[0] = '\0';
+    if (c->color_space) {
+        char depth[32] = "";
+        if (c->bit_depth > 0) snprintf(depth, sizeof(depth), ", %d-bit", c->bit_depth);
+        len += (size_t)snprintf(buf + len, sizeof(buf) - len, "Color:      %s%s, %s\n",
+                                c->color_space, depth, c->has_alpha ? "alpha" : "no alpha");
+    }
+    if (c->icc_profile[0] && len < sizeof(buf)) {
+        len += (size_t)snprintf(buf + len, sizeof(buf) - len, "Profile:    %s\n", c->icc_profile);
+    }
+    if (c->dpi_x > 0 && len < sizeof(buf)) {
+        if (c->dpi_y > 0 && c->dpi_y != c->dpi_x) {
+            snprintf(buf + len, sizeof(buf) - len, "Resolution: %d \xc3\x97 %d dpi\n", c->dpi_x, c->dpi_y);
+        } else {
+            snprintf(buf + len, sizeof(buf) - len, "Resolution: %d dpi\n", c->dpi_x);
+        }
+    }
+    return buf[0] ? strdup(buf) : NULL;
+}
+
+void info_free(ImageInfo *info)
+{
+    if (!info) return;
+    free(info->path);
+    info->path = NULL;
+    info->name = NULL;
+}
+
+/* Write `"key": "value"` with a leading separator, skipping empty values. */
+static void write_field(FILE *out, const char *key, const char *value, bool *first)
+{
+    if (!value || !value[0]) return;
+
+    char *quoted = json_quote(value);
+    if (!quoted) return;
+    fprintf(out, "%s\"%s\": %s", *first ? "" : ", ", key, quoted);
+    *first = false;
+    free(quoted);
+}
+
+void info_write_json(const ImageInfo *info, FILE *out)
+{
+    if (!info || !out) return;
+
+    char time_buf[64] = "";
+    struct tm *tm_info = localtime(&info->modified);
+    if (tm_info) {
+        strftime(time_buf, sizeof(time_buf), "%Y-%m-%dT%H:%M:%S%z", tm_info);
+    }
+
+    bool first = true;
+    fputc('{', out);
+    write_field(out, "path", info->path, &first);
+    write_field(out, "name", info->name, &first);
+    write_field(out, "format", info->format, &first);
+    fprintf(out, ", \"size\": %lld", info->file_size);
+    fprintf(out, ", \"width\": %d, \"height\": %d", info->width, info->height);
+    write_field(out, "modified", time_buf, &first);
+
+    const ImageColor *c = &info->color;
+    write_field(out, "color_space", c->color_space, &first);
+    if (c->bit_depth > 0) fprintf(out, ", \"bit_depth\": %d", c->bit_depth);
+    if (c->color_space) fprintf(out, ", \"alpha\": %s", c->has_alpha ? "true" : "false");
+    write_field(out, "icc_profile", c->icc_profile, &first);
+    if (c->dpi_x > 0) fprintf(out, ", \"dpi_x\": %d, \"dpi_y\": %d", c->dpi_x, c->dpi_y);
+
+    fputs(", \"exif\": ", out);
+    if (info->has_exif) {
+        const ExifInfo *e = &info->exif;
+        bool efirst = true;
+        fputc('{', out);
+        write_field(out, "make", e->make, &efirst);
+        write_field(out, "model", e->model, &efirst);
+        write_field(out, "lens", e->lens, &efirst);
+        write_field(out, "date", e->date, &efirst);
+        write_field(out, "exposure", e->exposure, &efirst);
+        write_field(out, "aperture", e->aperture, &efirst);
+        write_field(out, "iso", e->iso, &efirst);
+        write_field(out, "orientation", e->orientation, &efirst);
+        write_field(out, "focal_length", e->focal_length, &efirst);
+        write_field(out, "flash", e->flash, &efirst);
+        if (e->has_gps) {
+            fprintf(out, "%s\"latitude\": %.6f, \"longitude\": %.6f",
+                    efirst ? "" : ", ", e->latitude, e->longitude);
+        }
+        fputc('}', out);
+    } else {
+        fputs("null", out);
+    }
+    fputc('}', out);
+}
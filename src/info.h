@@ -0,0 +1,60 @@
+#ifndef FRAME_INFO_H
+#define FRAME_INFO_H
+
+#include "exif.h"
+#include <stdbool.h>
+#include <stdio.h>
+#include <time.h>
+
+/* Pixel layout and colour metadata from the file header. Fields a format
+   does not record stay empty (NULL, 0 or ""). */
+typedef struct {
+    const char *color_space; /* "RGB", "Grayscale", "Indexed" or "CMYK", string literal */
+    int bit_depth;           /* bits per channel (per index for palettes) */
+    bool has_alpha;          /* alpha channel or transparent colour */
+    char icc_profile[64];    /* description of the embedded ICC profile */
+    int dpi_x, dpi_y;        /* print resolution */
+} ImageColor;
+
+/* Metadata about a single image file, gathered with the native readers
+   (stat, SDL_image, libexif) — no external tools are involved. */
+typedef struct {
+    char *path;            /* absolute path (owned) */
+    const char *name;      /* basename, points into path */
+    const char *format;    /* format name from the content (else the extension), string literal */
+    long long file_size;   /* bytes */
+    time_t modified;       /* mtime */
+    int width, height;     /* pixel dimensions, 0 if unknown */
+    bool has_exif;
+    ExifInfo exif;
+    ImageColor color;
+} ImageInfo;
+
+/* Fill `out` with file, format, colour and EXIF metadata for `path`.
+   Dimensions are left at 0 — use info_read_dimensions() or fill them in
+   from an already decoded image. Returns false if the file cannot be stat'd.
+   Call info_free() on success.
+   Format, EXIF and dimensions are cached per file (path, mtime and size),
+   so repeated calls only stat the file. Call from the main thread only. */
+bool info_read(const char *path, ImageInfo *out);
+
+/* Decode the image to determine its dimensions. Returns false on failure. */
+bool info_read_dimensions(ImageInfo *info);
+
+/* Read the dimensions from the file header without decoding (PNG, GIF,
+   JPEG, BMP, WebP). Much cheaper than info_read_dimensions(); returns false
+   for other formats or damaged headers. */
+bool info_probe_dimensions(ImageInfo *info);
+
+/* Format the colour metadata as "Key: value" lines (colour space, bit
+   depth and alpha; ICC profile; resolution), aligned like the rest of the
+   info text. Returns NULL if nothing is known. The caller must free it. */
+char *info_format_color(const ImageInfo *info);
+
+/* Release memory owned by an ImageInfo (does not free the struct itself). */
+void info_free(ImageInfo *info);
+
+/* Write the info as a single JSON object (no trailing newline). */
+void info_write_json(const ImageInfo *info, FILE *out);
+
+#endif /* FRAME_INFO_H */
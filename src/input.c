@@ -6,19 +6,65 @@
 #include "overlay.h"
 #include "utils.h"
 #include "exif.h"
+#include "xmp.h"
+#include "info.h"
+#include "config.h"
+#include "hooks.h"
+#include "keyhandler.h"
+#include "filter.h"
+#include "dialog.h"
+#include "watch.h"
+#include "upload.h"
+#include "pdf.h"
+#include "contact.h"
+#include "gif.h"
+#include "loader.h"
+#include "openwith.h"
+#include "filmstrip.h"
+#include "statusbar.h"
+#include "compare.h"
+#include "about.h"
+#include "theme.h"
 #include <SDL3/SDL.h>
+#include <SDL3_image/SDL_image.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
+#include <strings.h>
+#include <errno.h>
+#include <math.h>
 #include <sys/stat.h>
 #include <time.h>
+#include <unistd.h>
+
+/* Quality used when a rotated JPEG is written back */
+#define JPEG_SAVE_QUALITY 95
+#define WHEEL_PAN_STEP 60.0f   /* pixels per wheel notch in pan mode */
 
 /* 'gg' double-tap state */
 static bool g_sequence = false;
 static Uint64 g_prev_tick = 0;
 
-/* Fullscreen state */
-static bool fullscreen_active = false;
+/* Ctrl+x was pressed: the next key goes to the external key handler */
+static bool keyhandler_pending = false;
+
+/* Folder of the last export this session; later exports are suggested
+   there instead of next to the image */
+static char last_export_dir[4096] = {0};
+
+/* Format last picked for contact sheets ("png" or "pdf") */
+static char last_sheet_ext[8] = "png";
+
+/* '/' or Ctrl+p was pressed: main loop should open the search overlay */
+static bool search_requested = false;
+static SearchMode search_request_mode = SEARCH_MODE_GRID;
+
+bool input_take_search_request(SearchMode *out_mode) {
+    bool requested = search_requested;
+    search_requested = false;
+    if (out_mode) *out_mode = search_request_mode;
+    return requested;
+}
 
 void input_reset_gg(void) {
     g_sequence = false;
@@ -35,6 +81,12 @@ bool input_nav_pending(void) {
     return nav_pending_load;
 }
 
+/* Run the image-changed hook for the current image */
+static void notify_image_changed(struct AppState *app) {
+    hooks_run(HOOK_IMAGE_CHANGED, app_current_path(app),
+              app_current_index(app), app_image_count(app));
+}
+
 bool input_check_and_trigger_nav(struct AppState *app, struct Viewer *viewer) {
     if (!nav_pending_load) return false;
     Uint64 now = SDL_GetTicks();
@@ -43,6 +95,7 @@ bool input_check_and_trigger_nav(struct AppState *app, struct Viewer *viewer) {
         if (path) {
             viewer_load_image(viewer, path);
             viewer_prefetch_around(viewer, app);
+            notify_image_changed(app);
         }
         nav_pending_load = false;
         return true;
@@ -50,11 +103,240 @@ bool input_check_and_trigger_nav(struct AppState *app, struct Viewer *viewer) {
     return false;
 }
 
+/* Describe the unsaved rotation and flips of the image on screen, e.g.
+   "rotated 90°, flipped horizontally". Returns false if there are none. */
+static bool describe_transform(struct Viewer *viewer, char *buf, size_t size) {
+    int degrees = viewer_get_rotation(viewer);
+    bool flip_h, flip_v;
+    viewer_get_flip(viewer, &flip_h, &flip_v);
+    const char *flip = flip_h && flip_v ? "flipped both ways"
+                     : flip_h ? "flipped horizontally"
+                     : flip_v ? "flipped vertically" : NULL;
+    if (degrees != 0 && flip) {
+        snprintf(buf, size, "rotated %d\xc2\xb0, %s", degrees, flip);
+    } else if (degrees != 0) {
+        snprintf(buf, size, "rotated %d\xc2\xb0", degrees);
+    } else if (flip) {
+        snprintf(buf, size, "%s", flip);
+    } else {
+        return false;
+    }
+    return true;
+}
+
+/* Set the window title to "filename (N/M) · 6000×4000 · folder - Frame", or
+   "Frame" with no image. An unsaved rotation or flip is flagged as
+   "[rotated 90°]" after the name; the count reads "N/M…" while the folder is still being
+   read, and the size "loading…" until the full image is decoded. */
+static void update_window_title(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    static char last_title[768];
+    char title[768];
+    const char *path = app_current_path(app);
+    if (!path) {
+        snprintf(title, sizeof(title), "Frame");
+    } else {
+        const char *name = strrchr(path, '/');
+        name = name ? name + 1 : path;
+        char rotated[64] = "";
+        char edit[48];
+        if (!config_get()->read_only && describe_transform(viewer, edit, sizeof(edit))) {
+            snprintf(rotated, sizeof(rotated), " [%s]", edit);
+        }
+
+        char size[48] = "";
+        SDL_Surface *full = viewer_transformed_image(viewer);
+        int w, h;
+        if (full) {
+            snprintf(size, sizeof(size), " \xc2\xb7 %d\xc3\x97%d", full->w, full->h);
+        } else if (viewer_is_loading(viewer) || viewer_get_dimensions(viewer, &w, &h)) {
+            snprintf(size, sizeof(size), " \xc2\xb7 loading\xe2\x80\xa6");
+        }
+
+        char folder[256] = "";
+        const char *dir = app_current_dir(app);
+        if (dir) {
+            const char *base = strrchr(dir, '/');
+            base = base && base[1] ? base + 1 : dir;
+            snprintf(folder, sizeof(folder), " \xc2\xb7 %s", base);
+        }
+
+        snprintf(title, sizeof(title), "%s%s (%d/%d%s)%s%s - Frame",
+                 name, rotated, app_current_index(app), app_image_count(app),
+                 app_scan_active(app) ? "\xe2\x80\xa6" : "", size, folder);
+    }
+
+    if (strcmp(title, last_title) != 0) {
+        snprintf(last_title, sizeof(last_title), "%s", title);
+        SDL_SetWindowTitle(window, title);
+    }
+}
+
+void input_refresh_title(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    update_window_title(app, viewer, window);
+}
+
+/* Parse a zoom percentage such as "150" or "150%". Returns false unless
+   it is a number within the zoom_min..zoom_max setting. */
+static bool parse_zoom_percent(const char *text, float *out) {
+    const FrameConfig *cfg = config_get();
+    char *end = NULL;
+    double pct = strtod(text, &end);
+    if (end == text) return false;
+    while (*end == ' ') end++;
+    if (*end == '%') end++;
+    while (*end == ' ') end++;
+    if (*end != '\0' || pct < cfg->zoom_min || pct > cfg->zoom_max) return false;
+    *out = (float)pct;
+    return true;
+}
+
+static const char *check_zoom_text(const char *text, bool *fatal, void *userdata) {
+    (void)userdata;
+    static char msg[64];
+    float pct;
+    if (parse_zoom_percent(text, &pct)) return NULL;
+    *fatal = true;
+    snprintf(msg, sizeof(msg), "Enter a zoom from %d%% to %d%%", config_get()->zoom_min, config_get()->zoom_max);
+    return msg;
+}
+
+void input_show_zoom(struct Viewer *viewer) {
+    float zoom = viewer_get_zoom(viewer);
+    if (zoom <= 0.0f) return;
+    char msg[32];
+    snprintf(msg, sizeof(msg), "Zoom %.0f%%", zoom * 100.0f);
+    overlay_show_osd(msg);
+}
+
+bool input_handle_click(struct Viewer *viewer, const SDL_MouseButtonEvent *event, SDL_Window *window) {
+    ClickAction action = CLICK_ACTION_NONE;
+    if (event->button == SDL_BUTTON_LEFT && event->clicks == 2) {
+        action = config_get()->double_click;
+    } else if (event->button == SDL_BUTTON_MIDDLE) {
+        action = config_get()->middle_click;
+    }
+
+    if (action == CLICK_ACTION_FULLSCREEN) {
+        SDL_SetWindowFullscreen(window, !(SDL_GetWindowFlags(window) & SDL_WINDOW_FULLSCREEN));
+    } else if (action == CLICK_ACTION_ZOOM) {
+        if (fabsf(viewer_get_zoom(viewer) - 1.0f) < 0.001f) {
+            viewer_zoom_fit(viewer);
+        } else {
+            viewer_zoom_original(viewer);
+        }
+        input_show_zoom(viewer);
+    }
+    return action != CLICK_ACTION_NONE;
+}
+
+/* Write the rotated image over its file (via a temporary file in the same
+   folder, keeping the permissions). Only formats SDL_image can write are
+   supported. With lossless_rotation, JPEGs only get a new EXIF orientation
+   tag, falling back to re-encoding where the tag cannot be written.
+   Re-encoded JPEGs keep their EXIF, XMP and ICC profile.
+   Returns an OSD message. */
+static const char *save_rotation(struct AppState *app, struct Viewer *viewer) {
+    const char *path = viewer_get_path(viewer);
+    SDL_Surface *image = viewer_transformed_image(viewer);
+    if (!path || !image) return "Image is not fully loaded yet";
+
+    const ImageFormat *fmt = image_format_from_ext(image_format_ext(path));
+    const char *mime = fmt ? fmt->mime : "";
+    bool jpeg = strcmp(mime, "image/jpeg") == 0;
+    bool png = strcmp(mime, "image/png") == 0;
+    bool bmp = strcmp(mime, "image/bmp") == 0;
+    if (!jpeg && !png && !bmp) return "Saving rotation is only supported for JPEG, PNG and BMP";
+
+    /* The EXIF orientation can only express the rotation */
+    bool lossless = false;
+    bool flip_h, flip_v;
+    viewer_get_flip(viewer, &flip_h, &flip_v);
+    if (jpeg && config_get()->lossless_rotation && !flip_h && !flip_v) {
+        int orientation = exif_orientation_rotate(exif_read_orientation(path), viewer_get_rotation(viewer));
+        lossless = exif_write_orientation(path, orientation);
+    }
+
+    if (!lossless) {
+        char tmp[4096];
+        const char *slash = strrchr(path, '/');
+        int dir_len = slash ? (int)(slash - path) : 0;
+        snprintf(tmp, sizeof(tmp), "%.*s/.%s.frame-save", dir_len, path, slash ? slash + 1 : path);
+
+        bool ok;
+        if (jpeg) {
+            ok = IMG_SaveJPG(image, tmp, JPEG_SAVE_QUALITY);
+            if (ok && !exif_copy_metadata(path, tmp)) {
+                /* Never trade the photo's EXIF, XMP or colour profile for a rotation */
+                fprintf(stderr, "input: cannot keep the metadata of '%s'\n", path);
+                unlink(tmp);
+                return "Not saved: the metadata could not be kept";
+            }
+        } else if (png) {
+            ok = IMG_SavePNG(image, tmp);
+        } else {
+            ok = SDL_SaveBMP(image, tmp);
+        }
+
+        struct stat st;
+        if (ok && stat(path, &st) == 0) chmod(tmp, st.st_mode & 07777);
+        if (!ok || rename(tmp, path) != 0) {
+            fprintf(stderr, "input: cannot save '%s': %s\n", path, ok ? strerror(errno) : SDL_GetError());
+            unlink(tmp);
+            return "Saving the rotated image failed";
+        }
+    }
+
+    /* Report the saved image's own position, which is not necessarily the
+       current one when saving on the way to another image */
+    int index = 0;
+    for (int i = 0; i < app_image_count(app); i++) {
+        if (strcmp(app_image_path(app, i), path) == 0) {
+            index = i + 1;
+            break;
+        }
+    }
+    hooks_run(HOOK_IMAGE_SAVED, path, index, app_image_count(app));
+
+    /* Show (and cache) what is now on disk; this also resets the rotation */
+    viewer_reload(viewer);
+    return lossless ? "Rotation saved (EXIF orientation, lossless)" : "Rotation saved";
+}
+
+/* The image on screen is about to be replaced: save or drop an unsaved
+   rotation according to the unsaved_rotation setting. */
+static void settle_rotation(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    const char *path = viewer_get_path(viewer);
+    const FrameConfig *cfg = config_get();
+    char edit[48];
+    if (!describe_transform(viewer, edit, sizeof(edit)) || !path || cfg->read_only) return;
+
+    /* Deleted or renamed underneath us: nothing left to save to */
+    bool save = access(path, F_OK) == 0 && cfg->unsaved_rotation == UNSAVED_ROTATION_SAVE;
+    if (access(path, F_OK) == 0 && cfg->unsaved_rotation == UNSAVED_ROTATION_ASK) {
+        const char *name = strrchr(path, '/');
+        char msg[512];
+        snprintf(msg, sizeof(msg), "'%s' is %s in the viewer. Save the change to the file?",
+                 name ? name + 1 : path, edit);
+        save = overlay_modal_confirm("Unsaved Rotation", msg, SDL_GetRenderer(window), viewer);
+    }
+
+    if (save) {
+        overlay_show_osd(save_rotation(app, viewer));
+    }
+    viewer_reset_rotation(viewer);
+}
+
+void input_settle_rotation(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    settle_rotation(app, viewer, window);
+}
+
 /* Navigate, reload image, update title, and prefetch neighbors */
 static bool do_nav(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
     const char *path = app_current_path(app);
     if (!path) return false;
 
+    settle_rotation(app, viewer, window);
+
     Uint64 now = SDL_GetTicks();
     Uint64 delta = now - last_nav_ticks;
     last_nav_ticks = now;
@@ -76,22 +358,688 @@ static bool do_nav(struct AppState *app, struct Viewer *viewer, SDL_Window *wind
         nav_pending_load = true;
     }
 
-    /* Update window title: "filename (N/M) - Frame" */
-    const char *name = strrchr(path, '/');
-    name = name ? name + 1 : path;
-    char title[512];
-    snprintf(title, sizeof(title), "%s (%d/%d) - Frame",
-             name, app_current_index(app), app_image_count(app));
-    SDL_SetWindowTitle(window, title);
+    update_window_title(app, viewer, window);
 
-    /* Prefetch neighbors only if we performed a full load (not in rapid scroll) */
+    /* Prefetch neighbors and run hooks only if we performed a full load
+       (not in rapid scroll — the pending load does it once scrolling stops) */
     if (!rapid) {
         viewer_prefetch_around(viewer, app);
+        notify_image_changed(app);
     }
 
     return loaded;
 }
 
+bool input_handle_wheel(struct AppState *app, struct Viewer *viewer, const SDL_MouseWheelEvent *event,
+                        SDL_Window *window) {
+    /* Touchpads send many small fractions of a notch; navigation waits
+       until they add up to a whole one */
+    static float wheel_steps = 0.0f;
+
+    bool flipped = event->direction == SDL_MOUSEWHEEL_FLIPPED;
+    float dx = flipped ? -event->x : event->x;
+    float dy = flipped ? -event->y : event->y;
+    SDL_Keymod mod = SDL_GetModState();
+    WheelMode mode = config_get()->scroll_wheel;
+    if (mod & SDL_KMOD_CTRL) {
+        mode = WHEEL_ZOOM;
+    } else if (mod & SDL_KMOD_SHIFT) {
+        mode = WHEEL_PAN;
+    } else if (fabsf(dx) > fabsf(dy)) {
+        /* Sideways scrolling (tilt wheels, two fingers on a touchpad) always pans */
+        viewer_do_drag(viewer, -dx * WHEEL_PAN_STEP, 0.0f);
+        return true;
+    }
+
+    if (mode == WHEEL_ZOOM) {
+        if (event->y == 0.0f) return false;
+        viewer_scroll_zoom(viewer, event->mouse_x, event->mouse_y, event->y);
+        input_show_zoom(viewer);
+        return true;
+    }
+    if (mode == WHEEL_PAN) {
+        /* Scrolling right or down shows more of the right or bottom */
+        viewer_do_drag(viewer, -dx * WHEEL_PAN_STEP, dy * WHEEL_PAN_STEP);
+        return true;
+    }
+
+    wheel_steps += dy;
+    if (wheel_steps > -1.0f && wheel_steps < 1.0f) return false;
+    /* Scrolling down moves on, like scrolling down a page */
+    if (wheel_steps < 0.0f) {
+        app_next_image(app);
+    } else {
+        app_prev_image(app);
+    }
+    wheel_steps = 0.0f;
+    return do_nav(app, viewer, window);
+}
+
+void input_show_current(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    settle_rotation(app, viewer, window);
+    nav_pending_load = false;
+    last_nav_ticks = SDL_GetTicks();
+
+    const char *path = app_current_path(app);
+    if (path) {
+        viewer_load_image(viewer, path);
+        viewer_prefetch_around(viewer, app);
+        notify_image_changed(app);
+    } else {
+        viewer_clear(viewer);
+    }
+    update_window_title(app, viewer, window);
+}
+
+void input_resize_viewer(struct Viewer *viewer, SDL_Window *window) {
+    int w, h;
+    if (SDL_GetWindowSizeInPixels(window, &w, &h)) {
+        viewer_handle_resize(viewer, compare_pane_width(w), h - filmstrip_height() - statusbar_height());
+    }
+}
+
+void input_list_changed(struct AppState *app, struct Viewer *viewer, SDL_Window *window) {
+    if (app_current_path(app)) {
+        viewer_prefetch_around(viewer, app);
+    }
+    update_window_title(app, viewer, window);
+}
+
+/* Pass `key` and the marked (or current) files to the external key handler,
+   then pick up whatever it changed on disk. */
+static void run_key_handler(struct AppState *app, struct Viewer *viewer,
+                            SDL_Window *window, const char *key) {
+    const char *current = app_current_path(app);
+    int count = app_marked_count(app);
+    const char **paths;
+
+    if (count > 0) {
+        paths = malloc((size_t)count * sizeof(char *));
+        if (!paths) return;
+        for (int i = 0; i < count; i++) {
+            paths[i] = app_marked_path(app, i);
+        }
+    } else if (current) {
+        paths = malloc(sizeof(char *));
+        if (!paths) return;
+        paths[0] = current;
+        count = 1;
+    } else {
+        overlay_show_osd("Key handler: no image");
+        return;
+    }
+
+    if (!keyhandler_run(key, paths, count)) {
+        char msg[128];
+        snprintf(msg, sizeof(msg), "Key handler failed for '%s'", key);
+        overlay_show_osd(msg);
+    }
+    free(paths);
+
+    /* The handler may have edited, moved or deleted the current file */
+    current = app_current_path(app);
+    if (!current) return;
+    if (access(current, F_OK) != 0) {
+        viewer_clear(viewer);
+        app_remove_current(app);
+        input_show_current(app, viewer, window);
+    } else {
+        viewer_reload(viewer);
+    }
+}
+
+/* Collect the images an export acts on, in list order: every image if `all`,
+   otherwise the marked ones, or the current image if none is marked.
+   Returns a malloc'd array of borrowed paths (NULL if there are none). */
+static const char **collect_selection(struct AppState *app, bool all, int *out_count) {
+    int total = app_image_count(app);
+    *out_count = 0;
+    if (total == 0) return NULL;
+
+    const char **paths = malloc((size_t)total * sizeof(char *));
+    if (!paths) return NULL;
+
+    int n = 0;
+    for (int i = 0; i < total; i++) {
+        if (all || app_is_marked(app, i)) {
+            paths[n++] = app_image_path(app, i);
+        }
+    }
+    if (n == 0) {
+        paths[n++] = app_current_path(app);
+    }
+    *out_count = n;
+    return paths;
+}
+
+/* Remove the location from an image: the EXIF GPS data of a JPEG and the
+   coordinates in its .xmp sidecar. Returns 1 if something was removed,
+   0 if it had no location, -1 if it could not be removed (or is not a
+   JPEG, whose GPS tags Frame cannot edit). */
+static int strip_location(const char *path) {
+    ExifInfo exif;
+    XmpInfo xmp;
+    bool in_file = exif_read(path, &exif) && exif.has_gps;
+    bool in_sidecar = xmp_read(path, &xmp) && xmp.has_gps;
+    if (!in_file && !in_sidecar) return 0;
+
+    if (in_file && exif_strip_gps(path) <= 0) return -1;
+    if (in_sidecar && !xmp_write_gps(path, false, 0.0, 0.0)) return -1;
+    return 1;
+}
+
+/* Parse "latitude, longitude" in decimal degrees (the comma is optional) */
+static bool parse_location(const char *text, double *lat, double *lon) {
+    char *end = NULL;
+    *lat = strtod(text, &end);
+    if (end == text) return false;
+    while (*end == ' ' || *end == ',') end++;
+    const char *next = end;
+    *lon = strtod(next, &end);
+    if (end == next) return false;
+    while (*end == ' ') end++;
+    return *end == '\0' && *lat >= -90.0 && *lat <= 90.0 && *lon >= -180.0 && *lon <= 180.0;
+}
+
+/* Parse a clock correction: "+1:30", "-2h", "+1h30m", "-45m" or "+1d 2h"
+   (days, hours and minutes). The sign is optional for forward shifts. */
+static bool parse_time_offset(const char *text, long *seconds) {
+    while (*text == ' ') text++;
+    int sign = 1;
+    if (*text == '+' || *text == '-') {
+        if (*text == '-') sign = -1;
+        text++;
+    }
+
+    int hours, minutes;
+    char rest;
+    if (sscanf(text, "%d:%d%c", &hours, &minutes, &rest) == 2) {
+        if (hours < 0 || minutes < 0 || minutes >= 60 || hours + minutes == 0) return false;
+        *seconds = sign * (hours * 3600L + minutes * 60L);
+        return true;
+    }
+
+    long total = 0;
+    bool any = false;
+    while (*text) {
+        char *end = NULL;
+        long n = strtol(text, &end, 10);
+        if (end == text || n < 0 || n > 100000) return false;
+        while (*end == ' ') end++;
+        if (*end == 'd') total += n * 86400L;
+        else if (*end == 'h') total += n * 3600L;
+        else if (*end == 'm') total += n * 60L;
+        else return false;
+        text = end + 1;
+        while (*text == ' ') text++;
+        any = true;
+    }
+    if (!any || total == 0) return false;
+    *seconds = sign * total;
+    return true;
+}
+
+/* What the rename dialogs check new names against */
+typedef struct {
+    char dir[4096];
+    const char *original;     /* single rename: the current name */
+    char **paths;             /* bulk rename: the files being renamed */
+    int count;
+} RenameCheck;
+
+static const char *check_rename(const char *text, bool *fatal, void *userdata) {
+    const RenameCheck *rc = userdata;
+    if (strcmp(text, rc->original) == 0) return NULL;
+    return fileops_check_name(rc->dir, text, fatal);
+}
+
+/* Build a bulk rename result: `pattern` with its '*' replaced by the file's
+   name without extension, then the extension. Returns false if it does not fit. */
+static bool apply_name_pattern(const char *pattern, const char *path, char *buf, size_t size) {
+    const char *name = strrchr(path, '/');
+    name = name ? name + 1 : path;
+    const char *dot = strrchr(name, '.');
+    if (dot == name) dot = NULL;
+    int stem_len = dot ? (int)(dot - name) : (int)strlen(name);
+    const char *star = strchr(pattern, '*');
+    if (!star) return false;
+
+    int ret = snprintf(buf, size, "%.*s%.*s%s%s", (int)(star - pattern), pattern,
+                       stem_len, name, star + 1, dot ? dot : "");
+    return ret >= 0 && (size_t)ret < size;
+}
+
+static const char *check_bulk_rename(const char *text, bool *fatal, void *userdata) {
+    const RenameCheck *rc = userdata;
+    static char msg[160];
+
+    *fatal = true;
+    const char *star = strchr(text, '*');
+    if (!star || strchr(star + 1, '*')) return "Use one * for the current name, e.g. trip_*";
+    if (strcmp(text, "*") == 0) return "Add a prefix or suffix around *";
+
+    /* Report the first name that cannot work, or else the first warning */
+    const char *warning = NULL;
+    for (int i = 0; i < rc->count; i++) {
+        char name[512];
+        if (!rc->paths[i]) continue;
+        if (!apply_name_pattern(text, rc->paths[i], name, sizeof(name))) return "Name is too long";
+        bool name_fatal = false;
+        const char *problem = fileops_check_name(rc->dir, name, &name_fatal);
+        if (problem && name_fatal) {
+            snprintf(msg, sizeof(msg), "%s: %s", name, problem);
+            return msg;
+        }
+        if (problem && !warning) warning = problem;
+    }
+    *fatal = false;
+    return warning;
+}
+
+/* Fill in the folder of path for a RenameCheck */
+static void rename_check_dir(RenameCheck *rc, const char *path) {
+    const char *slash = strrchr(path, '/');
+    snprintf(rc->dir, sizeof(rc->dir), "%.*s", slash ? (int)(slash - path) : 1, slash ? path : ".");
+}
+
+/* Build "<folder>/<stem>.<ext>" for a default output file name. The folder
+   is the last export folder of this session, else the export_directory
+   setting, else the folder of path. With stem NULL the image's own name
+   (without extension) is used. */
+static void default_output_path(const char *path, const char *stem, const char *ext,
+                                char *buf, size_t size) {
+    const char *slash = strrchr(path, '/');
+    const char *dir = path;
+    int dir_len = slash ? (int)(slash - path) : 0;
+    char configured[4096];
+    if (last_export_dir[0]) {
+        dir = last_export_dir;
+        dir_len = (int)strlen(last_export_dir);
+    } else if (config_get()->export_directory[0] &&
+               expand_home(config_get()->export_directory, configured, sizeof(configured))) {
+        dir = configured;
+        dir_len = (int)strlen(configured);
+        while (dir_len > 1 && configured[dir_len - 1] == '/') dir_len--;
+    }
+    const char *name = slash ? slash + 1 : path;
+    int name_len = (int)strlen(name);
+    if (stem) {
+        name = stem;
+        name_len = (int)strlen(stem);
+    } else {
+        const char *dot = strrchr(name, '.');
+        if (dot && dot != name) name_len = (int)(dot - name);
+    }
+    snprintf(buf, size, "%.*s/%.*s.%s", dir_len, dir, name_len, name, ext);
+}
+
+/* Ask where to write an export (prefilled with `suggested`), confirming
+   before overwriting. Returns a malloc'd path or NULL if cancelled. */
+static char *ask_output_path(const char *title, const char *suggested,
+                             SDL_Renderer *renderer, SDL_Window *window,
+                             struct Viewer *viewer) {
+    char *out = overlay_modal_entry(title, suggested, renderer, window, viewer);
+    if (!out) return NULL;
+    if (out[0] == '\0') {
+        free(out);
+        return NULL;
+    }
+    if (access(out, F_OK) == 0) {
+        char msg[512];
+        const char *name = strrchr(out, '/');
+        snprintf(msg, sizeof(msg), "'%s' already exists. Replace it?", name ? name + 1 : out);
+        if (!overlay_modal_confirm("Replace File", msg, renderer, viewer)) {
+            free(out);
+            return NULL;
+        }
+    }
+
+    const char *slash = strrchr(out, '/');
+    if (slash && slash != out && (size_t)(slash - out) < sizeof(last_export_dir)) {
+        snprintf(last_export_dir, sizeof(last_export_dir), "%.*s", (int)(slash - out), out);
+    }
+    return out;
+}
+
+/* Thumbnail sizes for the multi-image PDF layouts, large enough to print */
+#define PDF_2UP_CELL 1200
+#define PDF_4UP_CELL 1000
+
+/* Put the images on pages of `columns` x `rows` tiles. The last page keeps
+   the same grid so its images print at the same size as the others. */
+static void add_tiled_pages(PdfWriter *pdf, const char **paths, int count,
+                            int columns, int rows, int cell, ContactCaption captions,
+                            int *skipped) {
+    const FrameConfig *cfg = config_get();
+    int per_page = columns * rows;
+    const char **page = calloc((size_t)per_page, sizeof(char *));
+    if (!page) {
+        *skipped += count;
+        return;
+    }
+
+    for (int first = 0; first < count; first += per_page) {
+        int n = count - first < per_page ? count - first : per_page;
+        for (int i = 0; i < per_page; i++) {
+            page[i] = i < n ? paths[first + i] : NULL;
+        }
+
+        int page_skipped = 0;
+        SDL_Surface *sheet = contact_sheet_render(page, per_page, columns, cell, captions,
+                                                  overlay_ui_font(), cfg->export_resampler,
+                                                  &page_skipped);
+        if (!sheet || !pdf_add_page(pdf, sheet, cfg->pdf_page_size, PDF_FIT_CONTAIN)) {
+            page_skipped = n;
+        }
+        SDL_DestroySurface(sheet);
+        *skipped += page_skipped;
+    }
+    free(page);
+}
+
+/* Write the images to a PDF using the configured layout. Returns an OSD
+   message in buf. */
+static void export_pdf(const char *out_path, const char **paths, int count,
+                       char *buf, size_t size) {
+    PdfWriter *pdf = pdf_open(out_path);
+    if (!pdf) {
+        snprintf(buf, size, "Cannot create PDF");
+        return;
+    }
+
+    const FrameConfig *cfg = config_get();
+    int skipped = 0;
+    switch (cfg->pdf_layout) {
+    case PDF_LAYOUT_2UP:
+        add_tiled_pages(pdf, paths, count, 1, 2, PDF_2UP_CELL, CONTACT_CAPTION_NONE, &skipped);
+        break;
+    case PDF_LAYOUT_4UP:
+        add_tiled_pages(pdf, paths, count, 2, 2, PDF_4UP_CELL, CONTACT_CAPTION_NONE, &skipped);
+        break;
+    case PDF_LAYOUT_INDEX: {
+        /* Roughly the proportions of a portrait page */
+        int columns = cfg->contact_sheet_columns;
+        add_tiled_pages(pdf, paths, count, columns, columns * 7 / 5,
+                        CONTACT_THUMB_SIZE, cfg->contact_sheet_captions, &skipped);
+        break;
+    }
+    default:
+        for (int i = 0; i < count; i++) {
+            SDL_Surface *surface = loader_load_static(paths[i]);
+            if (!surface || !pdf_add_page(pdf, surface, cfg->pdf_page_size, cfg->pdf_fit)) {
+                skipped++;
+            }
+            SDL_DestroySurface(surface);
+        }
+        break;
+    }
+
+    int pages = pdf_page_count(pdf);
+    if (!pdf_close(pdf)) {
+        snprintf(buf, size, "Writing the PDF failed");
+    } else if (skipped > 0) {
+        snprintf(buf, size, "Exported %d pages (%d images skipped)", pages, skipped);
+    } else {
+        snprintf(buf, size, "Exported %d page%s to PDF", pages, pages == 1 ? "" : "s");
+    }
+}
+
+/* Tile the images into a contact sheet and save it as PNG, or as a one-page
+   PDF when out_path ends in ".pdf". Returns an OSD message in buf. */
+static void export_contact_sheet(const char *out_path, const char **paths, int count,
+                                 char *buf, size_t size) {
+    const FrameConfig *cfg = config_get();
+    int capacity = contact_sheet_capacity(cfg->contact_sheet_columns, CONTACT_THUMB_SIZE);
+    if (count > capacity) {
+        snprintf(buf, size, "Too many images for one sheet (at most %d)", capacity);
+        return;
+    }
+
+    int skipped = 0;
+    SDL_Surface *sheet = contact_sheet_render(paths, count, cfg->contact_sheet_columns,
+                                              CONTACT_THUMB_SIZE, cfg->contact_sheet_captions,
+                                              overlay_ui_font(), cfg->export_resampler, &skipped);
+    if (!sheet) {
+        snprintf(buf, size, "Cannot create contact sheet");
+        return;
+    }
+
+    bool ok;
+    const char *ext = strrchr(out_path, '.');
+    if (ext && strcasecmp(ext, ".pdf") == 0) {
+        PdfWriter *pdf = pdf_open(out_path);
+        ok = pdf && pdf_add_page(pdf, sheet, PDF_PAGE_IMAGE, PDF_FIT_CONTAIN);
+        if (pdf && !pdf_close(pdf)) ok = false;
+    } else {
+        ok = IMG_SavePNG(sheet, out_path);
+    }
+    SDL_DestroySurface(sheet);
+
+    if (!ok) {
+        snprintf(buf, size, "Writing the contact sheet failed");
+    } else if (skipped > 0) {
+        snprintf(buf, size, "Contact sheet saved (%d images skipped)", skipped);
+    } else {
+        snprintf(buf, size, "Contact sheet of %d images saved", count);
+    }
+}
+
+/* Turn the images into a looping GIF, one frame each in the given order.
+   The canvas takes the first image's shape. Returns an OSD message in buf. */
+static void export_animation(const char *out_path, const char **paths, int count,
+                             char *buf, size_t size) {
+    const FrameConfig *cfg = config_get();
+    GifWriter *gif = NULL;
+    int skipped = 0;
+
+    for (int i = 0; i < count; i++) {
+        SDL_Surface *surface = loader_load_static(paths[i]);
+        if (!surface) {
+            skipped++;
+            continue;
+        }
+        if (!gif) {
+            /* Scale the longest edge down to animation_size, never up */
+            int longest = surface->w > surface->h ? surface->w : surface->h;
+            float scale = longest > cfg->animation_size ? (float)cfg->animation_size / (float)longest : 1.0f;
+            int w = (int)(surface->w * scale + 0.5f);
+            int h = (int)(surface->h * scale + 0.5f);
+            gif = gif_open(out_path, w > 0 ? w : 1, h > 0 ? h : 1,
+                           cfg->export_resampler);
+            if (!gif) {
+                SDL_DestroySurface(surface);
+                snprintf(buf, size, "Cannot create GIF");
+                return;
+            }
+        }
+        if (!gif_add_frame(gif, surface, cfg->animation_delay_ms)) skipped++;
+        SDL_DestroySurface(surface);
+    }
+
+    if (!gif) {
+        snprintf(buf, size, "No images could be loaded");
+        return;
+    }
+    int frames = gif_frame_count(gif);
+    if (!gif_close(gif)) {
+        snprintf(buf, size, "Writing the GIF failed");
+    } else if (skipped > 0) {
+        snprintf(buf, size, "Saved %d-frame GIF (%d images skipped)", frames, skipped);
+    } else {
+        snprintf(buf, size, "Saved %d-frame GIF", frames);
+    }
+}
+
+/* Largest file Ctrl+c will turn into a data URI */
+#define DATA_URI_MAX_BYTES (16 * 1024 * 1024)
+
+/* Read the whole file. Returns a malloc'd buffer, or NULL with *out_size
+   set to 0 if it cannot be read or to its size if it is too large. */
+static unsigned char *read_data_uri_file(const char *path, size_t *out_size) {
+    *out_size = 0;
+    FILE *fp = fopen(path, "rb");
+    if (!fp) return NULL;
+
+    long size = -1;
+    if (fseek(fp, 0, SEEK_END) == 0) size = ftell(fp);
+    if (size <= 0 || size > DATA_URI_MAX_BYTES || fseek(fp, 0, SEEK_SET) != 0) {
+        if (size > DATA_URI_MAX_BYTES) *out_size = (size_t)size;
+        fclose(fp);
+        return NULL;
+    }
+
+    unsigned char *data = malloc((size_t)size);
+    if (data && fread(data, 1, (size_t)size, fp) == (size_t)size) {
+        *out_size = (size_t)size;
+    } else {
+        free(data);
+        data = NULL;
+    }
+    fclose(fp);
+    return data;
+}
+
+/* Encode the image as PNG. Returns a malloc'd buffer. */
+static unsigned char *encode_data_uri_png(SDL_Surface *image, size_t *out_size) {
+    *out_size = 0;
+    unsigned char *data = NULL;
+    SDL_IOStream *mem = SDL_IOFromDynamicMem();
+    if (mem && IMG_SavePNG_IO(image, mem, false)) {
+        Sint64 size = SDL_TellIO(mem);
+        if (size > 0 && SDL_SeekIO(mem, 0, SDL_IO_SEEK_SET) == 0) {
+            data = malloc((size_t)size);
+            if (data && SDL_ReadIO(mem, data, (size_t)size) == (size_t)size) {
+                *out_size = (size_t)size;
+            } else {
+                free(data);
+                data = NULL;
+            }
+        }
+    }
+    if (mem) SDL_CloseIO(mem);
+    return data;
+}
+
+/* Put the image on the clipboard as a base64 data: URI. The file is copied
+   as it is, unless it has an unsaved rotation or flip, in which case the
+   image on screen is encoded as PNG. Returns an OSD message. */
+static const char *copy_data_uri(struct Viewer *viewer, const char *path) {
+    char edit[48];
+    bool transformed = describe_transform(viewer, edit, sizeof(edit));
+    const char *mime;
+    unsigned char *data;
+    size_t size;
+    if (transformed) {
+        SDL_Surface *image = viewer_transformed_image(viewer);
+        if (!image) return "Image is not fully loaded yet";
+        data = encode_data_uri_png(image, &size);
+        if (!data) return "Cannot encode image";
+        mime = "image/png";
+    } else {
+        data = read_data_uri_file(path, &size);
+        if (!data) return size > DATA_URI_MAX_BYTES ? "Image too large for a data URI" : "Cannot read image";
+        mime = mime_from_ext(image_format_ext(path));
+    }
+    if (size > DATA_URI_MAX_BYTES) {
+        free(data);
+        return "Image too large for a data URI";
+    }
+
+    char *encoded = base64_encode(data, size);
+    free(data);
+    if (!encoded) return "Cannot read image";
+
+    size_t len = strlen("data:;base64,") + strlen(mime) + strlen(encoded) + 1;
+    char *uri = malloc(len);
+    bool copied = false;
+    if (uri) {
+        snprintf(uri, len, "data:%s;base64,%s", mime, encoded);
+        copied = SDL_SetClipboardText(uri);
+        free(uri);
+    }
+    free(encoded);
+    if (!copied) return "Could not set clipboard";
+    return transformed ? "Copied as data URI (PNG, as shown)" : "Copied as data URI";
+}
+
+/* --- Action menu --- */
+
+/* Actions offered by the menu, run as if their key had been pressed */
+typedef struct {
+    const char *label;
+    SDL_Keycode key;
+    SDL_Keymod mod;
+} MenuAction;
+
+static const MenuAction menu_actions[] = {
+    {"Open image   (o)", SDLK_O, SDL_KMOD_NONE},
+    {"Open folder   (O)", SDLK_O, SDL_KMOD_LSHIFT},
+    {"Open with another app   (Ctrl+o)", SDLK_O, SDL_KMOD_LCTRL},
+    {"Search images   (/)", SDLK_SLASH, SDL_KMOD_NONE},
+    {"Quick switcher   (Ctrl+p)", SDLK_P, SDL_KMOD_LCTRL},
+    {"Image info   (i)", SDLK_I, SDL_KMOD_NONE},
+    {"Zoom in   (+)", SDLK_EQUALS, SDL_KMOD_NONE},
+    {"Zoom out   (-)", SDLK_MINUS, SDL_KMOD_NONE},
+    {"Fit to window   (0)", SDLK_0, SDL_KMOD_NONE},
+    {"Original size   (1)", SDLK_1, SDL_KMOD_NONE},
+    {"Zoom to an exact level   (%)", SDLK_5, SDL_KMOD_LSHIFT},
+    {"Fullscreen   (f)", SDLK_F, SDL_KMOD_NONE},
+    {"Rotate clockwise   (r)", SDLK_R, SDL_KMOD_NONE},
+    {"Rotate counter-clockwise   (R)", SDLK_R, SDL_KMOD_LSHIFT},
+    {"Flip horizontally   (F)", SDLK_F, SDL_KMOD_LSHIFT},
+    {"Flip vertically   (V)", SDLK_V, SDL_KMOD_LSHIFT},
+    {"Save rotation / flip   (Ctrl+s)", SDLK_S, SDL_KMOD_LCTRL},
+    {"Undo   (Ctrl+z)", SDLK_Z, SDL_KMOD_LCTRL},
+    {"Redo   (Ctrl+Z)", SDLK_Z, SDL_KMOD_LCTRL | SDL_KMOD_LSHIFT},
+    {"Compare with pinned reference   (Ctrl+r)", SDLK_R, SDL_KMOD_LCTRL},
+    {"Clipping warning   (c)", SDLK_C, SDL_KMOD_NONE},
+    {"Pixel-art mode   (p)", SDLK_P, SDL_KMOD_NONE},
+    {"Cycle interpolation   (P)", SDLK_P, SDL_KMOD_LSHIFT},
+    {"Ambient background   (a)", SDLK_A, SDL_KMOD_NONE},
+    {"Cycle background colour   (A)", SDLK_A, SDL_KMOD_LSHIFT},
+    {"Colour-blindness simulation   (v)", SDLK_V, SDL_KMOD_NONE},
+    {"Reset view adjustments   (\\)", SDLK_BACKSLASH, SDL_KMOD_NONE},
+    {"Rename   (F2)", SDLK_F2, SDL_KMOD_NONE},
+    {"Delete   (d)", SDLK_D, SDL_KMOD_NONE},
+    {"Mark / unmark   (m)", SDLK_M, SDL_KMOD_NONE},
+    {"Edit location   (t)", SDLK_T, SDL_KMOD_NONE},
+    {"Shift capture time of marked   (Ctrl+d)", SDLK_D, SDL_KMOD_LCTRL},
+    {"Copy as data URI   (Ctrl+c)", SDLK_C, SDL_KMOD_LCTRL},
+    {"Contact sheet   (Ctrl+t)", SDLK_T, SDL_KMOD_LCTRL},
+    {"Export to PDF   (Ctrl+e)", SDLK_E, SDL_KMOD_LCTRL},
+    {"Animate marked images   (Ctrl+g)", SDLK_G, SDL_KMOD_LCTRL},
+    {"Upload and copy link   (u)", SDLK_U, SDL_KMOD_NONE},
+    {"Watch folder   (w)", SDLK_W, SDL_KMOD_NONE},
+    {"Filmstrip   (S)", SDLK_S, SDL_KMOD_LSHIFT},
+    {"Status bar   (Ctrl+b)", SDLK_B, SDL_KMOD_LCTRL},
+    {"Navigator   (N)", SDLK_N, SDL_KMOD_LSHIFT},
+    {"Keyboard shortcuts   (?)", SDLK_SLASH, SDL_KMOD_LSHIFT},
+    {"About Frame   (F1)", SDLK_F1, SDL_KMOD_NONE},
+    {"Quit   (q)", SDLK_Q, SDL_KMOD_NONE},
+};
+
+bool input_show_menu(struct AppState *app, struct Viewer *viewer,
+                     SDL_Window *window, SDL_Renderer *renderer) {
+    static int last_pick = 0;
+    enum { COUNT = (int)(sizeof(menu_actions) / sizeof(menu_actions[0])) };
+    const char *labels[COUNT];
+    for (int i = 0; i < COUNT; i++) {
+        labels[i] = menu_actions[i].label;
+    }
+
+    int pick = overlay_modal_choose("Menu", labels, COUNT, last_pick, renderer, viewer);
+    if (pick < 0) return true;
+    last_pick = pick;
+
+    SDL_KeyboardEvent event = {0};
+    event.type = SDL_EVENT_KEY_DOWN;
+    event.down = true;
+    event.key = menu_actions[pick].key;
+    event.mod = menu_actions[pick].mod;
+    bool key_dirty = false;
+    return input_handle_keyboard(app, viewer, &event, window, renderer, &key_dirty);
+}
+
 /* --- Main handler --- */
 
 bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
@@ -105,9 +1053,30 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
         *out_dirty = false;
     }
 
-    /* Quit first (don't reset gg state for these) */
-    if (key == SDLK_Q || key == SDLK_ESCAPE) {
-        return false;
+    /* The key after Ctrl+x belongs to the key handler (Esc cancels) */
+    if (keyhandler_pending) {
+        char name[64];
+        if (!keyhandler_key_name(key, event->mod, name, sizeof(name))) {
+            return true; /* bare modifier — keep waiting */
+        }
+        keyhandler_pending = false;
+        if (key == SDLK_ESCAPE) {
+            overlay_show_osd("Key handler cancelled");
+        } else {
+            run_key_handler(app, viewer, window, name);
+        }
+        if (out_dirty) *out_dirty = true;
+        g_sequence = false;
+        return true;
+    }
+
+    /* Esc closes the innermost context first: an overlay (any key does),
+       then a loading image, compare mode and fullscreen; only then does it
+       quit. The info overlay has its own keys for copying fields. */
+    if (overlay_info_handle_key(key)) {
+        g_sequence = false;
+        if (out_dirty) *out_dirty = true;
+        return true;
     }
 
     /* If overlay is active, any key dismisses it (without normal action) */
@@ -118,12 +1087,78 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
         return true;
     }
 
-    /* === Navigation (arrows + vim keys) === */
+    /* Esc while a slow image is loading cancels the load instead of quitting */
+    if (key == SDLK_ESCAPE && viewer_cancel_load(viewer)) {
+        overlay_show_osd("Loading cancelled");
+        if (out_dirty) *out_dirty = true;
+        g_sequence = false;
+        return true;
+    }
+
+    /* Esc leaves compare mode */
+    if (key == SDLK_ESCAPE && compare_is_active()) {
+        compare_clear();
+        overlay_show_osd("Compare off");
+        input_resize_viewer(viewer, window);
+        if (out_dirty) *out_dirty = true;
+        g_sequence = false;
+        return true;
+    }
+
+    /* Esc in fullscreen goes back to the window, like a video player */
+    if (key == SDLK_ESCAPE && config_get()->escape == ESCAPE_FULLSCREEN &&
+        (SDL_GetWindowFlags(window) & SDL_WINDOW_FULLSCREEN)) {
+        SDL_SetWindowFullscreen(window, false);
+        if (out_dirty) *out_dirty = true;
+        g_sequence = false;
+        return true;
+    }
+
+    /* Nothing left to close: quit (don't reset gg state for these) */
+    if (key == SDLK_Q || key == SDLK_ESCAPE) {
+        settle_rotation(app, viewer, window);
+        return false;
+    }
+
+    /* === Action menu (F10 or the Menu key) === */
+    if (key == SDLK_F10 || key == SDLK_APPLICATION || key == SDLK_MENU) {
+        g_sequence = false;
+        if (out_dirty) *out_dirty = true;  /* the menu covered the view */
+        return input_show_menu(app, viewer, window, renderer);
+    }
+
+    /* === Keyboard pan (Ctrl + arrows / vim keys), the keyboard path for dragging === */
+    if (event->mod & SDL_KMOD_CTRL) {
+        int dx = 0, dy = 0;
+        switch (key) {
+        case SDLK_LEFT:  case SDLK_H: dx = -1; break;
+        case SDLK_RIGHT: case SDLK_L: dx = 1; break;
+        case SDLK_UP:    case SDLK_K: dy = -1; break;
+        case SDLK_DOWN:  case SDLK_J: dy = 1; break;
+        default: break;
+        }
+        if (dx || dy) {
+            viewer_pan_step(viewer, dx, dy);
+            if (out_dirty) *out_dirty = true;
+            goto reset_gg;
+        }
+    }
+
+    /* === Navigation (arrows + vim keys, Space/Backspace, paging and media keys) === */
     switch (key) {
+    case SDLK_SPACE:
+        /* Space plays and pauses animations */
+        if (viewer_is_animated(viewer)) break;
+        app_next_image(app);
+        if (out_dirty) *out_dirty = do_nav(app, viewer, window);
+        goto reset_gg;
     case SDLK_LEFT:
     case SDLK_H:
     case SDLK_UP:
     case SDLK_K:
+    case SDLK_BACKSPACE:
+    case SDLK_PAGEUP:
+    case SDLK_MEDIA_PREVIOUS_TRACK:
         app_prev_image(app);
         if (out_dirty) *out_dirty = do_nav(app, viewer, window);
         goto reset_gg;
@@ -131,15 +1166,25 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
     case SDLK_L:
     case SDLK_DOWN:
     case SDLK_J:
+    case SDLK_PAGEDOWN:
+    case SDLK_MEDIA_NEXT_TRACK:
         app_next_image(app);
         if (out_dirty) *out_dirty = do_nav(app, viewer, window);
         goto reset_gg;
+    case SDLK_HOME:
+        app_first_image(app);
+        if (out_dirty) *out_dirty = do_nav(app, viewer, window);
+        goto reset_gg;
+    case SDLK_END:
+        app_last_image(app);
+        if (out_dirty) *out_dirty = do_nav(app, viewer, window);
+        goto reset_gg;
     default:
         break;
     }
 
     /* === g/G: first/last image === */
-    if (key == SDLK_G) {
+    if (key == SDLK_G && !(event->mod & SDL_KMOD_CTRL)) {
         if (shift) {
             /* SHIFT+G = 'G' = last image */
             app_last_image(app);
@@ -167,26 +1212,506 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
         *out_dirty = true;
     }
 
+    /* === Key handler prefix (Ctrl+x) === */
+    if (key == SDLK_X && (event->mod & SDL_KMOD_CTRL)) {
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: key handler is disabled");
+            goto reset_gg;
+        }
+        keyhandler_pending = true;
+        overlay_show_osd("Key handler: press a key (Esc to cancel)");
+        goto reset_gg;
+    }
+
+    /* === Export to PDF (Ctrl+e: marked or current, Ctrl+Shift+e: all) === */
+    if (key == SDLK_E && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        int count = 0;
+        const char **paths = path ? collect_selection(app, shift, &count) : NULL;
+        if (!paths) goto reset_gg;
+
+        char suggested[4096], title[64];
+        default_output_path(path, count == 1 ? NULL : "images", "pdf", suggested, sizeof(suggested));
+        snprintf(title, sizeof(title), "Export %d Image%s to PDF", count, count == 1 ? "" : "s");
+        char *out = ask_output_path(title, suggested, renderer, window, viewer);
+        if (out) {
+            char msg[128];
+            export_pdf(out, paths, count, msg, sizeof(msg));
+            overlay_show_osd(msg);
+            free(out);
+        }
+        free(paths);
+        goto reset_gg;
+    }
+
+    /* === Contact sheet (Ctrl+t: marked images, or the whole folder) === */
+    if (key == SDLK_T && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        int count = 0;
+        const char **paths = path ? collect_selection(app, app_marked_count(app) == 0, &count) : NULL;
+        if (!paths) goto reset_gg;
+
+        char suggested[4096], title[64];
+        default_output_path(path, "contact-sheet", last_sheet_ext, suggested, sizeof(suggested));
+        snprintf(title, sizeof(title), "Contact Sheet of %d Image%s", count, count == 1 ? "" : "s");
+        char *out = ask_output_path(title, suggested, renderer, window, viewer);
+        if (out) {
+            char msg[128];
+            export_contact_sheet(out, paths, count, msg, sizeof(msg));
+            overlay_show_osd(msg);
+            const char *ext = strrchr(out, '.');
+            snprintf(last_sheet_ext, sizeof(last_sheet_ext), "%s",
+                     ext && strcasecmp(ext, ".pdf") == 0 ? "pdf" : "png");
+            free(out);
+        }
+        free(paths);
+        goto reset_gg;
+    }
+
+    /* === Build an animated GIF from the marked images (Ctrl+g) === */
+    if (key == SDLK_G && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        if (!path) goto reset_gg;
+        if (app_marked_count(app) < 2) {
+            overlay_show_osd("Mark at least two images to build an animation");
+            goto reset_gg;
+        }
+        int count = 0;
+        const char **paths = collect_selection(app, false, &count);
+        if (!paths) goto reset_gg;
+
+        char suggested[4096], title[64];
+        default_output_path(path, "animation", "gif", suggested, sizeof(suggested));
+        snprintf(title, sizeof(title), "Animate %d Images", count);
+        char *out = ask_output_path(title, suggested, renderer, window, viewer);
+        if (out) {
+            char msg[128];
+            export_animation(out, paths, count, msg, sizeof(msg));
+            overlay_show_osd(msg);
+            free(out);
+        }
+        free(paths);
+        goto reset_gg;
+    }
+
+    /* === Quick switcher (Ctrl+p): jump to a file by fuzzy name === */
+    if (key == SDLK_P && (event->mod & SDL_KMOD_CTRL)) {
+        if (app_image_count(app) == 0) goto reset_gg;
+        search_requested = true;
+        search_request_mode = SEARCH_MODE_LIST;
+        if (out_dirty) *out_dirty = true;
+        g_sequence = false;
+        return true;
+    }
+
+    /* === Copy as data URI (Ctrl+c) === */
+    if (key == SDLK_C && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        if (path) {
+            overlay_show_osd(copy_data_uri(viewer, path));
+        }
+        goto reset_gg;
+    }
+
+    /* === Colour vision simulation (v cycles modes) === */
+    if (key == SDLK_V && !shift && !(event->mod & SDL_KMOD_CTRL)) {
+        ViewFilter filter = *viewer_get_filter(viewer);
+        filter.cvd = (CvdMode)((filter.cvd + 1) % CVD_COUNT);
+        viewer_set_filter(viewer, &filter);
+
+        char msg[64];
+        snprintf(msg, sizeof(msg), "Simulating: %s", filter_cvd_name(filter.cvd));
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Exposure warning: c blinks clipped highlights and shadows === */
+    if (key == SDLK_C && !shift) {
+        bool enabled = !viewer_get_clip_warning(viewer);
+        viewer_set_clip_warning(viewer, enabled);
+
+        char msg[128];
+        float high, low;
+        if (enabled && viewer_get_clip_stats(viewer, &high, &low)) {
+            snprintf(msg, sizeof(msg), "Clipping: %.1f%% highlights, %.1f%% shadows",
+                     high * 100.0f, low * 100.0f);
+        } else {
+            snprintf(msg, sizeof(msg), "Clipping warning %s", enabled ? "on" : "off");
+        }
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Compare with a pinned reference (Ctrl+r) === */
+    if (key == SDLK_R && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        if (compare_is_active()) {
+            compare_clear();
+            overlay_show_osd("Compare off");
+        } else if (!path) {
+            goto reset_gg;
+        } else if (compare_set_reference(renderer, path)) {
+            overlay_show_osd("Reference pinned: browse to compare (Ctrl+r to stop)");
+        } else {
+            overlay_show_osd("Could not load the reference");
+        }
+        input_resize_viewer(viewer, window);
+        goto reset_gg;
+    }
+
+    /* === Status bar (Ctrl+b) === */
+    if (key == SDLK_B && (event->mod & SDL_KMOD_CTRL)) {
+        bool enabled = !statusbar_is_visible();
+        statusbar_set_visible(enabled);
+        input_resize_viewer(viewer, window);
+        overlay_show_osd(enabled ? "Status bar on" : "Status bar off");
+        goto reset_gg;
+    }
+
+    /* === View adjustments: b/B exposure, y/Y gamma, backslash resets === */
+    if (key == SDLK_B || key == SDLK_Y || key == SDLK_BACKSLASH) {
+        ViewFilter filter = *viewer_get_filter(viewer);
+        char msg[64];
+        if (key == SDLK_B) {
+            filter.exposure += shift ? -0.25f : 0.25f;
+            if (filter.exposure < FILTER_EXPOSURE_MIN) filter.exposure = FILTER_EXPOSURE_MIN;
+            if (filter.exposure > FILTER_EXPOSURE_MAX) filter.exposure = FILTER_EXPOSURE_MAX;
+            snprintf(msg, sizeof(msg), "Exposure %+.2f EV", filter.exposure);
+        } else if (key == SDLK_Y) {
+            filter.gamma += shift ? -0.1f : 0.1f;
+            if (filter.gamma < FILTER_GAMMA_MIN) filter.gamma = FILTER_GAMMA_MIN;
+            if (filter.gamma > FILTER_GAMMA_MAX) filter.gamma = FILTER_GAMMA_MAX;
+            /* Snap back to exactly 1.0 so the filter can become a no-op again */
+            if (filter.gamma > 0.95f && filter.gamma < 1.05f) filter.gamma = 1.0f;
+            snprintf(msg, sizeof(msg), "Gamma %.1f", filter.gamma);
+        } else {
+            filter_init(&filter);
+            snprintf(msg, sizeof(msg), "View adjustments reset");
+        }
+        viewer_set_filter(viewer, &filter);
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Open with another application (Ctrl+o) === */
+    if (key == SDLK_O && (event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        if (!path) goto reset_gg;
+
+        OpenWithApp *apps = NULL;
+        int count = openwith_list(path, &apps);
+        if (count == 0) {
+            overlay_show_osd("No applications found for this image type");
+            goto reset_gg;
+        }
+
+        const char **names = malloc((size_t)count * sizeof(char *));
+        if (names) {
+            for (int i = 0; i < count; i++) names[i] = apps[i].name;
+            /* The last used application is listed first and preselected */
+            int pick = overlay_modal_choose("Open With", names, count, 0, renderer, viewer);
+            if (pick >= 0) {
+                char msg[192];
+                if (openwith_launch(&apps[pick], path)) {
+                    snprintf(msg, sizeof(msg), "Opened in %s", apps[pick].name);
+                } else {
+                    snprintf(msg, sizeof(msg), "Could not start %s", apps[pick].name);
+                }
+                overlay_show_osd(msg);
+            }
+            free(names);
+        }
+        free(apps);
+        goto reset_gg;
+    }
+
+    /* === Open dialogs: o picks an image, O a whole folder === */
+    if (key == SDLK_O) {
+        if (shift) {
+            dialog_open_folder(window, app_current_path(app));
+        } else {
+            dialog_open_file(window, app_current_path(app));
+        }
+        goto reset_gg;
+    }
+
+    /* === Sibling folders: [ previous, ] next === */
+    if (key == SDLK_LEFTBRACKET || key == SDLK_RIGHTBRACKET) {
+        if (!app_load_sibling(app, key == SDLK_RIGHTBRACKET ? 1 : -1)) {
+            overlay_show_osd(key == SDLK_RIGHTBRACKET ? "No next folder with images"
+                                                      : "No previous folder with images");
+            goto reset_gg;
+        }
+        /* Keep following the folder on screen */
+        if (watch_is_active() && app_current_path(app)) {
+            watch_start(app_current_path(app));
+        }
+        input_show_current(app, viewer, window);
+
+        const char *dir = app_current_dir(app);
+        const char *name = dir ? strrchr(dir, '/') : NULL;
+        char msg[512];
+        snprintf(msg, sizeof(msg), "%s (%d images)", name ? name + 1 : "", app_image_count(app));
+        overlay_show_osd(msg);
+        if (out_dirty) *out_dirty = true;
+        goto reset_gg;
+    }
+
+    /* === Watch mode (w): follow new images in this folder === */
+    if (key == SDLK_W && !shift) {
+        if (watch_is_active()) {
+            watch_stop();
+            overlay_show_osd("Watch mode off");
+        } else if (app_current_path(app) && watch_start(app_current_path(app))) {
+            overlay_show_osd("Watching folder for new images");
+        } else {
+            overlay_show_osd("Cannot watch this folder");
+        }
+        goto reset_gg;
+    }
+
+    /* === Upload (u): send the current image to the configured host === */
+    if (key == SDLK_U && !shift) {
+        const char *path = app_current_path(app);
+        if (!path) goto reset_gg;
+        if (!upload_is_configured()) {
+            overlay_show_osd("No uploader configured");
+        } else if (upload_in_progress()) {
+            overlay_show_osd("An upload is already running");
+        } else {
+            const char *name = strrchr(path, '/');
+            char msg[512];
+            snprintf(msg, sizeof(msg), "Upload '%s' to %s? It will be public.",
+                     name ? name + 1 : path, upload_target_name());
+            if (overlay_modal_confirm("Upload Image", msg, renderer, viewer)) {
+                overlay_show_osd(upload_start(path) ? "Uploading..." : "Could not start upload");
+            }
+        }
+        if (out_dirty) *out_dirty = true;
+        goto reset_gg;
+    }
+
+    /* === Scaling: p toggles pixel-art mode, P cycles interpolation === */
+    if (key == SDLK_P) {
+        char msg[64];
+        if (shift) {
+            InterpolationMode mode = (InterpolationMode)((viewer_get_interpolation(viewer) + 1) % INTERP_COUNT);
+            viewer_set_interpolation(viewer, mode);
+            snprintf(msg, sizeof(msg), "Interpolation: %s%s",
+                     mode == INTERP_NEAREST ? "nearest" : "linear",
+                     viewer_get_pixel_art(viewer) ? " (pixel-art mode overrides)" : "");
+        } else {
+            bool enabled = !viewer_get_pixel_art(viewer);
+            viewer_set_pixel_art(viewer, enabled);
+            snprintf(msg, sizeof(msg), "Pixel-art mode %s", enabled ? "on" : "off");
+        }
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Navigator while zoomed in (Shift+n) === */
+    if (key == SDLK_N && shift) {
+        bool enabled = !viewer_get_minimap(viewer);
+        viewer_set_minimap(viewer, enabled);
+        overlay_show_osd(enabled ? "Navigator on" : "Navigator off");
+        goto reset_gg;
+    }
+
+    /* === Never upscale small images when fitting (n) === */
+    if (key == SDLK_N && !shift) {
+        bool enabled = !viewer_get_never_upscale(viewer);
+        viewer_set_never_upscale(viewer, enabled);
+        viewer_zoom_fit(viewer);
+        overlay_show_osd(enabled ? "Never upscale on" : "Never upscale off");
+        goto reset_gg;
+    }
+
+    /* === Camera settings overlay (s; Ctrl+s saves) === */
+    if (key == SDLK_S && !shift && !(event->mod & SDL_KMOD_CTRL)) {
+        bool enabled = !overlay_get_camera_info();
+        overlay_set_camera_info(enabled);
+        overlay_show_osd(enabled ? "Camera settings on" : "Camera settings off");
+        goto reset_gg;
+    }
+
+    /* === Thumbnail filmstrip (Shift+s) === */
+    if (key == SDLK_S && shift && !(event->mod & SDL_KMOD_CTRL)) {
+        bool enabled = !filmstrip_is_visible();
+        filmstrip_set_visible(enabled);
+        /* The image gets the room above the strip */
+        input_resize_viewer(viewer, window);
+        overlay_show_osd(enabled ? "Filmstrip on" : "Filmstrip off");
+        goto reset_gg;
+    }
+
+    /* === Ambient blurred background (a) === */
+    if (key == SDLK_A && !shift) {
+        bool enabled = !viewer_get_ambient(viewer);
+        viewer_set_ambient(viewer, enabled);
+        overlay_show_osd(enabled ? "Ambient background on" : "Ambient background off");
+        goto reset_gg;
+    }
+
+    /* === Background colour behind the image (Shift+a) === */
+    if (key == SDLK_A && shift) {
+        char msg[64];
+        snprintf(msg, sizeof(msg), "Background: %s", theme_cycle_background());
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Clear caches (C) === */
+    if (key == SDLK_C && shift) {
+        ViewerMemoryUsage before, after;
+        viewer_get_memory_usage(viewer, &before);
+        viewer_trim_caches(viewer, 0);
+        viewer_get_memory_usage(viewer, &after);
+
+        size_t freed = (before.image_bytes + before.thumb_bytes) -
+                       (after.image_bytes + after.thumb_bytes);
+        char *freed_str = format_file_size((long long)freed);
+        char msg[128];
+        snprintf(msg, sizeof(msg), "Caches cleared (%s freed)", freed_str ? freed_str : "?");
+        free(freed_str);
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Marks (m toggles, M clears all) === */
+    if (key == SDLK_M) {
+        char msg[64];
+        if (shift) {
+            app_clear_marks(app);
+            overlay_show_osd("All marks cleared");
+        } else if (app_current_path(app)) {
+            bool marked = app_toggle_mark(app);
+            snprintf(msg, sizeof(msg), "%s (%d marked)",
+                     marked ? "Marked" : "Unmarked", app_marked_count(app));
+            overlay_show_osd(msg);
+        }
+        goto reset_gg;
+    }
+
+    /* === Undo / redo rotate and zoom (Ctrl+z, Ctrl+Shift+z) === */
+    if (key == SDLK_Z && (event->mod & SDL_KMOD_CTRL)) {
+        bool done = shift ? viewer_redo_view(viewer) : viewer_undo_view(viewer);
+        if (!done) {
+            overlay_show_osd(shift ? "Nothing to redo" : "Nothing to undo");
+        }
+        update_window_title(app, viewer, window);
+        goto reset_gg;
+    }
+
+    /* === Animation: Space play/pause, ,/. step frames, e export frame === */
+    if (viewer_is_animated(viewer) &&
+        (key == SDLK_SPACE || key == SDLK_MEDIA_PLAY_PAUSE || key == SDLK_COMMA || key == SDLK_PERIOD ||
+         key == SDLK_E)) {
+        int frame = 0, frames = 0;
+        char msg[128];
+        if (key == SDLK_E) {
+            const char *path = app_current_path(app);
+            SDL_Surface *surface = viewer_current_frame(viewer);
+            if (!path || !surface) goto reset_gg;
+
+            viewer_anim_get_position(viewer, &frame, &frames);
+            const char *name = strrchr(path, '/');
+            name = name ? name + 1 : path;
+            const char *dot = strrchr(name, '.');
+            char stem[512];
+            snprintf(stem, sizeof(stem), "%.*s-frame-%d",
+                     dot ? (int)(dot - name) : (int)strlen(name), name, frame + 1);
+            char suggested[4096];
+            default_output_path(path, stem, "png", suggested, sizeof(suggested));
+
+            char *out = ask_output_path("Export Frame", suggested, renderer, window, viewer);
+            if (out) {
+                /* The dialog may have redrawn the viewer; fetch the frame again */
+                surface = viewer_current_frame(viewer);
+                snprintf(msg, sizeof(msg), surface && IMG_SavePNG(surface, out)
+                         ? "Saved frame %d as PNG" : "Cannot save frame %d", frame + 1);
+                overlay_show_osd(msg);
+                free(out);
+            }
+            goto reset_gg;
+        }
+
+        if (key == SDLK_SPACE || key == SDLK_MEDIA_PLAY_PAUSE) {
+            viewer_anim_toggle_pause(viewer);
+        } else {
+            viewer_anim_step(viewer, key == SDLK_PERIOD ? 1 : -1);
+        }
+        viewer_anim_get_position(viewer, &frame, &frames);
+        snprintf(msg, sizeof(msg), "Frame %d/%d%s", frame + 1, frames,
+                 viewer_anim_is_paused(viewer) ? " (paused)" : "");
+        overlay_show_osd(msg);
+        goto reset_gg;
+    }
+
+    /* === Exact zoom (%): a common level or a typed percentage === */
+    if (key == SDLK_5 && shift) {
+        float zoom = viewer_get_zoom(viewer);
+        if (zoom <= 0.0f) goto reset_gg;
+
+        static const char *const levels[] = {
+            "Fit to window", "25%", "50%", "100%", "200%", "400%", "800%", "Custom\xe2\x80\xa6",
+        };
+        static const float scales[] = { 0.0f, 0.25f, 0.5f, 1.0f, 2.0f, 4.0f, 8.0f };
+        int count = (int)(sizeof(levels) / sizeof(levels[0]));
+        int selected = 3;
+        for (int i = 1; i < count - 1; i++) {
+            if (fabsf(scales[i] - zoom) < 0.001f) selected = i;
+        }
+
+        int pick = overlay_modal_choose("Zoom", levels, count, selected, renderer, viewer);
+        if (pick == 0) {
+            viewer_zoom_fit(viewer);
+        } else if (pick > 0 && pick < count - 1) {
+            viewer_set_zoom(viewer, scales[pick]);
+        } else if (pick == count - 1) {
+            char initial[16];
+            snprintf(initial, sizeof(initial), "%.0f", zoom * 100.0f);
+            char *text = overlay_modal_entry_checked("Zoom (%)", initial, check_zoom_text, NULL,
+                                                     renderer, window, viewer);
+            float pct;
+            if (text && parse_zoom_percent(text, &pct)) {
+                viewer_set_zoom(viewer, pct / 100.0f);
+            }
+            free(text);
+        }
+        if (pick >= 0) input_show_zoom(viewer);
+        goto reset_gg;
+    }
+
+    /* === Flip: Shift+f mirrors left to right, Shift+v top to bottom === */
+    if ((key == SDLK_F || key == SDLK_V) && shift) {
+        viewer_flip(viewer, key == SDLK_F);
+        update_window_title(app, viewer, window);
+        goto reset_gg;
+    }
+
     /* === View controls === */
     switch (key) {
     case SDLK_F:
-        fullscreen_active = !fullscreen_active;
-        SDL_SetWindowFullscreen(window, fullscreen_active);
+        SDL_SetWindowFullscreen(window, !(SDL_GetWindowFlags(window) & SDL_WINDOW_FULLSCREEN));
         goto reset_gg;
     case SDLK_EQUALS:
     case SDLK_PLUS:
     case SDLK_Z:
         viewer_zoom_in(viewer);
+        input_show_zoom(viewer);
         goto reset_gg;
     case SDLK_MINUS:
     case SDLK_X:
         viewer_zoom_out(viewer);
+        input_show_zoom(viewer);
         goto reset_gg;
     case SDLK_0:
         viewer_zoom_fit(viewer);
+        input_show_zoom(viewer);
         goto reset_gg;
     case SDLK_1:
         viewer_zoom_original(viewer);
+        input_show_zoom(viewer);
         goto reset_gg;
     default:
         break;
@@ -195,6 +1720,72 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
     /* === Rotation === */
     if (key == SDLK_R) {
         viewer_rotate(viewer, !shift); /* r = clockwise, R(shift+r) = counter-clockwise */
+        update_window_title(app, viewer, window);
+        goto reset_gg;
+    }
+
+    /* === Save rotation and flips (Ctrl+s) === */
+    if (key == SDLK_S && (event->mod & SDL_KMOD_CTRL)) {
+        char edit[48];
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: saving is disabled");
+        } else if (!describe_transform(viewer, edit, sizeof(edit))) {
+            overlay_show_osd("Nothing to save");
+        } else {
+            overlay_show_osd(save_rotation(app, viewer));
+            update_window_title(app, viewer, window);
+        }
+        goto reset_gg;
+    }
+
+    /* === Shift the capture time of the marked images (Ctrl+d) === */
+    if (key == SDLK_D && (event->mod & SDL_KMOD_CTRL)) {
+        int count = 0;
+        const char **paths = app_current_path(app) ? collect_selection(app, false, &count) : NULL;
+        if (!paths) goto reset_gg;
+
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: editing dates is disabled");
+            free(paths);
+            goto reset_gg;
+        }
+
+        char title[128];
+        snprintf(title, sizeof(title), "Shift the capture time of %d image%s by (e.g. +1h, -2h30m, +0:45)",
+                 count, count == 1 ? "" : "s");
+        char *text = overlay_modal_entry(title, "", renderer, window, viewer);
+        long seconds = 0;
+        if (text && !parse_time_offset(text, &seconds)) {
+            overlay_show_osd("Expected an offset such as +1h, -2h30m or +0:45");
+        } else if (text) {
+            int shifted = 0, failed = 0;
+            for (int i = 0; i < count; i++) {
+                int ret = exif_shift_dates(paths[i], seconds);
+                if (ret > 0) shifted++;
+                else failed++;
+            }
+
+            long span = seconds < 0 ? -seconds : seconds;
+            char offset[32];
+            if (span >= 86400) {
+                snprintf(offset, sizeof(offset), "%c%ldd %ld:%02ld", seconds < 0 ? '-' : '+', span / 86400,
+                         span % 86400 / 3600, span % 3600 / 60);
+            } else {
+                snprintf(offset, sizeof(offset), "%c%ld:%02ld", seconds < 0 ? '-' : '+', span / 3600,
+                         span % 3600 / 60);
+            }
+            char msg[256];
+            if (failed > 0) {
+                snprintf(msg, sizeof(msg), "Shifted %d by %s, %d without EXIF dates (only JPEG files can be edited)",
+                         shifted, offset, failed);
+            } else {
+                snprintf(msg, sizeof(msg), "Shifted the capture time of %d image%s by %s",
+                         shifted, shifted == 1 ? "" : "s", offset);
+            }
+            overlay_show_osd(msg);
+        }
+        free(text);
+        free(paths);
         goto reset_gg;
     }
 
@@ -206,108 +1797,289 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
         /* 'D' (shift+d) does nothing */
         if (key == SDLK_D && shift) goto reset_gg;
 
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: delete is disabled");
+            goto reset_gg;
+        }
+
         const char *name = strrchr(path, '/');
         name = name ? name + 1 : path;
 
-        char msg[512];
-        int ret = snprintf(msg, sizeof(msg), "Move \"%s\" to trash?", name);
-        if (ret < 0 || (size_t)ret >= sizeof(msg)) goto reset_gg;
+        /* A JPEG and the raw shot with it go together */
+        char *raw = config_get()->raw_pairs ? fileops_raw_companion(path) : NULL;
+        const char *raw_name = raw ? strrchr(raw, '/') + 1 : NULL;
 
-        if (!overlay_modal_confirm("Delete Image", msg, renderer, viewer)) {
+        char msg[512];
+        int ret = raw ? snprintf(msg, sizeof(msg), "Move \"%s\" and \"%s\" to trash?", name, raw_name)
+                      : snprintf(msg, sizeof(msg), "Move \"%s\" to trash?", name);
+        if (ret < 0 || (size_t)ret >= sizeof(msg) ||
+            !overlay_modal_confirm("Delete Image", msg, renderer, viewer)) {
+            free(raw);
             goto reset_gg;
         }
 
         if (fileops_trash(path) != 0) {
             fprintf(stderr, "input: fileops_trash failed\n");
+            free(raw);
             goto reset_gg;
         }
+        if (raw && fileops_trash(raw) != 0) {
+            snprintf(msg, sizeof(msg), "Could not trash %s", raw_name);
+            overlay_show_osd(msg);
+        }
+        free(raw);
+
+        hooks_run(HOOK_IMAGE_DELETED, path, app_current_index(app), app_image_count(app));
 
         viewer_clear(viewer);
         app_remove_current(app);
 
         /* Reload next image (if any) and update title */
-        const char *next = app_current_path(app);
-        if (next) {
-            viewer_load_image(viewer, next);
-            /* Update window title */
-            const char *name = strrchr(next, '/');
-            name = name ? name + 1 : next;
-            char title[512];
-            snprintf(title, sizeof(title), "%s (%d/%d) - Frame",
-                     name, app_current_index(app), app_image_count(app));
-            SDL_SetWindowTitle(window, title);
-            viewer_prefetch_around(viewer, app);
-        } else {
-            SDL_SetWindowTitle(window, "Frame");
-        }
+        input_show_current(app, viewer, window);
         goto reset_gg;
     }
 
-    /* === Rename (F2) === */
+    /* === Rename (F2) / bulk rename (Shift+F2) === */
     if (key == SDLK_F2) {
         const char *path = app_current_path(app);
         if (!path) goto reset_gg;
 
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: rename is disabled");
+            goto reset_gg;
+        }
+
+        /* The rotation would be lost once the file has a new name */
+        settle_rotation(app, viewer, window);
+        path = app_current_path(app);
+
+        RenameCheck rc = {0};
+        rename_check_dir(&rc, path);
+        bool sidecars = config_get()->rename_sidecars;
+        bool raw_pairs = config_get()->raw_pairs;
+        int sidecar_count = 0;
+        int raw_count = 0;
+        char msg[128];
+
+        if (shift) {
+            /* Marked images (or the current one): '*' stands for each name */
+            int count = 0;
+            const char **selection = collect_selection(app, false, &count);
+            char **paths = selection ? calloc((size_t)count, sizeof(char *)) : NULL;
+            if (!paths) {
+                free(selection);
+                goto reset_gg;
+            }
+            for (int i = 0; i < count; i++) {
+                paths[i] = strdup(selection[i]);
+            }
+            free(selection);
+            rc.paths = paths;
+            rc.count = count;
+
+            char title[64];
+            snprintf(title, sizeof(title), "Rename %d Image%s (* = name)", count, count == 1 ? "" : "s");
+            char *pattern = overlay_modal_entry_checked(title, "*", check_bulk_rename, &rc,
+                                                        renderer, window, viewer);
+            if (pattern) {
+                int renamed = 0, failed = 0;
+                for (int i = 0; i < count; i++) {
+                    char name[512];
+                    char *new_path = paths[i] && apply_name_pattern(pattern, paths[i], name, sizeof(name))
+                        ? fileops_rename(paths[i], name) : NULL;
+                    if (!new_path) {
+                        failed++;
+                        continue;
+                    }
+                    if (sidecars) sidecar_count += fileops_rename_sidecars(paths[i], new_path);
+                    if (raw_pairs) raw_count += fileops_rename_raw_companion(paths[i], new_path, sidecars) > 0;
+                    app_rename_path(app, paths[i], new_path);
+                    renamed++;
+                    free(new_path);
+                }
+                input_show_current(app, viewer, window);
+                if (failed > 0) {
+                    snprintf(msg, sizeof(msg), "Renamed %d images, %d failed", renamed, failed);
+                } else if (raw_count > 0) {
+                    snprintf(msg, sizeof(msg), "Renamed %d image%s and %d raw file%s", renamed,
+                             renamed == 1 ? "" : "s", raw_count, raw_count == 1 ? "" : "s");
+                } else {
+                    snprintf(msg, sizeof(msg), "Renamed %d image%s", renamed, renamed == 1 ? "" : "s");
+                }
+                overlay_show_osd(msg);
+                free(pattern);
+            }
+            for (int i = 0; i < count; i++) free(paths[i]);
+            free(paths);
+            goto reset_gg;
+        }
+
         const char *name = strrchr(path, '/');
         name = name ? name + 1 : path;
+        rc.original = name;
 
-        char *new_name = overlay_modal_entry("Rename Image", name, renderer, window, viewer);
+        char *new_name = overlay_modal_entry_checked("Rename Image", name, check_rename, &rc,
+                                                     renderer, window, viewer);
         if (!new_name) goto reset_gg;
+        if (strcmp(new_name, name) == 0) {
+            free(new_name);
+            goto reset_gg;
+        }
 
         /* Validate name */
-        if (new_name[0] == '\0' || strchr(new_name, '/') ||
-            strcmp(new_name, ".") == 0 || strcmp(new_name, "..") == 0) {
-            fprintf(stderr, "Invalid filename\n");
+        bool fatal = false;
+        const char *problem = fileops_check_name(NULL, new_name, &fatal);
+        if (problem && fatal) {
+            overlay_show_osd(problem);
             free(new_name);
             goto reset_gg;
         }
 
-        char *new_path = fileops_rename(path, new_name);
+        char *old_path = strdup(path);
+        char *new_path = old_path ? fileops_rename(old_path, new_name) : NULL;
         if (!new_path) {
-            fprintf(stderr, "Rename failed\n");
+            overlay_show_osd("Rename failed");
+            free(old_path);
             free(new_name);
             goto reset_gg;
         }
+        if (sidecars) sidecar_count = fileops_rename_sidecars(old_path, new_path);
+        if (raw_pairs) raw_count = fileops_rename_raw_companion(old_path, new_path, sidecars);
 
         app_rename_current(app, new_path);
         do_nav(app, viewer, window);
+        if (raw_count > 0) {
+            overlay_show_osd("Renamed with its raw file");
+        } else if (sidecar_count > 0) {
+            snprintf(msg, sizeof(msg), "Renamed with %d sidecar%s", sidecar_count,
+                     sidecar_count == 1 ? "" : "s");
+            overlay_show_osd(msg);
+        }
+        free(old_path);
         free(new_name);
         free(new_path);
         goto reset_gg;
     }
 
-    /* === Info (i) === */
+    /* === Set or adjust the location (t) / remove it from the marked images (T) === */
+    if (key == SDLK_T && !(event->mod & SDL_KMOD_CTRL)) {
+        const char *path = app_current_path(app);
+        if (!path) goto reset_gg;
+
+        if (config_get()->read_only) {
+            overlay_show_osd("Read-only mode: location editing is disabled");
+            goto reset_gg;
+        }
+
+        char msg[256];
+        if (shift) {
+            int count = 0;
+            const char **paths = collect_selection(app, false, &count);
+            if (!paths) goto reset_gg;
+
+            snprintf(msg, sizeof(msg), "Remove the location from %d image%s?", count, count == 1 ? "" : "s");
+            if (overlay_modal_confirm("Remove Location", msg, renderer, viewer)) {
+                int removed = 0, failed = 0;
+                for (int i = 0; i < count; i++) {
+                    int ret = strip_location(paths[i]);
+                    if (ret > 0) removed++;
+                    if (ret < 0) failed++;
+                }
+                if (failed > 0) {
+                    snprintf(msg, sizeof(msg), "Location removed from %d, failed for %d (only JPEG files can be edited)",
+                             removed, failed);
+                } else {
+                    snprintf(msg, sizeof(msg), "Location removed from %d image%s", removed, removed == 1 ? "" : "s");
+                }
+                overlay_show_osd(msg);
+            }
+            free(paths);
+            goto reset_gg;
+        }
+
+        /* Start from the sidecar's coordinates, which win over the file's */
+        ExifInfo exif;
+        XmpInfo xmp;
+        bool in_file = exif_read(path, &exif) && exif.has_gps;
+        bool in_sidecar = xmp_read(path, &xmp) && xmp.has_gps;
+        char current[64] = "";
+        if (in_sidecar) {
+            snprintf(current, sizeof(current), "%.6f, %.6f", xmp.latitude, xmp.longitude);
+        } else if (in_file) {
+            snprintf(current, sizeof(current), "%.6f, %.6f", exif.latitude, exif.longitude);
+        }
+
+        char *text = overlay_modal_entry("Location (latitude, longitude; empty removes it)", current,
+                                         renderer, window, viewer);
+        if (!text) goto reset_gg;
+
+        double lat, lon;
+        if (text[0] == '\0') {
+            int ret = strip_location(path);
+            overlay_show_osd(ret > 0 ? "Location removed" : ret == 0 ? "No location to remove"
+                                     : "Could not remove the location");
+        } else if (!parse_location(text, &lat, &lon)) {
+            overlay_show_osd("Expected latitude, longitude in degrees, e.g. 52.5200, 13.4050");
+        } else {
+            /* Overwrite GPS tags in place when the file has them; otherwise
+               (or if a sidecar already holds a location) use the sidecar */
+            bool in_place = in_file && exif_write_gps(path, lat, lon);
+            bool ok = in_place;
+            if (!in_place || in_sidecar) ok = xmp_write_gps(path, true, lat, lon);
+            overlay_show_osd(!ok ? "Could not save the location"
+                             : in_place ? "Location written to the file"
+                                        : "Location saved to the .xmp sidecar");
+        }
+        free(text);
+        goto reset_gg;
+    }
+
+    /* === Info (i) / copy the camera settings (I) === */
     if (key == SDLK_I) {
-        if (shift) goto reset_gg; /* 'I' does nothing */
         const char *path = app_current_path(app);
         if (!path) goto reset_gg;
 
+        if (shift) {
+            ExifInfo exif;
+            char *summary = exif_read(path, &exif) ? exif_summary(&exif) : NULL;
+            if (!summary) {
+                overlay_show_osd("No camera settings in this image");
+            } else if (SDL_SetClipboardText(summary)) {
+                char msg[256];
+                snprintf(msg, sizeof(msg), "Copied: %s", summary);
+                overlay_show_osd(msg);
+            } else {
+                overlay_show_osd("Could not set clipboard");
+            }
+            free(summary);
+            goto reset_gg;
+        }
+
         char info_text[4096];
         info_text[0] = '\0';
 
-        /* Get file stats */
-        struct stat st;
-        if (stat(path, &st) == 0) {
-            char *size_str = format_file_size((long long)st.st_size);
+        ImageInfo info;
+        if (info_read(path, &info)) {
+            char *size_str = format_file_size(info.file_size);
             char time_buf[64];
-            struct tm *tm_info = localtime(&st.st_mtime);
+            struct tm *tm_info = localtime(&info.modified);
             if (tm_info) {
                 strftime(time_buf, sizeof(time_buf), "%a, %d %b %Y %H:%M:%S %Z", tm_info);
             } else {
                 time_buf[0] = '\0';
             }
 
-            const char *name = strrchr(path, '/');
-            name = name ? name + 1 : path;
-            const char *ext = strrchr(name, '.');
-            const char *format_name = ext ? format_from_ext(ext) : "Unknown";
+            /* Dimensions come from the already decoded image */
+            viewer_get_dimensions(viewer, &info.width, &info.height);
 
-            /* Get image dimensions */
-            int img_w = 0, img_h = 0;
-            viewer_get_dimensions(viewer, &img_w, &img_h);
+            /* Get colour and EXIF data */
+            char *color_text = info_format_color(&info);
+            char *exif_text = info.has_exif ? exif_format(&info.exif) : NULL;
 
-            /* Get EXIF data */
-            char *exif_text = exif_get_data(path);
+            ViewerMemoryUsage mem;
+            viewer_get_memory_usage(viewer, &mem);
+            char *cache_str = format_file_size((long long)mem.image_bytes);
+            char *thumb_str = format_file_size((long long)mem.thumb_bytes);
 
             snprintf(info_text, sizeof(info_text),
                 "File:       %s\n"
@@ -315,28 +2087,48 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
                 "Dimensions: %dx%d\n"
                 "Format:     %s\n"
                 "Modified:   %s\n"
+                "%s"
                 "Index:      %d / %d\n"
+                "Cache:      %s in %d images, %s in %d thumbnails\n"
                 "%s%s",
-                name, size_str,
-                img_w, img_h,
-                format_name, time_buf,
+                info.name, size_str,
+                info.width, info.height,
+                info.format, time_buf,
+                color_text ? color_text : "",
                 app_current_index(app), app_image_count(app),
+                cache_str ? cache_str : "?", mem.image_count,
+                thumb_str ? thumb_str : "?", mem.thumb_count,
                 exif_text ? "EXIF:\n" : "",
                 exif_text ? exif_text : "");
 
+            free(cache_str);
+            free(thumb_str);
             free(size_str);
+            free(color_text);
             free(exif_text);
+            info_free(&info);
         }
 
         overlay_show_info("Image Information", info_text);
         goto reset_gg;
     }
 
+    /* === About box (F1) === */
+    if (key == SDLK_F1) {
+        char *text = about_text();
+        if (text) {
+            overlay_show_info("About Frame", text);
+            free(text);
+        }
+        goto reset_gg;
+    }
+
     /* === Help (?) and Search (/) === */
     if (key == SDLK_SLASH) {
         if (!shift) {
-            /* '/' without shift triggers search. This will be intercepted in main loop.
-               Return true and out_dirty=true so main loop can open search overlay. */
+            /* '/' without shift triggers search, which the main loop opens */
+            search_requested = true;
+            search_request_mode = SEARCH_MODE_GRID;
             if (out_dirty) *out_dirty = true;
             return true;
         }
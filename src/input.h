@@ -3,6 +3,7 @@
 
 #include <SDL3/SDL.h>
 #include <stdbool.h>
+#include "search.h"
 
 struct AppState;
 struct Viewer;
@@ -15,6 +16,35 @@ bool input_handle_keyboard(struct AppState *app, struct Viewer *viewer,
                            SDL_Window *window, SDL_Renderer *renderer,
                            bool *out_dirty);
 
+/* Show the action menu (F10, the Menu key or a right click) and run the
+   picked action as its key would. The view must be redrawn afterwards.
+   Returns false if Quit was picked. */
+bool input_show_menu(struct AppState *app, struct Viewer *viewer,
+                     SDL_Window *window, SDL_Renderer *renderer);
+
+/* Check (and clear) whether the last key asked to open the search overlay
+   ('/' for the grid, Ctrl+p for the quick switcher); sets *out_mode. */
+bool input_take_search_request(SearchMode *out_mode);
+
+/* Save or drop an unsaved rotation (per the unsaved_rotation setting,
+   asking if needed). Call before quitting. */
+void input_settle_rotation(struct AppState *app, struct Viewer *viewer, SDL_Window *window);
+
+/* Show the current zoom percentage in the OSD (after any zoom change). */
+void input_show_zoom(struct Viewer *viewer);
+
+/* Handle the scroll wheel per the scroll_wheel setting: zoom toward the
+   cursor, step through the images or pan. Ctrl+scroll always zooms,
+   Shift+scroll always pans, and horizontal scrolling (tilt wheels,
+   touchpads) pans sideways. Returns true if the view changed. */
+bool input_handle_wheel(struct AppState *app, struct Viewer *viewer, const SDL_MouseWheelEvent *event,
+                        SDL_Window *window);
+
+/* Run the double_click / middle_click action for a click on the image.
+   Returns true if the click did something (the caller should not also
+   start a drag with it). */
+bool input_handle_click(struct Viewer *viewer, const SDL_MouseButtonEvent *event, SDL_Window *window);
+
 /* Reset the 'gg' sequence state (e.g., when app loses focus). */
 void input_reset_gg(void);
 
@@ -25,4 +55,21 @@ bool input_nav_pending(void);
    Returns true if the image was loaded (needs redraw). */
 bool input_check_and_trigger_nav(struct AppState *app, struct Viewer *viewer);
 
+/* Load the current image, update the window title and prefetch neighbours.
+   Use after the current index or image list changed outside of keyboard
+   handling (search, IPC, deletion). Clears the viewer if there is no image. */
+void input_show_current(struct AppState *app, struct Viewer *viewer, SDL_Window *window);
+
+/* Give the viewer the window size less the filmstrip and status bar (and
+   the reference pane in compare mode). Use after any of those changed. */
+void input_resize_viewer(struct Viewer *viewer, SDL_Window *window);
+
+/* Update the window title and prefetching after images were added around
+   the current one (background folder scan) without reloading it. */
+void input_list_changed(struct AppState *app, struct Viewer *viewer, SDL_Window *window);
+
+/* Bring the window title up to date with background work: the folder scan
+   and the decode of the image on screen. Cheap when nothing changed. */
+void input_refresh_title(struct AppState *app, struct Viewer *viewer, SDL_Window *window);
+
 #endif /* FRAME_INPUT_H */
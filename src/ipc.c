@@ -0,0 +1,559 @@
+#define _GNU_SOURCE
+#include "ipc.h"
+#include "app.h"
+#include "config.h"
+#include "viewer.h"
+#include "input.h"
+#include "utils.h"
+#include "netfs.h"
+#include <ctype.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <poll.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/socket.h>
+#include <sys/stat.h>
+#include <sys/un.h>
+#include <unistd.h>
+
+#define IPC_MAX_CLIENTS 16
+#define IPC_LINE_MAX 4096
+#define IPC_MAX_ARGS 4
+
+typedef struct {
+    int fd;                    /* -1 when the slot is free */
+    char buf[IPC_LINE_MAX];    /* partial line received so far */
+    size_t len;
+} IpcClient;
+
+typedef struct {
+    char *args[IPC_MAX_ARGS];  /* args[0] is the command name (owned) */
+    int argc;
+    char request_id[32];       /* raw JSON number, empty if absent */
+} IpcCommand;
+
+static int listen_fd = -1;
+static char *socket_path = NULL;
+static IpcClient clients[IPC_MAX_CLIENTS];
+
+/* ---- minimal JSON reading ---- */
+
+static const char *skip_ws(const char *p)
+{
+    while (*p && isspace((unsigned char)*p)) p++;
+    return p;
+}
+
+/* Append a code point as UTF-8. `out` must have room for 4 bytes. */
+static char *put_utf8(char *out, unsigned int cp)
+{
+    if (cp < 0x80) {
+        *out++ = (char)cp;
+    } else if (cp < 0x800) {
+        *out++ = (char)(0xC0 | (cp >> 6));
+        *out++ = (char)(0x80 | (cp & 0x3F));
+    } else {
+        *out++ = (char)(0xE0 | (cp >> 12));
+        *out++ = (char)(0x80 | ((cp >> 6) & 0x3F));
+        *out++ = (char)(0x80 | (cp & 0x3F));
+    }
+    return out;
+}
+
+/* Parse a JSON string starting at the opening quote. Stores a malloc'd copy
+   in *out and returns the position after the closing quote, or NULL. */
+static const char *parse_string(const char *p, char **out)
+{
+    if (*p != '"') return NULL;
+    p++;
+
+    char *buf = malloc(strlen(p) * 3 + 1);
+    if (!buf) return NULL;
+    char *o = buf;
+
+    while (*p && *p != '"') {
+        if (*p != '\\') {
+            *o++ = *p++;
+            continue;
+        }
+        p++;
+        switch (*p) {
+        case '"':  *o++ = '"'; break;
+        case '\\': *o++ = '\\'; break;
+        case '/':  *o++ = '/'; break;
+        case 'b':  *o++ = '\b'; break;
+        case 'f':  *o++ = '\f'; break;
+        case 'n':  *o++ = '\n'; break;
+        case 'r':  *o++ = '\r'; break;
+        case 't':  *o++ = '\t'; break;
+        case 'u': {
+            unsigned int cp = 0;
+            for (int i = 1; i <= 4; i++) {
+                if (!isxdigit((unsigned char)p[i])) {
+                    free(buf);
+                    return NULL;
+                }
+                cp = cp * 16 + (unsigned int)(isdigit((unsigned char)p[i])
+                                              ? p[i] - '0'
+                                              : (tolower((unsigned char)p[i]) - 'a' + 10));
+            }
+            o = put_utf8(o, cp);
+            p += 4;
+            break;
+        }
+        default:
+            free(buf);
+            return NULL;
+        }
+        p++;
+    }
+
+    if (*p != '"') {
+        free(buf);
+        return NULL;
+    }
+    *o = '\0';
+    *out = buf;
+    return p + 1;
+}
+
+/* Parse a bare scalar (number, true, false, null) into a malloc'd string. */
+static const char *parse_scalar(const char *p, char **out)
+{
+    const char *start = p;
+    while (*p && (isalnum((unsigned char)*p) || *p == '-' || *p == '+' || *p == '.'))
+        p++;
+    if (p == start) return NULL;
+    *out = strndup(start, (size_t)(p - start));
+    return *out ? p : NULL;
+}
+
+/* Skip over any JSON value. Returns the position after it, or NULL. */
+static const char *skip_value(const char *p)
+{
+    p = skip_ws(p);
+    if (*p == '"') {
+        char *tmp = NULL;
+        p = parse_string(p, &tmp);
+        free(tmp);
+        return p;
+    }
+    if (*p == '{' || *p == '[') {
+        char close = (*p == '{') ? '}' : ']';
+        p = skip_ws(p + 1);
+        if (*p == close) return p + 1;
+        for (;;) {
+            if (close == '}') {
+                p = skip_value(p);      /* key */
+                if (!p) return NULL;
+                p = skip_ws(p);
+                if (*p != ':') return NULL;
+                p++;
+            }
+            p = skip_value(p);
+            if (!p) return NULL;
+            p = skip_ws(p);
+            if (*p == ',') {
+                p = skip_ws(p + 1);
+                continue;
+            }
+            return (*p == close) ? p + 1 : NULL;
+        }
+    }
+    char *tmp = NULL;
+    p = parse_scalar(p, &tmp);
+    free(tmp);
+    return p;
+}
+
+/* Parse the "command" array of a JSON request. */
+static const char *parse_command_array(const char *p, IpcCommand *cmd)
+{
+    p = skip_ws(p);
+    if (*p != '[') return NULL;
+    p = skip_ws(p + 1);
+    if (*p == ']') return p + 1;
+
+    for (;;) {
+        char *arg = NULL;
+        p = (*p == '"') ? parse_string(p, &arg) : parse_scalar(p, &arg);
+        if (!p) return NULL;
+        if (cmd->argc < IPC_MAX_ARGS) {
+            cmd->args[cmd->argc++] = arg;
+        } else {
+            free(arg);
+        }
+        p = skip_ws(p);
+        if (*p == ',') {
+            p = skip_ws(p + 1);
+            continue;
+        }
+        return (*p == ']') ? p + 1 : NULL;
+    }
+}
+
+static bool parse_json_command(const char *line, IpcCommand *cmd)
+{
+    const char *p = skip_ws(line);
+    if (*p != '{') return false;
+    p = skip_ws(p + 1);
+
+    while (*p && *p != '}') {
+        char *key = NULL;
+        p = parse_string(p, &key);
+        if (!p) return false;
+        p = skip_ws(p);
+        if (*p != ':') {
+            free(key);
+            return false;
+        }
+        p = skip_ws(p + 1);
+
+        if (strcmp(key, "command") == 0) {
+            p = parse_command_array(p, cmd);
+        } else if (strcmp(key, "request_id") == 0) {
+            char *id = NULL;
+            p = parse_scalar(p, &id);
+            if (id) {
+                snprintf(cmd->request_id, sizeof(cmd->request_id), "%s", id);
+                free(id);
+            }
+        } else {
+            p = skip_value(p);
+        }
+        free(key);
+        if (!p) return false;
+
+        p = skip_ws(p);
+        if (*p == ',') p = skip_ws(p + 1);
+    }
+    return cmd->argc > 0;
+}
+
+/* Plain text form: "<command> [argument]". The argument is the rest of the
+   line so paths with spaces work without quoting. */
+static bool parse_text_command(const char *line, IpcCommand *cmd)
+{
+    const char *p = skip_ws(line);
+    const char *end = p;
+    while (*end && !isspace((unsigned char)*end)) end++;
+    if (end == p) return false;
+
+    cmd->args[cmd->argc++] = strndup(p, (size_t)(end - p));
+
+    const char *rest = skip_ws(end);
+    size_t rest_len = strlen(rest);
+    while (rest_len > 0 && isspace((unsigned char)rest[rest_len - 1])) rest_len--;
+    if (rest_len > 0) {
+        cmd->args[cmd->argc++] = strndup(rest, rest_len);
+    }
+    return cmd->args[0] != NULL;
+}
+
+static void free_command(IpcCommand *cmd)
+{
+    for (int i = 0; i < cmd->argc; i++) free(cmd->args[i]);
+    cmd->argc = 0;
+}
+
+/* ---- replies ---- */
+
+static void send_reply(int fd, const IpcCommand *cmd, const char *data, const char *error)
+{
+    char reply[IPC_LINE_MAX + 128];
+    int len;
+    if (cmd->request_id[0]) {
+        len = snprintf(reply, sizeof(reply), "{\"data\": %s, \"request_id\": %s, \"error\": \"%s\"}\n",
+                       data ? data : "null", cmd->request_id, error);
+    } else {
+        len = snprintf(reply, sizeof(reply), "{\"data\": %s, \"error\": \"%s\"}\n",
+                       data ? data : "null", error);
+    }
+    if (len <= 0) return;
+    if ((size_t)len >= sizeof(reply)) len = (int)sizeof(reply) - 1;
+
+    /* Best effort — a client that does not read its replies is its own problem */
+    (void)send(fd, reply, (size_t)len, MSG_NOSIGNAL | MSG_DONTWAIT);
+}
+
+/* ---- commands ---- */
+
+static bool run_command(IpcCommand *cmd, int fd, struct AppState *app,
+                        struct Viewer *viewer, SDL_Window *window, bool *out_quit)
+{
+    const char *name = cmd->args[0];
+    const char *arg = cmd->argc > 1 ? cmd->args[1] : NULL;
+    int before = app_current_index(app);
+
+    if (strcmp(name, "next") == 0 || strcmp(name, "prev") == 0) {
+        if (name[0] == 'n') {
+            app_next_image(app);
+        } else {
+            app_prev_image(app);
+        }
+        bool changed = app_current_index(app) != before;
+        if (changed) input_show_current(app, viewer, window);
+        send_reply(fd, cmd, NULL, "success");
+        return changed;
+    }
+
+    if (strcmp(name, "goto") == 0) {
+        char *end = NULL;
+        long n = arg ? strtol(arg, &end, 10) : 0;
+        if (!arg || *end != '\0' || n < 1 || n > app_image_count(app)) {
+            send_reply(fd, cmd, NULL, "invalid parameter");
+            return false;
+        }
+        app_display_image(app, (int)n - 1);
+        input_show_current(app, viewer, window);
+        send_reply(fd, cmd, NULL, "success");
+        return true;
+    }
+
+    if (strcmp(name, "open") == 0) {
+        char local[4096];
+        struct stat st;
+        arg = netfs_local_path(arg, local, sizeof(local));
+        if (!arg || stat(arg, &st) != 0) {
+            send_reply(fd, cmd, NULL, "invalid parameter");
+            return false;
+        }
+        /* Folders are read in the background; the reply cannot wait for
+           the scan, so only an empty and already finished one is an error */
+        app_load_directory_async(app, arg);
+        input_show_current(app, viewer, window);
+        bool found = app_current_path(app) || app_scan_active(app);
+        send_reply(fd, cmd, NULL, found ? "success" : "no images found");
+        return true;
+    }
+
+    if (strcmp(name, "zoom") == 0) {
+        char *end = NULL;
+        double pct = arg ? strtod(arg, &end) : 0.0;
+        if (arg && strcmp(arg, "fit") == 0) {
+            viewer_zoom_fit(viewer);
+        } else if (!arg || *end != '\0' || pct < config_get()->zoom_min || pct > config_get()->zoom_max ||
+                   viewer_get_zoom(viewer) <= 0.0f) {
+            send_reply(fd, cmd, NULL, "invalid parameter");
+            return false;
+        } else {
+            viewer_set_zoom(viewer, (float)(pct / 100.0));
+        }
+        input_show_zoom(viewer);
+        send_reply(fd, cmd, NULL, "success");
+        return true;
+    }
+
+    if (strcmp(name, "get-path") == 0) {
+        const char *path = app_current_path(app);
+        char *quoted = path ? json_quote(path) : NULL;
+        send_reply(fd, cmd, quoted, "success");
+        free(quoted);
+        return false;
+    }
+
+    if (strcmp(name, "quit") == 0) {
+        send_reply(fd, cmd, NULL, "success");
+        if (out_quit) *out_quit = true;
+        return false;
+    }
+
+    send_reply(fd, cmd, NULL, "unknown command");
+    return false;
+}
+
+/* ---- connection handling ---- */
+
+static void close_client(IpcClient *c)
+{
+    if (c->fd >= 0) close(c->fd);
+    c->fd = -1;
+    c->len = 0;
+}
+
+static void accept_clients(void)
+{
+    for (;;) {
+        int fd = accept4(listen_fd, NULL, NULL, SOCK_NONBLOCK | SOCK_CLOEXEC);
+        if (fd < 0) return; /* EAGAIN or error — nothing more to accept */
+
+        IpcClient *slot = NULL;
+        for (int i = 0; i < IPC_MAX_CLIENTS; i++) {
+            if (clients[i].fd < 0) {
+                slot = &clients[i];
+                break;
+            }
+        }
+        if (!slot) {
+            fprintf(stderr, "ipc: too many clients, dropping connection\n");
+            close(fd);
+            continue;
+        }
+        slot->fd = fd;
+        slot->len = 0;
+    }
+}
+
+/* Read from a client and run every complete line. */
+static bool service_client(IpcClient *c, struct AppState *app, struct Viewer *viewer,
+                           SDL_Window *window, bool *out_quit)
+{
+    bool changed = false;
+
+    for (;;) {
+        if (c->len >= sizeof(c->buf) - 1) {
+            fprintf(stderr, "ipc: command too long, closing connection\n");
+            close_client(c);
+            return changed;
+        }
+
+        ssize_t n = recv(c->fd, c->buf + c->len, sizeof(c->buf) - 1 - c->len, 0);
+        if (n == 0) {
+            close_client(c);
+            return changed;
+        }
+        if (n < 0) {
+            if (errno != EAGAIN && errno != EWOULDBLOCK && errno != EINTR) {
+                close_client(c);
+            }
+            return changed;
+        }
+        c->len += (size_t)n;
+        c->buf[c->len] = '\0';
+
+        char *line = c->buf;
+        char *nl;
+        while ((nl = strchr(line, '\n')) != NULL) {
+            *nl = '\0';
+
+            IpcCommand cmd = {0};
+            const char *start = skip_ws(line);
+            bool ok = (*start == '{') ? parse_json_command(start, &cmd)
+                                      : parse_text_command(start, &cmd);
+            if (ok) {
+                if (run_command(&cmd, c->fd, app, viewer, window, out_quit)) {
+                    changed = true;
+                }
+            } else if (*start != '\0') {
+                send_reply(c->fd, &cmd, NULL, "invalid command");
+            }
+            free_command(&cmd);
+
+            line = nl + 1;
+        }
+
+        /* Keep the incomplete tail for the next read */
+        size_t rest = c->len - (size_t)(line - c->buf);
+        memmove(c->buf, line, rest);
+        c->len = rest;
+    }
+}
+
+/* ---- public API ---- */
+
+bool ipc_start(const char *path)
+{
+    if (!path || listen_fd >= 0) return false;
+
+    struct sockaddr_un addr;
+    memset(&addr, 0, sizeof(addr));
+    addr.sun_family = AF_UNIX;
+    if (strlen(path) >= sizeof(addr.sun_path)) {
+        fprintf(stderr, "ipc: socket path too long: %s\n", path);
+        return false;
+    }
+    strcpy(addr.sun_path, path);
+
+    /* Replace a stale socket left behind by a previous instance */
+    struct stat st;
+    if (lstat(path, &st) == 0) {
+        if (!S_ISSOCK(st.st_mode)) {
+            fprintf(stderr, "ipc: '%s' exists and is not a socket\n", path);
+            return false;
+        }
+        unlink(path);
+    }
+
+    int fd = socket(AF_UNIX, SOCK_STREAM | SOCK_NONBLOCK | SOCK_CLOEXEC, 0);
+    if (fd < 0) {
+        perror("ipc: socket");
+        return false;
+    }
+    if (bind(fd, (struct sockaddr *)&addr, sizeof(addr)) != 0) {
+        perror("ipc: bind");
+        close(fd);
+        return false;
+    }
+    if (listen(fd, 8) != 0) {
+        perror("ipc: listen");
+        close(fd);
+        unlink(path);
+        return false;
+    }
+
+    socket_path = strdup(path);
+    listen_fd = fd;
+    for (int i = 0; i < IPC_MAX_CLIENTS; i++) {
+        clients[i].fd = -1;
+        clients[i].len = 0;
+    }
+    return true;
+}
+
+void ipc_stop(void)
+{
+    if (listen_fd < 0) return;
+
+    for (int i = 0; i < IPC_MAX_CLIENTS; i++) {
+        close_client(&clients[i]);
+    }
+    close(listen_fd);
+    listen_fd = -1;
+
+    if (socket_path) {
+        unlink(socket_path);
+        free(socket_path);
+        socket_path = NULL;
+    }
+}
+
+bool ipc_is_active(void)
+{
+    return listen_fd >= 0;
+}
+
+bool ipc_poll(struct AppState *app, struct Viewer *viewer, SDL_Window *window, bool *out_quit)
+{
+    if (listen_fd < 0) return false;
+
+    struct pollfd fds[IPC_MAX_CLIENTS + 1];
+    int map[IPC_MAX_CLIENTS + 1];
+    int nfds = 0;
+
+    fds[nfds].fd = listen_fd;
+    fds[nfds].events = POLLIN;
+    map[nfds++] = -1;
+    for (int i = 0; i < IPC_MAX_CLIENTS; i++) {
+        if (clients[i].fd < 0) continue;
+        fds[nfds].fd = clients[i].fd;
+        fds[nfds].events = POLLIN;
+        map[nfds++] = i;
+    }
+
+    if (poll(fds, (nfds_t)nfds, 0) <= 0) return false;
+
+    bool changed = false;
+    for (int i = 1; i < nfds; i++) {
+        if (fds[i].revents & (POLLIN | POLLHUP | POLLERR)) {
+            if (service_client(&clients[map[i]], app, viewer, window, out_quit)) {
+                changed = true;
+            }
+        }
+    }
+    if (fds[0].revents & POLLIN) {
+        accept_clients();
+    }
+    return changed;
+}
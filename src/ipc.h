@@ -0,0 +1,40 @@
+#ifndef FRAME_IPC_H
+#define FRAME_IPC_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+struct AppState;
+struct Viewer;
+
+/*
+ * mpv-style control socket.
+ *
+ * Clients connect to a Unix socket and send one command per line, either as
+ * JSON — {"command": ["goto", 5], "request_id": 1} — or as plain text —
+ * "goto 5". Each command gets a one-line JSON reply:
+ * {"data": ..., "request_id": 1, "error": "success"}.
+ *
+ * Commands: next, prev, open <path>, goto <n> (1-based), quit, get-path.
+ *
+ * All socket I/O is non-blocking and happens on the main thread from
+ * ipc_poll(), so commands run in the same context as keyboard input.
+ */
+
+/* Create and listen on the socket at `path`. An existing socket file at that
+   path is replaced. Returns false on error. */
+bool ipc_start(const char *path);
+
+/* Close all connections and remove the socket file. */
+void ipc_stop(void);
+
+/* Check whether the control socket is listening (the main loop must keep
+   ticking to service it). */
+bool ipc_is_active(void);
+
+/* Accept new clients and run any complete commands they sent.
+   Sets *out_quit to true if a client asked Frame to quit.
+   Returns true if the display changed (caller should re-render). */
+bool ipc_poll(struct AppState *app, struct Viewer *viewer, SDL_Window *window, bool *out_quit);
+
+#endif /* FRAME_IPC_H */
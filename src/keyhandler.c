@@ -0,0 +1,128 @@
+#define _GNU_SOURCE
+#include "keyhandler.h"
+#include <ctype.h>
+#include <errno.h>
+#include <pwd.h>
+#include <signal.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/types.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+/* Build the handler path. Returns false if no location can be determined. */
+static bool get_handler_path(char *buf, size_t size)
+{
+    int ret;
+    const char *xdg = getenv("XDG_CONFIG_HOME");
+    if (xdg && xdg[0] == '/') {
+        ret = snprintf(buf, size, "%s/frame/key-handler", xdg);
+    } else {
+        const char *home = getenv("HOME");
+        if (!home) {
+            struct passwd *pw = getpwuid(getuid());
+            home = pw ? pw->pw_dir : NULL;
+        }
+        if (!home) return false;
+        ret = snprintf(buf, size, "%s/.config/frame/key-handler", home);
+    }
+    return ret > 0 && (size_t)ret < size;
+}
+
+bool keyhandler_key_name(SDL_Keycode key, SDL_Keymod mod, char *buf, size_t size)
+{
+    switch (key) {
+    case SDLK_LSHIFT: case SDLK_RSHIFT:
+    case SDLK_LCTRL:  case SDLK_RCTRL:
+    case SDLK_LALT:   case SDLK_RALT:
+    case SDLK_LGUI:   case SDLK_RGUI:
+        return false;
+    default:
+        break;
+    }
+
+    bool shift = (mod & SDL_KMOD_SHIFT) != 0;
+    char name[64];
+
+    if (key < 128 && isgraph((int)key)) {
+        /* Printable: shift is folded into the character for letters ("A") */
+        char c = (char)key;
+        if (shift && islower((unsigned char)c)) {
+            c = (char)toupper((unsigned char)c);
+            shift = false;
+        }
+        snprintf(name, sizeof(name), "%c", c);
+    } else {
+        const char *sdl_name = SDL_GetKeyName(key);
+        if (!sdl_name || sdl_name[0] == '\0') return false;
+        snprintf(name, sizeof(name), "%s", sdl_name);
+    }
+
+    int ret = snprintf(buf, size, "%s%s%s%s",
+                       (mod & SDL_KMOD_CTRL) ? "C-" : "",
+                       (mod & SDL_KMOD_ALT) ? "M-" : "",
+                       shift ? "S-" : "",
+                       name);
+    return ret > 0 && (size_t)ret < size;
+}
+
+bool keyhandler_available(void)
+{
+    char handler[4096];
+    return get_handler_path(handler, sizeof(handler)) && access(handler, X_OK) == 0;
+}
+
+bool keyhandler_run(const char *key, const char *const *paths, int count)
+{
+    char handler[4096];
+    if (!key || !get_handler_path(handler, sizeof(handler))) return false;
+
+    if (access(handler, X_OK) != 0) {
+        fprintf(stderr, "keyhandler: %s is not executable\n", handler);
+        return false;
+    }
+
+    int fds[2];
+    if (pipe(fds) != 0) {
+        perror("keyhandler: pipe");
+        return false;
+    }
+
+    pid_t pid = fork();
+    if (pid < 0) {
+        perror("keyhandler: fork");
+        close(fds[0]);
+        close(fds[1]);
+        return false;
+    }
+
+    if (pid == 0) {
+        dup2(fds[0], STDIN_FILENO);
+        close(fds[0]);
+        close(fds[1]);
+        execl(handler, handler, key, (char *)NULL);
+        _exit(127);
+    }
+
+    close(fds[0]);
+
+    /* A handler that exits without reading must not kill Frame with SIGPIPE */
+    void (*old_pipe)(int) = signal(SIGPIPE, SIG_IGN);
+    FILE *fp = fdopen(fds[1], "w");
+    if (fp) {
+        for (int i = 0; i < count; i++) {
+            if (paths[i]) fprintf(fp, "%s\n", paths[i]);
+        }
+        fclose(fp);
+    } else {
+        close(fds[1]);
+    }
+    signal(SIGPIPE, old_pipe);
+
+    int status = 0;
+    while (waitpid(pid, &status, 0) < 0) {
+        if (errno != EINTR) return false;
+    }
+    return WIFEXITED(status) && WEXITSTATUS(status) == 0;
+}
@@ -0,0 +1,29 @@
+#ifndef FRAME_KEYHANDLER_H
+#define FRAME_KEYHANDLER_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+/*
+ * External key handler, in the style of nsxiv.
+ *
+ * After the prefix key (Ctrl+x), the next keystroke is passed to the
+ * executable $XDG_CONFIG_HOME/frame/key-handler (falling back to
+ * ~/.config/frame/key-handler) as its only argument, e.g. "w", "C-c" or
+ * "S-Left". The affected file paths — the marked images, or the current one
+ * if nothing is marked — are written to its standard input, one per line.
+ * Frame waits for the handler to finish so it can reload changed files.
+ */
+
+/* Build the handler's key name for a key press. Returns false for keys that
+   cannot be passed on (bare modifiers). */
+bool keyhandler_key_name(SDL_Keycode key, SDL_Keymod mod, char *buf, size_t size);
+
+/* Check whether an executable key handler is installed. */
+bool keyhandler_available(void);
+
+/* Run the key handler for `key` with `paths` on stdin.
+   Returns true if the handler ran and exited successfully. */
+bool keyhandler_run(const char *key, const char *const *paths, int count);
+
+#endif /* FRAME_KEYHANDLER_H */
@@ -1,5 +1,9 @@
 #include "loader.h"
+#include "exif.h"
+#include "hdr.h"
+#include "utils.h"
 #include <SDL3_image/SDL_image.h>
+#include <libexif/exif-data.h>
 #include <stdio.h>
 #include <string.h>
 #include <strings.h>
@@ -7,48 +11,48 @@
 #include <sys/mman.h>
 #include <sys/stat.h>
 #include <unistd.h>
+#ifdef HAVE_TURBOJPEG
+#include <turbojpeg.h>
+#endif
 
-/* NULL-terminated array of supported image extensions */
-const char *supported_extensions[] = {
-    ".jpg", ".jpeg", ".png", ".gif", ".webp",
-    ".bmp", ".tiff", ".tif", ".ico", ".apng",
-    NULL
-};
+static bool verbose = false;
 
-/* Extract the file extension from a path (char after last '.', with '.').
-   Returns NULL if no extension found. */
-static const char *get_ext(const char *path)
+void loader_set_verbose(bool enabled)
 {
-    const char *dot = strrchr(path, '.');
-    if (!dot || dot == path)
-        return NULL;
-    return dot;
+    verbose = enabled;
 }
 
-bool loader_is_supported(const char *path)
+/* Check the VP8X header of a WebP file for the animation flag. Still
+   WebPs (plain VP8/VP8L, or VP8X without the flag) are decoded normally. */
+static bool webp_is_animated(const char *path)
 {
-    const char *ext = get_ext(path);
-    if (!ext)
+    int fd = open(path, O_RDONLY);
+    if (fd < 0)
         return false;
 
-    for (int i = 0; supported_extensions[i] != NULL; i++) {
-        if (strcasecmp(ext, supported_extensions[i]) == 0)
-            return true;
-    }
-    return false;
+    unsigned char buf[21];
+    ssize_t n = read(fd, buf, sizeof(buf));
+    close(fd);
+    return n == (ssize_t)sizeof(buf) && memcmp(buf, "RIFF", 4) == 0 &&
+           memcmp(buf + 8, "WEBPVP8X", 8) == 0 && (buf[20] & 0x02);
 }
 
 bool loader_is_animated(const char *path)
 {
-    const char *ext = get_ext(path);
+    const char *ext = image_format_ext(path);
     if (!ext)
         return false;
 
+    if (strcasecmp(ext, ".webp") == 0)
+        return webp_is_animated(path);
     return (strcasecmp(ext, ".gif") == 0 ||
             strcasecmp(ext, ".apng") == 0);
 }
 
-SDL_Surface *loader_load_static(const char *path)
+static SDL_Surface *decode_scaled(const char *path, const void *data, size_t size, int min_size);
+
+/* Map a file and decode it, at reduced size if min_size is not 0 */
+static SDL_Surface *load_file(const char *path, int min_size)
 {
     int fd = open(path, O_RDONLY);
     if (fd < 0) {
@@ -72,16 +76,116 @@ SDL_Surface *loader_load_static(const char *path)
         return NULL;
     }
 
-    SDL_IOStream *stream = SDL_IOFromConstMem(map, size);
+    SDL_Surface *surface = decode_scaled(path, map, size, min_size);
+    munmap(map, size);
+    return surface;
+}
+
+SDL_Surface *loader_load_static(const char *path)
+{
+    return load_file(path, 0);
+}
+
+SDL_Surface *loader_load_scaled(const char *path, int min_size)
+{
+    return load_file(path, min_size > 0 ? min_size : 0);
+}
+
+/* Turn an RGBA8888 surface upright according to its EXIF orientation
+   (1-8). Returns the surface to use; the input is freed if it is replaced. */
+static SDL_Surface *apply_orientation(SDL_Surface *src, int orientation)
+{
+    if (orientation <= 1 || orientation > 8 || src->format != SDL_PIXELFORMAT_RGBA8888)
+        return src;
+
+    int w = src->w, h = src->h;
+    bool swap = orientation >= 5;
+    SDL_Surface *dst = SDL_CreateSurface(swap ? h : w, swap ? w : h, SDL_PIXELFORMAT_RGBA8888);
+    if (!dst)
+        return src;
+
+    SDL_LockSurface(src);
+    SDL_LockSurface(dst);
+    for (int y = 0; y < dst->h; y++) {
+        Uint32 *out = (Uint32 *)((Uint8 *)dst->pixels + (size_t)y * dst->pitch);
+        for (int x = 0; x < dst->w; x++) {
+            int sx, sy;
+            switch (orientation) {
+            case 2: sx = w - 1 - x; sy = y; break;              /* mirrored */
+            case 3: sx = w - 1 - x; sy = h - 1 - y; break;      /* 180 */
+            case 4: sx = x; sy = h - 1 - y; break;              /* flipped */
+            case 5: sx = y; sy = x; break;                      /* transposed */
+            case 6: sx = y; sy = h - 1 - x; break;              /* 90 clockwise */
+            case 7: sx = w - 1 - y; sy = h - 1 - x; break;      /* transversed */
+            default: sx = w - 1 - y; sy = x; break;             /* 270 clockwise */
+            }
+            out[x] = *((Uint32 *)((Uint8 *)src->pixels + (size_t)sy * src->pitch) + sx);
+        }
+    }
+    SDL_UnlockSurface(dst);
+    SDL_UnlockSurface(src);
+    SDL_DestroySurface(src);
+    return dst;
+}
+
+#ifdef HAVE_TURBOJPEG
+/* Decode a JPEG with libjpeg-turbo's SIMD decoder straight into an
+   RGBA8888 surface. With min_size set, the DCT scaling of the decoder
+   skips detail that would be thrown away anyway: the image comes out at
+   the smallest of 1/2, 1/4, 1/8 ... whose longer side is still at least
+   min_size, which is far faster and smaller for 50 MP photos. The scale
+   used goes to *denom. Returns NULL for anything it cannot handle (CMYK,
+   broken files), which SDL_image then gets to try. */
+static SDL_Surface *decode_turbojpeg(const void *data, size_t size, int min_size, int *denom)
+{
+    tjhandle tj = tjInitDecompress();
+    if (!tj)
+        return NULL;
+
+    int w, h, subsamp, colorspace;
+    SDL_Surface *surface = NULL;
+    if (tjDecompressHeader3(tj, data, (unsigned long)size, &w, &h, &subsamp, &colorspace) == 0 &&
+        colorspace != TJCS_CMYK && colorspace != TJCS_YCCK) {
+        int full_w = w, full_h = h;
+        int count = 0;
+        tjscalingfactor *factors = min_size > 0 ? tjGetScalingFactors(&count) : NULL;
+        for (int i = 0; factors && i < count; i++) {
+            /* Only whole fractions (1/n); the list is not sorted */
+            if (factors[i].num != 1 || factors[i].denom <= *denom)
+                continue;
+            int sw = TJSCALED(full_w, factors[i]);
+            int sh = TJSCALED(full_h, factors[i]);
+            if ((sw > sh ? sw : sh) >= min_size) {
+                w = sw;
+                h = sh;
+                *denom = factors[i].denom;
+            }
+        }
+        surface = SDL_CreateSurface(w, h, SDL_PIXELFORMAT_RGBA8888);
+    }
+
+    /* RGBA8888 is a packed format: its bytes are A, B, G, R in memory on
+       little-endian machines */
+    int format = SDL_BYTEORDER == SDL_LIL_ENDIAN ? TJPF_ABGR : TJPF_RGBA;
+    if (surface && tjDecompress2(tj, data, (unsigned long)size, surface->pixels, w, surface->pitch,
+                                 h, format, 0) != 0 && tjGetErrorCode(tj) != TJERR_WARNING) {
+        SDL_DestroySurface(surface);
+        surface = NULL;
+    }
+    tjDestroy(tj);
+    return surface;
+}
+#endif
+
+static SDL_Surface *decode_sdl_image(const char *path, const void *data, size_t size)
+{
+    SDL_IOStream *stream = SDL_IOFromConstMem(data, size);
     if (!stream) {
-        munmap(map, size);
         fprintf(stderr, "mmap_load: SDL_IOFromConstMem failed for '%s'\n", path);
         return NULL;
     }
 
     SDL_Surface *surface = IMG_Load_IO(stream, true); /* closes stream */
-    munmap(map, size);
-
     if (!surface) {
         fprintf(stderr, "mmap_load: IMG_Load_IO failed for '%s': %s\n", path, SDL_GetError());
         return NULL;
@@ -99,6 +203,102 @@ SDL_Surface *loader_load_static(const char *path)
     return surface;
 }
 
+SDL_Surface *loader_decode_memory(const char *path, const void *data, size_t size)
+{
+    return decode_scaled(path, data, size, 0);
+}
+
+static SDL_Surface *decode_scaled(const char *path, const void *data, size_t size, int min_size)
+{
+    /* SDL_image has no Radiance HDR loader; tone-map it ourselves. Files
+       without the .hdr extension are caught by their content. */
+    const char *sniffed = image_sniff_data(data, size);
+    if (hdr_is_radiance(path) || (sniffed && strcmp(sniffed, ".hdr") == 0)) {
+        SDL_Surface *hdr = hdr_decode(data, size);
+        if (!hdr) {
+            fprintf(stderr, "mmap_load: cannot decode HDR image '%s'\n", path);
+            return NULL;
+        }
+        SDL_Surface *converted = SDL_ConvertSurface(hdr, SDL_PIXELFORMAT_RGBA8888);
+        SDL_DestroySurface(hdr);
+        return converted;
+    }
+
+    bool jpeg = sniffed && strcmp(sniffed, ".jpg") == 0;
+    Uint64 start = SDL_GetTicksNS();
+    SDL_Surface *surface = NULL;
+    const char *decoder = "SDL_image";
+    int denom = 1;
+#ifdef HAVE_TURBOJPEG
+    if (jpeg) {
+        surface = decode_turbojpeg(data, size, min_size, &denom);
+        if (surface)
+            decoder = "libjpeg-turbo";
+    }
+#else
+    (void)min_size;
+#endif
+    if (!surface)
+        surface = decode_sdl_image(path, data, size);
+    if (!surface)
+        return NULL;
+
+    if (verbose)
+        fprintf(stderr, "loader: %s: %dx%d decoded by %s at 1/%d in %.1f ms\n", path, surface->w,
+                surface->h, decoder, denom, (double)(SDL_GetTicksNS() - start) / 1e6);
+
+    /* Camera JPEGs are stored sideways and tagged with how to turn them */
+    if (jpeg)
+        surface = apply_orientation(surface, exif_orientation_from_data(data, size));
+    return surface;
+}
+
+SDL_Surface *loader_load_embedded_thumbnail(const char *path)
+{
+    const char *ext = image_format_ext(path);
+    if (!ext || (strcasecmp(ext, ".jpg") != 0 && strcasecmp(ext, ".jpeg") != 0))
+        return NULL;
+
+    /* libexif stops reading once it has the APP1 segment */
+    ExifData *ed = exif_data_new_from_file(path);
+    if (!ed)
+        return NULL;
+
+    SDL_Surface *surface = NULL;
+    if (ed->data && ed->size > 0) {
+        SDL_IOStream *stream = SDL_IOFromConstMem(ed->data, ed->size);
+        if (stream)
+            surface = IMG_Load_IO(stream, true);
+    }
+    exif_data_unref(ed);
+
+    if (surface && surface->format != SDL_PIXELFORMAT_RGBA8888) {
+        SDL_Surface *converted = SDL_ConvertSurface(surface, SDL_PIXELFORMAT_RGBA8888);
+        SDL_DestroySurface(surface);
+        surface = converted;
+    }
+    if (surface)
+        surface = apply_orientation(surface, exif_read_orientation(path));
+    return surface;
+}
+
+SDL_Surface *loader_make_thumbnail(SDL_Surface *surface, int max_size)
+{
+    if (!surface || surface->w <= 0 || surface->h <= 0)
+        return NULL;
+
+    int tw = max_size;
+    int th = max_size;
+    if (surface->w > surface->h) {
+        th = (int)((float)surface->h * max_size / (float)surface->w);
+        if (th < 1) th = 1;
+    } else {
+        tw = (int)((float)surface->w * max_size / (float)surface->h);
+        if (tw < 1) tw = 1;
+    }
+    return SDL_ScaleSurface(surface, tw, th, SDL_SCALEMODE_LINEAR);
+}
+
 SDL_Texture *loader_load_texture(const char *path, SDL_Renderer *renderer)
 {
     SDL_Surface *surface = loader_load_static(path);
@@ -4,15 +4,9 @@
 #include <SDL3/SDL.h>
 #include <stdbool.h>
 
-/* Supported image extensions for directory scanning */
-extern const char *supported_extensions[];
-
-/* Check if a file path has a supported image extension.
-   Case-insensitive comparison. */
-bool loader_is_supported(const char *path);
-
-/* Check if a file extension indicates an animated format (GIF, APNG).
-   Case-insensitive. */
+/* Check if a file is in an animated format (GIF, APNG, animated WebP), by
+   extension or, for files without a supported one, by content. WebP files
+   are always checked for the animation flag in their header. */
 bool loader_is_animated(const char *path);
 
 /* Load a static image from a file. Returns an SDL_Surface or NULL on error.
@@ -20,6 +14,33 @@ bool loader_is_animated(const char *path);
    This function does NOT check the max dimension limit — the caller should do that. */
 SDL_Surface *loader_load_static(const char *path);
 
+/* Log every decode (the decoder used and how long it took) to stderr.
+   Set once at startup, before any image is loaded. */
+void loader_set_verbose(bool enabled);
+
+/* Like loader_load_static, for when only a small rendition is needed
+   (thumbnails, contact sheets): JPEGs are decoded straight at a reduced
+   scale whose longer side is still at least min_size pixels, when Frame
+   was built with libjpeg-turbo. Other images are decoded at full size.
+   The result is not scaled any further; the caller does the final resize. */
+SDL_Surface *loader_load_scaled(const char *path, int min_size);
+
+/* Decode an image file already read into memory. `path` is only used to
+   pick special-cased formats by extension and for error messages. JPEGs
+   go through libjpeg-turbo when Frame was built with it, falling back to
+   SDL_image. The caller owns the returned surface (RGBA8888), or NULL on error. */
+SDL_Surface *loader_decode_memory(const char *path, const void *data, size_t size);
+
+/* Decode the small preview JPEG embedded in a photo's EXIF data, if any.
+   This is much faster than a full decode and is meant to be shown scaled up
+   until the real image is ready. Returns NULL if there is no thumbnail.
+   The caller owns the returned surface. */
+SDL_Surface *loader_load_embedded_thumbnail(const char *path);
+
+/* Scale a decoded image down so its longer side is `max_size` pixels, for
+   the thumbnail cache. Returns a new surface owned by the caller, or NULL. */
+SDL_Surface *loader_make_thumbnail(SDL_Surface *surface, int max_size);
+
 /* Load a static image, then convert it to a texture suitable for the given renderer.
    Returns NULL on error. The caller owns the texture and must call SDL_DestroyTexture().
    This is a convenience wrapper around loader_load_static() + SDL_CreateTextureFromSurface(). */
@@ -29,4 +50,7 @@ SDL_Texture *loader_load_texture(const char *path, SDL_Renderer *renderer);
    Images exceeding this should be rejected by the caller. */
 #define MAX_IMAGE_DIMENSION 16384
 
+/* Longer side of the thumbnails kept for the search grid and previews */
+#define LOADER_THUMB_SIZE 256
+
 #endif /* FRAME_LOADER_H */
@@ -0,0 +1,183 @@
+#define _GNU_SOURCE
+#include "loadjob.h"
+#include "loader.h"
+#include <errno.h>
+#include <fcntl.h>
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <unistd.h>
+
+/* Read size between progress updates and cancellation checks */
+#define LOADJOB_CHUNK (1024 * 1024)
+
+struct LoadJob {
+    pthread_mutex_t mutex;
+    int refs;                /* the worker thread and the owner */
+    bool cancelled;
+    bool done;
+    size_t bytes_read;
+    size_t bytes_total;
+    SDL_Surface *result;
+    char *path;
+};
+
+static void job_unref(LoadJob *job)
+{
+    pthread_mutex_lock(&job->mutex);
+    int refs = --job->refs;
+    pthread_mutex_unlock(&job->mutex);
+    if (refs > 0) return;
+
+    SDL_DestroySurface(job->result);
+    pthread_mutex_destroy(&job->mutex);
+    free(job->path);
+    free(job);
+}
+
+static bool job_cancelled(LoadJob *job)
+{
+    pthread_mutex_lock(&job->mutex);
+    bool cancelled = job->cancelled;
+    pthread_mutex_unlock(&job->mutex);
+    return cancelled;
+}
+
+/* Read the whole file into memory, publishing progress as it goes.
+   Returns a malloc'd buffer or NULL on error or cancellation. */
+static unsigned char *read_file(LoadJob *job, size_t *out_size)
+{
+    int fd = open(job->path, O_RDONLY);
+    if (fd < 0) {
+        fprintf(stderr, "loadjob: failed to open '%s'\n", job->path);
+        return NULL;
+    }
+
+    struct stat st;
+    if (fstat(fd, &st) != 0 || st.st_size <= 0) {
+        fprintf(stderr, "loadjob: failed to stat '%s'\n", job->path);
+        close(fd);
+        return NULL;
+    }
+
+    size_t size = (size_t)st.st_size;
+    unsigned char *data = malloc(size);
+    if (!data) {
+        close(fd);
+        return NULL;
+    }
+
+    pthread_mutex_lock(&job->mutex);
+    job->bytes_total = size;
+    pthread_mutex_unlock(&job->mutex);
+
+    size_t got = 0;
+    while (got < size && !job_cancelled(job)) {
+        size_t want = size - got < LOADJOB_CHUNK ? size - got : LOADJOB_CHUNK;
+        ssize_t n = read(fd, data + got, want);
+        if (n < 0 && errno == EINTR) continue;
+        if (n <= 0) break;
+        got += (size_t)n;
+
+        pthread_mutex_lock(&job->mutex);
+        job->bytes_read = got;
+        pthread_mutex_unlock(&job->mutex);
+    }
+    close(fd);
+
+    if (got < size) {
+        if (!job_cancelled(job)) {
+            fprintf(stderr, "loadjob: short read on '%s'\n", job->path);
+        }
+        free(data);
+        return NULL;
+    }
+    *out_size = size;
+    return data;
+}
+
+static void *job_thread(void *arg)
+{
+    LoadJob *job = arg;
+
+    size_t size = 0;
+    unsigned char *data = read_file(job, &size);
+    SDL_Surface *surface = NULL;
+    if (data && !job_cancelled(job)) {
+        surface = loader_decode_memory(job->path, data, size);
+    }
+    free(data);
+
+    pthread_mutex_lock(&job->mutex);
+    job->result = surface;
+    job->done = true;
+    pthread_mutex_unlock(&job->mutex);
+
+    job_unref(job);
+    return NULL;
+}
+
+LoadJob *loadjob_start(const char *path)
+{
+    if (!path) return NULL;
+
+    LoadJob *job = calloc(1, sizeof(LoadJob));
+    if (!job) return NULL;
+    job->path = strdup(path);
+    if (!job->path || pthread_mutex_init(&job->mutex, NULL) != 0) {
+        free(job->path);
+        free(job);
+        return NULL;
+    }
+    job->refs = 2;
+
+    pthread_t thread;
+    if (pthread_create(&thread, NULL, job_thread, job) != 0) {
+        pthread_mutex_destroy(&job->mutex);
+        free(job->path);
+        free(job);
+        return NULL;
+    }
+    pthread_detach(thread);
+    return job;
+}
+
+bool loadjob_poll(LoadJob *job, float *progress)
+{
+    if (!job) return true;
+
+    pthread_mutex_lock(&job->mutex);
+    bool done = job->done;
+    if (progress) {
+        *progress = job->bytes_total > 0
+            ? (float)job->bytes_read / (float)job->bytes_total : 0.0f;
+    }
+    pthread_mutex_unlock(&job->mutex);
+    return done;
+}
+
+SDL_Surface *loadjob_finish(LoadJob *job)
+{
+    if (!job) return NULL;
+
+    pthread_mutex_lock(&job->mutex);
+    SDL_Surface *surface = job->result;
+    job->result = NULL;
+    pthread_mutex_unlock(&job->mutex);
+
+    job_unref(job);
+    return surface;
+}
+
+void loadjob_cancel(LoadJob *job)
+{
+    if (!job) return;
+
+    pthread_mutex_lock(&job->mutex);
+    job->cancelled = true;
+    pthread_mutex_unlock(&job->mutex);
+
+    job_unref(job);
+}
@@ -0,0 +1,31 @@
+#ifndef FRAME_LOADJOB_H
+#define FRAME_LOADJOB_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+/*
+ * Background load of a single image, used for the image on screen when it
+ * is not cached so a slow source (network share, huge TIFF) doesn't freeze
+ * the window. The file is read in chunks so progress can be reported, then
+ * decoded on the same thread.
+ */
+typedef struct LoadJob LoadJob;
+
+/* Start loading `path` on a new thread. Returns NULL if it could not start. */
+LoadJob *loadjob_start(const char *path);
+
+/* Check whether the job has finished. If progress is non-NULL it receives
+   the fraction of the file read so far (0..1); decoding happens at 1. */
+bool loadjob_poll(LoadJob *job, float *progress);
+
+/* Take the decoded surface from a finished job and free the job.
+   Returns NULL if loading failed. The caller owns the surface. */
+SDL_Surface *loadjob_finish(LoadJob *job);
+
+/* Abandon a job. Reading stops at the next chunk; a decode already under way
+   runs to completion and its result is discarded. The job must not be
+   used afterwards. */
+void loadjob_cancel(LoadJob *job);
+
+#endif /* FRAME_LOADJOB_H */
@@ -4,12 +4,29 @@
 #include <stdio.h>
 #include <stdlib.h>
 #include <stdbool.h>
+#include <time.h>
 
 #include "app.h"
+#include "about.h"
 #include "viewer.h"
 #include "input.h"
 #include "overlay.h"
 #include "search.h"
+#include "info.h"
+#include "completion.h"
+#include "config.h"
+#include "ipc.h"
+#include "dialog.h"
+#include "winstate.h"
+#include "watch.h"
+#include "upload.h"
+#include "theme.h"
+#include "utils.h"
+#include "netfs.h"
+#include "filmstrip.h"
+#include "statusbar.h"
+#include "compare.h"
+#include "loader.h"
 
 #ifdef _WIN32
 /* SDL3 requires SDL_main on some platforms, but we define it ourselves here.
@@ -17,17 +34,125 @@
 #undef main
 #endif
 
-#ifndef FRAME_VERSION
-#define FRAME_VERSION "1.3.3"
-#endif
+/* `frame info [--json] FILE...` — print image metadata without opening a window. */
+static int run_info_command(int argc, char *argv[]) {
+    bool json = false;
+    int file_count = 0;
+
+    for (int i = 0; i < argc; i++) {
+        if (strcmp(argv[i], "--json") == 0) {
+            json = true;
+        } else {
+            file_count++;
+        }
+    }
+
+    if (file_count == 0) {
+        fprintf(stderr, "Usage: frame info [--json] FILE...\n");
+        return 2;
+    }
+
+    int status = 0;
+    int printed = 0;
+    if (json && file_count > 1) printf("[");
+
+    for (int i = 0; i < argc; i++) {
+        if (strcmp(argv[i], "--json") == 0) continue;
+
+        ImageInfo info;
+        if (!info_read(argv[i], &info)) {
+            fprintf(stderr, "frame info: cannot read '%s'\n", argv[i]);
+            status = 1;
+            continue;
+        }
+        info_read_dimensions(&info);
+
+        if (json) {
+            if (printed > 0) printf(",\n");
+            info_write_json(&info, stdout);
+        } else {
+            char *size_str = format_file_size(info.file_size);
+            char time_buf[64] = "";
+            struct tm *tm_info = localtime(&info.modified);
+            if (tm_info) {
+                strftime(time_buf, sizeof(time_buf), "%a, %d %b %Y %H:%M:%S %Z", tm_info);
+            }
+            char *color_text = info_format_color(&info);
+            char *exif_text = info.has_exif ? exif_format(&info.exif) : NULL;
+
+            if (printed > 0) printf("\n");
+            printf("File:       %s\n"
+                   "Path:       %s\n"
+                   "Size:       %s\n"
+                   "Dimensions: %dx%d\n"
+                   "Format:     %s\n"
+                   "Modified:   %s\n"
+                   "%s%s%s",
+                   info.name, info.path, size_str ? size_str : "",
+                   info.width, info.height, info.format, time_buf,
+                   color_text ? color_text : "",
+                   exif_text ? "EXIF:\n" : "", exif_text ? exif_text : "");
+
+            free(size_str);
+            free(color_text);
+            free(exif_text);
+        }
+        printed++;
+        info_free(&info);
+    }
+
+    if (json && file_count > 1) printf("]");
+    if (json && (printed > 0 || file_count > 1)) printf("\n");
+    return status;
+}
 
 int main(int argc, char *argv[]) {
-    if (argc > 1 && (strcmp(argv[1], "-v") == 0 || strcmp(argv[1], "--version") == 0)) {
-        printf("Frame version %s\n", FRAME_VERSION);
+    if (argc > 1 && strcmp(argv[1], "info") == 0) {
+        return run_info_command(argc - 2, argv + 2);
+    }
+
+    if (argc > 1 && strcmp(argv[1], "completion") == 0) {
+        if (argc != 3 || !completion_write(argv[2], stdout)) {
+            fprintf(stderr, "Usage: frame completion bash|zsh|fish\n");
+            return 2;
+        }
         return 0;
     }
 
-    const char *initial_path = (argc > 1) ? argv[1] : NULL;
+    const char *initial_path = NULL;
+    bool read_only = false;
+    const char *ipc_path = NULL;
+
+    for (int i = 1; i < argc; i++) {
+        if (strcmp(argv[i], "-v") == 0 || strcmp(argv[i], "--version") == 0) {
+            printf("Frame version %s\n", FRAME_VERSION);
+            return 0;
+        } else if (strcmp(argv[i], "--read-only") == 0) {
+            read_only = true;
+        } else if (strcmp(argv[i], "--verbose") == 0) {
+            loader_set_verbose(true);
+        } else if (strncmp(argv[i], "--ipc-server=", 13) == 0) {
+            ipc_path = argv[i] + 13;
+        } else if (argv[i][0] == '-' && argv[i][1] != '\0') {
+            fprintf(stderr, "Unknown option: %s\n", argv[i]);
+            return 2;
+        } else if (!initial_path) {
+            initial_path = argv[i];
+        }
+    }
+
+    /* URIs (mtp://, smb://, file://...) are opened through their local mount */
+    char initial_buf[4096];
+    if (initial_path) {
+        initial_path = netfs_local_path(initial_path, initial_buf, sizeof(initial_buf));
+        if (!initial_path) return 1;
+    }
+
+    /* Load user settings, then apply command-line overrides */
+    config_load();
+    if (read_only) {
+        config_set_read_only(true);
+    }
 
     /* Initialize SDL */
     if (!SDL_Init(SDL_INIT_VIDEO)) {
@@ -35,9 +160,11 @@ int main(int argc, char *argv[]) {
         return 1;
     }
 
-    /* Create window */
+    /* Create window with the size it had last time */
+    WindowState win_state;
+    winstate_load(&win_state);
     SDL_Window *window = SDL_CreateWindow(
-        "Frame", 1200, 800,
+        "Frame", win_state.width, win_state.height,
         SDL_WINDOW_RESIZABLE | SDL_WINDOW_HIGH_PIXEL_DENSITY
     );
     if (!window) {
@@ -45,6 +172,7 @@ int main(int argc, char *argv[]) {
         SDL_Quit();
         return 1;
     }
+    winstate_apply(window, &win_state);
 
     /* Create renderer */
     SDL_Renderer *renderer = SDL_CreateRenderer(window, NULL);
@@ -55,43 +183,56 @@ int main(int argc, char *argv[]) {
         return 1;
     }
 
-    /* Set dark background */
-    SDL_SetRenderDrawColor(renderer, 30, 30, 30, 255);
+    /* Pick the colour scheme and set the background */
+    theme_refresh();
+    theme_set_color(renderer, theme_get()->background, 255);
 
     /* Create application components */
     AppState *app = app_create(initial_path);
     Viewer *viewer = viewer_create(renderer);
+    viewer_set_cache_limit(viewer, (size_t)config_get()->cache_size_mb * 1024 * 1024);
+    viewer_set_interpolation(viewer, config_get()->interpolation);
+    viewer_set_initial_view(viewer, config_get()->initial_view);
+    viewer_set_never_upscale(viewer, config_get()->never_upscale);
+    viewer_set_zoom_limits(viewer, config_get()->zoom_min / 100.0f, config_get()->zoom_max / 100.0f,
+                           1.0f + config_get()->zoom_step / 100.0f);
+    viewer_set_pixel_art(viewer, config_get()->pixel_art);
+    viewer_set_ambient(viewer, config_get()->ambient_background);
+    viewer_set_minimap(viewer, config_get()->minimap);
 
     /* Initialize overlay system (fonts) */
     overlay_init();
+    overlay_set_camera_info(config_get()->camera_overlay);
+    filmstrip_set_visible(config_get()->filmstrip);
+    statusbar_set_visible(config_get()->status_bar);
     search_init();
 
     /* Load initial directory and display first image */
     if (initial_path) {
-        app_load_directory(app, initial_path);
-        if (app_current_path(app)) {
-            viewer_load_image(viewer, app_current_path(app));
-            /* Update window title for initial load */
-            const char *path = app_current_path(app);
-            const char *name = strrchr(path, '/');
-            name = name ? name + 1 : path;
-            char title[512];
-            snprintf(title, sizeof(title), "%s (%d/%d) - Frame",
-                     name, app_current_index(app), app_image_count(app));
-            SDL_SetWindowTitle(window, title);
-            printf("Loaded %d images. Current: %s\n",
-                   app_image_count(app), app_current_path(app));
-        } else {
-            printf("No supported images found at: %s\n", initial_path);
-        }
+        /* The folder is read in the background; the loop below picks up
+           the images as they are found */
+        app_load_directory_async(app, initial_path);
+        input_show_current(app, viewer, window);
     } else {
-        printf("No path provided. Drag an image onto the window or use:\n");
+        printf("No path provided. Pick an image, press O for a folder, or use:\n");
         printf("  frame /path/to/image.jpg\n");
+        /* Cancelling leaves the empty-state screen, which offers the
+           choosers again and Quit */
+        if (!ipc_path) {
+            dialog_open_file(window, NULL);
+        }
     }
 
-    /* Track mouse position for scroll zoom */
+    /* Start the control socket if requested */
+    if (ipc_path && !ipc_start(ipc_path)) {
+        fprintf(stderr, "Could not start IPC server at '%s'\n", ipc_path);
+    }
+
+    /* Track mouse position for scrubbing animations */
     float mouse_x = 0.0f, mouse_y = 0.0f;
     bool dragging = false;
+    bool scrubbing = false;     /* dragging the animation scrubber */
+    bool navigating = false;    /* dragging the frame of the navigator */
 
     /* Main event loop */
     SDL_Event event;
@@ -104,17 +245,37 @@ int main(int argc, char *argv[]) {
             timeout_ms = 50;
         } else if (viewer_needs_tick(viewer)) {
             timeout_ms = viewer_is_animated(viewer) ? 10 : 25;
-        } else if (input_nav_pending()) {
+        } else if (input_nav_pending() || app_scan_active(app)) {
             timeout_ms = 25;
+        } else if (overlay_osd_visible() || ipc_is_active() || filmstrip_waiting()) {
+            timeout_ms = 50;
+        } else if (watch_is_active()) {
+            timeout_ms = 250;
         }
 
         if (SDL_WaitEventTimeout(&event, timeout_ms)) {
             do {
+                /* The window has a high pixel density, so the viewer works
+                   in device pixels (100% is one image pixel per device
+                   pixel); mouse positions come in window points */
+                SDL_ConvertEventToRenderCoordinates(renderer, &event);
                 switch (event.type) {
                 case SDL_EVENT_QUIT:
+                    input_settle_rotation(app, viewer, window);
                     running = false;
                     break;
 
+                case SDL_EVENT_LOW_MEMORY:
+                    /* Keep only what is on screen */
+                    viewer_trim_caches(viewer, 0);
+                    break;
+
+                case SDL_EVENT_SYSTEM_THEME_CHANGED:
+                    if (theme_refresh()) {
+                        dirty = true;
+                    }
+                    break;
+
                 case SDL_EVENT_KEY_DOWN: {
                     bool key_dirty = false;
                     if (search_is_active()) {
@@ -122,27 +283,17 @@ int main(int argc, char *argv[]) {
                         if (res == SEARCH_SELECT) {
                             int target_idx = search_selected_index();
                             app_display_image(app, target_idx);
-                            
-                            const char *path = app_current_path(app);
-                            if (path) {
-                                viewer_load_image(viewer, path);
-                                /* Update window title */
-                                const char *name = strrchr(path, '/');
-                                name = name ? name + 1 : path;
-                                char title[512];
-                                snprintf(title, sizeof(title), "%s (%d/%d) - Frame",
-                                         name, app_current_index(app), app_image_count(app));
-                                SDL_SetWindowTitle(window, title);
-                                viewer_prefetch_around(viewer, app);
+                            if (app_current_path(app)) {
+                                input_show_current(app, viewer, window);
                             }
                         }
                         dirty = true;
                     } else {
                         running = input_handle_keyboard(app, viewer, &event.key, window, renderer, &key_dirty);
-                        /* Intercept search request: '/' key from input.c sets key_dirty but isn't handled.
-                           Check if slash key was pressed (without shift) and trigger search. */
-                        if (event.key.key == SDLK_SLASH && !(event.key.mod & SDL_KMOD_SHIFT)) {
-                            search_open(app, viewer, renderer, window);
+                        /* '/' is recognised by input.c but search is owned by the main loop */
+                        SearchMode search_mode;
+                        if (input_take_search_request(&search_mode)) {
+                            search_open(app, viewer, renderer, window, search_mode);
                         }
                         if (key_dirty) {
                             dirty = true;
@@ -159,8 +310,7 @@ int main(int argc, char *argv[]) {
                     break;
 
                 case SDL_EVENT_WINDOW_PIXEL_SIZE_CHANGED:
-                    viewer_handle_resize(viewer,
-                        (int)event.window.data1, (int)event.window.data2);
+                    input_resize_viewer(viewer, window);
                     dirty = true;
                     break;
 
@@ -168,10 +318,55 @@ int main(int argc, char *argv[]) {
                     dirty = true;
                     break;
 
+                case SDL_EVENT_WINDOW_RESIZED:
+                case SDL_EVENT_WINDOW_MOVED:
+                    winstate_track(window);
+                    break;
+
                 case SDL_EVENT_MOUSE_BUTTON_DOWN:
-                    if (!search_is_active() && event.button.button == SDL_BUTTON_LEFT) {
-                        viewer_begin_drag(viewer);
-                        dragging = true;
+                    if (!search_is_active() && !app_current_path(app)) {
+                        /* Buttons of the empty-state screen */
+                        EmptyAction action = event.button.button == SDL_BUTTON_LEFT
+                            ? overlay_empty_hit(event.button.x, event.button.y) : EMPTY_NONE;
+                        if (action == EMPTY_OPEN) {
+                            dialog_open_file(window, NULL);
+                        } else if (action == EMPTY_OPEN_FOLDER) {
+                            dialog_open_folder(window, NULL);
+                        } else if (action == EMPTY_RELOAD && app_current_dir(app)) {
+                            char *dir = strdup(app_current_dir(app));
+                            if (dir) {
+                                app_load_directory_async(app, dir);
+                                free(dir);
+                            }
+                        } else if (action == EMPTY_QUIT) {
+                            running = false;
+                        }
+                    } else if (!search_is_active() && event.button.button == SDL_BUTTON_LEFT) {
+                        int strip_idx = filmstrip_hit(event.button.x, event.button.y);
+                        if (strip_idx >= 0) {
+                            if (strip_idx != app_current_index(app) - 1) {
+                                input_settle_rotation(app, viewer, window);
+                                app_display_image(app, strip_idx);
+                                input_show_current(app, viewer, window);
+                            }
+                        } else if (viewer_minimap_seek(viewer, event.button.x, event.button.y, true)) {
+                            navigating = true;
+                        } else if (input_handle_click(viewer, &event.button, window)) {
+                            /* Double click: fullscreen or zoom toggle */
+                        } else if (viewer_anim_seek_at(viewer, event.button.x, event.button.y)) {
+                            scrubbing = true;
+                        } else {
+                            viewer_begin_drag(viewer);
+                            dragging = true;
+                        }
+                    } else if (!search_is_active() && event.button.button == SDL_BUTTON_MIDDLE) {
+                        input_handle_click(viewer, &event.button, window);
+                    } else if (!search_is_active() && event.button.button == SDL_BUTTON_RIGHT) {
+                        running = input_show_menu(app, viewer, window, renderer);
+                        SearchMode search_mode;
+                        if (input_take_search_request(&search_mode)) {
+                            search_open(app, viewer, renderer, window, search_mode);
+                        }
                     }
                     dirty = true;
                     break;
@@ -180,6 +375,8 @@ int main(int argc, char *argv[]) {
                     if (event.button.button == SDL_BUTTON_LEFT) {
                         viewer_end_drag(viewer);
                         dragging = false;
+                        scrubbing = false;
+                        navigating = false;
                     }
                     dirty = true;
                     break;
@@ -187,16 +384,46 @@ int main(int argc, char *argv[]) {
                 case SDL_EVENT_MOUSE_MOTION:
                     mouse_x = event.motion.x;
                     mouse_y = event.motion.y;
-                    if (dragging) {
+                    if (search_is_active()) {
+                        search_handle_event(&event, window);
+                        dirty = true;
+                    } else if (dragging) {
                         viewer_do_drag(viewer, event.motion.xrel, event.motion.yrel);
                         dirty = true;
+                    } else if (navigating) {
+                        viewer_minimap_seek(viewer, mouse_x, mouse_y, false);
+                        dirty = true;
+                    } else if (scrubbing) {
+                        viewer_anim_seek_at(viewer, mouse_x, mouse_y);
+                        dirty = true;
                     }
                     break;
 
+                case SDL_EVENT_DROP_FILE:
+                    /* An image opens in its folder; a folder opens as a whole */
+                    if (event.drop.data) {
+                        input_settle_rotation(app, viewer, window);
+                        app_load_directory_async(app, event.drop.data);
+                        if (search_is_active()) {
+                            search_close(window);
+                        }
+                        input_show_current(app, viewer, window);
+                        SDL_RaiseWindow(window);
+                    }
+                    dirty = true;
+                    break;
+
                 case SDL_EVENT_MOUSE_WHEEL:
                     if (!search_is_active()) {
-                        viewer_scroll_zoom(viewer, mouse_x, mouse_y,
-                                            event.wheel.y);
+                        /* Over the reference pane, zoom around the same
+                           point of the current image */
+                        SDL_MouseWheelEvent wheel = event.wheel;
+                        int out_w, out_h;
+                        if (compare_is_active() && SDL_GetRenderOutputSize(renderer, &out_w, &out_h) &&
+                            wheel.mouse_x >= compare_pane_width(out_w)) {
+                            wheel.mouse_x -= compare_pane_width(out_w);
+                        }
+                        input_handle_wheel(app, viewer, &wheel, window);
                     }
                     dirty = true;
                     break;
@@ -207,6 +434,87 @@ int main(int argc, char *argv[]) {
             } while (SDL_PollEvent(&event));
         }
 
+        /* Open whatever was picked in an open dialog */
+        char *picked = dialog_take_selection();
+        if (picked) {
+            app_load_directory_async(app, picked);
+            if (search_is_active()) {
+                search_close(window);
+            }
+            input_show_current(app, viewer, window);
+            free(picked);
+            dirty = true;
+        }
+
+        /* Merge images found by a background folder scan */
+        if (app_scan_active(app)) {
+            const char *shown = app_current_path(app);
+            if (app_poll_scan(app)) {
+                if (app_current_path(app) != shown) {
+                    input_show_current(app, viewer, window);
+                } else {
+                    input_list_changed(app, viewer, window);
+                }
+                search_refresh();
+                if (!app_scan_active(app)) {
+                    if (app_current_path(app)) {
+                        printf("Loaded %d images. Current: %s\n",
+                               app_image_count(app), app_current_path(app));
+                    } else {
+                        overlay_show_osd("No supported images found");
+                    }
+                }
+                dirty = true;
+            }
+        }
+
+        /* Watch mode: jump to images as they appear */
+        if (watch_is_active()) {
+            char *added = watch_poll(app_current_path(app));
+            if (added) {
+                if (app_dir_is_remote(app)) {
+                    app_load_directory_async(app, added);
+                } else {
+                    app_load_directory(app, added);
+                }
+                if (search_is_active()) {
+                    search_close(window);
+                }
+                input_show_current(app, viewer, window);
+                const char *name = strrchr(added, '/');
+                char msg[300];
+                snprintf(msg, sizeof(msg), "New image: %s", name ? name + 1 : added);
+                overlay_show_osd(msg);
+                free(added);
+                dirty = true;
+            }
+        }
+
+        /* Finished upload: put the link on the clipboard */
+        char upload_msg[1024];
+        bool upload_ok = false;
+        if (upload_take_result(upload_msg, sizeof(upload_msg), &upload_ok)) {
+            if (upload_ok && SDL_SetClipboardText(upload_msg)) {
+                overlay_show_osd("Uploaded, link copied to clipboard");
+                printf("Uploaded: %s\n", upload_msg);
+            } else {
+                overlay_show_osd(upload_msg);
+            }
+            dirty = true;
+        }
+
+        /* Run commands from IPC clients */
+        if (ipc_is_active()) {
+            bool ipc_quit = false;
+            if (ipc_poll(app, viewer, window, &ipc_quit)) {
+                dirty = true;
+            }
+            if (ipc_quit) {
+                input_settle_rotation(app, viewer, window);
+                running = false;
+            }
+        }
+
         /* Check if we stopped scrolling and need to load the final image */
         if (input_check_and_trigger_nav(app, viewer)) {
             dirty = true;
@@ -217,25 +525,64 @@ int main(int argc, char *argv[]) {
             dirty = true;
         }
 
+        /* The grid took over the thumbnail workers; the filmstrip asks again
+           once it is back, and is redrawn as its thumbnails arrive */
+        if (search_is_active()) {
+            filmstrip_invalidate();
+        } else if (filmstrip_check_dirty(viewer)) {
+            dirty = true;
+        }
+
+        /* Remove the OSD message once it has expired */
+        if (overlay_osd_tick()) {
+            dirty = true;
+        }
+
         /* Advance animation frames */
         if (viewer_animation_tick(viewer)) {
             dirty = true;
         }
+        if (viewer_take_load_error(viewer) && app_current_path(app)) {
+            const char *path = app_current_path(app);
+            const char *name = strrchr(path, '/');
+            char msg[300];
+            snprintf(msg, sizeof(msg), "Cannot decode %s", name ? name + 1 : path);
+            overlay_show_osd(msg);
+            dirty = true;
+        }
+        input_refresh_title(app, viewer, window);
 
         /* Render only if state is dirty */
         if (dirty && running) {
             viewer_render(viewer, renderer);
+            if (!search_is_active()) {
+                compare_render(renderer, viewer);
+                overlay_render_camera(renderer, viewer_get_path(viewer));
+                if (app_current_path(app)) {
+                    filmstrip_render(renderer, app, viewer);
+                    statusbar_render(renderer, app, viewer);
+                }
+            }
+            if (!app_current_path(app) && !viewer_is_loading(viewer) && !search_is_active()) {
+                overlay_render_empty(renderer, app_current_dir(app), app_scan_active(app));
+            }
             overlay_render(renderer);
             if (search_is_active()) {
                 search_render(renderer);
             }
+            overlay_render_osd(renderer);
             SDL_RenderPresent(renderer);
             dirty = false;
         }
     }
 
     /* Cleanup */
+    winstate_save(window);
+    watch_stop();
+    ipc_stop();
     search_shutdown();
+    filmstrip_shutdown();
+    compare_clear();
     overlay_shutdown();
     viewer_destroy(viewer);
     app_destroy(app);
@@ -0,0 +1,232 @@
+#define _DEFAULT_SOURCE
+#include "netfs.h"
+#include <ctype.h>
+#include <dirent.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <strings.h>
+#include <sys/vfs.h>
+#include <unistd.h>
+
+/* Filesystem magic numbers (linux/magic.h and the filesystems' sources) */
+#define NFS_SUPER_MAGIC   0x6969
+#define SMB_SUPER_MAGIC   0x517B
+#define CIFS_SUPER_MAGIC  0xFF534D42
+#define SMB2_SUPER_MAGIC  0xFE534D42
+#define FUSE_SUPER_MAGIC  0x65735546
+#define V9FS_MAGIC        0x01021997
+#define CEPH_SUPER_MAGIC  0x00C36400
+#define AFS_FS_MAGIC      0x6B414653
+#define CODA_SUPER_MAGIC  0x73757245
+
+bool netfs_is_remote(const char *path)
+{
+    if (!path)
+        return false;
+
+    /* gvfs mounts are FUSE too, but the path alone gives them away */
+    if (strstr(path, "/gvfs/"))
+        return true;
+
+    struct statfs sfs;
+    if (statfs(path, &sfs) != 0)
+        return false;
+
+    /* Most FUSE filesystems on a desktop are network ones (sshfs, rclone,
+       gvfs); the local exceptions only lose some prefetching */
+    switch ((unsigned long)sfs.f_type) {
+    case NFS_SUPER_MAGIC:
+    case SMB_SUPER_MAGIC:
+    case CIFS_SUPER_MAGIC:
+    case SMB2_SUPER_MAGIC:
+    case FUSE_SUPER_MAGIC:
+    case V9FS_MAGIC:
+    case CEPH_SUPER_MAGIC:
+    case AFS_FS_MAGIC:
+    case CODA_SUPER_MAGIC:
+        return true;
+    default:
+        return false;
+    }
+}
+
+/* ---- URIs ---- */
+
+/* Check for "scheme://" */
+static bool is_uri(const char *s)
+{
+    if (!isalpha((unsigned char)*s))
+        return false;
+    while (isalnum((unsigned char)*s) || *s == '+' || *s == '-' || *s == '.')
+        s++;
+    return strncmp(s, "://", 3) == 0;
+}
+
+/* Copy at most len bytes of src into dst, decoding %XX escapes.
+   Returns false if the result does not fit. */
+static bool percent_decode(const char *src, size_t len, char *dst, size_t size)
+{
+    size_t n = 0;
+    for (size_t i = 0; i < len && src[i]; i++) {
+        char c = src[i];
+        if (c == '%' && i + 2 < len && isxdigit((unsigned char)src[i + 1]) &&
+            isxdigit((unsigned char)src[i + 2])) {
+            char hex[3] = {src[i + 1], src[i + 2], '\0'};
+            c = (char)strtol(hex, NULL, 16);
+            i += 2;
+        }
+        if (n + 1 >= size)
+            return false;
+        dst[n++] = c;
+    }
+    dst[n] = '\0';
+    return true;
+}
+
+/* Find `key=value` among the comma-separated settings of a gvfs mount
+   name such as "smb-share:server=nas,share=photos" (values are escaped). */
+static bool mount_has(const char *settings, const char *key, const char *value)
+{
+    size_t key_len = strlen(key);
+    for (const char *p = settings; *p; ) {
+        size_t len = strcspn(p, ",");
+        if (len > key_len && strncmp(p, key, key_len) == 0 && p[key_len] == '=') {
+            char decoded[256];
+            if (percent_decode(p + key_len + 1, len - key_len - 1, decoded, sizeof(decoded)) &&
+                strcasecmp(decoded, value) == 0)
+                return true;
+        }
+        p += len;
+        if (*p == ',')
+            p++;
+    }
+    return false;
+}
+
+/* Locate the gvfs mount for a gio URI and append the rest of its path. */
+static const char *gvfs_path(const char *uri, char *buf, size_t size)
+{
+    char scheme[16];
+    size_t scheme_len = strcspn(uri, ":");
+    if (scheme_len >= sizeof(scheme))
+        return NULL;
+    memcpy(scheme, uri, scheme_len);
+    scheme[scheme_len] = '\0';
+    for (char *c = scheme; *c; c++)
+        *c = (char)tolower((unsigned char)*c);
+
+    /* Authority: [user@]host[:port] */
+    const char *auth = uri + scheme_len + 3;
+    size_t auth_len = strcspn(auth, "/");
+    const char *path = auth + auth_len;
+
+    char user[128] = "", host[256] = "", port[16] = "";
+    const char *at = memchr(auth, '@', auth_len);
+    const char *host_start = auth;
+    if (at) {
+        percent_decode(auth, (size_t)(at - auth), user, sizeof(user));
+        host_start = at + 1;
+    }
+    size_t host_len = (size_t)(auth + auth_len - host_start);
+    const char *colon = memchr(host_start, ':', host_len);
+    if (colon && strcmp(scheme, "mtp") != 0 && strcmp(scheme, "gphoto2") != 0) {
+        percent_decode(colon + 1, (size_t)(auth + auth_len - colon - 1), port, sizeof(port));
+        host_len = (size_t)(colon - host_start);
+    }
+    percent_decode(host_start, host_len, host, sizeof(host));
+    if (!host[0])
+        return NULL;
+
+    /* SMB mounts are per share: the first path component */
+    char share[256] = "";
+    const char *prefix = scheme;
+    const char *host_key = "host";
+    if (strcmp(scheme, "smb") == 0) {
+        while (*path == '/')
+            path++;
+        size_t share_len = strcspn(path, "/");
+        percent_decode(path, share_len, share, sizeof(share));
+        path += share_len;
+        if (!share[0])
+            return NULL;
+        prefix = "smb-share";
+        host_key = "server";
+    }
+
+    char gvfs[512];
+    const char *runtime = getenv("XDG_RUNTIME_DIR");
+    if (runtime && runtime[0] == '/')
+        snprintf(gvfs, sizeof(gvfs), "%s/gvfs", runtime);
+    else
+        snprintf(gvfs, sizeof(gvfs), "/run/user/%u/gvfs", (unsigned)getuid());
+
+    DIR *dp = opendir(gvfs);
+    if (!dp)
+        return NULL;
+
+    size_t prefix_len = strlen(prefix);
+    const char *found = NULL;
+    struct dirent *entry;
+    while (!found && (entry = readdir(dp)) != NULL) {
+        const char *name = entry->d_name;
+        if (strncmp(name, prefix, prefix_len) != 0 || name[prefix_len] != ':')
+            continue;
+        const char *settings = name + prefix_len + 1;
+        if (!mount_has(settings, host_key, host) ||
+            (share[0] && !mount_has(settings, "share", share)) ||
+            (user[0] && !mount_has(settings, "user", user)) ||
+            (port[0] && !mount_has(settings, "port", port)))
+            continue;
+
+        char rest[4096];
+        if (!percent_decode(path, strlen(path), rest, sizeof(rest)))
+            break;
+        int ret = snprintf(buf, size, "%s/%s%s", gvfs, name, rest);
+        if (ret > 0 && (size_t)ret < size)
+            found = buf;
+    }
+    closedir(dp);
+    return found;
+}
+
+const char *netfs_local_path(const char *arg, char *buf, size_t size)
+{
+    if (!arg || !is_uri(arg))
+        return arg;
+
+    if (strncasecmp(arg, "file://", 7) == 0) {
+        const char *path = arg + 7;
+        if (strncasecmp(path, "localhost/", 10) == 0)
+            path += 9;
+        if (*path == '/' && percent_decode(path, strlen(path), buf, size))
+            return buf;
+    } else if (strncasecmp(arg, "trash://", 8) == 0) {
+        /* Only the home trash; the per-drive ones are not addressed by URI */
+        char name[1024];
+        const char *rest = arg + 8;
+        while (*rest == '/')
+            rest++;
+        const char *data = getenv("XDG_DATA_HOME");
+        const char *home = getenv("HOME");
+        int ret = -1;
+        if (percent_decode(rest, strlen(rest), name, sizeof(name))) {
+            if (data && data[0] == '/')
+                ret = snprintf(buf, size, "%s/Trash/files/%s", data, name);
+            else if (home)
+                ret = snprintf(buf, size, "%s/.local/share/Trash/files/%s", home, name);
+        }
+        if (ret > 0 && (size_t)ret < size)
+            return buf;
+    } else {
+        const char *path = gvfs_path(arg, buf, size);
+        if (path)
+            return path;
+        fprintf(stderr, "netfs: '%s' is not mounted; open it in the file manager "
+                        "or run 'gio mount %s' first\n", arg, arg);
+        return NULL;
+    }
+
+    fprintf(stderr, "netfs: cannot use '%s'\n", arg);
+    return NULL;
+}
@@ -0,0 +1,22 @@
+#ifndef FRAME_NETFS_H
+#define FRAME_NETFS_H
+
+#include <stdbool.h>
+#include <stddef.h>
+
+/* Check whether a path lies on a network or user-space mount (NFS, SMB/CIFS,
+   sshfs, and gvfs, which is how phones over MTP and browsed shares show up),
+   where every file access may take a round trip. Frame reads less eagerly
+   from such folders. */
+bool netfs_is_remote(const char *path);
+
+/* Turn a command-line or IPC argument into a local path. Plain paths are
+   returned as they are. URIs are mapped to where they can be read: file://
+   to the path it names, trash:// to the home trash, and gio locations
+   (mtp://, smb://, sftp://, ftp://, dav://, gphoto2://, afc://) to their
+   gvfs FUSE mount under $XDG_RUNTIME_DIR/gvfs. The result is written to buf.
+   Returns NULL, with a message, for a URI that is not mounted or cannot be
+   mapped. */
+const char *netfs_local_path(const char *arg, char *buf, size_t size);
+
+#endif /* FRAME_NETFS_H */
@@ -0,0 +1,350 @@
+#define _DEFAULT_SOURCE
+#include "openwith.h"
+#include "utils.h"
+#include <dirent.h>
+#include <errno.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <strings.h>
+#include <sys/stat.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+/* Desktop IDs already seen; an entry in an earlier data dir hides later
+   ones with the same ID even if it does not handle the type */
+typedef struct {
+    char **ids;
+    int count, capacity;
+} IdSet;
+
+typedef struct {
+    OpenWithApp *apps;
+    int count, capacity;
+} AppList;
+
+/* ---- helpers ---- */
+
+static bool id_seen(IdSet *set, const char *id)
+{
+    for (int i = 0; i < set->count; i++) {
+        if (strcmp(set->ids[i], id) == 0) return true;
+    }
+    if (set->count >= set->capacity) {
+        int cap = set->capacity ? set->capacity * 2 : 64;
+        char **tmp = realloc(set->ids, (size_t)cap * sizeof(char *));
+        if (!tmp) return false;
+        set->ids = tmp;
+        set->capacity = cap;
+    }
+    char *copy = strdup(id);
+    if (copy) set->ids[set->count++] = copy;
+    return false;
+}
+
+/* Check whether a ';'-separated list contains `item` */
+static bool list_contains(const char *list, const char *item)
+{
+    size_t len = strlen(item);
+    for (const char *p = list; *p; ) {
+        const char *end = strchr(p, ';');
+        size_t n = end ? (size_t)(end - p) : strlen(p);
+        if (n == len && strncasecmp(p, item, len) == 0) return true;
+        if (!end) break;
+        p = end + 1;
+    }
+    return false;
+}
+
+/* Read the [Desktop Entry] group of a desktop file. Returns true if it is a
+   visible application handling `mime`. */
+static bool read_desktop_entry(const char *path, const char *mime, OpenWithApp *out)
+{
+    FILE *fp = fopen(path, "r");
+    if (!fp) return false;
+
+    bool in_entry = false, is_app = false, hidden = false, handles = false;
+    out->name[0] = '\0';
+    out->exec[0] = '\0';
+
+    char line[2048];
+    while (fgets(line, sizeof(line), fp)) {
+        line[strcspn(line, "\r\n")] = '\0';
+        if (line[0] == '[') {
+            in_entry = strcmp(line, "[Desktop Entry]") == 0;
+            continue;
+        }
+        if (!in_entry) continue;
+
+        char *eq = strchr(line, '=');
+        if (!eq) continue;
+        *eq = '\0';
+        const char *key = line, *value = eq + 1;
+
+        if (strcmp(key, "Type") == 0) {
+            is_app = strcmp(value, "Application") == 0;
+        } else if (strcmp(key, "Name") == 0) {
+            snprintf(out->name, sizeof(out->name), "%s", value);
+        } else if (strcmp(key, "Exec") == 0) {
+            snprintf(out->exec, sizeof(out->exec), "%s", value);
+        } else if (strcmp(key, "Hidden") == 0) {
+            hidden = strcmp(value, "true") == 0;
+        } else if (strcmp(key, "MimeType") == 0) {
+            handles = list_contains(value, mime);
+        }
+    }
+    fclose(fp);
+
+    return is_app && !hidden && handles && out->name[0] && out->exec[0];
+}
+
+/* Look for handlers in an applications folder. Files in subfolders get
+   IDs with '-' for '/', as the desktop entry spec says. */
+static void scan_applications(const char *dir, const char *prefix, const char *mime,
+                              IdSet *seen, AppList *list, int depth)
+{
+    DIR *dp = opendir(dir);
+    if (!dp) return;
+
+    struct dirent *entry;
+    while ((entry = readdir(dp)) != NULL) {
+        const char *name = entry->d_name;
+        if (name[0] == '.') continue;
+
+        char path[4096], id[256];
+        int path_len = snprintf(path, sizeof(path), "%s/%s", dir, name);
+        int id_len = snprintf(id, sizeof(id), "%s%s", prefix, name);
+        if (path_len < 0 || (size_t)path_len >= sizeof(path) ||
+            id_len < 0 || (size_t)id_len >= sizeof(id) - 1) continue;
+
+        struct stat st;
+        if (stat(path, &st) != 0) continue;
+        if (S_ISDIR(st.st_mode)) {
+            if (depth < 2) {
+                char sub_prefix[256];
+                memcpy(sub_prefix, id, (size_t)id_len);
+                sub_prefix[id_len] = '-';
+                sub_prefix[id_len + 1] = '\0';
+                scan_applications(path, sub_prefix, mime, seen, list, depth + 1);
+            }
+            continue;
+        }
+
+        size_t len = strlen(name);
+        if (len < 9 || strcmp(name + len - 8, ".desktop") != 0) continue;
+        if (id_seen(seen, id)) continue;
+        if (strcmp(id, "frame.desktop") == 0) continue;
+
+        OpenWithApp app;
+        if (!read_desktop_entry(path, mime, &app)) continue;
+        snprintf(app.id, sizeof(app.id), "%s", id);
+
+        if (list->count >= list->capacity) {
+            int cap = list->capacity ? list->capacity * 2 : 16;
+            OpenWithApp *tmp = realloc(list->apps, (size_t)cap * sizeof(OpenWithApp));
+            if (!tmp) break;
+            list->apps = tmp;
+            list->capacity = cap;
+        }
+        list->apps[list->count++] = app;
+    }
+    closedir(dp);
+}
+
+/* Get the remembered application ID for a MIME type into buf (empty if none) */
+static void read_last_used(const char *mime, char *buf, size_t size)
+{
+    buf[0] = '\0';
+    char path[4096];
+    if (!state_path("open-with", path, sizeof(path))) return;
+    FILE *fp = fopen(path, "r");
+    if (!fp) return;
+
+    char line[512];
+    size_t mime_len = strlen(mime);
+    while (fgets(line, sizeof(line), fp)) {
+        line[strcspn(line, "\r\n")] = '\0';
+        if (strncmp(line, mime, mime_len) == 0 && line[mime_len] == '=') {
+            snprintf(buf, size, "%s", line + mime_len + 1);
+            break;
+        }
+    }
+    fclose(fp);
+}
+
+/* Store the application for a MIME type, keeping the other entries */
+static void write_last_used(const char *mime, const char *id)
+{
+    char dir[4096], path[4096], tmp_path[4200];
+    if (!state_path("", dir, sizeof(dir)) ||
+        !state_path("open-with", path, sizeof(path)) || !make_dirs(dir)) {
+        fprintf(stderr, "openwith: cannot create the state directory\n");
+        return;
+    }
+    snprintf(tmp_path, sizeof(tmp_path), "%s.tmp", path);
+
+    FILE *out = fopen(tmp_path, "w");
+    if (!out) return;
+    fprintf(out, "%s=%s\n", mime, id);
+
+    FILE *in = fopen(path, "r");
+    if (in) {
+        char line[512];
+        size_t mime_len = strlen(mime);
+        while (fgets(line, sizeof(line), in)) {
+            if (strncmp(line, mime, mime_len) == 0 && line[mime_len] == '=') continue;
+            fputs(line, out);
+        }
+        fclose(in);
+    }
+
+    if (fclose(out) != 0 || rename(tmp_path, path) != 0) {
+        fprintf(stderr, "openwith: cannot write '%s'\n", path);
+        unlink(tmp_path);
+    }
+}
+
+static const char *remembered_id;
+
+static int compare_apps(const void *a, const void *b)
+{
+    const OpenWithApp *x = a, *y = b;
+    bool xr = strcmp(x->id, remembered_id) == 0;
+    bool yr = strcmp(y->id, remembered_id) == 0;
+    if (xr != yr) return xr ? -1 : 1;
+    return strcasecmp(x->name, y->name);
+}
+
+/* Turn an Exec line into a shell command where the file is "$1". Field
+   codes for files and URLs become the file; the others are dropped. */
+static bool build_command(const char *exec, char *buf, size_t size)
+{
+    size_t n = 0;
+    bool has_file = false;
+    bool quoted = false;    /* inside a "..." argument */
+    for (const char *p = exec; *p; p++) {
+        const char *add = NULL;
+        char one[3] = { *p, '\0', '\0' };
+        if (*p == '\\' && p[1]) {
+            one[1] = *++p;
+            add = one;
+        } else if (*p == '%' && p[1]) {
+            p++;
+            switch (*p) {
+            case 'f': case 'F': case 'u': case 'U':
+                add = quoted ? "$1" : "\"$1\"";
+                has_file = true;
+                break;
+            case '%':
+                add = "%";
+                break;
+            default:
+                add = "";       /* %i, %c, %k and deprecated codes */
+                break;
+            }
+        } else {
+            if (*p == '"') quoted = !quoted;
+            add = one;
+        }
+        size_t len = strlen(add);
+        if (n + len + 1 > size) return false;
+        memcpy(buf + n, add, len);
+        n += len;
+    }
+
+    /* Entries without a field code still get the file */
+    if (!has_file) {
+        if (n + 6 > size) return false;
+        memcpy(buf + n, " \"$1\"", 5);
+        n += 5;
+    }
+    buf[n] = '\0';
+    return true;
+}
+
+/* ---- public API ---- */
+
+const char *openwith_mime_type(const char *path)
+{
+    const ImageFormat *fmt = path ? image_format_from_ext(image_format_ext(path)) : NULL;
+    return fmt ? fmt->mime : NULL;
+}
+
+int openwith_list(const char *path, OpenWithApp **out_apps)
+{
+    *out_apps = NULL;
+    const char *mime = openwith_mime_type(path);
+    if (!mime) return 0;
+
+    IdSet seen = {0};
+    AppList list = {0};
+    char dir[4096];
+
+    /* User entries first, then the system data dirs in order */
+    const char *data_home = getenv("XDG_DATA_HOME");
+    const char *home = home_dir();
+    if (data_home && data_home[0] == '/') {
+        snprintf(dir, sizeof(dir), "%s/applications", data_home);
+        scan_applications(dir, "", mime, &seen, &list, 0);
+    } else if (home) {
+        snprintf(dir, sizeof(dir), "%s/.local/share/applications", home);
+        scan_applications(dir, "", mime, &seen, &list, 0);
+    }
+
+    const char *data_dirs = getenv("XDG_DATA_DIRS");
+    if (!data_dirs || !data_dirs[0]) data_dirs = "/usr/local/share:/usr/share";
+    for (const char *p = data_dirs; *p; ) {
+        const char *end = strchr(p, ':');
+        size_t len = end ? (size_t)(end - p) : strlen(p);
+        if (len > 0 && p[0] == '/') {
+            snprintf(dir, sizeof(dir), "%.*s/applications", (int)len, p);
+            scan_applications(dir, "", mime, &seen, &list, 0);
+        }
+        if (!end) break;
+        p = end + 1;
+    }
+
+    for (int i = 0; i < seen.count; i++) free(seen.ids[i]);
+    free(seen.ids);
+
+    char last[256];
+    read_last_used(mime, last, sizeof(last));
+    remembered_id = last;
+    if (list.count > 1) {
+        qsort(list.apps, (size_t)list.count, sizeof(OpenWithApp), compare_apps);
+    }
+    remembered_id = NULL;
+
+    *out_apps = list.apps;
+    return list.count;
+}
+
+bool openwith_launch(const OpenWithApp *app, const char *path)
+{
+    if (!app || !path) return false;
+
+    char cmd[2048];
+    if (!build_command(app->exec, cmd, sizeof(cmd))) {
+        fprintf(stderr, "openwith: Exec line of '%s' is too long\n", app->id);
+        return false;
+    }
+
+    /* Double fork so the application outlives Frame's children and never
+       becomes a zombie */
+    pid_t pid = fork();
+    if (pid < 0) {
+        perror("openwith: fork");
+        return false;
+    }
+    if (pid == 0) {
+        if (fork() != 0) _exit(0);
+        setsid();
+        execl("/bin/sh", "sh", "-c", cmd, "frame-open", path, (char *)NULL);
+        _exit(127);
+    }
+    waitpid(pid, NULL, 0);
+
+    const char *mime = openwith_mime_type(path);
+    if (mime) write_last_used(mime, app->id);
+    return true;
+}
@@ -0,0 +1,30 @@
+#ifndef FRAME_OPENWITH_H
+#define FRAME_OPENWITH_H
+
+#include <stdbool.h>
+
+/* "Open with" support: the applications that declare the image's MIME type
+   in their freedesktop.org desktop entry ($XDG_DATA_HOME/applications and
+   each $XDG_DATA_DIRS/applications). The application last used for a MIME
+   type is remembered in $XDG_STATE_HOME/frame/open-with. */
+
+typedef struct {
+    char id[256];      /* desktop file ID, e.g. "gimp.desktop" */
+    char name[128];    /* display name */
+    char exec[1024];   /* Exec line with field codes */
+} OpenWithApp;
+
+/* Get the MIME type Frame uses for a file, from its extension or, without
+   a supported one, its content. Returns NULL for anything else. */
+const char *openwith_mime_type(const char *path);
+
+/* List the applications that can open `path`, the last used one first and
+   the rest by name; Frame itself is left out. Returns the count and a
+   malloc'd array in *out_apps (NULL if there are none). */
+int openwith_list(const char *path, OpenWithApp **out_apps);
+
+/* Start the application on `path`, detached from Frame, and remember it for
+   the file's MIME type. Returns false if it could not be started. */
+bool openwith_launch(const OpenWithApp *app, const char *path);
+
+#endif /* FRAME_OPENWITH_H */
@@ -1,6 +1,10 @@
 #define _GNU_SOURCE
 #include "overlay.h"
 #include "viewer.h"
+#include "theme.h"
+#include "exif.h"
+#include "filmstrip.h"
+#include "statusbar.h"
 #include <SDL3_ttf/SDL_ttf.h>
 #include <stdio.h>
 #include <stdlib.h>
@@ -39,7 +43,18 @@ static HelpShortcut help_nav[] = {
     {"j / \xe2\x86\x93", "Next image (alt)"},
     {"k / \xe2\x86\x91", "Previous image (alt)"},
     {"gg", "First image"},
-    {"G", "Last image"}
+    {"G", "Last image"},
+    {"Space / Bksp", "Next / previous image"},
+    {"PgDn / PgUp", "Next / previous image"},
+    {"Home / End", "First / last image"},
+    {"[ / ]", "Previous / next sibling folder"},
+    {"Scroll", "Zoom, navigate or pan (setting)"},
+    {"Ctrl+Scroll", "Zoom with wheel"},
+    {"Shift+Scroll", "Pan with wheel"},
+    {"Drag", "Pan with mouse"},
+    {"N", "Navigator when zoomed in (drag to pan)"},
+    {"Double click", "Fullscreen (configurable)"},
+    {"Middle click", "100% / fit (configurable)"}
 };
 
 static HelpShortcut help_view[] = {
@@ -48,27 +63,95 @@ static HelpShortcut help_view[] = {
     {"- / x", "Zoom out"},
     {"0", "Fit to window"},
     {"1", "Original size (1:1)"},
-    {"Scroll", "Zoom with wheel"},
-    {"Drag", "Pan with mouse"}
+    {"%", "Zoom to an exact level"},
+    {"Ctrl + h/j/k/l", "Pan with keyboard"},
+    {"p", "Toggle pixel-art mode"},
+    {"P", "Cycle interpolation"},
+    {"n", "Toggle never upscaling when fitting"},
+    {"a", "Toggle ambient background"},
+    {"A", "Cycle background colour"},
+    {"v", "Cycle colour-blindness simulation"},
+    {"c", "Toggle clipping warning"},
+    {"b / B", "Exposure up / down (view only)"},
+    {"y / Y", "Gamma up / down (view only)"},
+    {"\\", "Reset view adjustments"},
+    {"Space", "Play / pause animation"},
+    {", / .", "Previous / next frame"},
+    {"e", "Export frame as PNG"}
 };
 
 static HelpShortcut help_ops[] = {
     {"r", "Rotate CW 90\xc2\xb0"},
     {"R", "Rotate CCW 90\xc2\xb0"},
+    {"F / V", "Flip horizontally / vertically"},
+    {"Ctrl+s", "Save rotation / flip to file"},
+    {"Ctrl+z / Ctrl+Z", "Undo / redo rotate or zoom"},
     {"d / Del", "Delete image"},
     {"F2", "Rename image"},
-    {"i", "Show image info"}
+    {"Shift+F2", "Prefix / suffix marked names"},
+    {"t / T", "Edit location / strip from marked"},
+    {"Ctrl+d", "Shift capture time of marked"},
+    {"i", "Show image info (c / a: copy)"},
+    {"I", "Copy camera settings line"},
+    {"s", "Camera settings overlay"},
+    {"S", "Thumbnail filmstrip"},
+    {"m", "Mark / unmark image"},
+    {"M", "Clear all marks"}
 };
 
 static HelpShortcut help_gen[] = {
     {"/", "Search images grid"},
+    {"Ctrl+p", "Quick switcher (jump by name)"},
+    {"o / O", "Open image / folder"},
+    {"Ctrl+o", "Open with another app"},
+    {"w", "Watch folder for new images"},
+    {"u", "Upload image, copy link"},
+    {"Ctrl+c", "Copy as data URI"},
+    {"Ctrl+e", "Export to PDF (+Shift: all)"},
+    {"Ctrl+t", "Contact sheet"},
+    {"Ctrl+g", "Animated GIF from marked"},
+    {"Ctrl+b", "Status bar"},
+    {"Ctrl+r", "Compare with pinned reference"},
+    {"F10 / Right click", "Action menu"},
     {"?", "Show this help"},
-    {"q / Esc", "Quit"}
+    {"F1", "About Frame (versions, decoders)"},
+    {"Ctrl+x <key>", "Run key handler"},
+    {"C", "Clear image caches"},
+    {"Esc", "Close overlay, load, compare, fullscreen"},
+    {"q / Esc", "Quit (Esc once nothing else is open)"}
 };
 
+/* Image Information table: "Key: value" lines of the body, with "EXIF:"
+   starting a section */
+#define INFO_TITLE "Image Information"
+#define INFO_MAX_ROWS 128
+
+typedef struct {
+    char key[64];
+    char val[256];
+    bool is_header;
+} InfoRow;
+
+static int info_selected = -1; /* row chosen for copying, -1 if none */
+
+/* Camera settings in the corner of the viewer, like in-camera playback */
+static bool camera_info = false;
+static char *camera_path = NULL;  /* image camera_line was read for */
+static char *camera_line = NULL;  /* NULL if that image has no settings */
+
+/* Transient on-screen message */
+#define OSD_DURATION_MS 1500
+static char osd_text[256] = {0};
+static Uint64 osd_until = 0;
+
+/* Palette the cached text textures were rendered with */
+static const ThemePalette *text_palette = NULL;
+
 /* For entry dialog */
 static char entry_buffer[512] = {0};  /* text being edited */
 static int entry_cursor = 0;          /* cursor position (not visually rendered, just logical) */
+static char entry_warning[160] = {0}; /* result of the entry check, shown under the field */
+static bool entry_fatal = false;      /* the warning blocks Enter */
 
 bool overlay_init(void)
 {
@@ -133,6 +216,8 @@ bool overlay_modal_confirm(const char *title_text, const char *message,
         while (SDL_PollEvent(&e)) {
             switch (e.type) {
             case SDL_EVENT_QUIT:
+                /* Closing the window cancels the dialog, then quits */
+                SDL_PushEvent(&e);
                 overlay_hide();
                 return false;
 
@@ -168,12 +253,120 @@ bool overlay_modal_confirm(const char *title_text, const char *message,
     return false;
 }
 
+/* ================================================================
+   Choice dialog
+   ================================================================ */
+
+/* Most items shown at once; the list scrolls around the selection */
+#define CHOOSE_VISIBLE 12
+
+/* Show the list with the selected item marked */
+static void show_choices(const char *title_text, const char *const *items, int count,
+                         int selected) {
+    int first = selected - CHOOSE_VISIBLE / 2;
+    if (first > count - CHOOSE_VISIBLE) first = count - CHOOSE_VISIBLE;
+    if (first < 0) first = 0;
+
+    char body[4096];
+    size_t n = 0;
+    for (int i = first; i < count && i < first + CHOOSE_VISIBLE && n < sizeof(body); i++) {
+        char number[8] = "  ";
+        if (i < 9) snprintf(number, sizeof(number), "%d", i + 1);
+        n += (size_t)snprintf(body + n, sizeof(body) - n, "%s %s  %s\n",
+                              i == selected ? ">" : " ", number, items[i]);
+    }
+    if (n < sizeof(body)) {
+        snprintf(body + n, sizeof(body) - n, "\n[Up/Down] Choose    [Enter] Open    [Esc] Cancel");
+    }
+    overlay_show_info(title_text, body);
+}
+
+int overlay_modal_choose(const char *title_text, const char *const *items, int count,
+                         int selected, SDL_Renderer *renderer, struct Viewer *viewer) {
+    if (!body_font || !title_font || count <= 0) {
+        return -1;
+    }
+    if (selected < 0 || selected >= count) selected = 0;
+
+    show_choices(title_text, items, count, selected);
+
+    /* Inner event loop — block until user picks or cancels */
+    SDL_Event e;
+    while (active) {
+        while (SDL_PollEvent(&e)) {
+            if (e.type == SDL_EVENT_QUIT) {
+                SDL_PushEvent(&e);
+                overlay_hide();
+                return -1;
+            }
+            if (e.type != SDL_EVENT_KEY_DOWN) continue;
+
+            int prev = selected;
+            SDL_Keycode key = e.key.key;
+            if (key == SDLK_RETURN || key == SDLK_KP_ENTER) {
+                overlay_hide();
+                return selected;
+            } else if (key == SDLK_ESCAPE || key == SDLK_Q) {
+                overlay_hide();
+                return -1;
+            } else if (key >= SDLK_1 && key <= SDLK_9) {
+                int pick = (int)(key - SDLK_1);
+                if (pick < count) {
+                    overlay_hide();
+                    return pick;
+                }
+            } else if (key == SDLK_DOWN || key == SDLK_J) {
+                if (selected < count - 1) selected++;
+            } else if (key == SDLK_UP || key == SDLK_K) {
+                if (selected > 0) selected--;
+            } else if (key == SDLK_HOME) {
+                selected = 0;
+            } else if (key == SDLK_END) {
+                selected = count - 1;
+            }
+            if (selected != prev) {
+                show_choices(title_text, items, count, selected);
+            }
+        }
+
+        /* Render the viewer background first to prevent flickering */
+        if (viewer) {
+            viewer_render(viewer, renderer);
+        }
+        overlay_render(renderer);
+        SDL_RenderPresent(renderer);
+        SDL_Delay(8);
+    }
+
+    return -1;
+}
+
 /* ================================================================
    Entry dialog
    ================================================================ */
 
+/* Run the entry check on the current text */
+static void update_entry_warning(EntryCheck check, void *userdata) {
+    entry_warning[0] = '\0';
+    entry_fatal = false;
+    if (!check) return;
+    const char *msg = check(entry_buffer, &entry_fatal, userdata);
+    if (msg) {
+        snprintf(entry_warning, sizeof(entry_warning), "%s", msg);
+    } else {
+        entry_fatal = false;
+    }
+}
+
 char *overlay_modal_entry(const char *title_text, const char *initial_text,
                           SDL_Renderer *renderer, SDL_Window *window, struct Viewer *viewer) {
+    return overlay_modal_entry_checked(title_text, initial_text, NULL, NULL,
+                                       renderer, window, viewer);
+}
+
+char *overlay_modal_entry_checked(const char *title_text, const char *initial_text,
+                                  EntryCheck check, void *userdata,
+                                  SDL_Renderer *renderer, SDL_Window *window, struct Viewer *viewer) {
     if (!body_font || !title_font) {
         return NULL;
     }
@@ -186,6 +379,7 @@ char *overlay_modal_entry(const char *title_text, const char *initial_text,
     }
     entry_cursor = strlen(entry_buffer);
     entry_buffer[sizeof(entry_buffer) - 1] = '\0';
+    update_entry_warning(check, userdata);
 
     free(current_title);
     current_title = strdup(title_text ? title_text : "Enter Text");
@@ -201,6 +395,7 @@ char *overlay_modal_entry(const char *title_text, const char *initial_text,
         while (SDL_PollEvent(&e)) {
             switch (e.type) {
             case SDL_EVENT_QUIT:
+                SDL_PushEvent(&e);
                 SDL_StopTextInput(window);
                 overlay_hide();
                 return NULL;
@@ -226,6 +421,9 @@ char *overlay_modal_entry(const char *title_text, const char *initial_text,
                 switch (e.key.key) {
                 case SDLK_RETURN:
                 case SDLK_KP_ENTER:
+                    if (entry_fatal && entry_buffer[0] != '\0') {
+                        break;  /* the warning says why */
+                    }
                     if (entry_buffer[0] != '\0') {
                         char *result = strdup(entry_buffer);
                         SDL_StopTextInput(window);
@@ -288,6 +486,9 @@ char *overlay_modal_entry(const char *title_text, const char *initial_text,
             default:
                 break;
             }
+            if (e.type == SDL_EVENT_TEXT_INPUT || e.type == SDL_EVENT_KEY_DOWN) {
+                update_entry_warning(check, userdata);
+            }
         }
 
         /* Render viewer background first to prevent flickering */
@@ -304,9 +505,240 @@ char *overlay_modal_entry(const char *title_text, const char *initial_text,
     return NULL;
 }
 
+void overlay_show_osd(const char *text)
+{
+    if (!text) return;
+    snprintf(osd_text, sizeof(osd_text), "%s", text);
+    osd_until = SDL_GetTicks() + OSD_DURATION_MS;
+}
+
+bool overlay_osd_visible(void)
+{
+    return osd_text[0] != '\0';
+}
+
+bool overlay_osd_tick(void)
+{
+    if (osd_text[0] == '\0' || SDL_GetTicks() < osd_until) return false;
+    osd_text[0] = '\0';
+    return true;
+}
+
+void overlay_render_osd(SDL_Renderer *renderer)
+{
+    if (osd_text[0] == '\0' || !help_font) return;
+
+    int vp_w, vp_h;
+    if (!SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) return;
+
+    SDL_Surface *surf = TTF_RenderText_Blended(help_font, osd_text, 0, theme_get()->text);
+    if (!surf) return;
+
+    SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+    if (tex) {
+        float pad = 12.0f;
+        float w = surf->w + pad * 2;
+        float h = surf->h + pad;
+        SDL_FRect bg = {(vp_w - w) / 2.0f, vp_h - filmstrip_height() - statusbar_height() - h - 40.0f, w, h};
+
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+        theme_set_color(renderer, theme_get()->panel, 220);
+        SDL_RenderFillRect(renderer, &bg);
+        theme_set_color(renderer, theme_get()->border, 255);
+        SDL_RenderRect(renderer, &bg);
+
+        SDL_FRect r = {bg.x + pad, bg.y + pad / 2.0f, (float)surf->w, (float)surf->h};
+        SDL_RenderTexture(renderer, tex, NULL, &r);
+        SDL_DestroyTexture(tex);
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+    }
+    SDL_DestroySurface(surf);
+}
+
+void overlay_set_camera_info(bool enabled)
+{
+    camera_info = enabled;
+}
+
+bool overlay_get_camera_info(void)
+{
+    return camera_info;
+}
+
+void overlay_render_camera(SDL_Renderer *renderer, const char *path)
+{
+    if (!camera_info || !path || !help_font) return;
+
+    /* Read the EXIF once per image, not every frame */
+    if (!camera_path || strcmp(camera_path, path) != 0) {
+        free(camera_path);
+        free(camera_line);
+        camera_path = strdup(path);
+        ExifInfo exif;
+        camera_line = exif_read(path, &exif) ? exif_settings(&exif, "    ") : NULL;
+    }
+    if (!camera_line) return;
+
+    int vp_w, vp_h;
+    if (!SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) return;
+
+    SDL_Surface *surf = TTF_RenderText_Blended(help_font, camera_line, 0, theme_get()->text);
+    if (!surf) return;
+
+    SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+    if (tex) {
+        float pad = 10.0f;
+        float w = surf->w + pad * 2;
+        float h = surf->h + pad;
+        SDL_FRect bg = {16.0f, vp_h - filmstrip_height() - statusbar_height() - h - 16.0f, w, h};
+
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+        theme_set_color(renderer, theme_get()->panel, 180);
+        SDL_RenderFillRect(renderer, &bg);
+
+        SDL_FRect r = {bg.x + pad, bg.y + pad / 2.0f, (float)surf->w, (float)surf->h};
+        SDL_RenderTexture(renderer, tex, NULL, &r);
+        SDL_DestroyTexture(tex);
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+    }
+    SDL_DestroySurface(surf);
+}
+
+/* ================================================================
+   Empty state
+   ================================================================ */
+
+#define EMPTY_MAX_BUTTONS 4
+
+static SDL_FRect empty_button_rects[EMPTY_MAX_BUTTONS];
+static EmptyAction empty_button_actions[EMPTY_MAX_BUTTONS];
+static int empty_button_count = 0;
+
+/* Draw a line of text centred on cx at y. Returns its height. */
+static float draw_centered(SDL_Renderer *renderer, TTF_Font *font, const char *text,
+                           SDL_Color color, float cx, float y, float max_w)
+{
+    SDL_Surface *surf = TTF_RenderText_Blended(font, text, 0, color);
+    if (!surf) return 0.0f;
+
+    float w = surf->w < max_w ? (float)surf->w : max_w;
+    float h = (float)surf->h;
+    SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+    if (tex) {
+        /* Long folder names are clipped rather than squeezed */
+        SDL_FRect src = {0.0f, 0.0f, w, h};
+        SDL_FRect dst = {cx - w / 2.0f, y, w, h};
+        SDL_RenderTexture(renderer, tex, &src, &dst);
+        SDL_DestroyTexture(tex);
+    }
+    SDL_DestroySurface(surf);
+    return h;
+}
+
+/* Draw a row of buttons centred on cx at y, remembering them for
+   overlay_empty_hit(). Returns the row height. */
+static float add_empty_buttons(SDL_Renderer *renderer, const char *const *labels,
+                               const EmptyAction *actions, int count, float cx, float y)
+{
+    const ThemePalette *pal = theme_get();
+    const float gap = 12.0f;
+    float widths[EMPTY_MAX_BUTTONS];
+    float total = 0.0f, height = 0.0f;
+
+    if (count > EMPTY_MAX_BUTTONS) count = EMPTY_MAX_BUTTONS;
+    for (int i = 0; i < count; i++) {
+        int label_w = 0, label_h = 0;
+        TTF_GetStringSize(help_font, labels[i], 0, &label_w, &label_h);
+        widths[i] = label_w + 32.0f;
+        total += widths[i] + (i > 0 ? gap : 0.0f);
+        if (label_h + 14.0f > height) height = label_h + 14.0f;
+    }
+
+    float x = cx - total / 2.0f;
+    for (int i = 0; i < count; i++) {
+        SDL_FRect r = {x, y, widths[i], height};
+        theme_set_color(renderer, pal->panel, 255);
+        SDL_RenderFillRect(renderer, &r);
+        theme_set_color(renderer, i == 0 ? pal->accent : pal->border, 255);
+        SDL_RenderRect(renderer, &r);
+        draw_centered(renderer, help_font, labels[i], pal->text, r.x + r.w / 2.0f, r.y + 7.0f, r.w);
+
+        empty_button_rects[empty_button_count] = r;
+        empty_button_actions[empty_button_count] = actions[i];
+        empty_button_count++;
+        x += widths[i] + gap;
+    }
+    return height;
+}
+
+void overlay_render_empty(SDL_Renderer *renderer, const char *dir, bool scanning)
+{
+    empty_button_count = 0;
+    if (!body_font) return;
+
+    int vp_w, vp_h;
+    if (!SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) return;
+
+    const ThemePalette *pal = theme_get();
+    float cx = vp_w / 2.0f;
+    float max_w = vp_w - 40.0f;
+    float y = vp_h / 2.0f - 80.0f;
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+
+    const char *title = scanning ? "Reading folder\xe2\x80\xa6" : dir ? "No images here" : "No image open";
+    y += draw_centered(renderer, title_font, title, pal->text, cx, y, max_w) + 10.0f;
+
+    char line[4200];
+    if (dir && scanning) {
+        snprintf(line, sizeof(line), "%s", dir);
+    } else if (dir) {
+        snprintf(line, sizeof(line), "0 images in %s", dir);
+    } else {
+        snprintf(line, sizeof(line), "Open an image or a folder to start");
+    }
+    y += draw_centered(renderer, help_font, line, pal->text_dim, cx, y, max_w) + 24.0f;
+
+    if (!scanning) {
+        const char *labels[EMPTY_MAX_BUTTONS];
+        EmptyAction actions[EMPTY_MAX_BUTTONS];
+        int count = 0;
+        labels[count] = "Open\xe2\x80\xa6";
+        actions[count++] = EMPTY_OPEN;
+        labels[count] = "Open Folder\xe2\x80\xa6";
+        actions[count++] = EMPTY_OPEN_FOLDER;
+        if (dir) {
+            labels[count] = "Reload";
+            actions[count++] = EMPTY_RELOAD;
+        }
+        labels[count] = "Quit";
+        actions[count++] = EMPTY_QUIT;
+        y += add_empty_buttons(renderer, labels, actions, count, cx, y) + 24.0f;
+    }
+
+    draw_centered(renderer, body_font,
+                  "Drop an image or folder on the window \xc2\xb7 o opens an image, O a folder",
+                  pal->text_dim, cx, y, max_w);
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+}
+
+EmptyAction overlay_empty_hit(float x, float y)
+{
+    SDL_FPoint p = {x, y};
+    for (int i = 0; i < empty_button_count; i++) {
+        if (SDL_PointInRectFloat(&p, &empty_button_rects[i])) return empty_button_actions[i];
+    }
+    return EMPTY_NONE;
+}
+
 void overlay_shutdown(void)
 {
     overlay_hide();
+    free(camera_path);
+    free(camera_line);
+    camera_path = NULL;
+    camera_line = NULL;
     if (body_font && body_font != title_font) TTF_CloseFont(body_font);
     if (title_font) TTF_CloseFont(title_font);
     if (help_font && help_font != body_font && help_font != title_font) TTF_CloseFont(help_font);
@@ -316,6 +748,8 @@ void overlay_shutdown(void)
     TTF_Quit();
 }
 
+TTF_Font *overlay_ui_font(void) { return help_font; }
+
 bool overlay_is_active(void) { return active; }
 
 bool overlay_is_available(void) {
@@ -337,11 +771,11 @@ static SDL_Texture *render_text(const char *text, TTF_Font *font,
                                  SDL_Renderer *renderer,
                                  int *out_w, int *out_h)
 {
-    SDL_Color white = {255, 255, 255, 255};
+    SDL_Color text_color = theme_get()->text;
     int max_w = viewport_w - 80; /* 40px padding each side */
     if (max_w < 200) max_w = 200;
 
-    SDL_Surface *surf = TTF_RenderText_Blended_Wrapped(font, text, 0, white, max_w);
+    SDL_Surface *surf = TTF_RenderText_Blended_Wrapped(font, text, 0, text_color, max_w);
     if (!surf) return NULL;
 
     SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
@@ -354,11 +788,106 @@ static SDL_Texture *render_text(const char *text, TTF_Font *font,
 void overlay_show_info(const char *title, const char *text)
 {
     overlay_hide();
-    current_title = strdup(title ? title : "Image Information");
+    current_title = strdup(title ? title : INFO_TITLE);
     current_body = strdup(text ? text : "");
+    info_selected = -1;
     active = true;
 }
 
+/* Split the info body into table rows. Returns the row count. */
+static int parse_info_rows(const char *body, InfoRow *rows, int max)
+{
+    int row_count = 0;
+
+    char *body_copy = strdup(body ? body : "");
+    if (!body_copy) return 0;
+    char *line = strtok(body_copy, "\n");
+    while (line && row_count < max) {
+        /* Trim leading space */
+        while (*line == ' ') line++;
+
+        if (strcmp(line, "EXIF:") == 0) {
+            strcpy(rows[row_count].key, "EXIF DATA");
+            rows[row_count].val[0] = '\0';
+            rows[row_count].is_header = true;
+            row_count++;
+        } else {
+            char *colon = strchr(line, ':');
+            if (colon) {
+                *colon = '\0';
+                char *k = line;
+                char *v = colon + 1;
+                /* Trim trailing spaces from key */
+                int k_len = strlen(k);
+                while (k_len > 0 && k[k_len - 1] == ' ') {
+                    k[k_len - 1] = '\0';
+                    k_len--;
+                }
+                /* Trim leading spaces from val */
+                while (*v == ' ') v++;
+
+                strncpy(rows[row_count].key, k, sizeof(rows[row_count].key) - 1);
+                rows[row_count].key[sizeof(rows[row_count].key) - 1] = '\0';
+                strncpy(rows[row_count].val, v, sizeof(rows[row_count].val) - 1);
+                rows[row_count].val[sizeof(rows[row_count].val) - 1] = '\0';
+                rows[row_count].is_header = false;
+                row_count++;
+            } else if (line[0] != '\0') {
+                /* Regular text line without colon */
+                strncpy(rows[row_count].key, line, sizeof(rows[row_count].key) - 1);
+                rows[row_count].key[sizeof(rows[row_count].key) - 1] = '\0';
+                rows[row_count].val[0] = '\0';
+                rows[row_count].is_header = false;
+                row_count++;
+            }
+        }
+        line = strtok(NULL, "\n");
+    }
+    free(body_copy);
+    return row_count;
+}
+
+bool overlay_info_handle_key(SDL_Keycode key)
+{
+    if (!active || !current_title || strcmp(current_title, INFO_TITLE) != 0) return false;
+
+    InfoRow rows[INFO_MAX_ROWS];
+    int row_count = parse_info_rows(current_body, rows, INFO_MAX_ROWS);
+    char msg[96];
+
+    if (key == SDLK_UP || key == SDLK_K || key == SDLK_DOWN || key == SDLK_J) {
+        /* Step to the next field, passing over section headers */
+        int dir = (key == SDLK_UP || key == SDLK_K) ? -1 : 1;
+        int i = info_selected < 0 ? (dir > 0 ? -1 : row_count) : info_selected;
+        for (i += dir; i >= 0 && i < row_count; i += dir) {
+            if (!rows[i].is_header) {
+                info_selected = i;
+                break;
+            }
+        }
+        return true;
+    }
+
+    if (key == SDLK_C && info_selected >= 0 && info_selected < row_count) {
+        const InfoRow *row = &rows[info_selected];
+        if (SDL_SetClipboardText(row->val[0] ? row->val : row->key)) {
+            snprintf(msg, sizeof(msg), "Copied %s", row->key);
+            overlay_show_osd(msg);
+        } else {
+            overlay_show_osd("Could not set clipboard");
+        }
+        return true;
+    }
+
+    if (key == SDLK_A || key == SDLK_C) {
+        overlay_show_osd(SDL_SetClipboardText(current_body) ? "Copied image information"
+                                                            : "Could not set clipboard");
+        return true;
+    }
+
+    return false;
+}
+
 void overlay_show_help(void)
 {
     overlay_hide();
@@ -370,7 +899,7 @@ void overlay_show_help(void)
 static void render_shortcut_table(SDL_Renderer *renderer, HelpShortcut *items, int count, const char *category_name, float x, float y, float w, float row_h) {
     /* 1. Draw Category Name */
     TTF_SetFontStyle(help_font, TTF_STYLE_BOLD);
-    SDL_Surface *cat_surf = TTF_RenderText_Blended(help_font, category_name, 0, (SDL_Color){220, 50, 50, 255});
+    SDL_Surface *cat_surf = TTF_RenderText_Blended(help_font, category_name, 0, theme_get()->accent_text);
     TTF_SetFontStyle(help_font, TTF_STYLE_NORMAL);
     float cat_h = 0;
     if (cat_surf) {
@@ -391,12 +920,12 @@ static void render_shortcut_table(SDL_Renderer *renderer, HelpShortcut *items, i
 
     /* 2. Draw Table Background */
     SDL_FRect tbl_rect = {x, table_y, w, table_h};
-    SDL_SetRenderDrawColor(renderer, 25, 25, 25, 255);
+    theme_set_color(renderer, theme_get()->surface, 255);
     SDL_RenderFillRect(renderer, &tbl_rect);
 
     /* 3. Draw Header Background */
     SDL_FRect hdr_rect = {x, table_y, w, row_h};
-    SDL_SetRenderDrawColor(renderer, 45, 45, 45, 255);
+    theme_set_color(renderer, theme_get()->header, 255);
     SDL_RenderFillRect(renderer, &hdr_rect);
 
     /* 4. Draw Row Separators and Zebra Stripes */
@@ -404,14 +933,14 @@ static void render_shortcut_table(SDL_Renderer *renderer, HelpShortcut *items, i
         float ry = table_y + row_h * (i + 1);
         if (i % 2 == 1) {
             SDL_FRect row_rect = {x, ry, w, row_h};
-            SDL_SetRenderDrawColor(renderer, 32, 32, 32, 255);
+            theme_set_color(renderer, theme_get()->surface_alt, 255);
             SDL_RenderFillRect(renderer, &row_rect);
         }
     }
 
     /* 5. Draw Header Text */
-    SDL_Color header_color = {150, 150, 150, 255};
-    SDL_Color text_color = {230, 230, 230, 255};
+    SDL_Color header_color = theme_get()->text_dim;
+    SDL_Color text_color = theme_get()->text;
 
     TTF_SetFontStyle(help_font, TTF_STYLE_BOLD);
 
@@ -471,11 +1000,11 @@ static void render_shortcut_table(SDL_Renderer *renderer, HelpShortcut *items, i
     }
 
     /* 7. Draw Borders (Clear separations) */
-    SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+    theme_set_color(renderer, theme_get()->border, 255);
     SDL_RenderRect(renderer, &tbl_rect);
 
     /* Draw vertical column separator line */
-    SDL_SetRenderDrawColor(renderer, 60, 60, 60, 255);
+    theme_set_color(renderer, theme_get()->separator, 255);
     SDL_RenderLine(renderer, x + key_col_w, table_y, x + key_col_w, table_y + table_h);
 
     /* Draw header horizontal separator line */
@@ -492,82 +1021,31 @@ void overlay_render(SDL_Renderer *renderer)
         return;
     }
 
-    /* Rebuild textures if viewport changed */
-    if (vp_w != viewport_w || vp_h != viewport_h) {
+    /* Rebuild textures if viewport or colour scheme changed */
+    if (vp_w != viewport_w || vp_h != viewport_h || theme_get() != text_palette) {
         viewport_w = vp_w;
         viewport_h = vp_h;
+        text_palette = theme_get();
         SDL_DestroyTexture(text_texture); text_texture = NULL;
         SDL_DestroyTexture(title_texture); title_texture = NULL;
     }
 
     /* Special rendering code if it's the IMAGE INFO overlay */
-    if (current_title && strcmp(current_title, "Image Information") == 0) {
+    if (current_title && strcmp(current_title, INFO_TITLE) == 0) {
         if (!title_texture && current_title) {
             title_texture = render_text(current_title, title_font, renderer, &title_w, &title_h);
         }
 
-        /* Parse current_body into a key-value list */
-        typedef struct {
-            char key[64];
-            char val[256];
-            bool is_header;
-        } InfoRow;
-
-        InfoRow rows[128];
-        int row_count = 0;
-
-        char *body_copy = strdup(current_body ? current_body : "");
-        char *line = strtok(body_copy, "\n");
-        while (line && row_count < 128) {
-            /* Trim leading space */
-            while (*line == ' ') line++;
-            
-            if (strcmp(line, "EXIF:") == 0) {
-                strcpy(rows[row_count].key, "EXIF DATA");
-                rows[row_count].val[0] = '\0';
-                rows[row_count].is_header = true;
-                row_count++;
-            } else {
-                char *colon = strchr(line, ':');
-                if (colon) {
-                    *colon = '\0';
-                    char *k = line;
-                    char *v = colon + 1;
-                    /* Trim trailing spaces from key */
-                    int k_len = strlen(k);
-                    while (k_len > 0 && k[k_len - 1] == ' ') {
-                        k[k_len - 1] = '\0';
-                        k_len--;
-                    }
-                    /* Trim leading spaces from val */
-                    while (*v == ' ') v++;
-
-                    strncpy(rows[row_count].key, k, sizeof(rows[row_count].key) - 1);
-                    rows[row_count].key[sizeof(rows[row_count].key) - 1] = '\0';
-                    strncpy(rows[row_count].val, v, sizeof(rows[row_count].val) - 1);
-                    rows[row_count].val[sizeof(rows[row_count].val) - 1] = '\0';
-                    rows[row_count].is_header = false;
-                    row_count++;
-                } else if (line[0] != '\0') {
-                    /* Regular text line without colon */
-                    strncpy(rows[row_count].key, line, sizeof(rows[row_count].key) - 1);
-                    rows[row_count].key[sizeof(rows[row_count].key) - 1] = '\0';
-                    rows[row_count].val[0] = '\0';
-                    rows[row_count].is_header = false;
-                    row_count++;
-                }
-            }
-            line = strtok(NULL, "\n");
-        }
-        free(body_copy);
+        InfoRow rows[INFO_MAX_ROWS];
+        int row_count = parse_info_rows(current_body, rows, INFO_MAX_ROWS);
 
         /* Now render the info rows in a table! */
         int pad = 24;
         int row_h = 28;
         int total_w = 600;
         
-        /* Calculate height dynamically */
-        int total_h = pad * 2 + title_h + 15 + row_h * row_count;
+        /* Calculate height dynamically (the last row holds the copy hint) */
+        int total_h = pad * 2 + title_h + 15 + row_h * (row_count + 1);
         if (total_h > vp_h - 60) {
             total_h = vp_h - 60;
         }
@@ -578,12 +1056,12 @@ void overlay_render(SDL_Renderer *renderer)
 
         /* Draw semi-transparent background */
         SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
-        SDL_SetRenderDrawColor(renderer, 15, 15, 15, 235);
+        theme_set_color(renderer, theme_get()->panel, 235);
         SDL_FRect bg = {ox, oy, (float)total_w, (float)total_h};
         SDL_RenderFillRect(renderer, &bg);
 
         /* Draw border */
-        SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+        theme_set_color(renderer, theme_get()->border, 255);
         SDL_RenderRect(renderer, &bg);
 
         /* Draw title centered */
@@ -596,31 +1074,32 @@ void overlay_render(SDL_Renderer *renderer)
         float table_x = ox + pad;
         float table_y = oy + pad + title_h + 10;
         float table_w = total_w - pad * 2;
+        float table_bottom = oy + total_h - pad - row_h;
         float table_h_actual = row_h * row_count;
-        if (table_y + table_h_actual > oy + total_h - pad) {
-            table_h_actual = oy + total_h - pad - table_y;
+        if (table_y + table_h_actual > table_bottom) {
+            table_h_actual = table_bottom - table_y;
         }
 
         /* Draw Table Background */
         SDL_FRect tbl_rect = {table_x, table_y, table_w, table_h_actual};
-        SDL_SetRenderDrawColor(renderer, 25, 25, 25, 255);
+        theme_set_color(renderer, theme_get()->surface, 255);
         SDL_RenderFillRect(renderer, &tbl_rect);
 
         float key_col_w = table_w * 0.3f;
         float val_col_w = table_w - key_col_w;
 
-        SDL_Color text_color = {230, 230, 230, 255};
-        SDL_Color header_color = {220, 50, 50, 255};
+        SDL_Color text_color = theme_get()->text;
+        SDL_Color header_color = theme_get()->accent_text;
 
         /* Draw Row background and Text */
         for (int i = 0; i < row_count; i++) {
             float ry = table_y + row_h * i;
-            if (ry + row_h > oy + total_h - pad) break; /* clip if too tall */
+            if (ry + row_h > table_bottom) break; /* clip if too tall */
 
             if (rows[i].is_header) {
                 /* Header row */
                 SDL_FRect r_rect = {table_x, ry, table_w, (float)row_h};
-                SDL_SetRenderDrawColor(renderer, 45, 45, 45, 255);
+                theme_set_color(renderer, theme_get()->header, 255);
                 SDL_RenderFillRect(renderer, &r_rect);
 
                 TTF_SetFontStyle(help_font, TTF_STYLE_BOLD);
@@ -636,10 +1115,14 @@ void overlay_render(SDL_Renderer *renderer)
                 }
                 TTF_SetFontStyle(help_font, TTF_STYLE_NORMAL);
             } else {
-                /* Regular row, zebra striping */
-                if (i % 2 == 1) {
+                /* Regular row, zebra striping; the row picked for copying is highlighted */
+                if (i == info_selected) {
                     SDL_FRect r_rect = {table_x, ry, table_w, (float)row_h};
-                    SDL_SetRenderDrawColor(renderer, 32, 32, 32, 255);
+                    theme_set_color(renderer, theme_get()->selection, 255);
+                    SDL_RenderFillRect(renderer, &r_rect);
+                } else if (i % 2 == 1) {
+                    SDL_FRect r_rect = {table_x, ry, table_w, (float)row_h};
+                    theme_set_color(renderer, theme_get()->surface_alt, 255);
                     SDL_RenderFillRect(renderer, &r_rect);
                 }
 
@@ -676,19 +1159,34 @@ void overlay_render(SDL_Renderer *renderer)
 
             /* Draw horizontal separator line for this row */
             if (i > 0) {
-                SDL_SetRenderDrawColor(renderer, 60, 60, 60, 255);
+                theme_set_color(renderer, theme_get()->separator, 255);
                 SDL_RenderLine(renderer, table_x, ry, table_x + table_w, ry);
             }
         }
 
         /* Draw Table Border */
-        SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+        theme_set_color(renderer, theme_get()->border, 255);
         SDL_RenderRect(renderer, &tbl_rect);
 
         /* Draw vertical column separator line */
-        SDL_SetRenderDrawColor(renderer, 60, 60, 60, 255);
+        theme_set_color(renderer, theme_get()->separator, 255);
         SDL_RenderLine(renderer, table_x + key_col_w, table_y, table_x + key_col_w, table_y + table_h_actual);
 
+        /* Copy hint under the table */
+        const char *hint = info_selected >= 0 ? "c: copy field    a: copy all    \xe2\x86\x91/\xe2\x86\x93: choose field"
+                                              : "\xe2\x86\x91/\xe2\x86\x93: choose a field to copy    a: copy all";
+        SDL_Surface *hint_surf = TTF_RenderText_Blended(help_font, hint, 0, theme_get()->text_dim);
+        if (hint_surf) {
+            SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, hint_surf);
+            if (tex) {
+                SDL_FRect r = {table_x, table_bottom + (row_h - hint_surf->h) / 2.0f,
+                               (float)hint_surf->w, (float)hint_surf->h};
+                SDL_RenderTexture(renderer, tex, NULL, &r);
+                SDL_DestroyTexture(tex);
+            }
+            SDL_DestroySurface(hint_surf);
+        }
+
         SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
         return;
     }
@@ -709,12 +1207,12 @@ void overlay_render(SDL_Renderer *renderer)
 
         /* Draw semi-transparent background */
         SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
-        SDL_SetRenderDrawColor(renderer, 18, 18, 18, 240);
+        theme_set_color(renderer, theme_get()->panel, 240);
         SDL_FRect bg = {ox, oy, (float)total_w, (float)total_h};
         SDL_RenderFillRect(renderer, &bg);
 
         /* Draw border */
-        SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+        theme_set_color(renderer, theme_get()->border, 255);
         SDL_RenderRect(renderer, &bg);
 
         /* Draw title centered */
@@ -731,11 +1229,11 @@ void overlay_render(SDL_Renderer *renderer)
         SDL_FRect input_rect = {input_x, input_y, input_w, input_h};
 
         /* Background of input box */
-        SDL_SetRenderDrawColor(renderer, 30, 30, 30, 255);
+        theme_set_color(renderer, theme_get()->input, 255);
         SDL_RenderFillRect(renderer, &input_rect);
 
         /* Glowing red border to show focus */
-        SDL_SetRenderDrawColor(renderer, 153, 0, 0, 255);
+        theme_set_color(renderer, theme_get()->accent, 255);
         SDL_RenderRect(renderer, &input_rect);
 
         /* Render input text */
@@ -743,7 +1241,7 @@ void overlay_render(SDL_Renderer *renderer)
         float cursor_x = input_x + 12;
 
         if (entry_buffer[0] != '\0') {
-            SDL_Color text_color = {240, 240, 240, 255};
+            SDL_Color text_color = theme_get()->text;
             SDL_Surface *surf = TTF_RenderText_Blended(help_font, entry_buffer, 0, text_color);
             if (surf) {
                 SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
@@ -773,17 +1271,35 @@ void overlay_render(SDL_Renderer *renderer)
         /* Draw blinking cursor */
         if ((SDL_GetTicks() / 500) % 2 == 0) {
             SDL_FRect cursor_rect = {cursor_x, text_y, 3, 18};
-            SDL_SetRenderDrawColor(renderer, 153, 0, 0, 255);
+            theme_set_color(renderer, theme_get()->accent, 255);
             SDL_RenderFillRect(renderer, &cursor_rect);
         }
 
+        /* Draw the check's warning under the field */
+        float hint_y = input_y + input_h + 20;
+        if (entry_warning[0] != '\0') {
+            SDL_Color warn_color = entry_fatal ? theme_get()->accent : theme_get()->accent_text;
+            SDL_Surface *warn_surf = TTF_RenderText_Blended(body_font, entry_warning, 0, warn_color);
+            if (warn_surf) {
+                SDL_Texture *warn_tex = SDL_CreateTextureFromSurface(renderer, warn_surf);
+                if (warn_tex) {
+                    float ww = warn_surf->w > input_w ? input_w : warn_surf->w;
+                    SDL_FRect r = {input_x, input_y + input_h + 6, ww, (float)warn_surf->h};
+                    SDL_RenderTexture(renderer, warn_tex, NULL, &r);
+                    SDL_DestroyTexture(warn_tex);
+                }
+                SDL_DestroySurface(warn_surf);
+            }
+            hint_y += 12;
+        }
+
         /* Draw Buttons/Hints at the bottom */
-        SDL_Color hint_color = {150, 150, 150, 255};
+        SDL_Color hint_color = theme_get()->text_dim;
         SDL_Surface *hint_surf = TTF_RenderText_Blended(body_font, "[Enter] Confirm      [Esc] Cancel", 0, hint_color);
         if (hint_surf) {
             SDL_Texture *hint_tex = SDL_CreateTextureFromSurface(renderer, hint_surf);
             if (hint_tex) {
-                SDL_FRect r = {ox + (total_w - hint_surf->w) / 2.0f, input_y + input_h + 20, (float)hint_surf->w, (float)hint_surf->h};
+                SDL_FRect r = {ox + (total_w - hint_surf->w) / 2.0f, hint_y, (float)hint_surf->w, (float)hint_surf->h};
                 SDL_RenderTexture(renderer, hint_tex, NULL, &r);
                 SDL_DestroyTexture(hint_tex);
             }
@@ -811,8 +1327,16 @@ void overlay_render(SDL_Renderer *renderer)
         if (col_w < 300) col_w = 300;
 
         int total_w = col_w * 2 + col_gap + pad * 2;
-        int total_h = vp_h - 100; /* leave some margin top/bottom */
-        if (total_h > 580) total_h = 580;
+        /* Height follows the taller column (each table: title + rows + spacer) */
+        int nav_n = (int)(sizeof(help_nav) / sizeof(help_nav[0]));
+        int gen_n = (int)(sizeof(help_gen) / sizeof(help_gen[0]));
+        int view_n = (int)(sizeof(help_view) / sizeof(help_view[0]));
+        int ops_n = (int)(sizeof(help_ops) / sizeof(help_ops[0]));
+        int left_rows = nav_n + gen_n;
+        int right_rows = view_n + ops_n;
+        int rows = left_rows > right_rows ? left_rows : right_rows;
+        int total_h = pad * 2 + title_h + 10 + (rows + 2) * row_h + 50 + row_h;
+        if (total_h > vp_h - 40) total_h = vp_h - 40; /* leave some margin top/bottom */
 
         /* Center overlay */
         float ox = (vp_w - total_w) / 2.0f;
@@ -820,12 +1344,12 @@ void overlay_render(SDL_Renderer *renderer)
 
         /* Draw semi-transparent background */
         SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
-        SDL_SetRenderDrawColor(renderer, 15, 15, 15, 235);
+        theme_set_color(renderer, theme_get()->panel, 235);
         SDL_FRect bg = {ox, oy, (float)total_w, (float)total_h};
         SDL_RenderFillRect(renderer, &bg);
 
         /* Draw border */
-        SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+        theme_set_color(renderer, theme_get()->border, 255);
         SDL_RenderRect(renderer, &bg);
 
         /* Draw title centered */
@@ -879,12 +1403,12 @@ void overlay_render(SDL_Renderer *renderer)
 
     /* Draw semi-transparent background */
     SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
-    SDL_SetRenderDrawColor(renderer, 0, 0, 0, 200);
+    theme_set_color(renderer, theme_get()->panel, 200);
     SDL_FRect bg = {ox, oy, (float)total_w, (float)total_h};
     SDL_RenderFillRect(renderer, &bg);
 
     /* Draw border */
-    SDL_SetRenderDrawColor(renderer, 80, 80, 80, 255);
+    theme_set_color(renderer, theme_get()->border, 255);
     SDL_RenderRect(renderer, &bg);
 
     /* Draw title */
@@ -8,9 +8,21 @@
    Returns false if no font could be loaded (overlays disabled). */
 bool overlay_init(void);
 
+struct TTF_Font;
+
+/* Get the shared 16pt UI font (used by the help tables, OSD and search grid).
+   Owned by the overlay module; NULL if no font could be loaded. */
+struct TTF_Font *overlay_ui_font(void);
+
 /* Show the image info overlay. */
 void overlay_show_info(const char *title, const char *text);
 
+/* Keys of the image info overlay: Up/Down (or j/k) pick a field, c copies
+   its value to the clipboard and a copies the whole text (so does c with
+   no field picked). Returns false, doing nothing, for other keys or when
+   the info overlay is not shown. */
+bool overlay_info_handle_key(SDL_Keycode key);
+
 /* Show the keybindings help overlay with vim-style key table. */
 void overlay_show_help(void);
 
@@ -40,10 +52,69 @@ bool overlay_modal_confirm(const char *title, const char *message,
 char *overlay_modal_entry(const char *title, const char *initial_text,
                           SDL_Renderer *renderer, SDL_Window *window, struct Viewer *viewer);
 
+/* Modal list dialog. Up/Down (or j/k) move, Enter or a digit 1-9 picks,
+   Esc cancels. Returns the chosen index, or -1 if cancelled. */
+int overlay_modal_choose(const char *title, const char *const *items, int count,
+                         int selected, SDL_Renderer *renderer, struct Viewer *viewer);
+
+/* Check run on the entry text after every edit. Returns NULL if the text is
+   fine, or a message shown under the field; setting *fatal also keeps Enter
+   from accepting it. */
+typedef const char *(*EntryCheck)(const char *text, bool *fatal, void *userdata);
+
+/* Like overlay_modal_entry, with a live check of the text. */
+char *overlay_modal_entry_checked(const char *title, const char *initial_text,
+                                  EntryCheck check, void *userdata,
+                                  SDL_Renderer *renderer, SDL_Window *window, struct Viewer *viewer);
+
 /* Render the active overlay on top of the current frame.
    Must be called AFTER viewer_render() in the main loop. */
 void overlay_render(SDL_Renderer *renderer);
 
+/* Show a short transient message (OSD) near the bottom of the window.
+   It disappears on its own after a moment and does not block input. */
+void overlay_show_osd(const char *text);
+
+/* Check whether an OSD message is on screen (the main loop must keep
+   ticking so it can be removed when it expires). */
+bool overlay_osd_visible(void);
+
+/* Expire the OSD message if its time is up. Returns true if it was removed
+   (caller should re-render). */
+bool overlay_osd_tick(void);
+
+/* Render the OSD message, if any. Call after overlay_render(). */
+void overlay_render_osd(SDL_Renderer *renderer);
+
+/* Camera settings overlay: shutter speed, aperture, ISO and focal length
+   from the EXIF data, in the bottom-left corner like in-camera playback.
+   Images without these tags show nothing. */
+void overlay_set_camera_info(bool enabled);
+bool overlay_get_camera_info(void);
+
+/* Render the camera settings of the image at path, if enabled. The EXIF
+   data is read once per image. Call after viewer_render(). */
+void overlay_render_camera(SDL_Renderer *renderer, const char *path);
+
+/* Actions offered by the empty-state screen */
+typedef enum {
+    EMPTY_NONE,
+    EMPTY_OPEN,         /* file chooser for an image */
+    EMPTY_OPEN_FOLDER,  /* folder chooser */
+    EMPTY_RELOAD,       /* scan the folder again */
+    EMPTY_QUIT,
+} EmptyAction;
+
+/* Render the screen shown when there is no image: the folder and its image
+   count (or a note that a folder is being read), buttons for the actions
+   above and a drag-and-drop hint. `dir` may be NULL before anything was
+   opened (or when the file chooser was cancelled at startup); Reload is
+   only offered with a folder. */
+void overlay_render_empty(SDL_Renderer *renderer, const char *dir, bool scanning);
+
+/* Get the empty-state button at window position (x, y), as last rendered. */
+EmptyAction overlay_empty_hit(float x, float y);
+
 /* Shutdown overlay system, free all resources. */
 void overlay_shutdown(void);
 
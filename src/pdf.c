@@ -0,0 +1,207 @@
+#define _GNU_SOURCE
+#include "pdf.h"
+#include <SDL3_image/SDL_image.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define JPEG_QUALITY 90
+#define MARGIN_PT 36.0      /* half an inch */
+#define PX_TO_PT 0.75       /* 96 dpi */
+
+/* Objects 1 and 2 are the catalog and page tree, written last */
+#define FIRST_PAGE_OBJ 3
+
+struct PdfWriter {
+    FILE *fp;
+    char *path;
+    long *offsets;          /* file offset of each object, by number */
+    int obj_count;          /* highest object number used */
+    int obj_capacity;
+    int *page_objs;
+    int page_count;
+    bool failed;
+};
+
+/* Start object `num` at the current position */
+static void begin_object(PdfWriter *pdf, int num)
+{
+    if (num >= pdf->obj_capacity) {
+        int cap = pdf->obj_capacity ? pdf->obj_capacity * 2 : 64;
+        while (cap <= num) cap *= 2;
+        long *offsets = realloc(pdf->offsets, (size_t)cap * sizeof(long));
+        if (!offsets) {
+            pdf->failed = true;
+            return;
+        }
+        memset(offsets + pdf->obj_capacity, 0, (size_t)(cap - pdf->obj_capacity) * sizeof(long));
+        pdf->offsets = offsets;
+        pdf->obj_capacity = cap;
+    }
+    pdf->offsets[num] = ftell(pdf->fp);
+    if (num > pdf->obj_count) pdf->obj_count = num;
+    fprintf(pdf->fp, "%d 0 obj\n", num);
+}
+
+/* Flatten onto white and encode as a baseline JPEG. Returns a malloc'd buffer. */
+static unsigned char *encode_jpeg(SDL_Surface *image, size_t *out_size)
+{
+    SDL_Surface *rgb = SDL_CreateSurface(image->w, image->h, SDL_PIXELFORMAT_RGB24);
+    if (!rgb) return NULL;
+    memset(rgb->pixels, 0xFF, (size_t)rgb->pitch * (size_t)rgb->h);
+    SDL_SetSurfaceBlendMode(image, SDL_BLENDMODE_BLEND);
+    SDL_BlitSurface(image, NULL, rgb, NULL);
+
+    unsigned char *data = NULL;
+    SDL_IOStream *mem = SDL_IOFromDynamicMem();
+    if (mem && IMG_SaveJPG_IO(rgb, mem, false, JPEG_QUALITY)) {
+        Sint64 size = SDL_TellIO(mem);
+        if (size > 0 && SDL_SeekIO(mem, 0, SDL_IO_SEEK_SET) == 0) {
+            data = malloc((size_t)size);
+            if (data && SDL_ReadIO(mem, data, (size_t)size) == (size_t)size) {
+                *out_size = (size_t)size;
+            } else {
+                free(data);
+                data = NULL;
+            }
+        }
+    }
+    if (mem) SDL_CloseIO(mem);
+    SDL_DestroySurface(rgb);
+    return data;
+}
+
+PdfWriter *pdf_open(const char *path)
+{
+    PdfWriter *pdf = calloc(1, sizeof(PdfWriter));
+    if (!pdf) return NULL;
+
+    pdf->fp = fopen(path, "wb");
+    pdf->path = strdup(path);
+    if (!pdf->fp || !pdf->path) {
+        fprintf(stderr, "pdf: cannot create '%s'\n", path);
+        if (pdf->fp) fclose(pdf->fp);
+        free(pdf->path);
+        free(pdf);
+        return NULL;
+    }
+    pdf->obj_count = FIRST_PAGE_OBJ - 1;
+
+    /* Binary comment marks the file as binary for transfer tools */
+    fputs("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n", pdf->fp);
+    return pdf;
+}
+
+bool pdf_add_page(PdfWriter *pdf, SDL_Surface *image, PdfPageSize size, PdfFit fit)
+{
+    if (!pdf || !image || pdf->failed) return false;
+
+    size_t jpeg_size = 0;
+    unsigned char *jpeg = encode_jpeg(image, &jpeg_size);
+    if (!jpeg) {
+        fprintf(stderr, "pdf: cannot encode page image: %s\n", SDL_GetError());
+        return false;
+    }
+
+    int *pages = realloc(pdf->page_objs, (size_t)(pdf->page_count + 1) * sizeof(int));
+    if (!pages) {
+        free(jpeg);
+        return false;
+    }
+    pdf->page_objs = pages;
+
+    /* Page geometry in points */
+    double iw = image->w, ih = image->h;
+    double pw, ph;
+    if (size == PDF_PAGE_IMAGE) {
+        pw = iw * PX_TO_PT;
+        ph = ih * PX_TO_PT;
+    } else {
+        pw = size == PDF_PAGE_LETTER ? 612.0 : 595.28;
+        ph = size == PDF_PAGE_LETTER ? 792.0 : 841.89;
+        if (iw > ih) {
+            double t = pw;
+            pw = ph;
+            ph = t;
+        }
+    }
+
+    double margin = (size != PDF_PAGE_IMAGE && fit == PDF_FIT_CONTAIN) ? MARGIN_PT : 0.0;
+    double sx = (pw - 2 * margin) / iw;
+    double sy = (ph - 2 * margin) / ih;
+    double s = fit == PDF_FIT_COVER ? (sx > sy ? sx : sy) : (sx < sy ? sx : sy);
+    double dw = iw * s, dh = ih * s;
+    double x = (pw - dw) / 2.0, y = (ph - dh) / 2.0;
+
+    int page_obj = pdf->obj_count + 1;
+    int content_obj = page_obj + 1;
+    int image_obj = page_obj + 2;
+    FILE *fp = pdf->fp;
+
+    begin_object(pdf, page_obj);
+    fprintf(fp, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f]\n"
+                "   /Resources << /XObject << /Im0 %d 0 R >> >>\n"
+                "   /Contents %d 0 R >>\nendobj\n",
+            pw, ph, image_obj, content_obj);
+
+    char content[256];
+    int content_len = snprintf(content, sizeof(content),
+                               "q 0 0 %.2f %.2f re W n %.2f 0 0 %.2f %.2f %.2f cm /Im0 Do Q\n",
+                               pw, ph, dw, dh, x, y);
+    begin_object(pdf, content_obj);
+    fprintf(fp, "<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", content_len, content);
+
+    begin_object(pdf, image_obj);
+    fprintf(fp, "<< /Type /XObject /Subtype /Image /Width %d /Height %d\n"
+                "   /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode\n"
+                "   /Length %zu >>\nstream\n",
+            image->w, image->h, jpeg_size);
+    if (fwrite(jpeg, 1, jpeg_size, fp) != jpeg_size) pdf->failed = true;
+    fputs("\nendstream\nendobj\n", fp);
+    free(jpeg);
+
+    pdf->page_objs[pdf->page_count++] = page_obj;
+    return !pdf->failed;
+}
+
+int pdf_page_count(const PdfWriter *pdf)
+{
+    return pdf ? pdf->page_count : 0;
+}
+
+bool pdf_close(PdfWriter *pdf)
+{
+    if (!pdf) return false;
+    FILE *fp = pdf->fp;
+
+    begin_object(pdf, 1);
+    fputs("<< /Type /Catalog /Pages 2 0 R >>\nendobj\n", fp);
+
+    begin_object(pdf, 2);
+    fputs("<< /Type /Pages /Kids [", fp);
+    for (int i = 0; i < pdf->page_count; i++) {
+        fprintf(fp, "%s%d 0 R", i ? " " : "", pdf->page_objs[i]);
+    }
+    fprintf(fp, "] /Count %d >>\nendobj\n", pdf->page_count);
+
+    /* Cross-reference table: fixed 20-byte entries */
+    long xref = ftell(fp);
+    fprintf(fp, "xref\n0 %d\n0000000000 65535 f \n", pdf->obj_count + 1);
+    for (int i = 1; i <= pdf->obj_count && !pdf->failed; i++) {
+        fprintf(fp, "%010ld 00000 n \n", pdf->offsets[i]);
+    }
+    fprintf(fp, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%ld\n%%%%EOF\n",
+            pdf->obj_count + 1, xref);
+
+    bool ok = !pdf->failed && !ferror(fp);
+    if (fclose(fp) != 0) ok = false;
+    if (!ok) {
+        fprintf(stderr, "pdf: failed writing '%s'\n", pdf->path);
+    }
+
+    free(pdf->offsets);
+    free(pdf->page_objs);
+    free(pdf->path);
+    free(pdf);
+    return ok;
+}
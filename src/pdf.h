@@ -0,0 +1,27 @@
+#ifndef FRAME_PDF_H
+#define FRAME_PDF_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+#include "config.h"
+
+/* Minimal PDF writer: one image per page, embedded as JPEG.
+   Page size and fit options (PdfPageSize, PdfFit) are in config.h. */
+
+typedef struct PdfWriter PdfWriter;
+
+/* Create a PDF file at path. Returns NULL (with a message on stderr) on error. */
+PdfWriter *pdf_open(const char *path);
+
+/* Add a page showing the image. A4 and Letter pages turn to landscape for
+   landscape images. Transparent areas are drawn on white. */
+bool pdf_add_page(PdfWriter *pdf, SDL_Surface *image, PdfPageSize size, PdfFit fit);
+
+/* Get the number of pages added so far. */
+int pdf_page_count(const PdfWriter *pdf);
+
+/* Finish and close the file. Returns false if anything failed to write;
+   the writer is freed either way. */
+bool pdf_close(PdfWriter *pdf);
+
+#endif /* FRAME_PDF_H */
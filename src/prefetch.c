@@ -13,6 +13,9 @@
 
 #define NUM_WORKERS 3
 
+/* Recent paths that could not be decoded */
+#define MAX_FAILED 16
+
 struct Prefetcher {
     ImageCache *cache;            /* borrowed, already mutex-protected */
     ImageCache *thumb_cache;      /* borrowed, already mutex-protected */
@@ -25,6 +28,8 @@ struct Prefetcher {
     int    queue_pos;             /* next index the worker will process */
     unsigned int generation;      /* bumped on every prefetch_submit */
     bool   shutdown;
+    char  *failed[MAX_FAILED];    /* ring of strdup'd paths, NULL if free */
+    int    failed_next;
 
     pthread_t threads[NUM_WORKERS];
 };
@@ -40,6 +45,17 @@ static void clear_queue_locked(Prefetcher *pf)
     pf->queue_pos = 0;
 }
 
+/* Forget a recorded failure of path (caller must hold mutex). */
+static void clear_failed_locked(Prefetcher *pf, const char *path)
+{
+    for (int i = 0; i < MAX_FAILED; i++) {
+        if (pf->failed[i] && strcmp(pf->failed[i], path) == 0) {
+            free(pf->failed[i]);
+            pf->failed[i] = NULL;
+        }
+    }
+}
+
 /* Worker thread entry point. */
 static void *worker_func(void *arg)
 {
@@ -77,6 +93,14 @@ static void *worker_func(void *arg)
            If the user navigated again while we were decoding, drop the result
            so we don't pollute the cache with stale entries. */
         pthread_mutex_lock(&pf->mutex);
+        if (!surface) {
+            /* Record the failure even for a stale batch: the viewer may be
+               showing a thumbnail while it waits for this file */
+            clear_failed_locked(pf, path);
+            free(pf->failed[pf->failed_next]);
+            pf->failed[pf->failed_next] = strdup(path);
+            pf->failed_next = (pf->failed_next + 1) % MAX_FAILED;
+        }
         if (gen != pf->generation || pf->shutdown) {
             /* Stale — discard. */
             if (surface)
@@ -86,17 +110,8 @@ static void *worker_func(void *arg)
         }
 
         if (surface) {
-            /* Create a scaled-down thumbnail (max dimension 256) for quick display previews */
-            int tw = 256;
-            int th = 256;
-            if (surface->w > surface->h) {
-                th = (int)((float)surface->h * 256.0f / (float)surface->w);
-                if (th < 1) th = 1;
-            } else {
-                tw = (int)((float)surface->w * 256.0f / (float)surface->h);
-                if (tw < 1) tw = 1;
-            }
-            SDL_Surface *thumb = SDL_ScaleSurface(surface, tw, th, SDL_SCALEMODE_LINEAR);
+            /* Create a scaled-down thumbnail for quick display previews */
+            SDL_Surface *thumb = loader_make_thumbnail(surface, LOADER_THUMB_SIZE);
             if (thumb) {
                 cache_put(pf->thumb_cache, path, thumb);
             }
@@ -173,6 +188,8 @@ void prefetch_destroy(Prefetcher *pf)
 
     /* Clean up remaining queue entries. */
     clear_queue_locked(pf);  /* safe — worker has exited */
+    for (int i = 0; i < MAX_FAILED; i++)
+        free(pf->failed[i]);
 
     pthread_cond_destroy(&pf->cond);
     pthread_mutex_destroy(&pf->mutex);
@@ -204,8 +221,10 @@ void prefetch_submit(Prefetcher *pf, const char **paths, int count)
 
     pthread_mutex_lock(&pf->mutex);
 
-    /* Replace the old queue. */
+    /* Replace the old queue; submitted paths get another chance */
     clear_queue_locked(pf);
+    for (int i = 0; i < copied; i++)
+        clear_failed_locked(pf, new_queue[i]);
     pf->queue     = new_queue;
     pf->queue_len = copied;
     pf->queue_pos = 0;
@@ -214,3 +233,16 @@ void prefetch_submit(Prefetcher *pf, const char **paths, int count)
     pthread_cond_broadcast(&pf->cond);
     pthread_mutex_unlock(&pf->mutex);
 }
+
+bool prefetch_failed(Prefetcher *pf, const char *path)
+{
+    if (!pf || !path)
+        return false;
+
+    pthread_mutex_lock(&pf->mutex);
+    bool failed = false;
+    for (int i = 0; i < MAX_FAILED && !failed; i++)
+        failed = pf->failed[i] && strcmp(pf->failed[i], path) == 0;
+    pthread_mutex_unlock(&pf->mutex);
+    return failed;
+}
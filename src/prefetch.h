@@ -2,6 +2,7 @@
 #define FRAME_PREFETCH_H
 
 #include "cache.h"
+#include <stdbool.h>
 
 /*
  * Background prefetch worker thread.
@@ -25,4 +26,7 @@ void prefetch_destroy(Prefetcher *pf);
    `paths` is an array of `count` path strings — they are copied internally. */
 void prefetch_submit(Prefetcher *pf, const char **paths, int count);
 
+/* Check whether decoding path failed since it was last submitted. */
+bool prefetch_failed(Prefetcher *pf, const char *path);
+
 #endif /* FRAME_PREFETCH_H */
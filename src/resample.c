@@ -0,0 +1,199 @@
+#include "resample.h"
+#include <math.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+#define PI_F 3.14159265358979f
+
+/* Mitchell-Netravali with B = C = 1/3 */
+static float mitchell(float x)
+{
+    const float B = 1.0f / 3.0f, C = 1.0f / 3.0f;
+    x = fabsf(x);
+    if (x < 1.0f) {
+        return ((12 - 9 * B - 6 * C) * x * x * x + (-18 + 12 * B + 6 * C) * x * x + (6 - 2 * B)) / 6.0f;
+    }
+    if (x < 2.0f) {
+        return ((-B - 6 * C) * x * x * x + (6 * B + 30 * C) * x * x +
+                (-12 * B - 48 * C) * x + (8 * B + 24 * C)) / 6.0f;
+    }
+    return 0.0f;
+}
+
+static float sinc(float x)
+{
+    if (x == 0.0f) return 1.0f;
+    x *= PI_F;
+    return sinf(x) / x;
+}
+
+/* Lanczos with three lobes */
+static float lanczos3(float x)
+{
+    x = fabsf(x);
+    return x < 3.0f ? sinc(x) * sinc(x / 3.0f) : 0.0f;
+}
+
+/* Filter taps for every output position along one axis */
+typedef struct {
+    int *first;         /* first source index per output pixel */
+    int *count;         /* number of taps per output pixel */
+    float *weights;     /* taps, max_taps per output pixel */
+    int max_taps;
+} Taps;
+
+static bool build_taps(Taps *t, int src_len, int dst_len, ResampleFilter filter)
+{
+    float (*kernel)(float) = filter == RESAMPLE_LANCZOS ? lanczos3 : mitchell;
+    float radius = filter == RESAMPLE_LANCZOS ? 3.0f : 2.0f;
+
+    /* Widen the kernel when shrinking so every source pixel contributes */
+    float scale = (float)dst_len / (float)src_len;
+    float stretch = scale < 1.0f ? 1.0f / scale : 1.0f;
+    float support = radius * stretch;
+
+    t->max_taps = (int)ceilf(support) * 2 + 1;
+    t->first = malloc((size_t)dst_len * sizeof(int));
+    t->count = malloc((size_t)dst_len * sizeof(int));
+    t->weights = malloc((size_t)dst_len * (size_t)t->max_taps * sizeof(float));
+    if (!t->first || !t->count || !t->weights) return false;
+
+    for (int i = 0; i < dst_len; i++) {
+        float center = ((float)i + 0.5f) / scale - 0.5f;
+        int lo = (int)floorf(center - support);
+        int hi = (int)ceilf(center + support);
+        if (lo < 0) lo = 0;
+        if (hi > src_len - 1) hi = src_len - 1;
+        if (hi - lo + 1 > t->max_taps) hi = lo + t->max_taps - 1;
+
+        float *w = t->weights + (size_t)i * (size_t)t->max_taps;
+        float sum = 0.0f;
+        for (int j = lo; j <= hi; j++) {
+            w[j - lo] = kernel(((float)j - center) / stretch);
+            sum += w[j - lo];
+        }
+        if (sum != 0.0f) {
+            for (int j = 0; j <= hi - lo; j++) w[j] /= sum;
+        }
+        t->first[i] = lo;
+        t->count[i] = hi - lo + 1;
+    }
+    return true;
+}
+
+static void free_taps(Taps *t)
+{
+    free(t->first);
+    free(t->count);
+    free(t->weights);
+}
+
+static unsigned char to_byte(float v)
+{
+    if (v <= 0.0f) return 0;
+    if (v >= 255.0f) return 255;
+    return (unsigned char)(v + 0.5f);
+}
+
+SDL_Surface *resample_surface(SDL_Surface *src, int w, int h, ResampleFilter filter)
+{
+    if (!src || w <= 0 || h <= 0) return NULL;
+
+    if (filter == RESAMPLE_LINEAR) {
+        SDL_Surface *rgba = SDL_ConvertSurface(src, SDL_PIXELFORMAT_RGBA32);
+        if (!rgba) return NULL;
+        SDL_Surface *out = SDL_ScaleSurface(rgba, w, h, SDL_SCALEMODE_LINEAR);
+        SDL_DestroySurface(rgba);
+        return out;
+    }
+
+    SDL_Surface *in = SDL_ConvertSurface(src, SDL_PIXELFORMAT_RGBA32);
+    if (!in) return NULL;
+    SDL_Surface *out = SDL_CreateSurface(w, h, SDL_PIXELFORMAT_RGBA32);
+    float *mid = malloc((size_t)w * (size_t)in->h * 4 * sizeof(float));
+    Taps tx = {0}, ty = {0};
+    bool ok = out && mid &&
+              build_taps(&tx, in->w, w, filter) && build_taps(&ty, in->h, h, filter);
+    if (!ok) {
+        fprintf(stderr, "resample: out of memory scaling to %dx%d\n", w, h);
+        free_taps(&tx);
+        free_taps(&ty);
+        free(mid);
+        SDL_DestroySurface(in);
+        SDL_DestroySurface(out);
+        return NULL;
+    }
+
+    /* Horizontal pass into premultiplied floats */
+    for (int y = 0; y < in->h; y++) {
+        const unsigned char *row = (const unsigned char *)in->pixels + (size_t)y * (size_t)in->pitch;
+        float *dst = mid + (size_t)y * (size_t)w * 4;
+        for (int x = 0; x < w; x++) {
+            const float *wt = tx.weights + (size_t)x * (size_t)tx.max_taps;
+            float r = 0, g = 0, b = 0, a = 0;
+            for (int k = 0; k < tx.count[x]; k++) {
+                const unsigned char *p = row + (size_t)(tx.first[x] + k) * 4;
+                float pa = p[3] * wt[k];
+                r += p[0] * pa;
+                g += p[1] * pa;
+                b += p[2] * pa;
+                a += pa;
+            }
+            dst[x * 4 + 0] = r / 255.0f;
+            dst[x * 4 + 1] = g / 255.0f;
+            dst[x * 4 + 2] = b / 255.0f;
+            dst[x * 4 + 3] = a;
+        }
+    }
+
+    /* Vertical pass, un-premultiplying on the way out */
+    for (int y = 0; y < h; y++) {
+        const float *wt = ty.weights + (size_t)y * (size_t)ty.max_taps;
+        unsigned char *row = (unsigned char *)out->pixels + (size_t)y * (size_t)out->pitch;
+        for (int x = 0; x < w; x++) {
+            float r = 0, g = 0, b = 0, a = 0;
+            for (int k = 0; k < ty.count[y]; k++) {
+                const float *p = mid + ((size_t)(ty.first[y] + k) * (size_t)w + (size_t)x) * 4;
+                r += p[0] * wt[k];
+                g += p[1] * wt[k];
+                b += p[2] * wt[k];
+                a += p[3] * wt[k];
+            }
+            unsigned char *q = row + (size_t)x * 4;
+            if (a > 0.5f) {
+                q[0] = to_byte(r * 255.0f / a);
+                q[1] = to_byte(g * 255.0f / a);
+                q[2] = to_byte(b * 255.0f / a);
+            } else {
+                q[0] = q[1] = q[2] = 0;
+            }
+            q[3] = to_byte(a);
+        }
+    }
+
+    free_taps(&tx);
+    free_taps(&ty);
+    free(mid);
+    SDL_DestroySurface(in);
+    return out;
+}
+
+bool resample_blit(SDL_Surface *src, SDL_Surface *dst, const SDL_Rect *dst_rect,
+                   ResampleFilter filter)
+{
+    if (!src || !dst || !dst_rect) return false;
+
+    SDL_SetSurfaceBlendMode(src, SDL_BLENDMODE_BLEND);
+    if (filter == RESAMPLE_LINEAR ||
+        (src->w == dst_rect->w && src->h == dst_rect->h)) {
+        return SDL_BlitSurfaceScaled(src, NULL, dst, dst_rect, SDL_SCALEMODE_LINEAR);
+    }
+
+    SDL_Surface *scaled = resample_surface(src, dst_rect->w, dst_rect->h, filter);
+    if (!scaled) return false;
+    SDL_SetSurfaceBlendMode(scaled, SDL_BLENDMODE_BLEND);
+    SDL_Rect r = *dst_rect;
+    bool ok = SDL_BlitSurface(scaled, NULL, dst, &r);
+    SDL_DestroySurface(scaled);
+    return ok;
+}
@@ -0,0 +1,19 @@
+#ifndef FRAME_RESAMPLE_H
+#define FRAME_RESAMPLE_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+#include "config.h"
+
+/* Scale src into dst_rect of dst using the given filter and blend it over
+   what is there. RESAMPLE_LINEAR is SDL's bilinear blit; Mitchell and
+   Lanczos are separable convolutions in premultiplied alpha, slower but
+   much sharper and free of aliasing when shrinking a lot. */
+bool resample_blit(SDL_Surface *src, SDL_Surface *dst, const SDL_Rect *dst_rect,
+                   ResampleFilter filter);
+
+/* Return a new RGBA surface of w x h scaled with the filter (caller frees),
+   or NULL on error. */
+SDL_Surface *resample_surface(SDL_Surface *src, int w, int h, ResampleFilter filter);
+
+#endif /* FRAME_RESAMPLE_H */
@@ -4,19 +4,32 @@
 #include "viewer.h"
 #include "cache.h"
 #include "loader.h"
+#include "theme.h"
+#include "overlay.h"
+#include "info.h"
+#include "utils.h"
+#include "fileops.h"
+#include "xmp.h"
+#include "burst.h"
+#include "config.h"
+#include "hooks.h"
 #include <SDL3_ttf/SDL_ttf.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 #include <strings.h>
+#include <ctype.h>
+#include <time.h>
 
 #define GRID_COLS 5
 #define GRID_ROWS 5
 #define CELL_PADDING 10
 #define TOP_BAR_HEIGHT 60
 #define FILENAME_HEIGHT 20
+#define LIST_ROW_HEIGHT 28
 
 static bool active = false;
+static SearchMode mode = SEARCH_MODE_GRID;
 static struct AppState *current_app = NULL;
 static struct Viewer *current_viewer = NULL;
 
@@ -26,15 +39,17 @@ static int filtered_count = 0;
 
 /* Navigation / selection indexes (relative to filtered list) */
 static int selected_item = 0;
-static int scroll_offset = 0; /* Row index of first visible row in grid */
+static int scroll_offset = 0; /* Row index of first visible row in grid (or list) */
+static int list_visible_rows = 1; /* rows that fit in the list, updated on render */
 
 /* Search query string */
 static char search_query[256] = {0};
 
 /* Cache font / text textures */
-static TTF_Font *search_font = NULL;
+static TTF_Font *search_font = NULL; /* borrowed from overlay_ui_font() */
 static SDL_Texture *query_texture = NULL;
 static int query_w = 0, query_h = 0;
+static const ThemePalette *query_palette = NULL; /* palette query_texture was drawn with */
 
 /* Grid cell texture cache to avoid reloading/re-creating textures on every frame */
 typedef struct {
@@ -45,6 +60,16 @@ typedef struct {
 #define MAX_VISIBLE_TEX (GRID_COLS * GRID_ROWS)
 static CellTextureCache visible_textures[MAX_VISIBLE_TEX];
 
+/* Per-cell badge data (rating, tags, sidecar), read once when an image
+   scrolls into a slot rather than on every frame */
+typedef struct {
+    int app_idx;
+    XmpInfo xmp;
+    bool has_sidecar;
+} CellMeta;
+
+static CellMeta cell_meta[MAX_VISIBLE_TEX];
+
 static void clear_visible_textures(void) {
     for (int i = 0; i < MAX_VISIBLE_TEX; i++) {
         if (visible_textures[i].texture) {
@@ -52,32 +77,219 @@ static void clear_visible_textures(void) {
             visible_textures[i].texture = NULL;
         }
         visible_textures[i].app_idx = -1;
+        cell_meta[i].app_idx = -1;
     }
 }
 
 static int selected_app_index = 0;
 
+/* Burst stacks: shots taken within a second of each other share one grid
+   cell, their first shot, until the stack is expanded. Indexed by app index
+   and rebuilt as capture dates arrive from the background reader; images
+   whose date is not known yet stay on their own. */
+static bool group_bursts = false;
+static int *burst_first = NULL;   /* app index of the first shot of each image's burst */
+static int *burst_length = NULL;  /* shots in each image's burst (1 = on its own) */
+static int burst_images = 0;      /* list length the arrays were built for */
+static int burst_total = 0;       /* bursts of two or more shots */
+static int expanded_burst = -1;   /* burst_first of the open stack, -1 if none */
+static int burst_pending = 0;     /* images whose date is still being read */
+
+static void free_bursts(void) {
+    free(burst_first);
+    free(burst_length);
+    burst_first = NULL;
+    burst_length = NULL;
+    burst_images = 0;
+    burst_total = 0;
+    burst_pending = 0;
+    expanded_burst = -1;
+}
+
+static void build_bursts(void) {
+    int expanded = expanded_burst;
+    free_bursts();
+    int count = app_image_count(current_app);
+    if (count == 0) return;
+
+    time_t *times = malloc(sizeof(time_t) * count);
+    burst_first = malloc(sizeof(int) * count);
+    burst_length = malloc(sizeof(int) * count);
+    if (!times || !burst_first || !burst_length) {
+        free(times);
+        free_bursts();
+        return;
+    }
+
+    for (int i = 0; i < count; i++) {
+        if (!burst_lookup_time(app_image_path(current_app, i), &times[i])) {
+            times[i] = 0;
+            burst_pending++;
+        }
+    }
+    burst_group(times, count, burst_first, burst_length);
+    free(times);
+
+    burst_images = count;
+    for (int i = 0; i < count; i++) {
+        if (burst_first[i] == i && burst_length[i] > 1) burst_total++;
+    }
+    if (expanded >= 0 && expanded < count && burst_first[expanded] == expanded) {
+        expanded_burst = expanded;
+    }
+}
+
+/* Check whether image app_idx is part of a burst of two or more shots */
+static bool in_burst(int app_idx) {
+    return group_bursts && app_idx < burst_images && burst_length[app_idx] > 1;
+}
+
+static int find_app_index(const char *path) {
+    int count = app_image_count(current_app);
+    for (int i = 0; i < count; i++) {
+        if (strcmp(app_image_path(current_app, i), path) == 0) return i;
+    }
+    return -1;
+}
+
+/* Hover preview: the grid item under the mouse pointer is shown enlarged,
+   with its name, size and date, once the pointer rests on it */
+#define HOVER_DELAY_MS 400
+static int hover_item = -1;           /* filtered index under the pointer, -1 if none */
+static Uint64 hover_since = 0;
+static bool hover_shown = false;      /* preview drawn since the pointer stopped */
+static int hover_caption_item = -1;   /* item the caption lines were read for */
+static char hover_caption[3][192];
+
+/* Place of visible grid slot `i` (row-major from the top-left) */
+static SDL_FRect grid_cell_rect(int i, int vp_w, int vp_h) {
+    float grid_y = TOP_BAR_HEIGHT + CELL_PADDING;
+    float grid_h = vp_h - grid_y - CELL_PADDING;
+    float grid_w = vp_w - CELL_PADDING * 2;
+    float cell_w = (grid_w - (GRID_COLS - 1) * CELL_PADDING) / GRID_COLS;
+    float cell_h = (grid_h - (GRID_ROWS - 1) * CELL_PADDING) / GRID_ROWS;
+
+    SDL_FRect r = {
+        CELL_PADDING + (i % GRID_COLS) * (cell_w + CELL_PADDING),
+        grid_y + (i / GRID_COLS) * (cell_h + CELL_PADDING),
+        cell_w, cell_h
+    };
+    return r;
+}
+
+/* Find the filtered item under a point in the grid, or -1 */
+static int grid_item_at(float x, float y, int vp_w, int vp_h) {
+    int start_item = scroll_offset * GRID_COLS;
+    for (int i = 0; i < GRID_COLS * GRID_ROWS && start_item + i < filtered_count; i++) {
+        SDL_FRect r = grid_cell_rect(i, vp_w, vp_h);
+        if (x >= r.x && x < r.x + r.w && y >= r.y && y < r.y + r.h) {
+            return start_item + i;
+        }
+    }
+    return -1;
+}
+
+static void clear_hover(void) {
+    hover_item = -1;
+    hover_shown = false;
+}
+
+static bool hover_due(void) {
+    return hover_item >= 0 && SDL_GetTicks() - hover_since >= HOVER_DELAY_MS;
+}
+
+/* Ask for the thumbnails on screen first, then the page below and the page
+   above (nearest rows first) so scrolling either way finds them ready.
+   Work still queued for rows scrolled away from is dropped. */
 static void request_visible_thumbnails(void) {
     if (!current_viewer || !current_app || filtered_count == 0) return;
 
-    const char *paths[MAX_VISIBLE_TEX];
+    const char *paths[MAX_VISIBLE_TEX * 3];
     int count = 0;
 
     int start_item = scroll_offset * GRID_COLS;
-    for (int i = 0; i < MAX_VISIBLE_TEX; i++) {
-        int item_idx = start_item + i;
-        if (item_idx >= filtered_count) break;
+    for (int i = 0; i < MAX_VISIBLE_TEX * 3; i++) {
+        /* 0..24 on screen, 25..49 below, 50..74 above counting upwards */
+        int item_idx = i < MAX_VISIBLE_TEX * 2 ? start_item + i
+                                               : start_item - 1 - (i - MAX_VISIBLE_TEX * 2);
+        if (item_idx < 0 || item_idx >= filtered_count) continue;
 
-        int app_idx = filtered_indices[item_idx];
-        const char *path = app_image_path(current_app, app_idx);
+        const char *path = app_image_path(current_app, filtered_indices[item_idx]);
         if (path) {
             paths[count++] = path;
         }
     }
 
-    if (count > 0) {
-        viewer_prefetch_paths(current_viewer, paths, count);
+    viewer_request_thumbnails(current_viewer, paths, count);
+}
+
+/* Fuzzy match: every query character must appear in order (ignoring case).
+   Runs of consecutive characters and matches at the start of a word score
+   higher; gaps cost a little. Returns -1 if the name does not match. */
+static int fuzzy_score(const char *name, const char *query) {
+    int score = 0;
+    int run = 0;
+    const char *prev = NULL;
+    const char *n = name;
+    for (const char *q = query; *q; q++) {
+        char qc = (char)tolower((unsigned char)*q);
+        while (*n && tolower((unsigned char)*n) != qc) n++;
+        if (!*n) return -1;
+
+        bool word_start = n == name || strchr(" -_.", n[-1]) != NULL;
+        if (prev && n == prev + 1) {
+            run++;
+            score += 5 * run;
+        } else {
+            run = 0;
+            if (prev) score -= (int)(n - prev - 1) > 10 ? 10 : (int)(n - prev - 1);
+        }
+        if (word_start) score += 10;
+        score += 1;
+        prev = n++;
     }
+    return score;
+}
+
+typedef struct {
+    int index;
+    int score;
+} RankedItem;
+
+/* Best score first; ties keep list order */
+static int compare_ranked(const void *a, const void *b) {
+    const RankedItem *ra = a, *rb = b;
+    if (ra->score != rb->score) return rb->score - ra->score;
+    return ra->index - rb->index;
+}
+
+/* Quick switcher: keep fuzzy matches, ranked by score */
+static void update_fuzzy_filter(int total_files) {
+    RankedItem *ranked = malloc(sizeof(RankedItem) * (total_files > 0 ? total_files : 1));
+    if (!ranked) return;
+
+    int n = 0;
+    for (int i = 0; i < total_files; i++) {
+        const char *path = app_image_path(current_app, i);
+        if (!path) continue;
+        const char *filename = strrchr(path, '/');
+        filename = filename ? filename + 1 : path;
+
+        int score = fuzzy_score(filename, search_query);
+        if (score >= 0) {
+            ranked[n].index = i;
+            ranked[n].score = score;
+            n++;
+        }
+    }
+    if (search_query[0]) {
+        qsort(ranked, (size_t)n, sizeof(RankedItem), compare_ranked);
+    }
+    for (int i = 0; i < n; i++) {
+        filtered_indices[i] = ranked[i].index;
+    }
+    filtered_count = n;
+    free(ranked);
 }
 
 static void update_filter(void) {
@@ -91,8 +303,9 @@ static void update_filter(void) {
     if (!filtered_indices) return;
 
     size_t query_len = strlen(search_query);
+    int stack = -1; /* burst whose first match is already listed */
 
-    for (int i = 0; i < total_files; i++) {
+    for (int i = 0; i < total_files && mode == SEARCH_MODE_GRID; i++) {
         const char *path = app_image_path(current_app, i);
         if (!path) continue;
 
@@ -101,13 +314,22 @@ static void update_filter(void) {
         else filename = path;
 
         if (query_len == 0 || strcasestr(filename, search_query) != NULL) {
+            /* A closed stack shows only its first matching shot */
+            if (in_burst(i) && burst_first[i] != expanded_burst) {
+                if (burst_first[i] == stack) continue;
+                stack = burst_first[i];
+            }
             filtered_indices[filtered_count++] = i;
         }
     }
+    if (mode == SEARCH_MODE_LIST) {
+        update_fuzzy_filter(total_files);
+    }
 
     /* Auto-select first matching element */
     selected_item = 0;
     scroll_offset = 0;
+    clear_hover();
     if (filtered_count > 0) {
         selected_app_index = filtered_indices[0];
     } else {
@@ -115,7 +337,14 @@ static void update_filter(void) {
     }
 
     clear_visible_textures();
-    request_visible_thumbnails();
+    if (mode == SEARCH_MODE_GRID) {
+        request_visible_thumbnails();
+    }
+}
+
+/* Label in front of the typed query */
+static const char *query_prefix(void) {
+    return mode == SEARCH_MODE_LIST ? "Go to: " : "Search: ";
 }
 
 static void rebuild_query_texture(SDL_Renderer *renderer) {
@@ -126,10 +355,11 @@ static void rebuild_query_texture(SDL_Renderer *renderer) {
     if (!search_font) return;
 
     char display_text[512];
-    snprintf(display_text, sizeof(display_text), "Search: %s", search_query);
+    snprintf(display_text, sizeof(display_text), "%s%s", query_prefix(), search_query);
 
-    SDL_Color white = {255, 255, 255, 255};
-    SDL_Surface *surf = TTF_RenderText_Blended(search_font, display_text, 0, white);
+    query_palette = theme_get();
+    SDL_Color text_color = query_palette->text;
+    SDL_Surface *surf = TTF_RenderText_Blended(search_font, display_text, 0, text_color);
     if (surf) {
         query_texture = SDL_CreateTextureFromSurface(renderer, surf);
         query_w = surf->w;
@@ -139,30 +369,25 @@ static void rebuild_query_texture(SDL_Renderer *renderer) {
 }
 
 void search_init(void) {
-    /* Fonts will be retrieved from the overlay system or local font search */
-    const char *font_paths[] = {
-        "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
-        "/usr/share/fonts/TTF/DejaVuSans.ttf",
-        "/usr/share/fonts/dejavu/DejaVuSans.ttf",
-        "/usr/share/fonts/truetype/liberation/LiberationSans-Regular.ttf",
-        "/run/current-system/sw/share/X11/fonts/DejaVuSans.ttf",
-        NULL
-    };
-    for (int i = 0; font_paths[i]; i++) {
-        search_font = TTF_OpenFont(font_paths[i], 16.0f);
-        if (search_font) break;
-    }
     for (int i = 0; i < MAX_VISIBLE_TEX; i++) {
         visible_textures[i].texture = NULL;
         visible_textures[i].app_idx = -1;
+        cell_meta[i].app_idx = -1;
     }
+    group_bursts = config_get()->group_bursts;
 }
 
-void search_open(struct AppState *app, struct Viewer *viewer, SDL_Renderer *renderer, SDL_Window *window) {
+void search_open(struct AppState *app, struct Viewer *viewer, SDL_Renderer *renderer, SDL_Window *window,
+                 SearchMode open_mode) {
+    search_font = overlay_ui_font();
+    mode = open_mode;
     current_app = app;
     current_viewer = viewer;
     active = true;
     search_query[0] = '\0';
+    if (group_bursts && mode == SEARCH_MODE_GRID) {
+        build_bursts();
+    }
     update_filter();
     rebuild_query_texture(renderer);
 
@@ -173,10 +398,15 @@ void search_open(struct AppState *app, struct Viewer *viewer, SDL_Renderer *rend
 void search_close(SDL_Window *window) {
     if (!active) return;
     active = false;
+    viewer_request_thumbnails(current_viewer, NULL, 0);
     clear_visible_textures();
+    clear_hover();
+    hover_caption_item = -1;
     free(filtered_indices);
     filtered_indices = NULL;
     filtered_count = 0;
+    free_bursts();
+    burst_forget();
     if (query_texture) {
         SDL_DestroyTexture(query_texture);
         query_texture = NULL;
@@ -191,12 +421,72 @@ bool search_is_active(void) {
     return active;
 }
 
+void search_refresh(void) {
+    if (!active) return;
+
+    int item = selected_item;
+    int scroll = scroll_offset;
+    if (group_bursts && mode == SEARCH_MODE_GRID &&
+        burst_images != app_image_count(current_app)) {
+        build_bursts();
+    }
+    update_filter();
+    if (filtered_count == 0) return;
+
+    /* Stay where the user was rather than jumping back to the top */
+    selected_item = item < filtered_count ? item : filtered_count - 1;
+    selected_app_index = filtered_indices[selected_item];
+    scroll_offset = scroll;
+    if (mode == SEARCH_MODE_GRID && scroll > 0) {
+        clear_visible_textures();
+        request_visible_thumbnails();
+    }
+}
+
 int search_selected_index(void) {
     return selected_app_index;
 }
 
+/* Select image app_idx (or the stack holding it) after the grid was
+   filtered again, scrolling it into view */
+static void select_app_index(int app_idx) {
+    for (int i = 0; i < filtered_count; i++) {
+        int idx = filtered_indices[i];
+        if (idx == app_idx || (in_burst(app_idx) && in_burst(idx) &&
+                               burst_first[idx] == burst_first[app_idx])) {
+            selected_item = i;
+            break;
+        }
+    }
+    if (filtered_count == 0) return;
+    selected_app_index = filtered_indices[selected_item];
+
+    int sel_row = selected_item / GRID_COLS;
+    if (sel_row < scroll_offset) {
+        scroll_offset = sel_row;
+    } else if (sel_row >= scroll_offset + GRID_ROWS) {
+        scroll_offset = sel_row - GRID_ROWS + 1;
+    }
+    clear_visible_textures();
+    request_visible_thumbnails();
+}
+
+/* Capture dates arrived from the background reader: regroup the stacks,
+   staying on the selected image */
+static void regroup_bursts(void) {
+    int app_idx = filtered_count > 0 ? filtered_indices[selected_item] : -1;
+    build_bursts();
+    update_filter();
+    if (app_idx >= 0) select_app_index(app_idx);
+}
+
 bool search_check_dirty(void) {
-    if (!active || !current_viewer) return false;
+    if (!active || !current_viewer || mode != SEARCH_MODE_GRID) return false;
+    if (!hover_shown && hover_due()) return true;
+    if (group_bursts && burst_times_updated()) {
+        regroup_bursts();
+        return true;
+    }
 
     struct ImageCache *thumb_cache = viewer_get_thumb_cache(current_viewer);
     if (!thumb_cache) return false;
@@ -225,6 +515,157 @@ bool search_check_dirty(void) {
     return found_new;
 }
 
+/* Ctrl+b: switch burst stacks on or off, staying on the selected image */
+static void toggle_burst_grouping(void) {
+    int app_idx = filtered_count > 0 ? filtered_indices[selected_item] : -1;
+
+    group_bursts = !group_bursts;
+    if (group_bursts) {
+        build_bursts();
+    } else {
+        free_bursts();
+    }
+    update_filter();
+    if (app_idx >= 0) select_app_index(app_idx);
+
+    char msg[64];
+    if (!group_bursts) {
+        snprintf(msg, sizeof(msg), "Burst stacks off");
+    } else if (burst_total == 0 && burst_pending > 0) {
+        snprintf(msg, sizeof(msg), "Reading capture dates\xe2\x80\xa6");
+    } else if (burst_total == 0) {
+        snprintf(msg, sizeof(msg), "No bursts in this folder");
+    } else {
+        snprintf(msg, sizeof(msg), "%d burst%s stacked", burst_total, burst_total == 1 ? "" : "s");
+    }
+    overlay_show_osd(msg);
+}
+
+/* Tab: open the selected stack to show every shot, or close it again */
+static void toggle_selected_stack(void) {
+    if (filtered_count == 0) return;
+    int app_idx = filtered_indices[selected_item];
+    if (!in_burst(app_idx)) return;
+
+    expanded_burst = expanded_burst == burst_first[app_idx] ? -1 : burst_first[app_idx];
+    update_filter();
+    select_app_index(app_idx);
+}
+
+/* Ctrl+k: keep the selected shot and move the rest of its burst to trash
+   (with their raw files when raw_pairs is set). Returns true if anything
+   was trashed; the keeper is then the selected image. */
+static bool keep_selected_shot(SDL_Window *window) {
+    if (filtered_count == 0) return false;
+    int app_idx = filtered_indices[selected_item];
+    if (!in_burst(app_idx)) {
+        overlay_show_osd("Not part of a burst");
+        return false;
+    }
+    if (config_get()->read_only) {
+        overlay_show_osd("Read-only mode: delete is disabled");
+        return false;
+    }
+
+    int first = burst_first[app_idx];
+    int length = burst_length[app_idx];
+    const char *name = strrchr(app_image_path(current_app, app_idx), '/');
+    name = name ? name + 1 : app_image_path(current_app, app_idx);
+
+    char msg[512];
+    int ret = snprintf(msg, sizeof(msg), "Keep \"%s\" and move the other %d shot%s of this burst to trash?",
+                       name, length - 1, length == 2 ? "" : "s");
+    if (ret < 0 || (size_t)ret >= sizeof(msg) ||
+        !overlay_modal_confirm("Keep Shot", msg, SDL_GetRenderer(window), current_viewer)) {
+        return false;
+    }
+
+    /* The list shifts as shots are removed, so work from copies */
+    char *keeper = strdup(app_image_path(current_app, app_idx));
+    char **others = malloc(sizeof(char *) * length);
+    if (!keeper || !others) {
+        free(keeper);
+        free(others);
+        return false;
+    }
+    int other_count = 0;
+    for (int i = first; i < first + length; i++) {
+        if (i == app_idx) continue;
+        others[other_count] = strdup(app_image_path(current_app, i));
+        if (others[other_count]) other_count++;
+    }
+
+    int trashed = 0;
+    for (int i = 0; i < other_count; i++) {
+        char *raw = config_get()->raw_pairs ? fileops_raw_companion(others[i]) : NULL;
+        if (fileops_trash(others[i]) != 0) {
+            fprintf(stderr, "search: could not trash %s\n", others[i]);
+            free(raw);
+            free(others[i]);
+            continue;
+        }
+        if (raw && fileops_trash(raw) != 0) {
+            fprintf(stderr, "search: could not trash %s\n", raw);
+        }
+        free(raw);
+
+        hooks_run(HOOK_IMAGE_DELETED, others[i], find_app_index(others[i]) + 1,
+                  app_image_count(current_app));
+        app_remove_path(current_app, others[i]);
+        trashed++;
+        free(others[i]);
+    }
+    free(others);
+
+    if (trashed < other_count) {
+        snprintf(msg, sizeof(msg), "Could not trash %d of %d shots", other_count - trashed, other_count);
+    } else {
+        name = strrchr(keeper, '/');
+        snprintf(msg, sizeof(msg), "Kept %s, trashed %d", name ? name + 1 : keeper, trashed);
+    }
+    overlay_show_osd(msg);
+
+    int keeper_idx = find_app_index(keeper);
+    free(keeper);
+    if (trashed == 0 || keeper_idx < 0) return false;
+    selected_app_index = keeper_idx;
+    return true;
+}
+
+/* Quick switcher navigation. Letters go to the query, so only arrows,
+   Page Up/Down, Home/End and Ctrl+n/p/j/k move the selection. */
+static void handle_list_key(const SDL_Event *event) {
+    SDL_Keycode key = event->key.key;
+    bool ctrl = (event->key.mod & SDL_KMOD_CTRL) != 0;
+    if (filtered_count == 0) return;
+
+    if (key == SDLK_UP || (ctrl && (key == SDLK_P || key == SDLK_K))) {
+        selected_item--;
+    } else if (key == SDLK_DOWN || (ctrl && (key == SDLK_N || key == SDLK_J))) {
+        selected_item++;
+    } else if (key == SDLK_PAGEUP) {
+        selected_item -= list_visible_rows;
+    } else if (key == SDLK_PAGEDOWN) {
+        selected_item += list_visible_rows;
+    } else if (key == SDLK_HOME) {
+        selected_item = 0;
+    } else if (key == SDLK_END) {
+        selected_item = filtered_count - 1;
+    } else {
+        return;
+    }
+
+    if (selected_item < 0) selected_item = 0;
+    if (selected_item >= filtered_count) selected_item = filtered_count - 1;
+    selected_app_index = filtered_indices[selected_item];
+
+    if (selected_item < scroll_offset) {
+        scroll_offset = selected_item;
+    } else if (selected_item >= scroll_offset + list_visible_rows) {
+        scroll_offset = selected_item - list_visible_rows + 1;
+    }
+}
+
 SearchResult search_handle_event(const SDL_Event *event, SDL_Window *window) {
     if (!active) return SEARCH_CONTINUE;
 
@@ -240,9 +681,28 @@ SearchResult search_handle_event(const SDL_Event *event, SDL_Window *window) {
         return SEARCH_CONTINUE;
     }
 
+    case SDL_EVENT_MOUSE_MOTION: {
+        int vp_w, vp_h;
+        SDL_Renderer *renderer = SDL_GetRenderer(window);
+        if (mode != SEARCH_MODE_GRID || !renderer ||
+            !SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) {
+            return SEARCH_CONTINUE;
+        }
+        int item = grid_item_at(event->motion.x, event->motion.y, vp_w, vp_h);
+        if (item != hover_item) {
+            hover_item = item;
+            hover_since = SDL_GetTicks();
+            hover_shown = false;
+        }
+        return SEARCH_CONTINUE;
+    }
+
     case SDL_EVENT_KEY_DOWN: {
         SDL_Keycode key = event->key.key;
 
+        /* The grid may move under the pointer; wait for the mouse again */
+        clear_hover();
+
         if (key == SDLK_ESCAPE) {
             search_close(window);
             return SEARCH_CANCEL;
@@ -269,6 +729,29 @@ SearchResult search_handle_event(const SDL_Event *event, SDL_Window *window) {
             return SEARCH_CONTINUE;
         }
 
+        if (mode == SEARCH_MODE_LIST) {
+            handle_list_key(event);
+            return SEARCH_CONTINUE;
+        }
+
+        /* Burst stacks */
+        bool ctrl = (event->key.mod & SDL_KMOD_CTRL) != 0;
+        if (ctrl && key == SDLK_B) {
+            toggle_burst_grouping();
+            return SEARCH_CONTINUE;
+        }
+        if (key == SDLK_TAB) {
+            toggle_selected_stack();
+            return SEARCH_CONTINUE;
+        }
+        if (ctrl && key == SDLK_K) {
+            if (keep_selected_shot(window)) {
+                search_close(window);
+                return SEARCH_SELECT;
+            }
+            return SEARCH_CONTINUE;
+        }
+
         /* Keyboard navigation: hjkl + arrows */
         int row = selected_item / GRID_COLS;
 
@@ -317,6 +800,249 @@ SearchResult search_handle_event(const SDL_Event *event, SDL_Window *window) {
     return SEARCH_CONTINUE;
 }
 
+/* Quick switcher rows: file name on the left, position in the set on the right */
+static void render_list(SDL_Renderer *renderer, int vp_w, int vp_h) {
+    float list_y = TOP_BAR_HEIGHT + CELL_PADDING;
+    list_visible_rows = (int)((vp_h - list_y - CELL_PADDING) / LIST_ROW_HEIGHT);
+    if (list_visible_rows < 1) list_visible_rows = 1;
+
+    /* Keep the selection in view after a resize */
+    if (selected_item >= scroll_offset + list_visible_rows) {
+        scroll_offset = selected_item - list_visible_rows + 1;
+    }
+
+    int total = app_image_count(current_app);
+    for (int row = 0; row < list_visible_rows; row++) {
+        int item_idx = scroll_offset + row;
+        if (item_idx >= filtered_count) break;
+
+        int app_idx = filtered_indices[item_idx];
+        const char *path = app_image_path(current_app, app_idx);
+        if (!path) continue;
+
+        SDL_FRect row_rect = {CELL_PADDING, list_y + row * LIST_ROW_HEIGHT,
+                              vp_w - CELL_PADDING * 2.0f, LIST_ROW_HEIGHT};
+        if (item_idx == selected_item) {
+            theme_set_color(renderer, theme_get()->selection, 255);
+            SDL_RenderFillRect(renderer, &row_rect);
+            theme_set_color(renderer, theme_get()->accent, 255);
+            SDL_FRect bar = {row_rect.x, row_rect.y, 3, row_rect.h};
+            SDL_RenderFillRect(renderer, &bar);
+        }
+
+        /* Position in the set, right-aligned and dimmed */
+        char pos_text[32];
+        snprintf(pos_text, sizeof(pos_text), "%d / %d", app_idx + 1, total);
+        float pos_w = 0;
+        SDL_Surface *pos_surf = TTF_RenderText_Blended(search_font, pos_text, 0, theme_get()->text_dim);
+        if (pos_surf) {
+            SDL_Texture *pos_tex = SDL_CreateTextureFromSurface(renderer, pos_surf);
+            if (pos_tex) {
+                pos_w = pos_surf->w;
+                SDL_FRect r = {row_rect.x + row_rect.w - pos_w - 12,
+                               row_rect.y + (row_rect.h - pos_surf->h) / 2.0f,
+                               (float)pos_surf->w, (float)pos_surf->h};
+                SDL_RenderTexture(renderer, pos_tex, NULL, &r);
+                SDL_DestroyTexture(pos_tex);
+            }
+            SDL_DestroySurface(pos_surf);
+        }
+
+        const char *name = strrchr(path, '/');
+        name = name ? name + 1 : path;
+        SDL_Surface *name_surf = TTF_RenderText_Blended(search_font, name, 0, theme_get()->text);
+        if (name_surf) {
+            SDL_Texture *name_tex = SDL_CreateTextureFromSurface(renderer, name_surf);
+            if (name_tex) {
+                /* Clip long names before the position column */
+                float max_w = row_rect.w - pos_w - 48;
+                float tw = name_surf->w < max_w ? name_surf->w : max_w;
+                SDL_FRect src = {0, 0, tw, (float)name_surf->h};
+                SDL_FRect dst = {row_rect.x + 12, row_rect.y + (row_rect.h - name_surf->h) / 2.0f,
+                                 tw, (float)name_surf->h};
+                SDL_RenderTexture(renderer, name_tex, &src, &dst);
+                SDL_DestroyTexture(name_tex);
+            }
+            SDL_DestroySurface(name_surf);
+        }
+    }
+}
+
+/* Read the caption lines of the hovered item: name, size, date */
+static void load_hover_caption(const char *path) {
+    for (int i = 0; i < 3; i++) hover_caption[i][0] = '\0';
+
+    ImageInfo info;
+    if (!info_read(path, &info)) {
+        const char *name = strrchr(path, '/');
+        snprintf(hover_caption[0], sizeof(hover_caption[0]), "%s", name ? name + 1 : path);
+        return;
+    }
+    snprintf(hover_caption[0], sizeof(hover_caption[0]), "%s", info.name);
+
+    char *size_str = format_file_size(info.file_size);
+    if (info_probe_dimensions(&info)) {
+        snprintf(hover_caption[1], sizeof(hover_caption[1]), "%d \xc3\x97 %d  \xc2\xb7  %s",
+                 info.width, info.height, size_str ? size_str : "?");
+    } else {
+        snprintf(hover_caption[1], sizeof(hover_caption[1]), "%s  \xc2\xb7  %s",
+                 info.format, size_str ? size_str : "?");
+    }
+    free(size_str);
+
+    /* Capture date if the camera recorded one, else the modification time */
+    if (info.has_exif && info.exif.date[0]) {
+        snprintf(hover_caption[2], sizeof(hover_caption[2]), "Taken %s", info.exif.date);
+    } else {
+        struct tm *tm_info = localtime(&info.modified);
+        if (tm_info) {
+            strftime(hover_caption[2], sizeof(hover_caption[2]), "Modified %Y-%m-%d %H:%M", tm_info);
+        }
+    }
+    info_free(&info);
+}
+
+/* Popover next to the hovered cell: the thumbnail at full size and a caption */
+static void render_hover_preview(SDL_Renderer *renderer, int vp_w, int vp_h) {
+    int slot = hover_item - scroll_offset * GRID_COLS;
+    if (slot < 0 || slot >= MAX_VISIBLE_TEX || hover_item >= filtered_count) return;
+
+    int app_idx = filtered_indices[hover_item];
+    const char *path = app_image_path(current_app, app_idx);
+    SDL_Texture *tex = visible_textures[slot].app_idx == app_idx ? visible_textures[slot].texture : NULL;
+    if (!path || !tex) return;
+
+    if (hover_caption_item != app_idx) {
+        load_hover_caption(path);
+        hover_caption_item = app_idx;
+    }
+
+    /* Caption textures, drawn fresh as the overlay does for short text */
+    SDL_Texture *lines[3] = {NULL};
+    float line_w[3] = {0}, line_h[3] = {0};
+    for (int i = 0; i < 3; i++) {
+        if (!hover_caption[i][0]) continue;
+        SDL_Color color = i == 0 ? theme_get()->text : theme_get()->text_dim;
+        SDL_Surface *surf = TTF_RenderText_Blended(search_font, hover_caption[i], 0, color);
+        if (!surf) continue;
+        lines[i] = SDL_CreateTextureFromSurface(renderer, surf);
+        line_w[i] = (float)surf->w;
+        line_h[i] = (float)surf->h;
+        SDL_DestroySurface(surf);
+    }
+
+    float tw, th;
+    SDL_GetTextureSize(tex, &tw, &th);
+    float box_w = tw;
+    float text_h = 0;
+    for (int i = 0; i < 3; i++) {
+        if (line_w[i] > box_w) box_w = line_w[i];
+        text_h += line_h[i];
+    }
+    float max_w = vp_w - CELL_PADDING * 4.0f;
+    if (box_w > max_w) box_w = max_w;
+    box_w += CELL_PADDING * 2;
+    float box_h = th + text_h + CELL_PADDING * 3;
+
+    /* Beside the cell where there is room, otherwise over it */
+    SDL_FRect cell = grid_cell_rect(slot, vp_w, vp_h);
+    float bx = cell.x + cell.w + CELL_PADDING;
+    if (bx + box_w > vp_w) bx = cell.x - box_w - CELL_PADDING;
+    if (bx < 0) bx = cell.x + (cell.w - box_w) / 2.0f;
+    if (bx < 0) bx = 0;
+    float by = cell.y;
+    if (by + box_h > vp_h) by = vp_h - box_h;
+    if (by < TOP_BAR_HEIGHT) by = TOP_BAR_HEIGHT;
+
+    SDL_FRect box = {bx, by, box_w, box_h};
+    theme_set_color(renderer, theme_get()->panel, 250);
+    SDL_RenderFillRect(renderer, &box);
+    theme_set_color(renderer, theme_get()->accent, 255);
+    SDL_RenderRect(renderer, &box);
+
+    SDL_FRect img = {bx + (box_w - tw) / 2.0f, by + CELL_PADDING, tw, th};
+    SDL_RenderTexture(renderer, tex, NULL, &img);
+
+    float y = by + th + CELL_PADDING * 2;
+    for (int i = 0; i < 3; i++) {
+        if (!lines[i]) continue;
+        float w = line_w[i] > box_w - CELL_PADDING * 2 ? box_w - CELL_PADDING * 2 : line_w[i];
+        SDL_FRect r = {bx + CELL_PADDING, y, w, line_h[i]};
+        SDL_RenderTexture(renderer, lines[i], NULL, &r);
+        SDL_DestroyTexture(lines[i]);
+        y += line_h[i];
+    }
+}
+
+static const CellMeta *cell_meta_get(int slot, int app_idx, const char *path) {
+    CellMeta *meta = &cell_meta[slot];
+    if (meta->app_idx != app_idx) {
+        xmp_read(path, &meta->xmp);
+        meta->has_sidecar = fileops_has_sidecar(path);
+        meta->app_idx = app_idx;
+    }
+    return meta;
+}
+
+/* Draw one badge at (*x, y) and advance *x past it */
+static void draw_badge(SDL_Renderer *renderer, const char *text, SDL_Color fill, SDL_Color fg,
+                       float *x, float y, float max_x) {
+    SDL_Surface *surf = TTF_RenderText_Blended(search_font, text, 0, fg);
+    if (!surf) return;
+
+    float w = surf->w + 8.0f;
+    float h = surf->h + 2.0f;
+    if (*x + w <= max_x) {
+        SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+        if (tex) {
+            theme_set_color(renderer, fill, 220);
+            SDL_FRect bg = {*x, y, w, h};
+            SDL_RenderFillRect(renderer, &bg);
+            SDL_FRect dst = {*x + 4.0f, y + 1.0f, (float)surf->w, (float)surf->h};
+            SDL_RenderTexture(renderer, tex, NULL, &dst);
+            SDL_DestroyTexture(tex);
+        }
+    }
+    *x += w + 3.0f;
+    SDL_DestroySurface(surf);
+}
+
+/* Culling badges in the top-left corner of a grid cell: burst (shot count
+   of a closed stack, or the shot's place in an open one), marked, star
+   rating (or rejected), tag count and "has edits" (a sidecar exists) */
+static void render_badges(SDL_Renderer *renderer, SDL_FRect cell, int app_idx, const CellMeta *meta,
+                          bool marked) {
+    const ThemePalette *pal = theme_get();
+    float x = cell.x + 4.0f;
+    float y = cell.y + 4.0f;
+    float max_x = cell.x + cell.w - 4.0f;
+    char text[32];
+
+    if (in_burst(app_idx)) {
+        int first = burst_first[app_idx];
+        if (first == expanded_burst) {
+            snprintf(text, sizeof(text), "%d/%d", app_idx - first + 1, burst_length[app_idx]);
+            draw_badge(renderer, text, pal->panel, pal->accent, &x, y, max_x);
+        } else {
+            snprintf(text, sizeof(text), "\xc3\x97%d", burst_length[app_idx]);
+            draw_badge(renderer, text, pal->accent, pal->background, &x, y, max_x);
+        }
+    }
+    if (marked) draw_badge(renderer, "\xe2\x9c\x93", pal->accent, pal->background, &x, y, max_x);
+    if (meta->xmp.rating < 0) {
+        draw_badge(renderer, "\xe2\x9c\x95", pal->panel, pal->text, &x, y, max_x);
+    } else if (meta->xmp.rating > 0) {
+        text[0] = '\0';
+        for (int i = 0; i < meta->xmp.rating; i++) strcat(text, "\xe2\x98\x85");
+        draw_badge(renderer, text, pal->panel, pal->accent, &x, y, max_x);
+    }
+    if (meta->xmp.tag_count > 0) {
+        snprintf(text, sizeof(text), "#%d", meta->xmp.tag_count);
+        draw_badge(renderer, text, pal->panel, pal->text, &x, y, max_x);
+    }
+    if (meta->has_sidecar) draw_badge(renderer, "\xe2\x9c\x8e", pal->panel, pal->text, &x, y, max_x);
+}
+
 void search_render(SDL_Renderer *renderer) {
     if (!active) return;
 
@@ -325,12 +1051,12 @@ void search_render(SDL_Renderer *renderer) {
 
     /* 1. Semi-transparent black background */
     SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
-    SDL_SetRenderDrawColor(renderer, 20, 20, 20, 240);
+    theme_set_color(renderer, theme_get()->panel, 240);
     SDL_FRect bg = {0, 0, (float)vp_w, (float)vp_h};
     SDL_RenderFillRect(renderer, &bg);
 
     /* 2. Top Bar (Search Box) styled like rename modal */
-    SDL_SetRenderDrawColor(renderer, 18, 18, 18, 255);
+    theme_set_color(renderer, theme_get()->panel, 255);
     SDL_FRect top_bar = {0, 0, (float)vp_w, (float)TOP_BAR_HEIGHT};
     SDL_RenderFillRect(renderer, &top_bar);
 
@@ -342,14 +1068,17 @@ void search_render(SDL_Renderer *renderer) {
     SDL_FRect input_rect = {input_x, input_y, input_w, input_h};
 
     /* Background of input box */
-    SDL_SetRenderDrawColor(renderer, 30, 30, 30, 255);
+    theme_set_color(renderer, theme_get()->input, 255);
     SDL_RenderFillRect(renderer, &input_rect);
 
     /* Glowing red border to show focus */
-    SDL_SetRenderDrawColor(renderer, 153, 0, 0, 255);
+    theme_set_color(renderer, theme_get()->accent, 255);
     SDL_RenderRect(renderer, &input_rect);
 
-    /* Draw search text query */
+    /* Draw search text query (re-rendered if the colour scheme changed) */
+    if (query_palette != theme_get()) {
+        rebuild_query_texture(renderer);
+    }
     if (query_texture) {
         SDL_FRect q_rect = {input_x + 12, input_y + (input_h - query_h) / 2.0f, (float)query_w, (float)query_h};
         SDL_RenderTexture(renderer, query_texture, NULL, &q_rect);
@@ -359,21 +1088,25 @@ void search_render(SDL_Renderer *renderer) {
     if ((SDL_GetTicks() / 500) % 2 == 0) {
         /* Calculate cursor position dynamically */
         char display_text[512];
-        snprintf(display_text, sizeof(display_text), "Search: %s", search_query);
+        snprintf(display_text, sizeof(display_text), "%s%s", query_prefix(), search_query);
         int text_size_w = 0;
         TTF_GetStringSize(search_font, display_text, 0, &text_size_w, NULL);
         
         float cursor_x = input_x + 12 + text_size_w;
         float text_y = input_y + (input_h - 18) / 2.0f;
         SDL_FRect cursor_rect = {cursor_x, text_y, 3, 18};
-        SDL_SetRenderDrawColor(renderer, 153, 0, 0, 255);
+        theme_set_color(renderer, theme_get()->accent, 255);
         SDL_RenderFillRect(renderer, &cursor_rect);
     }
 
     /* Info text on right of top bar inside input box */
     char info_text[64];
-    snprintf(info_text, sizeof(info_text), "%d matches", filtered_count);
-    SDL_Surface *info_surf = TTF_RenderText_Blended(search_font, info_text, 0, (SDL_Color){150, 150, 150, 255});
+    if (group_bursts && mode == SEARCH_MODE_GRID && burst_total > 0) {
+        snprintf(info_text, sizeof(info_text), "%d matches, %d bursts", filtered_count, burst_total);
+    } else {
+        snprintf(info_text, sizeof(info_text), "%d matches", filtered_count);
+    }
+    SDL_Surface *info_surf = TTF_RenderText_Blended(search_font, info_text, 0, theme_get()->text_dim);
     if (info_surf) {
         SDL_Texture *info_tex = SDL_CreateTextureFromSurface(renderer, info_surf);
         if (info_tex) {
@@ -384,13 +1117,16 @@ void search_render(SDL_Renderer *renderer) {
         SDL_DestroySurface(info_surf);
     }
 
-    /* 3. Render 5x5 Grid */
-    float grid_y = TOP_BAR_HEIGHT + CELL_PADDING;
-    float grid_h = vp_h - grid_y - CELL_PADDING;
-    float grid_w = vp_w - CELL_PADDING * 2;
+    if (mode == SEARCH_MODE_LIST) {
+        render_list(renderer, vp_w, vp_h);
+        SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+        return;
+    }
 
-    float cell_w = (grid_w - (GRID_COLS - 1) * CELL_PADDING) / GRID_COLS;
-    float cell_h = (grid_h - (GRID_ROWS - 1) * CELL_PADDING) / GRID_ROWS;
+    /* 3. Render 5x5 Grid */
+    SDL_FRect first_cell = grid_cell_rect(0, vp_w, vp_h);
+    float cell_w = first_cell.w;
+    float cell_h = first_cell.h;
 
     struct ImageCache *thumb_cache = viewer_get_thumb_cache(current_viewer);
 
@@ -404,24 +1140,29 @@ void search_render(SDL_Renderer *renderer) {
         const char *path = app_image_path(current_app, app_idx);
         if (!path) continue;
 
-        int row = i / GRID_COLS;
-        int col = i % GRID_COLS;
+        SDL_FRect cell_rect = grid_cell_rect(i, vp_w, vp_h);
+        float cx = cell_rect.x;
+        float cy = cell_rect.y;
 
-        float cx = CELL_PADDING + col * (cell_w + CELL_PADDING);
-        float cy = grid_y + row * (cell_h + CELL_PADDING);
-
-        SDL_FRect cell_rect = {cx, cy, cell_w, cell_h};
+        /* A closed burst stack gets the edges of the shots behind it */
+        if (in_burst(app_idx) && burst_first[app_idx] != expanded_burst) {
+            theme_set_color(renderer, theme_get()->border, 255);
+            for (int k = 2; k >= 1; k--) {
+                SDL_FRect edge = {cx + k * 3.0f, cy + k * 3.0f, cell_w, cell_h};
+                SDL_RenderRect(renderer, &edge);
+            }
+        }
 
         /* Draw cell border / background */
         if (item_idx == selected_item) {
-            SDL_SetRenderDrawColor(renderer, 153, 0, 0, 255); /* Selection Highlight Red */
+            theme_set_color(renderer, theme_get()->accent, 255); /* Selection Highlight Red */
             SDL_RenderRect(renderer, &cell_rect);
-            SDL_SetRenderDrawColor(renderer, 60, 10, 10, 255);
+            theme_set_color(renderer, theme_get()->selection, 255);
             SDL_RenderFillRect(renderer, &cell_rect);
         } else {
-            SDL_SetRenderDrawColor(renderer, 50, 50, 50, 255);
+            theme_set_color(renderer, theme_get()->border, 255);
             SDL_RenderRect(renderer, &cell_rect);
-            SDL_SetRenderDrawColor(renderer, 25, 25, 25, 255);
+            theme_set_color(renderer, theme_get()->surface, 255);
             SDL_RenderFillRect(renderer, &cell_rect);
         }
 
@@ -464,16 +1205,19 @@ void search_render(SDL_Renderer *renderer) {
             SDL_RenderTexture(renderer, tex, NULL, &dst_rect);
         } else {
             /* Render a simple gray placeholder if not cached yet */
-            SDL_SetRenderDrawColor(renderer, 70, 70, 70, 255);
+            theme_set_color(renderer, theme_get()->separator, 255);
             SDL_FRect placeholder = {cx + cell_w / 4.0f, cy + CELL_PADDING, cell_w / 2.0f, img_area_h};
             SDL_RenderFillRect(renderer, &placeholder);
         }
 
+        render_badges(renderer, cell_rect, app_idx, cell_meta_get(i, app_idx, path),
+                      app_is_marked(current_app, app_idx));
+
         /* Render filename below thumbnail */
         const char *name = strrchr(path, '/');
         name = name ? name + 1 : path;
 
-        SDL_Surface *name_surf = TTF_RenderText_Blended(search_font, name, 0, (SDL_Color){220, 220, 220, 255});
+        SDL_Surface *name_surf = TTF_RenderText_Blended(search_font, name, 0, theme_get()->text);
         if (name_surf) {
             SDL_Texture *name_tex = SDL_CreateTextureFromSurface(renderer, name_surf);
             if (name_tex) {
@@ -494,13 +1238,16 @@ void search_render(SDL_Renderer *renderer) {
         }
     }
 
+    /* 4. Enlarged preview of the hovered thumbnail */
+    if (hover_due()) {
+        render_hover_preview(renderer, vp_w, vp_h);
+        hover_shown = true;
+    }
+
     SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
 }
 
 void search_shutdown(void) {
     search_close(NULL);
-    if (search_font) {
-        TTF_CloseFont(search_font);
-        search_font = NULL;
-    }
+    search_font = NULL; /* owned by the overlay module */
 }
@@ -7,6 +7,12 @@
 struct AppState;
 struct Viewer;
 
+/* Layout of the search overlay */
+typedef enum {
+    SEARCH_MODE_GRID,     /* 5x5 thumbnail grid, substring filter */
+    SEARCH_MODE_LIST,     /* quick switcher: file names ranked by fuzzy match */
+} SearchMode;
+
 typedef enum {
     SEARCH_CONTINUE,
     SEARCH_SELECT,
@@ -16,8 +22,9 @@ typedef enum {
 /* Initialize search system */
 void search_init(void);
 
-/* Open search grid overlay */
-void search_open(struct AppState *app, struct Viewer *viewer, SDL_Renderer *renderer, SDL_Window *window);
+/* Open the search overlay as a thumbnail grid or a quick-switcher list */
+void search_open(struct AppState *app, struct Viewer *viewer, SDL_Renderer *renderer, SDL_Window *window,
+                 SearchMode mode);
 
 /* Close search grid overlay */
 void search_close(SDL_Window *window);
@@ -25,6 +32,10 @@ void search_close(SDL_Window *window);
 /* Check if search grid is active */
 bool search_is_active(void);
 
+/* Filter the image list again after it changed while the overlay is open
+   (background folder scan). The selected position is kept. */
+void search_refresh(void);
+
 /* Handle events when search is active. Returns status. */
 SearchResult search_handle_event(const SDL_Event *event, SDL_Window *window);
 
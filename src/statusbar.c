@@ -0,0 +1,96 @@
+#include "statusbar.h"
+#include "app.h"
+#include "viewer.h"
+#include "overlay.h"
+#include "theme.h"
+#include "utils.h"
+#include <SDL3_ttf/SDL_ttf.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <sys/stat.h>
+
+#define BAR_PADDING 10.0f
+#define FIELD_GAP "      "
+
+static bool visible = false;
+
+void statusbar_set_visible(bool show)
+{
+    visible = show;
+}
+
+bool statusbar_is_visible(void)
+{
+    return visible;
+}
+
+int statusbar_height(void)
+{
+    return visible ? STATUSBAR_HEIGHT : 0;
+}
+
+/* Draw text vertically centred in bar, starting at x (or ending at x when
+   right-aligned). Returns its width. */
+static float draw_text(SDL_Renderer *renderer, TTF_Font *font, const char *text,
+                       const SDL_FRect *bar, float x, bool right)
+{
+    SDL_Surface *surf = TTF_RenderText_Blended(font, text, 0, theme_get()->text);
+    if (!surf) return 0.0f;
+    float w = (float)surf->w;
+    SDL_Texture *tex = SDL_CreateTextureFromSurface(renderer, surf);
+    if (tex) {
+        SDL_FRect dst = {right ? x - w : x, bar->y + (bar->h - surf->h) / 2.0f, w, (float)surf->h};
+        SDL_RenderTexture(renderer, tex, NULL, &dst);
+        SDL_DestroyTexture(tex);
+    }
+    SDL_DestroySurface(surf);
+    return w;
+}
+
+void statusbar_render(SDL_Renderer *renderer, struct AppState *app, struct Viewer *viewer)
+{
+    TTF_Font *font = overlay_ui_font();
+    if (!visible || !app || !viewer || !font) return;
+
+    int vp_w, vp_h;
+    if (!SDL_GetRenderOutputSize(renderer, &vp_w, &vp_h)) return;
+
+    const ThemePalette *pal = theme_get();
+    SDL_FRect bar = {0, (float)(vp_h - STATUSBAR_HEIGHT), (float)vp_w, (float)STATUSBAR_HEIGHT};
+    theme_set_color(renderer, pal->panel, 255);
+    SDL_RenderFillRect(renderer, &bar);
+    theme_set_color(renderer, pal->border, 255);
+    SDL_RenderLine(renderer, 0, bar.y, (float)vp_w, bar.y);
+
+    const char *path = app_current_path(app);
+    if (!path) return;
+
+    /* Left: position, dimensions and file size */
+    char left[160];
+    int n = snprintf(left, sizeof(left), "%d / %d", app_current_index(app), app_image_count(app));
+    int w, h;
+    if (viewer_get_dimensions(viewer, &w, &h) && n < (int)sizeof(left)) {
+        SDL_Surface *full = viewer_transformed_image(viewer);
+        if (full) {
+            n += snprintf(left + n, sizeof(left) - n, FIELD_GAP "%d \xc3\x97 %d", full->w, full->h);
+        } else {
+            n += snprintf(left + n, sizeof(left) - n, FIELD_GAP "loading\xe2\x80\xa6");
+        }
+    }
+    /* Read each time, so it follows saves (the bar is only drawn on change) */
+    struct stat st;
+    char *size = stat(path, &st) == 0 ? format_file_size((long long)st.st_size) : NULL;
+    if (size && n < (int)sizeof(left)) {
+        snprintf(left + n, sizeof(left) - n, FIELD_GAP "%s", size);
+    }
+    free(size);
+    draw_text(renderer, font, left, &bar, BAR_PADDING, false);
+
+    /* Right: zoom */
+    float zoom = viewer_get_zoom(viewer);
+    if (zoom > 0.0f) {
+        char right[32];
+        snprintf(right, sizeof(right), "%.0f%%", zoom * 100.0f);
+        draw_text(renderer, font, right, &bar, vp_w - BAR_PADDING, true);
+    }
+}
@@ -0,0 +1,28 @@
+#ifndef FRAME_STATUSBAR_H
+#define FRAME_STATUSBAR_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+struct AppState;
+struct Viewer;
+
+/* Height of the bar along the bottom of the window */
+#define STATUSBAR_HEIGHT 28
+
+/*
+ * Status bar: one line under the image (and under the filmstrip) with the
+ * position in the folder, the image dimensions, the file size and the zoom.
+ * Unlike the window title it stays visible in fullscreen.
+ */
+
+void statusbar_set_visible(bool visible);
+bool statusbar_is_visible(void);
+
+/* Pixels the bar takes from the bottom of the window (0 when hidden) */
+int statusbar_height(void);
+
+/* Draw the bar over the bottom of the window. */
+void statusbar_render(SDL_Renderer *renderer, struct AppState *app, struct Viewer *viewer);
+
+#endif /* FRAME_STATUSBAR_H */
@@ -0,0 +1,167 @@
+#include "theme.h"
+#include "config.h"
+#include <stdio.h>
+#include <string.h>
+#include <strings.h>
+
+static const ThemePalette dark_palette = {
+    .background  = {30, 30, 30, 255},
+    .panel       = {15, 15, 15, 255},
+    .surface     = {25, 25, 25, 255},
+    .surface_alt = {32, 32, 32, 255},
+    .header      = {45, 45, 45, 255},
+    .input       = {30, 30, 30, 255},
+    .border      = {80, 80, 80, 255},
+    .separator   = {60, 60, 60, 255},
+    .text        = {235, 235, 235, 255},
+    .text_dim    = {150, 150, 150, 255},
+    .accent      = {153, 0, 0, 255},
+    .accent_text = {220, 50, 50, 255},
+    .selection   = {60, 10, 10, 255},
+};
+
+static const ThemePalette light_palette = {
+    .background  = {236, 236, 236, 255},
+    .panel       = {248, 248, 248, 255},
+    .surface     = {255, 255, 255, 255},
+    .surface_alt = {242, 242, 242, 255},
+    .header      = {225, 225, 225, 255},
+    .input       = {255, 255, 255, 255},
+    .border      = {170, 170, 170, 255},
+    .separator   = {205, 205, 205, 255},
+    .text        = {30, 30, 30, 255},
+    .text_dim    = {100, 100, 100, 255},
+    .accent      = {180, 0, 0, 255},
+    .accent_text = {180, 20, 20, 255},
+    .selection   = {250, 215, 215, 255},
+};
+
+/* Backgrounds offered by theme_cycle_background, after the palette's own */
+static const struct {
+    const char *name;
+    SDL_Color color;
+} background_presets[] = {
+    {"black", {0, 0, 0, 255}},
+    {"gray",  {128, 128, 128, 255}},
+    {"white", {255, 255, 255, 255}},
+};
+#define PRESET_COUNT (int)(sizeof(background_presets) / sizeof(background_presets[0]))
+
+static const ThemePalette *current = &dark_palette;
+
+/* The palette handed out: `current` with the background override applied */
+static ThemePalette active;
+static bool active_ready = false;
+static bool background_override = false;
+static SDL_Color background_color;
+static int background_step = 0;     /* 0 = palette, then presets, then custom */
+
+static bool same_color(SDL_Color a, SDL_Color b)
+{
+    return a.r == b.r && a.g == b.g && a.b == b.b;
+}
+
+static void update_active(void)
+{
+    if (!active_ready) {
+        /* Start from the `background` setting ("theme" or empty: none) */
+        SDL_Color color;
+        if (theme_parse_color(config_get()->background, &color)) {
+            background_override = true;
+            background_color = color;
+            background_step = PRESET_COUNT + 1;
+            for (int i = 0; i < PRESET_COUNT; i++) {
+                if (same_color(color, background_presets[i].color)) background_step = i + 1;
+            }
+        }
+    }
+    active = *current;
+    if (background_override) active.background = background_color;
+    active_ready = true;
+}
+
+bool theme_refresh(void)
+{
+    const ThemePalette *next;
+
+    switch (config_get()->theme) {
+    case THEME_LIGHT:
+        next = &light_palette;
+        break;
+    case THEME_DARK:
+        next = &dark_palette;
+        break;
+    default:
+        /* Unknown (no portal, older desktops) keeps Frame's dark look */
+        next = SDL_GetSystemTheme() == SDL_SYSTEM_THEME_LIGHT ? &light_palette : &dark_palette;
+        break;
+    }
+
+    bool changed = next != current;
+    current = next;
+    update_active();
+    return changed;
+}
+
+const ThemePalette *theme_get(void)
+{
+    if (!active_ready) update_active();
+    return &active;
+}
+
+bool theme_parse_color(const char *text, SDL_Color *out)
+{
+    for (int i = 0; i < PRESET_COUNT; i++) {
+        if (strcasecmp(text, background_presets[i].name) == 0) {
+            *out = background_presets[i].color;
+            return true;
+        }
+    }
+    if (strcasecmp(text, "grey") == 0) {
+        return theme_parse_color("gray", out);
+    }
+
+    unsigned int r, g, b;
+    char rest;
+    if (text[0] != '#' || strlen(text) != 7 ||
+        sscanf(text + 1, "%2x%2x%2x%c", &r, &g, &b, &rest) != 3) {
+        return false;
+    }
+    *out = (SDL_Color){(Uint8)r, (Uint8)g, (Uint8)b, 255};
+    return true;
+}
+
+void theme_set_background(const SDL_Color *color)
+{
+    background_override = color != NULL;
+    if (color) background_color = *color;
+    update_active();
+}
+
+const char *theme_cycle_background(void)
+{
+    /* The configured colour joins the cycle unless it is one of the presets */
+    SDL_Color custom;
+    const char *setting = config_get()->background;
+    bool has_custom = setting[0] == '#' && theme_parse_color(setting, &custom);
+    for (int i = 0; has_custom && i < PRESET_COUNT; i++) {
+        if (same_color(custom, background_presets[i].color)) has_custom = false;
+    }
+
+    background_step = (background_step + 1) % (PRESET_COUNT + (has_custom ? 2 : 1));
+    if (background_step == 0) {
+        theme_set_background(NULL);
+        return "theme";
+    }
+    if (background_step <= PRESET_COUNT) {
+        theme_set_background(&background_presets[background_step - 1].color);
+        return background_presets[background_step - 1].name;
+    }
+    theme_set_background(&custom);
+    return setting;
+}
+
+void theme_set_color(SDL_Renderer *renderer, SDL_Color color, Uint8 alpha)
+{
+    SDL_SetRenderDrawColor(renderer, color.r, color.g, color.b, alpha);
+}
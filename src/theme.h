@@ -0,0 +1,47 @@
+#ifndef FRAME_THEME_H
+#define FRAME_THEME_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+/* Colours used by the viewer and all overlays. */
+typedef struct {
+    SDL_Color background;   /* behind the image */
+    SDL_Color panel;        /* overlay and dialog backgrounds */
+    SDL_Color surface;      /* tables and grid cells */
+    SDL_Color surface_alt;  /* zebra stripes */
+    SDL_Color header;       /* table header rows */
+    SDL_Color input;        /* text input fields */
+    SDL_Color border;
+    SDL_Color separator;    /* lines inside tables, placeholders */
+    SDL_Color text;
+    SDL_Color text_dim;     /* hints and column headers */
+    SDL_Color accent;       /* focus borders, cursors, selection outline */
+    SDL_Color accent_text;  /* section titles */
+    SDL_Color selection;    /* selected cell fill */
+} ThemePalette;
+
+/* Pick the palette from the `theme` setting, asking the system (XDG
+   settings portal on Linux) when it is "system". Returns true if the
+   palette changed — call again on SDL_EVENT_SYSTEM_THEME_CHANGED. */
+bool theme_refresh(void);
+
+/* Get the active palette. Never returns NULL. */
+const ThemePalette *theme_get(void);
+
+/* Parse a background colour: "black", "gray" (or "grey"), "white" or
+   "#rrggbb". Returns false if the text is none of these. */
+bool theme_parse_color(const char *text, SDL_Color *out);
+
+/* Draw images on `color` instead of the palette's background, or on the
+   palette's again with NULL. Survives theme changes. */
+void theme_set_background(const SDL_Color *color);
+
+/* Switch to the next background: the palette's, black, gray, white and
+   the `background` setting if it is a custom colour. Returns its name. */
+const char *theme_cycle_background(void);
+
+/* Set the renderer draw colour from a palette entry with the given alpha. */
+void theme_set_color(SDL_Renderer *renderer, SDL_Color color, Uint8 alpha);
+
+#endif /* FRAME_THEME_H */
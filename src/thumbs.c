@@ -0,0 +1,202 @@
+#define _DEFAULT_SOURCE
+#include "thumbs.h"
+#include "loader.h"
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+#include <stdbool.h>
+
+/* Visible cells plus the rows around them (defensive limit) */
+#define MAX_THUMB_PATHS 128
+
+/* Enough threads that one slow file on a network share does not hold up
+   the rest of the grid */
+#define NUM_THUMB_WORKERS 4
+
+/* Each worker knows its slot in `busy` */
+typedef struct {
+    struct ThumbPool *tp;
+    int slot;
+} WorkerArg;
+
+struct ThumbPool {
+    ImageCache *thumb_cache;      /* borrowed, already mutex-protected */
+
+    /* --- request queue (protected by `mutex`) --- */
+    pthread_mutex_t mutex;
+    pthread_cond_t  cond;
+    char **queue;                 /* strdup'd paths, most urgent first */
+    int    queue_len;
+    int    queue_pos;             /* next index a worker will take */
+    char  *busy[NUM_THUMB_WORKERS]; /* path each worker is decoding, or NULL */
+    bool   shutdown;
+
+    pthread_t threads[NUM_THUMB_WORKERS];
+    WorkerArg args[NUM_THUMB_WORKERS];
+    int       thread_count;
+};
+
+/* Free all paths in the queue (caller must hold mutex). */
+static void clear_queue_locked(ThumbPool *tp)
+{
+    for (int i = 0; i < tp->queue_len; i++)
+        free(tp->queue[i]);
+    free(tp->queue);
+    tp->queue     = NULL;
+    tp->queue_len = 0;
+    tp->queue_pos = 0;
+}
+
+/* Check if another worker is already decoding path (caller must hold mutex). */
+static bool is_busy_locked(const ThumbPool *tp, const char *path)
+{
+    for (int i = 0; i < NUM_THUMB_WORKERS; i++) {
+        if (tp->busy[i] && strcmp(tp->busy[i], path) == 0)
+            return true;
+    }
+    return false;
+}
+
+/* Worker thread entry point. */
+static void *worker_func(void *arg)
+{
+    ThumbPool *tp = ((WorkerArg *)arg)->tp;
+    int slot = ((WorkerArg *)arg)->slot;
+
+    pthread_mutex_lock(&tp->mutex);
+    for (;;) {
+        while (!tp->shutdown && tp->queue_pos >= tp->queue_len)
+            pthread_cond_wait(&tp->cond, &tp->mutex);
+
+        if (tp->shutdown)
+            break;
+
+        /* Take ownership of the next path; the queue slot is left empty so a
+           later clear does not free it twice. */
+        char *path = tp->queue[tp->queue_pos];
+        tp->queue[tp->queue_pos] = NULL;
+        tp->queue_pos++;
+        if (!path || is_busy_locked(tp, path)) {
+            free(path);
+            continue;
+        }
+        tp->busy[slot] = path;
+        pthread_mutex_unlock(&tp->mutex);
+
+        /* Decode and scale without holding the queue mutex. A thumbnail that
+           finishes after the user scrolled on is still kept: it is small and
+           likely wanted again when they scroll back. */
+        if (!cache_get(tp->thumb_cache, path)) {
+            SDL_Surface *surface = loader_load_scaled(path, LOADER_THUMB_SIZE);
+            if (surface) {
+                SDL_Surface *thumb = loader_make_thumbnail(surface, LOADER_THUMB_SIZE);
+                SDL_DestroySurface(surface);
+                if (thumb)
+                    cache_put(tp->thumb_cache, path, thumb); /* cache takes ownership */
+            }
+        }
+
+        pthread_mutex_lock(&tp->mutex);
+        tp->busy[slot] = NULL;
+        free(path);
+    }
+
+    pthread_mutex_unlock(&tp->mutex);
+    return NULL;
+}
+
+ThumbPool *thumbs_create(ImageCache *thumb_cache)
+{
+    if (!thumb_cache)
+        return NULL;
+
+    ThumbPool *tp = calloc(1, sizeof(ThumbPool));
+    if (!tp)
+        return NULL;
+
+    tp->thumb_cache = thumb_cache;
+
+    if (pthread_mutex_init(&tp->mutex, NULL) != 0) {
+        free(tp);
+        return NULL;
+    }
+    if (pthread_cond_init(&tp->cond, NULL) != 0) {
+        pthread_mutex_destroy(&tp->mutex);
+        free(tp);
+        return NULL;
+    }
+
+    /* Run with however many threads could be started */
+    for (int i = 0; i < NUM_THUMB_WORKERS; i++) {
+        tp->args[i].tp = tp;
+        tp->args[i].slot = i;
+        if (pthread_create(&tp->threads[i], NULL, worker_func, &tp->args[i]) != 0)
+            break;
+        tp->thread_count++;
+    }
+    if (tp->thread_count == 0) {
+        pthread_cond_destroy(&tp->cond);
+        pthread_mutex_destroy(&tp->mutex);
+        free(tp);
+        return NULL;
+    }
+
+    return tp;
+}
+
+void thumbs_destroy(ThumbPool *tp)
+{
+    if (!tp)
+        return;
+
+    pthread_mutex_lock(&tp->mutex);
+    tp->shutdown = true;
+    pthread_cond_broadcast(&tp->cond);
+    pthread_mutex_unlock(&tp->mutex);
+
+    for (int i = 0; i < tp->thread_count; i++)
+        pthread_join(tp->threads[i], NULL);
+
+    clear_queue_locked(tp);  /* safe — workers have exited */
+
+    pthread_cond_destroy(&tp->cond);
+    pthread_mutex_destroy(&tp->mutex);
+    free(tp);
+}
+
+void thumbs_submit(ThumbPool *tp, const char **paths, int count)
+{
+    if (!tp)
+        return;
+
+    if (count < 0 || !paths)
+        count = 0;
+    if (count > MAX_THUMB_PATHS)
+        count = MAX_THUMB_PATHS;
+
+    /* Build the new queue outside the lock, leaving out finished thumbnails
+       (a cheap cache lookup) so workers only wake for real work. */
+    char **new_queue = NULL;
+    int copied = 0;
+    if (count > 0) {
+        new_queue = malloc((size_t)count * sizeof(char *));
+        if (!new_queue)
+            return;
+        for (int i = 0; i < count; i++) {
+            if (!paths[i] || cache_get(tp->thumb_cache, paths[i]))
+                continue;
+            new_queue[copied] = strdup(paths[i]);
+            if (new_queue[copied])
+                copied++;
+        }
+    }
+
+    pthread_mutex_lock(&tp->mutex);
+    clear_queue_locked(tp);
+    tp->queue     = new_queue;
+    tp->queue_len = copied;
+    tp->queue_pos = 0;
+    if (copied > 0)
+        pthread_cond_broadcast(&tp->cond);
+    pthread_mutex_unlock(&tp->mutex);
+}
@@ -0,0 +1,29 @@
+#ifndef FRAME_THUMBS_H
+#define FRAME_THUMBS_H
+
+#include "cache.h"
+
+/*
+ * Thumbnail worker pool for the search grid.
+ *
+ * Several threads decode images and put scaled-down copies into the thumbnail
+ * cache.  Work is taken strictly in the order of the last submitted list, so
+ * the cells on screen are done before the rows around them.  Each submit
+ * replaces the list: thumbnails queued for rows the user has scrolled away
+ * from are dropped before anything is read from disk.
+ */
+typedef struct ThumbPool ThumbPool;
+
+/* Create a pool and spawn its worker threads.
+   `thumb_cache` is borrowed — must outlive the pool. */
+ThumbPool *thumbs_create(ImageCache *thumb_cache);
+
+/* Drop the queue, join the workers and free the pool. */
+void thumbs_destroy(ThumbPool *tp);
+
+/* Replace the wanted thumbnails with `paths`, most urgent first.  Paths that
+   are cached or already being decoded are skipped.  The strings are copied.
+   Pass a count of 0 to cancel everything still queued. */
+void thumbs_submit(ThumbPool *tp, const char **paths, int count);
+
+#endif /* FRAME_THUMBS_H */
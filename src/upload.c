@@ -0,0 +1,240 @@
+#define _GNU_SOURCE
+#include "upload.h"
+#include "config.h"
+#include <SDL3/SDL.h>
+#include <ctype.h>
+#include <errno.h>
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/types.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+/* The file is passed to the command as $1 and the imgur client ID as $2 */
+#define CMD_0X0 "curl -fsS -F \"file=@$1\" https://0x0.st"
+#define CMD_IMGUR "curl -fsS -H \"Authorization: Client-ID $2\" " \
+                  "-F \"image=@$1\" https://api.imgur.com/3/image"
+
+/* Most output we look at for the URL */
+#define MAX_OUTPUT 65536
+
+static pthread_mutex_t upload_mutex = PTHREAD_MUTEX_INITIALIZER;
+static bool running = false;
+static bool finished = false;
+static bool result_ok = false;
+static char result[1024];
+
+typedef struct {
+    char *command;
+    char *path;
+    char *client_id;
+} UploadJob;
+
+static const char *upload_command(void)
+{
+    const FrameConfig *cfg = config_get();
+    switch (cfg->uploader) {
+    case UPLOADER_0X0:     return CMD_0X0;
+    case UPLOADER_IMGUR:   return CMD_IMGUR;
+    case UPLOADER_COMMAND: return cfg->upload_command[0] ? cfg->upload_command : NULL;
+    case UPLOADER_NONE:    break;
+    }
+    return NULL;
+}
+
+/* Find the uploaded image's URL in the command output: imgur's JSON "link"
+   field, otherwise the first word starting with http:// or https://. */
+static bool extract_url(const char *out, char *buf, size_t size)
+{
+    const char *link = strstr(out, "\"link\":\"");
+    if (link) {
+        link += 8;
+        size_t n = 0;
+        for (const char *p = link; *p && *p != '"' && n + 1 < size; p++) {
+            if (*p == '\\') continue; /* JSON escapes "\/" */
+            buf[n++] = *p;
+        }
+        buf[n] = '\0';
+        return n > 0;
+    }
+
+    for (const char *p = out; *p; p++) {
+        if (strncmp(p, "http://", 7) != 0 && strncmp(p, "https://", 8) != 0) continue;
+        if (p != out && !isspace((unsigned char)p[-1])) continue;
+        size_t n = 0;
+        while (p[n] && !isspace((unsigned char)p[n]) && n + 1 < size) {
+            buf[n] = p[n];
+            n++;
+        }
+        buf[n] = '\0';
+        return true;
+    }
+    return false;
+}
+
+/* Run the command and capture its standard output (NUL-terminated).
+   Returns the exit status, or -1 if it could not run. */
+static int run_capture(const char *command, const char *path, const char *client_id,
+                       char *out, size_t size)
+{
+    int fds[2];
+    if (pipe(fds) != 0) return -1;
+
+    pid_t pid = fork();
+    if (pid < 0) {
+        close(fds[0]);
+        close(fds[1]);
+        return -1;
+    }
+
+    if (pid == 0) {
+        dup2(fds[1], STDOUT_FILENO);
+        close(fds[0]);
+        close(fds[1]);
+        /* Only async-signal-safe calls here: other threads may hold locks */
+        execl("/bin/sh", "sh", "-c", command, "frame-upload", path, client_id, (char *)NULL);
+        _exit(127);
+    }
+
+    close(fds[1]);
+    size_t len = 0;
+    for (;;) {
+        char chunk[4096];
+        ssize_t n = read(fds[0], chunk, sizeof(chunk));
+        if (n < 0 && errno == EINTR) continue;
+        if (n <= 0) break;
+        /* Keep draining after the buffer is full so the child can't block */
+        size_t copy = (size_t)n < size - 1 - len ? (size_t)n : size - 1 - len;
+        memcpy(out + len, chunk, copy);
+        len += copy;
+    }
+    out[len] = '\0';
+    close(fds[0]);
+
+    int status = 0;
+    while (waitpid(pid, &status, 0) < 0) {
+        if (errno != EINTR) return -1;
+    }
+    return WIFEXITED(status) ? WEXITSTATUS(status) : -1;
+}
+
+static void *upload_thread(void *arg)
+{
+    UploadJob *job = arg;
+    char *out = malloc(MAX_OUTPUT);
+    char url[1024] = "";
+    bool ok = false;
+    const char *error = "Upload failed";
+
+    if (out) {
+        int status = run_capture(job->command, job->path, job->client_id, out, MAX_OUTPUT);
+        if (status == 0 && extract_url(out, url, sizeof(url))) {
+            ok = true;
+        } else if (status == 0) {
+            error = "Upload finished but no URL was returned";
+        } else if (status == 127) {
+            error = "Upload command not found";
+        }
+        if (!ok && out[0]) {
+            fprintf(stderr, "upload: %s\n", out);
+        }
+    }
+
+    pthread_mutex_lock(&upload_mutex);
+    result_ok = ok;
+    snprintf(result, sizeof(result), "%s", ok ? url : error);
+    running = false;
+    finished = true;
+    pthread_mutex_unlock(&upload_mutex);
+
+    /* Wake the main loop */
+    SDL_Event event;
+    memset(&event, 0, sizeof(event));
+    event.type = SDL_EVENT_USER;
+    SDL_PushEvent(&event);
+
+    free(out);
+    free(job->command);
+    free(job->path);
+    free(job->client_id);
+    free(job);
+    return NULL;
+}
+
+bool upload_is_configured(void)
+{
+    return upload_command() != NULL;
+}
+
+const char *upload_target_name(void)
+{
+    switch (config_get()->uploader) {
+    case UPLOADER_0X0:     return "0x0.st";
+    case UPLOADER_IMGUR:   return "imgur";
+    case UPLOADER_COMMAND: return "upload command";
+    case UPLOADER_NONE:    break;
+    }
+    return "nowhere";
+}
+
+bool upload_start(const char *path)
+{
+    const char *command = upload_command();
+    if (!command || !path) return false;
+
+    pthread_mutex_lock(&upload_mutex);
+    if (running) {
+        pthread_mutex_unlock(&upload_mutex);
+        return false;
+    }
+    running = true;
+    finished = false;
+    pthread_mutex_unlock(&upload_mutex);
+
+    UploadJob *job = calloc(1, sizeof(UploadJob));
+    if (job) {
+        job->command = strdup(command);
+        job->path = strdup(path);
+        job->client_id = strdup(config_get()->imgur_client_id);
+    }
+
+    pthread_t thread;
+    if (!job || !job->command || !job->path || !job->client_id ||
+        pthread_create(&thread, NULL, upload_thread, job) != 0) {
+        if (job) {
+            free(job->command);
+            free(job->path);
+            free(job->client_id);
+            free(job);
+        }
+        pthread_mutex_lock(&upload_mutex);
+        running = false;
+        pthread_mutex_unlock(&upload_mutex);
+        return false;
+    }
+    pthread_detach(thread);
+    return true;
+}
+
+bool upload_in_progress(void)
+{
+    pthread_mutex_lock(&upload_mutex);
+    bool busy = running;
+    pthread_mutex_unlock(&upload_mutex);
+    return busy;
+}
+
+bool upload_take_result(char *buf, size_t size, bool *ok)
+{
+    pthread_mutex_lock(&upload_mutex);
+    bool have = finished;
+    if (have) {
+        snprintf(buf, size, "%s", result);
+        *ok = result_ok;
+        finished = false;
+    }
+    pthread_mutex_unlock(&upload_mutex);
+    return have;
+}
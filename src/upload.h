@@ -0,0 +1,27 @@
+#ifndef FRAME_UPLOAD_H
+#define FRAME_UPLOAD_H
+
+#include <stdbool.h>
+#include <stddef.h>
+
+/* Check whether an uploader is configured (see `uploader` in config.h). */
+bool upload_is_configured(void);
+
+/* Get a short name for the configured destination ("0x0.st", "imgur", ...). */
+const char *upload_target_name(void);
+
+/* Start uploading a file in the background with the configured uploader.
+   Returns false if no uploader is set, another upload is running, or the
+   command could not be started. The main loop is woken with an
+   SDL_EVENT_USER event when it finishes. */
+bool upload_start(const char *path);
+
+/* Check whether an upload is still running. */
+bool upload_in_progress(void);
+
+/* Collect the outcome of a finished upload. Returns false if there is none.
+   On success *ok is true and buf holds the URL; otherwise buf holds a short
+   error message. */
+bool upload_take_result(char *buf, size_t size, bool *ok);
+
+#endif /* FRAME_UPLOAD_H */
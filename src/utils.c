@@ -1,8 +1,13 @@
 #include "utils.h"
+#include <errno.h>
+#include <fcntl.h>
+#include <pwd.h>
 #include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 #include <strings.h>
+#include <sys/stat.h>
+#include <unistd.h>
 
 char *format_file_size(long long bytes) {
     const long long KB = 1024;
@@ -24,24 +29,245 @@ char *format_file_size(long long bytes) {
     return buf;
 }
 
+/* Every format Frame opens. Extensions are matched case-insensitively
+   everywhere through this table. */
+const ImageFormat image_formats[] = {
+    { ".jpg",  "JPEG",         "image/jpeg" },
+    { ".jpeg", "JPEG",         "image/jpeg" },
+    { ".png",  "PNG",          "image/png" },
+    { ".gif",  "GIF",          "image/gif" },
+    { ".webp", "WebP",         "image/webp" },
+    { ".bmp",  "BMP",          "image/bmp" },
+    { ".tiff", "TIFF",         "image/tiff" },
+    { ".tif",  "TIFF",         "image/tiff" },
+    { ".ico",  "ICO",          "image/vnd.microsoft.icon" },
+    { ".apng", "APNG",         "image/apng" },
+    { ".hdr",  "Radiance HDR", "image/vnd.radiance" },
+    { NULL, NULL, NULL }
+};
+
+const ImageFormat *image_format_from_ext(const char *ext) {
+    if (!ext) return NULL;
+    for (int i = 0; image_formats[i].ext; i++) {
+        if (strcasecmp(ext, image_formats[i].ext) == 0) return &image_formats[i];
+    }
+    return NULL;
+}
+
+const ImageFormat *image_format_from_path(const char *path) {
+    if (!path) return NULL;
+    const char *name = strrchr(path, '/');
+    name = name ? name + 1 : path;
+    const char *dot = strrchr(name, '.');
+    if (!dot || dot == name) return NULL;
+    return image_format_from_ext(dot);
+}
+
+bool image_is_supported(const char *path) {
+    return image_format_from_path(path) != NULL;
+}
+
+/* For PNG, the chunks up to the image data are searched for acTL, which
+   makes it an APNG. */
+const char *image_sniff_data(const void *data, size_t n) {
+    const unsigned char *p = data;
+    if (n >= 3 && p[0] == 0xFF && p[1] == 0xD8 && p[2] == 0xFF)
+        return ".jpg";
+    if (n >= 8 && memcmp(p, "\x89PNG\r\n\x1a\n", 8) == 0) {
+        for (size_t off = 8; off + 8 <= n; ) {
+            size_t len = (size_t)p[off] << 24 | (size_t)p[off + 1] << 16 |
+                         (size_t)p[off + 2] << 8 | p[off + 3];
+            if (memcmp(p + off + 4, "acTL", 4) == 0)
+                return ".apng";
+            if (memcmp(p + off + 4, "IDAT", 4) == 0 || len > n)
+                break;
+            off += len + 12;
+        }
+        return ".png";
+    }
+    if (n >= 6 && (memcmp(p, "GIF87a", 6) == 0 || memcmp(p, "GIF89a", 6) == 0))
+        return ".gif";
+    if (n >= 12 && memcmp(p, "RIFF", 4) == 0 && memcmp(p + 8, "WEBP", 4) == 0)
+        return ".webp";
+    if (n >= 14 && p[0] == 'B' && p[1] == 'M')
+        return ".bmp";
+    if (n >= 4 && (memcmp(p, "II*\0", 4) == 0 || memcmp(p, "MM\0*", 4) == 0))
+        return ".tif";
+    if (n >= 6 && memcmp(p, "\0\0\1\0", 4) == 0 && (p[4] | p[5]) != 0)
+        return ".ico";
+    if ((n >= 10 && memcmp(p, "#?RADIANCE", 10) == 0) || (n >= 6 && memcmp(p, "#?RGBE", 6) == 0))
+        return ".hdr";
+    return NULL;
+}
+
+const char *image_sniff_file(const char *path) {
+    int fd = open(path, O_RDONLY);
+    if (fd < 0)
+        return NULL;
+
+    unsigned char buf[4096];
+    ssize_t n = read(fd, buf, sizeof(buf));
+    close(fd);
+    return n > 0 ? image_sniff_data(buf, (size_t)n) : NULL;
+}
+
+const char *image_format_ext(const char *path) {
+    const ImageFormat *fmt = image_format_from_path(path);
+    return fmt ? fmt->ext : image_sniff_file(path);
+}
+
 const char *format_from_ext(const char *ext) {
-    if (!ext) return "Unknown";
-
-    /* Case-insensitive comparison helper */
-    #define EXT_EQ(e, name) (strcasecmp(ext, e) == 0 ? name : NULL)
-
-    const char *result = NULL;
-    if ((result = EXT_EQ(".jpg", "JPEG"))) return result;
-    if ((result = EXT_EQ(".jpeg", "JPEG"))) return result;
-    if ((result = EXT_EQ(".png", "PNG"))) return result;
-    if ((result = EXT_EQ(".gif", "GIF"))) return result;
-    if ((result = EXT_EQ(".webp", "WebP"))) return result;
-    if ((result = EXT_EQ(".bmp", "BMP"))) return result;
-    if ((result = EXT_EQ(".tiff", "TIFF"))) return result;
-    if ((result = EXT_EQ(".tif", "TIFF"))) return result;
-    if ((result = EXT_EQ(".ico", "ICO"))) return result;
-    if ((result = EXT_EQ(".apng", "APNG"))) return result;
-    return "Unknown";
-
-    #undef EXT_EQ
+    const ImageFormat *fmt = image_format_from_ext(ext);
+    return fmt ? fmt->name : "Unknown";
+}
+
+const char *mime_from_ext(const char *ext) {
+    const ImageFormat *fmt = image_format_from_ext(ext);
+    return fmt ? fmt->mime : "application/octet-stream";
+}
+
+bool expand_home(const char *path, char *buf, size_t size) {
+    int ret;
+    const char *home = getenv("HOME");
+    if (home && path[0] == '~' && (path[1] == '\0' || path[1] == '/')) {
+        ret = snprintf(buf, size, "%s%s", home, path + 1);
+    } else {
+        ret = snprintf(buf, size, "%s", path);
+    }
+    return ret >= 0 && (size_t)ret < size;
+}
+
+const char *home_dir(void) {
+    const char *home = getenv("HOME");
+    if (home) return home;
+    struct passwd *pw = getpwuid(getuid());
+    return pw ? pw->pw_dir : NULL;
+}
+
+bool state_path(const char *leaf, char *buf, size_t size) {
+    const char *sep = leaf[0] ? "/" : "";
+    int ret;
+    const char *xdg = getenv("XDG_STATE_HOME");
+    if (xdg && xdg[0] == '/') {
+        ret = snprintf(buf, size, "%s/frame%s%s", xdg, sep, leaf);
+    } else {
+        const char *home = home_dir();
+        if (!home) return false;
+        ret = snprintf(buf, size, "%s/.local/state/frame%s%s", home, sep, leaf);
+    }
+    return ret > 0 && (size_t)ret < size;
+}
+
+bool make_dirs(const char *path) {
+    char buf[4096];
+    int ret = snprintf(buf, sizeof(buf), "%s", path);
+    if (ret <= 0 || (size_t)ret >= sizeof(buf)) {
+        errno = ENAMETOOLONG;
+        return false;
+    }
+    for (char *p = buf + 1; *p; p++) {
+        if (*p != '/') continue;
+        *p = '\0';
+        bool ok = mkdir(buf, 0700) == 0 || errno == EEXIST;
+        *p = '/';
+        if (!ok) return false;
+    }
+    return mkdir(buf, 0700) == 0 || errno == EEXIST;
+}
+
+char *base64_encode(const void *data, size_t len) {
+    static const char alphabet[] =
+        "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+    char *buf = malloc((len + 2) / 3 * 4 + 1);
+    if (!buf) return NULL;
+
+    const unsigned char *in = data;
+    char *out = buf;
+    size_t i = 0;
+    for (; i + 2 < len; i += 3) {
+        unsigned int v = (unsigned int)in[i] << 16 | (unsigned int)in[i + 1] << 8 | in[i + 2];
+        *out++ = alphabet[(v >> 18) & 63];
+        *out++ = alphabet[(v >> 12) & 63];
+        *out++ = alphabet[(v >> 6) & 63];
+        *out++ = alphabet[v & 63];
+    }
+    if (i < len) {
+        unsigned int v = (unsigned int)in[i] << 16;
+        if (i + 1 < len) v |= (unsigned int)in[i + 1] << 8;
+        *out++ = alphabet[(v >> 18) & 63];
+        *out++ = alphabet[(v >> 12) & 63];
+        *out++ = i + 1 < len ? alphabet[(v >> 6) & 63] : '=';
+        *out++ = '=';
+    }
+    *out = '\0';
+    return buf;
+}
+
+/* Length of the well-formed UTF-8 sequence starting at p (no overlong
+   forms, surrogates or code points past U+10FFFF), or 0 if it is not one. */
+static int utf8_sequence_length(const unsigned char *p) {
+    if (p[0] < 0x80) return 1;
+    int len;
+    unsigned int cp;
+    if ((p[0] & 0xE0) == 0xC0) {
+        len = 2;
+        cp = p[0] & 0x1F;
+    } else if ((p[0] & 0xF0) == 0xE0) {
+        len = 3;
+        cp = p[0] & 0x0F;
+    } else if ((p[0] & 0xF8) == 0xF0) {
+        len = 4;
+        cp = p[0] & 0x07;
+    } else {
+        return 0;
+    }
+    for (int i = 1; i < len; i++) {
+        if ((p[i] & 0xC0) != 0x80) return 0;  /* also stops at the terminator */
+        cp = cp << 6 | (p[i] & 0x3F);
+    }
+    static const unsigned int min_cp[5] = { 0, 0, 0x80, 0x800, 0x10000 };
+    if (cp < min_cp[len] || cp > 0x10FFFF || (cp >= 0xD800 && cp <= 0xDFFF)) return 0;
+    return len;
+}
+
+char *json_quote(const char *s) {
+    if (!s) s = "";
+
+    /* Worst case every byte becomes a 6-byte \u00XX escape */
+    size_t len = strlen(s);
+    char *buf = malloc(len * 6 + 3);
+    if (!buf) return NULL;
+
+    char *out = buf;
+    *out++ = '"';
+    for (const unsigned char *p = (const unsigned char *)s; *p; p++) {
+        switch (*p) {
+        case '"':  *out++ = '\\'; *out++ = '"'; break;
+        case '\\': *out++ = '\\'; *out++ = '\\'; break;
+        case '\n': *out++ = '\\'; *out++ = 'n'; break;
+        case '\r': *out++ = '\\'; *out++ = 'r'; break;
+        case '\t': *out++ = '\\'; *out++ = 't'; break;
+        default:
+            if (*p < 0x20) {
+                out += sprintf(out, "\\u%04x", *p);
+            } else {
+                /* File names need not be UTF-8: bytes that are not part of
+                   a valid sequence are escaped one by one, so the output
+                   is always valid JSON */
+                int n = utf8_sequence_length(p);
+                if (n == 0) {
+                    out += sprintf(out, "\\u%04x", *p);
+                } else {
+                    memcpy(out, p, (size_t)n);
+                    out += n;
+                    p += n - 1;
+                }
+            }
+            break;
+        }
+    }
+    *out++ = '"';
+    *out = '\0';
+    return buf;
 }
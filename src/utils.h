@@ -1,12 +1,76 @@
 #ifndef FRAME_UTILS_H
 #define FRAME_UTILS_H
 
+#include <stdbool.h>
+#include <stddef.h>
+
 /* Format a file size in bytes to a human-readable string.
    The returned string must be freed by the caller. */
 char *format_file_size(long long bytes);
 
+/* A supported image format */
+typedef struct {
+    const char *ext;    /* lower-case with the dot, e.g. ".jpg" */
+    const char *name;   /* e.g. "JPEG" */
+    const char *mime;   /* e.g. "image/jpeg" */
+} ImageFormat;
+
+/* All supported formats, ended by an entry whose ext is NULL */
+extern const ImageFormat image_formats[];
+
+/* Find the format for an extension such as ".JPG" (with the dot, any
+   case). Returns NULL if it is not supported. */
+const ImageFormat *image_format_from_ext(const char *ext);
+
+/* Same for the extension of a path's file name; NULL for names without
+   one ("photo", ".hidden"). */
+const ImageFormat *image_format_from_path(const char *path);
+
+/* Check if a file path has a supported image extension (any case). */
+bool image_is_supported(const char *path);
+
+/* Detect the format of an image from its first bytes (magic numbers), in
+   memory or read from a file. Returns the extension for it (".jpg",
+   ".apng", ...), or NULL if the content is not a supported image. */
+const char *image_sniff_data(const void *data, size_t size);
+const char *image_sniff_file(const char *path);
+
+/* Get the format of a file: its extension if that is supported (without
+   reading the file), otherwise the sniffed content. NULL if neither. */
+const char *image_format_ext(const char *path);
+
 /* Get the format name from a file extension (e.g. ".jpg" -> "JPEG").
    Returns a string literal — do not free. */
 const char *format_from_ext(const char *ext);
 
+/* Get the MIME type for a file extension (e.g. ".png" -> "image/png").
+   Returns a string literal — do not free. */
+const char *mime_from_ext(const char *ext);
+
+/* Copy a path into buf, replacing a leading "~" or "~/" with $HOME.
+   Returns false if it does not fit. */
+bool expand_home(const char *path, char *buf, size_t size);
+
+/* Get the home directory: $HOME, else the password database entry.
+   Returns NULL if neither is known. Do not free. */
+const char *home_dir(void);
+
+/* Build the path of `leaf` in Frame's state directory, $XDG_STATE_HOME/frame
+   or ~/.local/state/frame; an empty leaf gives the directory itself.
+   Returns false if there is no home directory or the path does not fit. */
+bool state_path(const char *leaf, char *buf, size_t size);
+
+/* Create a directory and any missing parents (mode 0700), like mkdir -p.
+   An existing directory is fine. Returns false, with errno set, on failure. */
+bool make_dirs(const char *path);
+
+/* Encode bytes as standard base64 with padding.
+   The returned string must be freed by the caller. Returns NULL on allocation failure. */
+char *base64_encode(const void *data, size_t len);
+
+/* Quote and escape a string as a JSON string literal (including the quotes).
+   Bytes that are not valid UTF-8 become \u00XX escapes.
+   The returned string must be freed by the caller. Returns NULL on allocation failure. */
+char *json_quote(const char *s);
+
 #endif /* FRAME_UTILS_H */
@@ -4,12 +4,45 @@
 #include "loader.h"
 #include "cache.h"
 #include "prefetch.h"
+#include "thumbs.h"
 #include "anim.h"
 #include "app.h"
+#include "theme.h"
+#include "filter.h"
+#include "loadjob.h"
 #include <stdlib.h>
 #include <stdio.h>
 #include <string.h>
 #include <stdint.h>
+#include <math.h>
+
+/* Rotation and zoom of the image on screen, for undo */
+typedef struct {
+    int rotation;
+    bool flip_h, flip_v;
+    float scale;
+    float offset_x, offset_y;
+} ViewState;
+
+#define VIEW_HISTORY_MAX 32
+#define VIEW_MERGE_MS 500
+
+/* Images over TILED_MIN_PIXELS (or too big for one texture) are drawn from
+   a pyramid: each level halves the one below until the longest side fits
+   OVERVIEW_SIZE. The top level is the ordinary texture; the others are cut
+   into TILE_SIZE tiles, uploaded only when they come into view. */
+#define TILED_MIN_PIXELS (48 * 1024 * 1024)
+#define OVERVIEW_SIZE 4096
+#define TILE_SIZE 1024
+#define MAX_TILE_LEVELS 8
+#define TILES_KEEP 64   /* tiles kept uploaded before off-screen ones go */
+
+typedef struct {
+    SDL_Surface *surface;
+    bool owned;                  /* level 0 may borrow the shown surface */
+    int cols, rows;
+    SDL_Texture **tiles;         /* cols * rows, NULL until first seen */
+} TileLevel;
 
 struct Viewer {
     SDL_Renderer *renderer;      /* borrowed */
@@ -20,6 +53,7 @@ struct Viewer {
     bool owns_original;          /* true if the viewer owns original and must free it */
     SDL_Surface *rotated;        /* cached rotated version */
     int rotation_degrees;        /* 0, 90, 180, 270 */
+    bool flip_h, flip_v;         /* mirrored after rotating */
 
     /* Zoom/Pan */
     float scale;                 /* 0.1 to 10.0 */
@@ -32,27 +66,109 @@ struct Viewer {
     bool needs_fit;              /* recompute fit on next render */
     bool is_animated;
 
+    /* Undo/redo of rotation and zoom for the image on screen */
+    ViewState undo[VIEW_HISTORY_MAX];
+    int undo_count;
+    ViewState redo[VIEW_HISTORY_MAX];
+    int redo_count;
+    Uint64 last_view_push;       /* for merging bursts of wheel zoom */
+
     /* Animation state */
     struct Animation *animation;
     int anim_frame;
     Uint64 anim_last_tick;
+    bool anim_paused;            /* frame stays put; the scrubber is shown */
 
     /* Cache for prefetching */
     struct ImageCache *cache;
     struct ImageCache *thumb_cache;
     struct Prefetcher *prefetcher;
+    struct ThumbPool *thumbs;    /* search grid thumbnails */
 
     /* Texture reuse size/format tracking */
     int texture_w, texture_h;
     SDL_PixelFormat texture_format;
 
+    /* Pyramid of a very large image (empty for ordinary ones) */
+    TileLevel tile_levels[MAX_TILE_LEVELS];
+    int tile_level_count;
+    int tiles_live;
+
     /* Thumbnail display tracking */
     char *current_path;
     bool showing_thumbnail;
+    bool load_failed;        /* decoding the current image failed, not reported yet */
+
+    /* Display-only pixel transforms (kept across images) */
+    ViewFilter filter;
+
+    /* Scaling filter and integer-zoom pixel-art mode (kept across images) */
+    InterpolationMode interpolation;
+    bool pixel_art;
+
+    /* How a newly shown image is scaled, and whether fitting may enlarge
+       it (kept across images) */
+    InitialView initial_view;
+    bool never_upscale;
+
+    /* Zoom range and the factor of one zoom in/out key press */
+    float zoom_min, zoom_max, zoom_step;
+
+    /* Background load of the current image (NULL when idle) */
+    LoadJob *loading;
+    Uint64 loading_since;
+
+    /* Exposure warning: clipped pixels blink over the image. The mask is
+       NULL while nothing is clipped. */
+    bool clip_warning;
+    SDL_Texture *clip_mask;
+    float clip_high, clip_low;   /* clipped fractions of the shown image */
+    bool clip_blink_on;
+
+    /* Blurred copy of the image filling the letterbox area */
+    bool ambient_enabled;
+    SDL_Texture *ambient;        /* built lazily on render, dropped on change */
+
+    /* Navigator in the corner while the image overflows the window */
+    bool minimap_enabled;
 };
 
+static void fit_to_viewport(Viewer *v);
+static void show_initial_view(Viewer *v);
+static void push_view(Viewer *v, bool merge);
+
 /* ---- internal helpers ---- */
 
+/* Texture scale mode for the current interpolation settings */
+static SDL_ScaleMode texture_scale_mode(const Viewer *v)
+{
+    if (v->pixel_art || v->interpolation == INTERP_NEAREST)
+        return SDL_SCALEMODE_NEAREST;
+    return SDL_SCALEMODE_LINEAR;
+}
+
+/* Next whole-number zoom step from scale in the given direction:
+   ... 1/3, 1/2, 1, 2, 3 ... */
+static float pixel_art_step(float scale, int dir)
+{
+    const float eps = 0.001f;
+    if (dir > 0) {
+        if (scale >= 1.0f - eps) return floorf(scale + eps) + 1.0f;
+        float n = ceilf(1.0f / scale - eps) - 1.0f;
+        return n <= 1.0f ? 1.0f : 1.0f / n;
+    }
+    if (scale > 1.0f + eps) return ceilf(scale - eps) - 1.0f;
+    return 1.0f / (floorf(1.0f / scale + eps) + 1.0f);
+}
+
+/* Largest whole-number zoom step not above scale */
+static float pixel_art_snap(float scale)
+{
+    const float eps = 0.001f;
+    if (scale >= 1.0f - eps) return floorf(scale + eps);
+    return 1.0f / ceilf(1.0f / scale - eps);
+}
+
 /* Rotate an SDL_Surface by 90, 180, or 270 degrees.
    Returns a new surface that the caller owns, or NULL on failure. */
 static SDL_Surface *rotate_surface(SDL_Surface *src, int degrees)
@@ -128,10 +244,235 @@ static SDL_Surface *rotate_surface(SDL_Surface *src, int degrees)
     return dst;
 }
 
+/* ---- tiled rendering ---- */
+
+static bool needs_tiles(const Viewer *v, int w, int h)
+{
+    int max_size = (int)SDL_GetNumberProperty(SDL_GetRendererProperties(v->renderer),
+                                              SDL_PROP_RENDERER_MAX_TEXTURE_SIZE_NUMBER, 0);
+    if (max_size > 0 && (w > max_size || h > max_size)) return true;
+    return (long long)w * h > TILED_MIN_PIXELS;
+}
+
+/* Size of the top pyramid level of a w x h image */
+static void overview_size(int *w, int *h)
+{
+    while (*w > OVERVIEW_SIZE || *h > OVERVIEW_SIZE) {
+        *w = *w / 2 > 1 ? *w / 2 : 1;
+        *h = *h / 2 > 1 ? *h / 2 : 1;
+    }
+}
+
+static void drop_tiles(Viewer *v)
+{
+    for (int i = 0; i < v->tile_level_count; i++) {
+        TileLevel *t = &v->tile_levels[i];
+        for (int j = 0; t->tiles && j < t->cols * t->rows; j++) {
+            SDL_DestroyTexture(t->tiles[j]);
+        }
+        free(t->tiles);
+        if (t->owned) SDL_DestroySurface(t->surface);
+        memset(t, 0, sizeof(*t));
+    }
+    v->tile_level_count = 0;
+    v->tiles_live = 0;
+}
+
+/* Build the pyramid over surface (taking it over if owned) and return the
+   top level, which is uploaded as the overview texture */
+static SDL_Surface *build_tile_levels(Viewer *v, SDL_Surface *surface, bool owned)
+{
+    SDL_Surface *level = surface;
+    while (v->tile_level_count < MAX_TILE_LEVELS) {
+        TileLevel *t = &v->tile_levels[v->tile_level_count++];
+        t->surface = level;
+        t->owned = owned;
+        if (level->w <= OVERVIEW_SIZE && level->h <= OVERVIEW_SIZE) break;
+
+        t->cols = (level->w + TILE_SIZE - 1) / TILE_SIZE;
+        t->rows = (level->h + TILE_SIZE - 1) / TILE_SIZE;
+        t->tiles = calloc((size_t)t->cols * t->rows, sizeof(*t->tiles));
+        int w = level->w, h = level->h;
+        w = w / 2 > 1 ? w / 2 : 1;
+        h = h / 2 > 1 ? h / 2 : 1;
+        level = SDL_ScaleSurface(level, w, h, SDL_SCALEMODE_LINEAR);
+        if (!t->tiles || !level) {
+            fprintf(stderr, "viewer: cannot build tiles: %s\n", SDL_GetError());
+            SDL_DestroySurface(level);
+            return NULL;
+        }
+        owned = true;
+    }
+    return v->tile_levels[v->tile_level_count - 1].surface;
+}
+
+static SDL_Texture *upload_tile(Viewer *v, SDL_Surface *s, int col, int row)
+{
+    int x = col * TILE_SIZE, y = row * TILE_SIZE;
+    int w = s->w - x < TILE_SIZE ? s->w - x : TILE_SIZE;
+    int h = s->h - y < TILE_SIZE ? s->h - y : TILE_SIZE;
+    SDL_Texture *tex = SDL_CreateTexture(v->renderer, s->format, SDL_TEXTUREACCESS_STATIC, w, h);
+    if (!tex) return NULL;
+    const uint8_t *pixels = (const uint8_t *)s->pixels + (size_t)y * s->pitch +
+                            (size_t)x * SDL_BYTESPERPIXEL(s->format);
+    SDL_UpdateTexture(tex, NULL, pixels, s->pitch);
+    SDL_SetTextureScaleMode(tex, texture_scale_mode(v));
+    return tex;
+}
+
+/* Window rectangle of a tile, for the image drawn at dst. Returns false if
+   it is outside the viewport. */
+static bool tile_rect(const Viewer *v, const TileLevel *t, const SDL_FRect *dst,
+                      int col, int row, SDL_FRect *out)
+{
+    float sx = dst->w / (float)t->surface->w;
+    float sy = dst->h / (float)t->surface->h;
+    int x1 = (col + 1) * TILE_SIZE < t->surface->w ? (col + 1) * TILE_SIZE : t->surface->w;
+    int y1 = (row + 1) * TILE_SIZE < t->surface->h ? (row + 1) * TILE_SIZE : t->surface->h;
+    out->x = dst->x + (float)(col * TILE_SIZE) * sx;
+    out->y = dst->y + (float)(row * TILE_SIZE) * sy;
+    out->w = dst->x + (float)x1 * sx - out->x;
+    out->h = dst->y + (float)y1 * sy - out->y;
+    return out->x + out->w > 0.0f && out->x < (float)v->viewport_w &&
+           out->y + out->h > 0.0f && out->y < (float)v->viewport_h;
+}
+
+/* Draw the image at dst from the smallest pyramid level that still has a
+   source pixel per screen pixel */
+static void render_tiles(Viewer *v, SDL_Renderer *renderer, const SDL_FRect *dst)
+{
+    int level = 0;
+    for (float s = v->scale * 2.0f; s <= 1.0f && level < v->tile_level_count - 1; s *= 2.0f) {
+        level++;
+    }
+    if (level == v->tile_level_count - 1) {
+        SDL_RenderTexture(renderer, v->texture, NULL, dst);
+        return;
+    }
+
+    /* Past the budget, let go of everything not on screen */
+    if (v->tiles_live > TILES_KEEP) {
+        for (int i = 0; i < v->tile_level_count; i++) {
+            TileLevel *t = &v->tile_levels[i];
+            for (int j = 0; t->tiles && j < t->cols * t->rows; j++) {
+                SDL_FRect r;
+                if (t->tiles[j] && (i != level || !tile_rect(v, t, dst, j % t->cols, j / t->cols, &r))) {
+                    SDL_DestroyTexture(t->tiles[j]);
+                    t->tiles[j] = NULL;
+                    v->tiles_live--;
+                }
+            }
+        }
+    }
+
+    TileLevel *t = &v->tile_levels[level];
+    for (int row = 0; row < t->rows; row++) {
+        for (int col = 0; col < t->cols; col++) {
+            SDL_FRect r;
+            if (!tile_rect(v, t, dst, col, row, &r)) continue;
+            SDL_Texture **tex = &t->tiles[row * t->cols + col];
+            if (!*tex) {
+                *tex = upload_tile(v, t->surface, col, row);
+                if (!*tex) continue;
+                v->tiles_live++;
+            }
+            SDL_RenderTexture(renderer, *tex, NULL, &r);
+        }
+    }
+}
+
+/* Pixel size of what is shown (the full image, also when it is tiled) */
+static void shown_size(const Viewer *v, float *w, float *h)
+{
+    if (v->tile_level_count > 0) {
+        *w = (float)v->tile_levels[0].surface->w;
+        *h = (float)v->tile_levels[0].surface->h;
+    } else {
+        SDL_GetTextureSize(v->texture, w, h);
+    }
+}
+
+static void update_scale_mode(Viewer *v)
+{
+    if (v->texture) {
+        SDL_SetTextureScaleMode(v->texture, texture_scale_mode(v));
+    }
+    for (int i = 0; i < v->tile_level_count; i++) {
+        TileLevel *t = &v->tile_levels[i];
+        for (int j = 0; t->tiles && j < t->cols * t->rows; j++) {
+            if (t->tiles[j]) SDL_SetTextureScaleMode(t->tiles[j], texture_scale_mode(v));
+        }
+    }
+}
+
+static void drop_texture(Viewer *v)
+{
+    SDL_DestroyTexture(v->texture);
+    v->texture = NULL;
+    drop_tiles(v);
+    v->texture_w = 0;
+    v->texture_h = 0;
+    v->texture_format = SDL_PIXELFORMAT_UNKNOWN;
+}
+
+static void drop_clip_mask(Viewer *v)
+{
+    SDL_DestroyTexture(v->clip_mask);
+    v->clip_mask = NULL;
+    v->clip_high = 0.0f;
+    v->clip_low = 0.0f;
+}
+
+/* Rebuild the exposure warning from what is shown (after view filters,
+   so raising the exposure reveals what it blows out) */
+static void update_clip_mask(Viewer *v, SDL_Surface *shown)
+{
+    drop_clip_mask(v);
+    if (!v->clip_warning) return;
+
+    SDL_Surface *mask = filter_clipping_mask(shown, &v->clip_high, &v->clip_low);
+    if (!mask) return;
+    if (needs_tiles(v, mask->w, mask->h)) {
+        /* Drawn over the whole image at once, so at overview size */
+        int w = mask->w, h = mask->h;
+        overview_size(&w, &h);
+        SDL_Surface *small = SDL_ScaleSurface(mask, w, h, SDL_SCALEMODE_NEAREST);
+        SDL_DestroySurface(mask);
+        mask = small;
+        if (!mask) return;
+    }
+    if (v->clip_high > 0.0f || v->clip_low > 0.0f) {
+        v->clip_mask = SDL_CreateTextureFromSurface(v->renderer, mask);
+        if (v->clip_mask) {
+            SDL_SetTextureScaleMode(v->clip_mask, SDL_SCALEMODE_NEAREST);
+        }
+    }
+    SDL_DestroySurface(mask);
+}
+
 static void update_texture_from_surface(Viewer *v, SDL_Surface *surface)
 {
     if (!v || !surface) return;
 
+    /* Upload a filtered copy if a view filter is active */
+    SDL_Surface *filtered = NULL;
+    if (!filter_is_identity(&v->filter)) {
+        filtered = filter_apply(surface, &v->filter);
+        if (filtered) surface = filtered;
+    }
+    update_clip_mask(v, surface);
+
+    /* Very large images get a pyramid; the texture holds its top level */
+    drop_tiles(v);
+    if (needs_tiles(v, surface->w, surface->h)) {
+        surface = build_tile_levels(v, surface, filtered != NULL);
+        filtered = NULL;
+        if (!surface) {
+            drop_texture(v);
+            return;
+        }
+    }
+
     if (v->texture && v->texture_w == surface->w && v->texture_h == surface->h && v->texture_format == surface->format) {
         SDL_UpdateTexture(v->texture, NULL, surface->pixels, surface->pitch);
     } else {
@@ -146,41 +487,115 @@ static void update_texture_from_surface(Viewer *v, SDL_Surface *surface)
             v->texture_h = surface->h;
             v->texture_format = surface->format;
             SDL_UpdateTexture(v->texture, NULL, surface->pixels, surface->pitch);
-            SDL_SetTextureScaleMode(v->texture, SDL_SCALEMODE_LINEAR);
+            SDL_SetTextureScaleMode(v->texture, texture_scale_mode(v));
         } else {
             v->texture_w = 0;
             v->texture_h = 0;
             v->texture_format = SDL_PIXELFORMAT_UNKNOWN;
         }
     }
+
+    SDL_DestroySurface(filtered);
+}
+
+/* Half period of the blinking exposure warning */
+#define CLIP_BLINK_MS 500
+
+/* Uncached images that take longer than this show a progress indicator */
+#define LOAD_INDICATOR_DELAY_MS 120
+
+/* Longest side of the ambient background before blurring */
+#define AMBIENT_SIZE 32
+#define AMBIENT_RADIUS 3
+
+/* One separable box blur pass over 4-byte pixels, edges clamped.
+   stride/step are in pixels: (1, w) blurs rows, (w, 1) blurs columns. */
+static void box_blur_pass(uint8_t *pix, uint8_t *tmp, int lines, int len,
+                          int line_stride, int step)
+{
+    for (int l = 0; l < lines; l++) {
+        uint8_t *base = pix + (size_t)l * line_stride * 4;
+        for (int i = 0; i < len; i++) {
+            int sum[4] = {0, 0, 0, 0};
+            for (int k = -AMBIENT_RADIUS; k <= AMBIENT_RADIUS; k++) {
+                int j = i + k;
+                if (j < 0) j = 0;
+                if (j >= len) j = len - 1;
+                const uint8_t *p = base + (size_t)j * step * 4;
+                for (int c = 0; c < 4; c++) sum[c] += p[c];
+            }
+            for (int c = 0; c < 4; c++) {
+                tmp[i * 4 + c] = (uint8_t)(sum[c] / (2 * AMBIENT_RADIUS + 1));
+            }
+        }
+        for (int i = 0; i < len; i++) {
+            memcpy(base + (size_t)i * step * 4, tmp + i * 4, 4);
+        }
+    }
+}
+
+/* Build the ambient background: a tiny, heavily blurred copy of src.
+   Working at AMBIENT_SIZE keeps this cheap even for huge images; the GPU
+   stretches it with linear filtering, which smooths it further. */
+static SDL_Surface *make_ambient_surface(SDL_Surface *src, const ViewFilter *filter)
+{
+    int w = AMBIENT_SIZE, h = AMBIENT_SIZE;
+    if (src->w >= src->h) {
+        h = src->h * AMBIENT_SIZE / src->w;
+    } else {
+        w = src->w * AMBIENT_SIZE / src->h;
+    }
+    if (w < 1) w = 1;
+    if (h < 1) h = 1;
+
+    SDL_Surface *small = SDL_ScaleSurface(src, w, h, SDL_SCALEMODE_LINEAR);
+    if (!small) return NULL;
+    SDL_Surface *out = filter_is_identity(filter) ? SDL_ConvertSurface(small, SDL_PIXELFORMAT_RGBA32)
+                                                  : filter_apply(small, filter);
+    SDL_DestroySurface(small);
+    if (!out) return NULL;
+
+    uint8_t tmp[AMBIENT_SIZE * 4];
+    int stride = out->pitch / 4;
+    for (int pass = 0; pass < 3; pass++) {
+        box_blur_pass(out->pixels, tmp, h, w, stride, 1);
+        box_blur_pass(out->pixels, tmp, w, h, 1, stride);
+    }
+    return out;
+}
+
+static void drop_ambient(Viewer *v)
+{
+    SDL_DestroyTexture(v->ambient);
+    v->ambient = NULL;
 }
 
-/* Create the rotated surface and upload to a texture.
+/* Create the rotated (and flipped) surface and upload to a texture.
    Frees old rotated surface first. Reuses the texture if size/format matches. */
 static void viewer_apply_rotation(Viewer *v)
 {
+    drop_tiles(v);
     SDL_DestroySurface(v->rotated);
     v->rotated = NULL;
+    drop_ambient(v);
 
     if (!v->original) {
-        if (v->texture) {
-            SDL_DestroyTexture(v->texture);
-            v->texture = NULL;
-            v->texture_w = 0;
-            v->texture_h = 0;
-            v->texture_format = SDL_PIXELFORMAT_UNKNOWN;
-        }
+        drop_clip_mask(v);
+        drop_texture(v);
         return;
     }
 
-    if (v->rotation_degrees == 0) {
+    if (v->rotation_degrees == 0 && !v->flip_h && !v->flip_v) {
         update_texture_from_surface(v, v->original);
-    } else {
-        v->rotated = rotate_surface(v->original, v->rotation_degrees);
-        if (v->rotated) {
-            update_texture_from_surface(v, v->rotated);
-        }
+        return;
     }
+
+    v->rotated = v->rotation_degrees ? rotate_surface(v->original, v->rotation_degrees)
+                                     : SDL_DuplicateSurface(v->original);
+    if (!v->rotated) return;
+    if (v->flip_h) SDL_FlipSurface(v->rotated, SDL_FLIP_HORIZONTAL);
+    if (v->flip_v) SDL_FlipSurface(v->rotated, SDL_FLIP_VERTICAL);
+    update_texture_from_surface(v, v->rotated);
 }
 
 /* Zoom from the center of the viewport by a given factor.
@@ -189,8 +604,8 @@ static void zoom_from_center(Viewer *v, float factor)
 {
     if (v->is_animated) return;
     float new_scale = v->scale * factor;
-    if (new_scale < 0.1f) new_scale = 0.1f;
-    if (new_scale > 10.0f) new_scale = 10.0f;
+    if (new_scale < v->zoom_min) new_scale = v->zoom_min;
+    if (new_scale > v->zoom_max) new_scale = v->zoom_max;
 
     float cx = v->viewport_w / 2.0f;
     float cy = v->viewport_h / 2.0f;
@@ -203,6 +618,49 @@ static void zoom_from_center(Viewer *v, float factor)
     v->scale = new_scale;
 }
 
+/* Cache a freshly decoded, viewer-owned original under path. Ownership moves
+   to the cache unless another thread cached the same image in the meantime. */
+static void cache_decoded(Viewer *v, const char *path)
+{
+    SDL_Surface *existing = cache_get(v->cache, path);
+    if (!existing) {
+        cache_put(v->cache, path, v->original);
+        if (cache_get(v->cache, path) == v->original) {
+            v->owns_original = false;
+        }
+    } else {
+        /* Already cached by prefetcher thread, keep our loaded copy as owned */
+        v->owns_original = true;
+    }
+}
+
+/* Check the loaded original against the size limit and upload it */
+static void show_original(Viewer *v)
+{
+    if (v->original->w > VIEWER_MAX_DIMENSION || v->original->h > VIEWER_MAX_DIMENSION) {
+        fprintf(stderr, "Image dimensions (%dx%d) exceed maximum (%d)\n",
+                v->original->w, v->original->h, VIEWER_MAX_DIMENSION);
+        if (v->owns_original) {
+            SDL_DestroySurface(v->original);
+        }
+        v->original = NULL;
+        v->owns_original = false;
+        return;
+    }
+
+    /* Create initial rotated surface and texture */
+    viewer_apply_rotation(v);
+}
+
+/* Drop a background load that is still running */
+static void cancel_loading(Viewer *v)
+{
+    if (v->loading) {
+        loadjob_cancel(v->loading);
+        v->loading = NULL;
+    }
+}
+
 /* ---- public API ---- */
 
 Viewer *viewer_create(SDL_Renderer *renderer)
@@ -213,13 +671,18 @@ Viewer *viewer_create(SDL_Renderer *renderer)
     }
     v->renderer = renderer;
     v->scale = 1.0f;
+    v->zoom_min = 0.1f;
+    v->zoom_max = 10.0f;
+    v->zoom_step = 1.05f;
     v->rotation_degrees = 0;
     v->needs_fit = true;
     v->offset_x = 0.0f;
     v->offset_y = 0.0f;
+    filter_init(&v->filter);
     v->cache = cache_create(50, 128 * 1024 * 1024);       /* 128 MB budget */
     v->thumb_cache = cache_create(500, 32 * 1024 * 1024);  /* 32 MB budget */
     v->prefetcher = prefetch_create(v->cache, v->thumb_cache);
+    v->thumbs = thumbs_create(v->thumb_cache);
     return v;
 }
 
@@ -228,6 +691,7 @@ void viewer_destroy(Viewer *v)
     if (!v) return;
     viewer_clear(v);
     prefetch_destroy(v->prefetcher);
+    thumbs_destroy(v->thumbs);
     cache_destroy(v->cache);
     cache_destroy(v->thumb_cache);
     free(v->current_path);
@@ -238,12 +702,16 @@ void viewer_load_image(Viewer *v, const char *path)
 {
     if (!v || !path) return;
 
+    cancel_loading(v);
     free(v->current_path);
     v->current_path = strdup(path);
     v->showing_thumbnail = false;
+    v->load_failed = false;
 
-    /* Pin the path in the cache so background prefetch worker won't evict it */
+    /* Pin the path in both caches so background prefetch and trimming
+       won't evict what is on screen (possibly a thumbnail) */
     cache_pin(v->cache, path);
+    cache_pin(v->thumb_cache, path);
 
     /* Free existing animation */
     if (v->animation) {
@@ -253,13 +721,19 @@ void viewer_load_image(Viewer *v, const char *path)
     v->is_animated = false;
 
     /* Reset state */
+    v->undo_count = 0;
+    v->redo_count = 0;
     v->rotation_degrees = 0;
+    v->flip_h = false;
+    v->flip_v = false;
     v->scale = 1.0f;
     v->offset_x = 0.0f;
     v->offset_y = 0.0f;
     v->needs_fit = true;
 
-    /* Free old surfaces, keeping texture for reuse */
+    /* Free old surfaces, keeping texture for reuse (tiles may point into
+       them, so those go) */
+    drop_tiles(v);
     SDL_DestroySurface(v->rotated);
     if (v->owns_original && v->original) {
         SDL_DestroySurface(v->original);
@@ -274,6 +748,7 @@ void viewer_load_image(Viewer *v, const char *path)
         if (v->animation) {
             v->anim_frame = 0;
             v->anim_last_tick = SDL_GetTicks();
+            v->anim_paused = false;
             /* Load first frame (before setting is_animated so zoom_fit works) */
             SDL_Surface *frame = anim_get_frame(v->animation, 0);
             if (frame) {
@@ -282,7 +757,7 @@ void viewer_load_image(Viewer *v, const char *path)
                     v->owns_original = true;
                     viewer_apply_rotation(v);
                     if (v->viewport_w > 0) {
-                        viewer_zoom_fit(v);
+                        show_initial_view(v);
                         v->needs_fit = false;
                     }
                 }
@@ -305,63 +780,84 @@ void viewer_load_image(Viewer *v, const char *path)
             v->original = thumb;
             v->owns_original = false;
             v->showing_thumbnail = true;
+        } else if ((thumb = loader_load_embedded_thumbnail(path)) != NULL) {
+            /* Show the EXIF preview right away and decode the full image in
+               the background; viewer_animation_tick() swaps it in when ready */
+            v->original = thumb;
+            v->owns_original = true;
+            v->showing_thumbnail = true;
+            prefetch_submit(v->prefetcher, &path, 1);
         } else {
-            /* Cache miss & thumbnail miss — load from file synchronously */
-            v->original = loader_load_static(path);
+            /* Cache miss & thumbnail miss — load on a background thread, but
+               wait briefly so ordinary images appear without an indicator */
+            v->loading = loadjob_start(path);
+            if (v->loading) {
+                v->loading_since = SDL_GetTicks();
+                while (!loadjob_poll(v->loading, NULL) &&
+                       SDL_GetTicks() - v->loading_since < LOAD_INDICATOR_DELAY_MS) {
+                    SDL_Delay(2);
+                }
+                if (!loadjob_poll(v->loading, NULL)) {
+                    /* Slow source: blank the view and show progress until
+                       viewer_animation_tick() picks up the result */
+                    drop_texture(v);
+                    drop_ambient(v);
+                    drop_clip_mask(v);
+                    return;
+                }
+                v->original = loadjob_finish(v->loading);
+                v->loading = NULL;
+            } else {
+                v->original = loader_load_static(path);
+            }
             if (!v->original) {
                 viewer_clear(v);
+                v->load_failed = true;
                 return;
             }
             v->owns_original = true;
-
-            /* Put into cache only if another thread hasn't cached it in the meantime.
-               If successfully cached, the cache takes ownership. */
-            SDL_Surface *existing = cache_get(v->cache, path);
-            if (!existing) {
-                cache_put(v->cache, path, v->original);
-                if (cache_get(v->cache, path) == v->original) {
-                    v->owns_original = false;
-                }
-            } else {
-                /* Already cached by prefetcher thread, keep our loaded copy as owned */
-                v->owns_original = true;
-            }
+            cache_decoded(v, path);
         }
     }
 
-    /* Check dimensions against maximum */
-    if (v->original->w > VIEWER_MAX_DIMENSION || v->original->h > VIEWER_MAX_DIMENSION) {
-        fprintf(stderr, "Image dimensions (%dx%d) exceed maximum (%d)\n",
-                v->original->w, v->original->h, VIEWER_MAX_DIMENSION);
-        if (v->owns_original) {
-            SDL_DestroySurface(v->original);
-        }
-        v->original = NULL;
-        v->owns_original = false;
-        return;
-    }
+    show_original(v);
+}
 
-    /* Create initial rotated surface and texture */
-    viewer_apply_rotation(v);
+void viewer_reload(Viewer *v)
+{
+    if (!v || !v->current_path) return;
+
+    char *path = strdup(v->current_path);
+    if (!path) return;
+
+    /* Drop stale copies first; viewer_load_image does not touch the
+       (cache-owned) original before replacing it. */
+    cache_invalidate(v->cache, path);
+    cache_invalidate(v->thumb_cache, path);
+    viewer_load_image(v, path);
+    free(path);
 }
 
 void viewer_clear(Viewer *v)
 {
     if (!v) return;
+    cancel_loading(v);
     cache_pin(v->cache, NULL);
-    SDL_DestroyTexture(v->texture);
-    v->texture = NULL;
-    v->texture_w = 0;
-    v->texture_h = 0;
-    v->texture_format = SDL_PIXELFORMAT_UNKNOWN;
+    cache_pin(v->thumb_cache, NULL);
+    drop_texture(v);
+    drop_ambient(v);
+    drop_clip_mask(v);
     if (v->owns_original && v->original) {
         SDL_DestroySurface(v->original);
     }
     v->original = NULL;
     v->owns_original = false;
+    v->showing_thumbnail = false;
     SDL_DestroySurface(v->rotated);
     v->rotated = NULL;
     v->rotation_degrees = 0;
+    v->flip_h = false;
+    v->flip_v = false;
     v->scale = 1.0f;
     v->offset_x = 0.0f;
     v->offset_y = 0.0f;
@@ -373,31 +869,214 @@ void viewer_clear(Viewer *v)
     v->is_animated = false;
 }
 
+/* Spinner and read progress bar for an image still loading */
+static void render_loading(Viewer *v, SDL_Renderer *renderer)
+{
+    const ThemePalette *pal = theme_get();
+    float cx = v->viewport_w / 2.0f;
+    float cy = v->viewport_h / 2.0f;
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+
+    /* 12 dots around a circle; the lit one moves every 80 ms */
+    const int dots = 12;
+    int lit = (int)((SDL_GetTicks() / 80) % (Uint64)dots);
+    for (int i = 0; i < dots; i++) {
+        float angle = (float)i * 2.0f * 3.14159265f / (float)dots;
+        int age = (lit - i + dots) % dots;
+        theme_set_color(renderer, pal->text, (Uint8)(255 - age * 18));
+        SDL_FRect dot = { cx + 20.0f * cosf(angle) - 3.0f, cy + 20.0f * sinf(angle) - 3.0f, 6.0f, 6.0f };
+        SDL_RenderFillRect(renderer, &dot);
+    }
+
+    /* Progress bar while the file is still being read */
+    float progress = 0.0f;
+    loadjob_poll(v->loading, &progress);
+    if (progress > 0.0f && progress < 1.0f) {
+        SDL_FRect track = { cx - 100.0f, cy + 44.0f, 200.0f, 4.0f };
+        theme_set_color(renderer, pal->border, 255);
+        SDL_RenderFillRect(renderer, &track);
+        track.w *= progress;
+        theme_set_color(renderer, pal->accent, 255);
+        SDL_RenderFillRect(renderer, &track);
+    }
+}
+
+/* Navigator geometry: the whole image in the top-right corner */
+#define MINIMAP_SIZE 160.0f
+#define MINIMAP_MARGIN 16.0f
+
+/* Where the navigator goes. Returns false while it is hidden: turned off,
+   or the whole image fits in the window. */
+static bool minimap_rect(const Viewer *v, SDL_FRect *out)
+{
+    if (!v->minimap_enabled || !v->texture || v->needs_fit) return false;
+    float w, h;
+    shown_size(v, &w, &h);
+    if (w * v->scale <= v->viewport_w + 0.5f && h * v->scale <= v->viewport_h + 0.5f) return false;
+
+    float s = MINIMAP_SIZE / (w > h ? w : h);
+    out->w = w * s;
+    out->h = h * s;
+    out->x = v->viewport_w - MINIMAP_MARGIN - out->w;
+    out->y = MINIMAP_MARGIN;
+    return out->x > 0.0f && out->y + out->h < (float)v->viewport_h;
+}
+
+/* The image in miniature with a frame around the part in the window */
+static void render_minimap(Viewer *v, SDL_Renderer *renderer)
+{
+    SDL_FRect r;
+    if (!minimap_rect(v, &r)) return;
+    const ThemePalette *pal = theme_get();
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+    SDL_FRect bg = { r.x - 4.0f, r.y - 4.0f, r.w + 8.0f, r.h + 8.0f };
+    theme_set_color(renderer, pal->panel, 200);
+    SDL_RenderFillRect(renderer, &bg);
+    SDL_RenderTexture(renderer, v->texture, NULL, &r);
+
+    float w, h;
+    shown_size(v, &w, &h);
+    float x0 = -v->offset_x / (w * v->scale);
+    float y0 = -v->offset_y / (h * v->scale);
+    float x1 = x0 + v->viewport_w / (w * v->scale);
+    float y1 = y0 + v->viewport_h / (h * v->scale);
+    x0 = x0 < 0.0f ? 0.0f : x0;
+    y0 = y0 < 0.0f ? 0.0f : y0;
+    x1 = x1 > 1.0f ? 1.0f : x1;
+    y1 = y1 > 1.0f ? 1.0f : y1;
+    SDL_FRect view = { r.x + x0 * r.w, r.y + y0 * r.h, (x1 - x0) * r.w, (y1 - y0) * r.h };
+    theme_set_color(renderer, pal->accent, 255);
+    SDL_RenderRect(renderer, &view);
+    SDL_FRect inner = { view.x + 1.0f, view.y + 1.0f, view.w - 2.0f, view.h - 2.0f };
+    SDL_RenderRect(renderer, &inner);
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+}
+
+/* Scrubber geometry: a thin track along the bottom of the window */
+#define SCRUBBER_MARGIN 24.0f
+#define SCRUBBER_HEIGHT 6.0f
+#define SCRUBBER_BOTTOM 18.0f
+#define SCRUBBER_HIT_SLOP 10.0f
+
+static SDL_FRect scrubber_rect(const Viewer *v)
+{
+    SDL_FRect r = { SCRUBBER_MARGIN, v->viewport_h - SCRUBBER_BOTTOM - SCRUBBER_HEIGHT,
+                    v->viewport_w - 2.0f * SCRUBBER_MARGIN, SCRUBBER_HEIGHT };
+    return r;
+}
+
+/* Timeline of a paused animation: elapsed part, frame ticks and a handle */
+static void render_scrubber(Viewer *v, SDL_Renderer *renderer)
+{
+    const ThemePalette *pal = theme_get();
+    int count = anim_frame_count(v->animation);
+    SDL_FRect track = scrubber_rect(v);
+    if (count <= 1 || track.w <= 0.0f) return;
+
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_BLEND);
+    SDL_FRect bg = { track.x - 8.0f, track.y - 8.0f, track.w + 16.0f, track.h + 16.0f };
+    theme_set_color(renderer, pal->panel, 200);
+    SDL_RenderFillRect(renderer, &bg);
+
+    theme_set_color(renderer, pal->border, 255);
+    SDL_RenderFillRect(renderer, &track);
+
+    float step = track.w / (float)(count - 1);
+    SDL_FRect done = { track.x, track.y, step * (float)v->anim_frame, track.h };
+    theme_set_color(renderer, pal->accent, 255);
+    SDL_RenderFillRect(renderer, &done);
+
+    /* Frame ticks, when they are far enough apart to tell apart */
+    if (step >= 4.0f) {
+        theme_set_color(renderer, pal->text, 96);
+        for (int i = 0; i < count; i++) {
+            SDL_FRect tick = { track.x + step * (float)i, track.y - 3.0f, 1.0f, 3.0f };
+            SDL_RenderFillRect(renderer, &tick);
+        }
+    }
+
+    SDL_FRect handle = { done.x + done.w - 3.0f, track.y - 4.0f, 6.0f, track.h + 8.0f };
+    theme_set_color(renderer, pal->text, 255);
+    SDL_RenderFillRect(renderer, &handle);
+    SDL_SetRenderDrawBlendMode(renderer, SDL_BLENDMODE_NONE);
+}
+
+/* Fill the window with the blurred, darkened image (scaled to cover) */
+static void render_ambient(Viewer *v, SDL_Renderer *renderer)
+{
+    if (!v->ambient) {
+        SDL_Surface *src = v->rotated ? v->rotated : v->original;
+        if (!src) return;
+        SDL_Surface *blurred = make_ambient_surface(src, &v->filter);
+        if (!blurred) return;
+        v->ambient = SDL_CreateTextureFromSurface(renderer, blurred);
+        SDL_DestroySurface(blurred);
+        if (!v->ambient) return;
+        SDL_SetTextureScaleMode(v->ambient, SDL_SCALEMODE_LINEAR);
+        SDL_SetTextureColorMod(v->ambient, 96, 96, 96);
+    }
+
+    float aw, ah;
+    SDL_GetTextureSize(v->ambient, &aw, &ah);
+    float sx = v->viewport_w / aw;
+    float sy = v->viewport_h / ah;
+    float s = sx > sy ? sx : sy;
+    SDL_FRect dst = { (v->viewport_w - aw * s) / 2.0f, (v->viewport_h - ah * s) / 2.0f,
+                      aw * s, ah * s };
+    SDL_RenderTexture(renderer, v->ambient, NULL, &dst);
+}
+
 void viewer_render(Viewer *v, SDL_Renderer *renderer)
 {
     if (!v) return;
 
     /* Clear with dark background */
-    SDL_SetRenderDrawColor(renderer, 30, 30, 30, 255);
+    theme_set_color(renderer, theme_get()->background, 255);
     SDL_RenderClear(renderer);
 
+    if (v->loading) {
+        render_loading(v, renderer);
+        return;
+    }
     if (!v->texture) return;
 
-    /* If needs_fit and we have a viewport, recompute fit */
+    /* If needs_fit and we have a viewport, recompute the initial view */
     if (v->needs_fit && v->viewport_w > 0 && v->viewport_h > 0) {
-        viewer_zoom_fit(v);
+        show_initial_view(v);
         v->needs_fit = false;
     }
 
-    /* Get texture size */
     float tex_w, tex_h;
-    SDL_GetTextureSize(v->texture, &tex_w, &tex_h);
+    shown_size(v, &tex_w, &tex_h);
 
     float w = tex_w * v->scale;
     float h = tex_h * v->scale;
 
+    if (v->ambient_enabled) {
+        render_ambient(v, renderer);
+    }
+
     SDL_FRect dst = { v->offset_x, v->offset_y, w, h };
-    SDL_RenderTexture(renderer, v->texture, NULL, &dst);
+    if (v->pixel_art) {
+        /* Whole-pixel placement keeps every source pixel the same size */
+        dst.x = floorf(dst.x);
+        dst.y = floorf(dst.y);
+    }
+    if (v->tile_level_count > 0) {
+        render_tiles(v, renderer, &dst);
+    } else {
+        SDL_RenderTexture(renderer, v->texture, NULL, &dst);
+    }
+    if (v->clip_mask && v->clip_blink_on) {
+        SDL_RenderTexture(renderer, v->clip_mask, NULL, &dst);
+    }
+
+    render_minimap(v, renderer);
+    if (v->is_animated && v->anim_paused) {
+        render_scrubber(v, renderer);
+    }
 }
 
 void viewer_handle_resize(Viewer *v, int new_w, int new_h)
@@ -428,16 +1107,18 @@ void viewer_prefetch_around(Viewer *v, struct AppState *app)
 
     int center = app_current_index(app) - 1; /* convert 1-based to 0-based */
 
-    /* Build nearest-first list: current first (if not cached), then +1, -1, +2, -2, … +5, -5 */
+    /* Build nearest-first list: current first (if not cached), then +1, -1, +2, -2, … +5, -5.
+       Over a network mount only the direct neighbours are read ahead. */
     const char *paths[11];
     int n = 0;
+    int radius = app_dir_is_remote(app) ? 1 : 5;
 
     const char *current_path = app_image_path(app, center);
-    if (current_path && !cache_get(v->cache, current_path)) {
+    if (current_path && !v->loading && !cache_get(v->cache, current_path)) {
         paths[n++] = current_path;
     }
 
-    for (int d = 1; d <= 5; d++) {
+    for (int d = 1; d <= radius; d++) {
         int fwd = center + d;
         if (fwd >= 0 && fwd < count)
             paths[n++] = app_image_path(app, fwd);
@@ -468,26 +1149,30 @@ bool viewer_is_thumb_cached(Viewer *v, const char *path)
 void viewer_zoom_in(Viewer *v)
 {
     if (!v) return;
-    zoom_from_center(v, 1.05f);
+    push_view(v, false);
+    zoom_from_center(v, v->pixel_art ? pixel_art_step(v->scale, 1) / v->scale : v->zoom_step);
 }
 
 void viewer_zoom_out(Viewer *v)
 {
     if (!v) return;
-    zoom_from_center(v, 1.0f / 1.05f);
+    push_view(v, false);
+    zoom_from_center(v, v->pixel_art ? pixel_art_step(v->scale, -1) / v->scale : 1.0f / v->zoom_step);
 }
 
 void viewer_scroll_zoom(Viewer *v, float mx, float my, float dy)
 {
     if (!v || v->is_animated) return;
+    push_view(v, true);
 
     float factor = 1.0f + dy * 0.01f;
     if (factor < 0.5f) factor = 0.5f;
     if (factor > 2.0f) factor = 2.0f;
 
-    float new_scale = v->scale * factor;
-    if (new_scale < 0.1f) new_scale = 0.1f;
-    if (new_scale > 10.0f) new_scale = 10.0f;
+    float new_scale = v->pixel_art ? pixel_art_step(v->scale, dy > 0 ? 1 : -1)
+                                   : v->scale * factor;
+    if (new_scale < v->zoom_min) new_scale = v->zoom_min;
+    if (new_scale > v->zoom_max) new_scale = v->zoom_max;
 
     /* Zoom toward mouse cursor */
     float img_x = (mx - v->offset_x) / v->scale;
@@ -501,6 +1186,12 @@ void viewer_scroll_zoom(Viewer *v, float mx, float my, float dy)
 void viewer_zoom_fit(Viewer *v)
 {
     if (!v || v->is_animated || v->viewport_w == 0 || v->viewport_h == 0) return;
+    push_view(v, false);
+    fit_to_viewport(v);
+}
+
+static void fit_to_viewport(Viewer *v)
+{
     SDL_Surface *ref = v->rotated ? v->rotated : v->original;
     if (!ref) return;
 
@@ -510,15 +1201,38 @@ void viewer_zoom_fit(Viewer *v)
     float scale_w = v->viewport_w / w;
     float scale_h = v->viewport_h / h;
     v->scale = (scale_w < scale_h) ? scale_w : scale_h;
+    if (v->never_upscale && v->scale > 1.0f) {
+        v->scale = 1.0f;
+    }
+    if (v->pixel_art) {
+        v->scale = pixel_art_snap(v->scale);
+    }
 
     v->offset_x = (v->viewport_w - w * v->scale) / 2.0f;
     v->offset_y = (v->viewport_h - h * v->scale) / 2.0f;
 }
 
+/* Scale a new image according to the initial_view setting */
+static void show_initial_view(Viewer *v)
+{
+    fit_to_viewport(v);
+    if (v->initial_view == INITIAL_VIEW_FIT ||
+        (v->initial_view == INITIAL_VIEW_SHRINK && v->scale <= 1.0f)) {
+        return;
+    }
+
+    /* 100%, centered both ways (a larger image shows its middle) */
+    SDL_Surface *ref = v->rotated ? v->rotated : v->original;
+    v->scale = 1.0f;
+    v->offset_x = (v->viewport_w - (float)ref->w) / 2.0f;
+    v->offset_y = (v->viewport_h - (float)ref->h) / 2.0f;
+}
+
 void viewer_zoom_original(Viewer *v)
 {
     SDL_Surface *ref = v->rotated ? v->rotated : v->original;
     if (!v || v->is_animated || !ref) return;
+    push_view(v, false);
 
     v->scale = 1.0f;
     float w = (float)ref->w;
@@ -529,11 +1243,57 @@ void viewer_zoom_original(Viewer *v)
     v->offset_y = (v->viewport_h > h) ? (v->viewport_h - h) / 2.0f : 0.0f;
 }
 
+void viewer_set_zoom_limits(Viewer *v, float min, float max, float step)
+{
+    if (!v || min <= 0.0f || max < min || step <= 1.0f) return;
+    v->zoom_min = min;
+    v->zoom_max = max;
+    v->zoom_step = step;
+}
+
+void viewer_set_initial_view(Viewer *v, InitialView view)
+{
+    if (!v) return;
+    v->initial_view = view;
+}
+
+void viewer_set_never_upscale(Viewer *v, bool enabled)
+{
+    if (!v) return;
+    v->never_upscale = enabled;
+}
+
+bool viewer_get_never_upscale(const Viewer *v)
+{
+    return v && v->never_upscale;
+}
+
+float viewer_get_zoom(const Viewer *v)
+{
+    return v && v->texture ? v->scale : 0.0f;
+}
+
+void viewer_get_offset(const Viewer *v, float *out_x, float *out_y)
+{
+    if (!v) return;
+    *out_x = v->offset_x;
+    *out_y = v->offset_y;
+}
+
+void viewer_set_zoom(Viewer *v, float scale)
+{
+    if (!v || v->is_animated || !v->original || scale <= 0.0f) return;
+    push_view(v, false);
+    if (v->pixel_art) scale = pixel_art_snap(scale);
+    zoom_from_center(v, scale / v->scale);
+}
+
 /* ---- Rotation ---- */
 
 void viewer_rotate(Viewer *v, bool clockwise)
 {
     if (!v || v->is_animated || !v->original) return;
+    push_view(v, false);
 
     if (clockwise) {
         v->rotation_degrees = (v->rotation_degrees + 90) % 360;
@@ -544,6 +1304,114 @@ void viewer_rotate(Viewer *v, bool clockwise)
     viewer_apply_rotation(v);
 }
 
+int viewer_get_rotation(const Viewer *v)
+{
+    return v ? v->rotation_degrees : 0;
+}
+
+void viewer_flip(Viewer *v, bool horizontal)
+{
+    if (!v || v->is_animated || !v->original) return;
+    push_view(v, false);
+    if (horizontal) {
+        v->flip_h = !v->flip_h;
+    } else {
+        v->flip_v = !v->flip_v;
+    }
+    viewer_apply_rotation(v);
+}
+
+void viewer_get_flip(const Viewer *v, bool *out_h, bool *out_v)
+{
+    *out_h = v && v->flip_h;
+    *out_v = v && v->flip_v;
+}
+
+void viewer_reset_rotation(Viewer *v)
+{
+    if (!v || (v->rotation_degrees == 0 && !v->flip_h && !v->flip_v)) return;
+    v->rotation_degrees = 0;
+    v->flip_h = false;
+    v->flip_v = false;
+    v->undo_count = 0;
+    v->redo_count = 0;
+    viewer_apply_rotation(v);
+}
+
+/* ---- View history ---- */
+
+static ViewState current_view(const Viewer *v)
+{
+    ViewState s = { v->rotation_degrees, v->flip_h, v->flip_v, v->scale, v->offset_x, v->offset_y };
+    return s;
+}
+
+static void append_view(ViewState *stack, int *count, ViewState s)
+{
+    if (*count == VIEW_HISTORY_MAX) {
+        memmove(stack, stack + 1, (VIEW_HISTORY_MAX - 1) * sizeof(ViewState));
+        (*count)--;
+    }
+    stack[(*count)++] = s;
+}
+
+/* Remember the view before a rotate or zoom. With merge set, changes less
+   than VIEW_MERGE_MS apart (wheel zoom) count as one step. */
+static void push_view(Viewer *v, bool merge)
+{
+    if (!v->original) return;
+    Uint64 now = SDL_GetTicks();
+    bool merged = merge && v->undo_count > 0 && now - v->last_view_push < VIEW_MERGE_MS;
+    v->last_view_push = now;
+    if (merged) return;
+
+    append_view(v->undo, &v->undo_count, current_view(v));
+    v->redo_count = 0;
+}
+
+static void restore_view(Viewer *v, ViewState s)
+{
+    if (s.rotation != v->rotation_degrees || s.flip_h != v->flip_h || s.flip_v != v->flip_v) {
+        v->rotation_degrees = s.rotation;
+        v->flip_h = s.flip_h;
+        v->flip_v = s.flip_v;
+        viewer_apply_rotation(v);
+    }
+    v->scale = s.scale;
+    v->offset_x = s.offset_x;
+    v->offset_y = s.offset_y;
+    v->needs_fit = false;
+}
+
+bool viewer_undo_view(Viewer *v)
+{
+    if (!v || v->undo_count == 0) return false;
+    append_view(v->redo, &v->redo_count, current_view(v));
+    restore_view(v, v->undo[--v->undo_count]);
+    v->last_view_push = 0;
+    return true;
+}
+
+bool viewer_redo_view(Viewer *v)
+{
+    if (!v || v->redo_count == 0) return false;
+    append_view(v->undo, &v->undo_count, current_view(v));
+    restore_view(v, v->redo[--v->redo_count]);
+    v->last_view_push = 0;
+    return true;
+}
+
+const char *viewer_get_path(const Viewer *v)
+{
+    return v ? v->current_path : NULL;
+}
+
+SDL_Surface *viewer_transformed_image(const Viewer *v)
+{
+    if (!v || v->loading || v->showing_thumbnail || v->is_animated) return NULL;
+    return v->rotated ? v->rotated : v->original;
+}
+
 /* ---- Pan (drag) ---- */
 
 void viewer_begin_drag(Viewer *v)
@@ -552,11 +1420,28 @@ void viewer_begin_drag(Viewer *v)
     /* Nothing to initialize — we track deltas, not absolute positions */
 }
 
+/* Move an offset by delta along an axis where the image is `size` wide in
+   a viewport of `view`: only when the image overflows it, and never so far
+   that a gap opens at an edge (an image already past that, after zooming
+   at the cursor, may still be dragged back) */
+static float drag_axis(float offset, float delta, float size, float view)
+{
+    if (size <= view) return offset;
+    float lo = view - size;
+    float hi = 0.0f;
+    if (offset < lo) lo = offset;
+    if (offset > hi) hi = offset;
+    float moved = offset + delta;
+    return moved < lo ? lo : moved > hi ? hi : moved;
+}
+
 void viewer_do_drag(Viewer *v, float dx, float dy)
 {
-    if (!v) return;
-    v->offset_x += dx;
-    v->offset_y += dy;
+    if (!v || !v->texture) return;
+    float tex_w, tex_h;
+    shown_size(v, &tex_w, &tex_h);
+    v->offset_x = drag_axis(v->offset_x, dx, tex_w * v->scale, (float)v->viewport_w);
+    v->offset_y = drag_axis(v->offset_y, dy, tex_h * v->scale, (float)v->viewport_h);
 }
 
 void viewer_end_drag(Viewer *v)
@@ -565,6 +1450,173 @@ void viewer_end_drag(Viewer *v)
     /* Nothing to clean up */
 }
 
+void viewer_pan_step(Viewer *v, int dir_x, int dir_y)
+{
+    if (!v) return;
+    /* Move the view by a tenth of the viewport; the image moves the opposite way */
+    v->offset_x -= (float)dir_x * (float)v->viewport_w * 0.1f;
+    v->offset_y -= (float)dir_y * (float)v->viewport_h * 0.1f;
+}
+
+/* ---- Interpolation ---- */
+
+void viewer_set_interpolation(Viewer *v, InterpolationMode mode)
+{
+    if (!v) return;
+    v->interpolation = mode;
+    update_scale_mode(v);
+}
+
+InterpolationMode viewer_get_interpolation(const Viewer *v)
+{
+    return v ? v->interpolation : INTERP_LINEAR;
+}
+
+void viewer_set_pixel_art(Viewer *v, bool enabled)
+{
+    if (!v) return;
+    v->pixel_art = enabled;
+    update_scale_mode(v);
+    if (enabled && v->original) {
+        zoom_from_center(v, pixel_art_snap(v->scale) / v->scale);
+    }
+}
+
+bool viewer_get_pixel_art(const Viewer *v)
+{
+    return v && v->pixel_art;
+}
+
+/* ---- Loading ---- */
+
+bool viewer_is_loading(const Viewer *v)
+{
+    return v && v->loading;
+}
+
+bool viewer_cancel_load(Viewer *v)
+{
+    if (!v || !v->loading) return false;
+    viewer_clear(v);
+    return true;
+}
+
+bool viewer_take_load_error(Viewer *v)
+{
+    if (!v || !v->load_failed) return false;
+    v->load_failed = false;
+    return true;
+}
+
+/* ---- Ambient background ---- */
+
+void viewer_set_ambient(Viewer *v, bool enabled)
+{
+    if (!v) return;
+    v->ambient_enabled = enabled;
+    if (!enabled) {
+        drop_ambient(v);
+    }
+}
+
+bool viewer_get_ambient(const Viewer *v)
+{
+    return v && v->ambient_enabled;
+}
+
+/* ---- Navigator ---- */
+
+void viewer_set_minimap(Viewer *v, bool enabled)
+{
+    if (v) v->minimap_enabled = enabled;
+}
+
+bool viewer_get_minimap(const Viewer *v)
+{
+    return v && v->minimap_enabled;
+}
+
+bool viewer_minimap_seek(Viewer *v, float x, float y, bool start)
+{
+    SDL_FRect r;
+    if (!v || !minimap_rect(v, &r)) return false;
+    if (start && (x < r.x || x >= r.x + r.w || y < r.y || y >= r.y + r.h)) return false;
+
+    /* Centre the view on the point under the mouse */
+    float fx = (x - r.x) / r.w;
+    float fy = (y - r.y) / r.h;
+    fx = fx < 0.0f ? 0.0f : fx > 1.0f ? 1.0f : fx;
+    fy = fy < 0.0f ? 0.0f : fy > 1.0f ? 1.0f : fy;
+    float w, h;
+    shown_size(v, &w, &h);
+    v->offset_x = v->viewport_w / 2.0f - fx * w * v->scale;
+    v->offset_y = v->viewport_h / 2.0f - fy * h * v->scale;
+    return true;
+}
+
+/* ---- View filters ---- */
+
+void viewer_set_filter(Viewer *v, const ViewFilter *filter)
+{
+    if (!v || !filter) return;
+    v->filter = *filter;
+    if (v->original) {
+        viewer_apply_rotation(v);
+    }
+}
+
+const ViewFilter *viewer_get_filter(const Viewer *v)
+{
+    return v ? &v->filter : NULL;
+}
+
+void viewer_set_clip_warning(Viewer *v, bool enabled)
+{
+    if (!v || v->clip_warning == enabled) return;
+    v->clip_warning = enabled;
+    v->clip_blink_on = true;
+    if (v->original) {
+        viewer_apply_rotation(v);
+    }
+}
+
+bool viewer_get_clip_warning(const Viewer *v)
+{
+    return v && v->clip_warning;
+}
+
+bool viewer_get_clip_stats(const Viewer *v, float *out_high, float *out_low)
+{
+    if (!v || !v->clip_warning || !v->texture) return false;
+    *out_high = v->clip_high;
+    *out_low = v->clip_low;
+    return true;
+}
+
+/* ---- Memory ---- */
+
+void viewer_get_memory_usage(const Viewer *v, ViewerMemoryUsage *out)
+{
+    if (!out) return;
+    memset(out, 0, sizeof(*out));
+    if (!v) return;
+    cache_get_usage(v->cache, &out->image_count, &out->image_bytes);
+    cache_get_usage(v->thumb_cache, &out->thumb_count, &out->thumb_bytes);
+}
+
+void viewer_trim_caches(Viewer *v, size_t image_bytes)
+{
+    if (!v) return;
+    cache_trim(v->cache, image_bytes);
+    cache_trim(v->thumb_cache, image_bytes / 4);
+}
+
+void viewer_set_cache_limit(Viewer *v, size_t max_bytes)
+{
+    if (!v) return;
+    cache_set_max_bytes(v->cache, max_bytes);
+}
+
 /* ---- Info ---- */
 
 bool viewer_get_dimensions(const Viewer *v, int *out_w, int *out_h)
@@ -580,6 +1632,27 @@ bool viewer_get_dimensions(const Viewer *v, int *out_w, int *out_h)
 
 /* ---- Animation ---- */
 
+/* Put animation frame anim_frame on screen. Returns true if it changed. */
+static bool show_anim_frame(Viewer *v)
+{
+    SDL_Surface *frame = anim_get_frame(v->animation, v->anim_frame);
+    if (!frame) return false;
+
+    if (v->owns_original && v->original) {
+        SDL_DestroySurface(v->original);
+    }
+    v->original = SDL_DuplicateSurface(frame);
+    v->owns_original = true;
+
+    /* Regenerate texture from the new frame, reusing it if possible */
+    if (v->rotation_degrees == 0 && !v->flip_h && !v->flip_v) {
+        update_texture_from_surface(v, v->original);
+    } else {
+        viewer_apply_rotation(v);
+    }
+    return true;
+}
+
 bool viewer_is_animated(const Viewer *v)
 {
     return v->is_animated;
@@ -591,18 +1664,53 @@ bool viewer_animation_tick(Viewer *v)
 
     bool dirty = false;
 
+    /* Blink the exposure warning */
+    if (v->clip_mask) {
+        bool on = (SDL_GetTicks() / CLIP_BLINK_MS) % 2 == 0;
+        if (on != v->clip_blink_on) {
+            v->clip_blink_on = on;
+            dirty = true;
+        }
+    }
+
+    /* Pick up a finished background load; keep the indicator moving otherwise */
+    if (v->loading) {
+        if (!loadjob_poll(v->loading, NULL)) {
+            return true;
+        }
+        v->original = loadjob_finish(v->loading);
+        v->loading = NULL;
+        if (!v->original) {
+            viewer_clear(v);
+            v->load_failed = true;
+            return true;
+        }
+        v->owns_original = true;
+        cache_decoded(v, v->current_path);
+        show_original(v);
+        return true;
+    }
+
     /* Check if we can swap the thumbnail for the full resolution image */
     if (v->showing_thumbnail && v->current_path) {
         SDL_Surface *full = cache_get(v->cache, v->current_path);
         if (full) {
+            if (v->owns_original && v->original) {
+                SDL_DestroySurface(v->original);
+            }
             v->original = full;
             v->owns_original = false;
             v->showing_thumbnail = false;
             viewer_apply_rotation(v);
             if (v->viewport_w > 0) {
-                viewer_zoom_fit(v);
+                show_initial_view(v);
             }
             dirty = true;
+        } else if (prefetch_failed(v->prefetcher, v->current_path)) {
+            /* Never leave the preview up as if it were the image */
+            viewer_clear(v);
+            v->load_failed = true;
+            return true;
         }
     }
 
@@ -611,7 +1719,7 @@ bool viewer_animation_tick(Viewer *v)
     }
 
     int count = anim_frame_count(v->animation);
-    if (count <= 1) return dirty;
+    if (count <= 1 || v->anim_paused) return dirty;
 
     int delay = anim_get_delay(v->animation, v->anim_frame);
     Uint64 now = SDL_GetTicks();
@@ -628,31 +1736,75 @@ bool viewer_animation_tick(Viewer *v)
         v->anim_frame = 0;
     }
 
-    /* Update the display with the new frame */
-    SDL_Surface *frame = anim_get_frame(v->animation, v->anim_frame);
-    if (frame) {
-        if (v->owns_original && v->original) {
-            SDL_DestroySurface(v->original);
-        }
-        v->original = SDL_DuplicateSurface(frame);
-        v->owns_original = true;
+    return show_anim_frame(v) || dirty;
+}
 
-        /* Regenerate texture from the new frame, reusing it if possible */
-        if (v->rotation_degrees == 0) {
-            update_texture_from_surface(v, v->original);
-        } else {
-            viewer_apply_rotation(v);
-        }
-        dirty = true;
+void viewer_anim_get_position(const Viewer *v, int *out_frame, int *out_count)
+{
+    bool anim = v && v->is_animated && v->animation;
+    if (out_frame) *out_frame = anim ? v->anim_frame : 0;
+    if (out_count) *out_count = anim ? anim_frame_count(v->animation) : 0;
+}
+
+bool viewer_anim_is_paused(const Viewer *v)
+{
+    return v && v->is_animated && v->anim_paused;
+}
+
+bool viewer_anim_toggle_pause(Viewer *v)
+{
+    if (!v || !v->is_animated || !v->animation) return false;
+    v->anim_paused = !v->anim_paused;
+    if (!v->anim_paused) {
+        /* Resume with the current frame's full delay */
+        v->anim_last_tick = SDL_GetTicks();
+    }
+    return v->anim_paused;
+}
+
+void viewer_anim_step(Viewer *v, int delta)
+{
+    if (!v || !v->is_animated || !v->animation) return;
+    int count = anim_frame_count(v->animation);
+    if (count <= 1) return;
+
+    v->anim_paused = true;
+    v->anim_frame = ((v->anim_frame + delta) % count + count) % count;
+    show_anim_frame(v);
+}
+
+bool viewer_anim_seek_at(Viewer *v, float x, float y)
+{
+    if (!v || !v->is_animated || !v->animation || !v->anim_paused) return false;
+    int count = anim_frame_count(v->animation);
+    SDL_FRect track = scrubber_rect(v);
+    if (count <= 1 || track.w <= 0.0f) return false;
+    if (y < track.y - SCRUBBER_HIT_SLOP || y > track.y + track.h + SCRUBBER_HIT_SLOP ||
+        x < track.x - SCRUBBER_HIT_SLOP || x > track.x + track.w + SCRUBBER_HIT_SLOP) {
+        return false;
     }
 
-    return dirty;
+    float t = (x - track.x) / track.w;
+    int frame = (int)(t * (float)(count - 1) + 0.5f);
+    if (frame < 0) frame = 0;
+    if (frame >= count) frame = count - 1;
+    if (frame != v->anim_frame) {
+        v->anim_frame = frame;
+        show_anim_frame(v);
+    }
+    return true;
+}
+
+SDL_Surface *viewer_current_frame(const Viewer *v)
+{
+    if (!v) return NULL;
+    return v->rotated ? v->rotated : v->original;
 }
 
 bool viewer_needs_tick(const Viewer *v)
 {
     if (!v) return false;
-    return v->is_animated || v->showing_thumbnail;
+    return v->is_animated || v->showing_thumbnail || v->loading || v->clip_mask;
 }
 
 struct ImageCache *viewer_get_thumb_cache(const Viewer *v)
@@ -661,9 +1813,9 @@ struct ImageCache *viewer_get_thumb_cache(const Viewer *v)
     return v->thumb_cache;
 }
 
-void viewer_prefetch_paths(Viewer *v, const char **paths, int count)
+void viewer_request_thumbnails(Viewer *v, const char **paths, int count)
 {
-    if (v && v->prefetcher) {
-        prefetch_submit(v->prefetcher, paths, count);
+    if (v && v->thumbs) {
+        thumbs_submit(v->thumbs, paths, count);
     }
 }
\ No newline at end of file
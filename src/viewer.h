@@ -3,12 +3,14 @@
 
 #include <SDL3/SDL.h>
 #include <stdbool.h>
+#include "config.h"
 
 typedef struct Viewer Viewer;
 struct AppState;
 
-/* Maximum image dimension to prevent OOM */
-#define VIEWER_MAX_DIMENSION 16384
+/* Maximum image dimension to prevent OOM (images this large are drawn
+   from tiles, see viewer.c) */
+#define VIEWER_MAX_DIMENSION 32768
 
 /* Create a viewer. The renderer is borrowed (not owned) — must outlive the viewer. */
 Viewer *viewer_create(SDL_Renderer *renderer);
@@ -20,6 +22,10 @@ void viewer_destroy(Viewer *v);
    Triggers fit-to-window if needs_fit is set. */
 void viewer_load_image(Viewer *v, const char *path);
 
+/* Reload the current image from disk, discarding cached copies
+   (after an external program changed the file). */
+void viewer_reload(Viewer *v);
+
 /* Clear the current image (shows dark background). */
 void viewer_clear(Viewer *v);
 
@@ -48,21 +54,127 @@ void viewer_zoom_out(Viewer *v);      /* -5% from center */
 void viewer_zoom_fit(Viewer *v);      /* fit to viewport, preserving aspect ratio */
 void viewer_zoom_original(Viewer *v); /* 1:1 pixel mapping */
 
+/* Choose how each newly shown image is scaled: fitted, fitted only when
+   larger than the window, or at 100% (default fitted). */
+void viewer_set_initial_view(Viewer *v, InitialView view);
+
+/* Keep fitting from enlarging images smaller than the window; they are
+   shown at 100%, centered. Takes effect on the next fit. */
+void viewer_set_never_upscale(Viewer *v, bool enabled);
+bool viewer_get_never_upscale(const Viewer *v);
+
+/* Set the zoom range (default 0.1 to 10.0) and the factor of one zoom in or
+   out step (default 1.05). Wheel and keyboard zoom stay within the range. */
+void viewer_set_zoom_limits(Viewer *v, float min, float max, float step);
+
+/* Current zoom factor (1.0 = 100%), 0 when nothing is shown. */
+float viewer_get_zoom(const Viewer *v);
+
+/* Window position of the image's top-left corner (it may be negative when
+   zoomed in). */
+void viewer_get_offset(const Viewer *v, float *out_x, float *out_y);
+
+/* Zoom to an exact factor (clamped to the zoom range) around the viewport
+   center. In pixel-art mode it snaps down to a whole-number step. */
+void viewer_set_zoom(Viewer *v, float scale);
+
 /* Zoom toward a specific point (mouse wheel zoom).
    mx, my: mouse position in window coordinates.
    dy > 0: zoom in, dy < 0: zoom out */
 void viewer_scroll_zoom(Viewer *v, float mx, float my, float dy);
 
+/* --- Interpolation --- */
+
+/* Set the filter used when the image is drawn scaled. */
+void viewer_set_interpolation(Viewer *v, InterpolationMode mode);
+InterpolationMode viewer_get_interpolation(const Viewer *v);
+
+/* Pixel-art mode: nearest-neighbour scaling with whole-number zoom steps
+   (1x, 2x, 3x ... or 1/2, 1/3 ...) so sprites and icons stay sharp.
+   Enabling it snaps the current zoom to the nearest integer step. */
+void viewer_set_pixel_art(Viewer *v, bool enabled);
+bool viewer_get_pixel_art(const Viewer *v);
+
+/* --- Loading --- */
+
+/* Uncached images that take more than a moment to read and decode are
+   loaded in the background while a progress indicator is shown. */
+bool viewer_is_loading(const Viewer *v);
+
+/* Cancel a background load, leaving the view empty.
+   Returns false if nothing was loading. */
+bool viewer_cancel_load(Viewer *v);
+
+/* Check whether the current image failed to decode (leaving the view
+   empty) since the last call. */
+bool viewer_take_load_error(Viewer *v);
+
+/* --- Ambient background --- */
+
+/* Fill the area around a fitted image with a blurred, darkened copy of the
+   image instead of the plain background colour. The blur is built once per
+   image (and after rotation or filter changes) and kept until it changes. */
+void viewer_set_ambient(Viewer *v, bool enabled);
+bool viewer_get_ambient(const Viewer *v);
+
+/* --- Navigator --- */
+
+/* Show the whole image in miniature in the top-right corner, framing the
+   part in the window, whenever the image does not fit the window. */
+void viewer_set_minimap(Viewer *v, bool enabled);
+bool viewer_get_minimap(const Viewer *v);
+
+/* Pan so the point of the navigator under window position (x, y) is in the
+   middle of the window. With start set (the button press) nothing happens
+   unless (x, y) is on the navigator; while dragging, positions outside it
+   pan to the nearest edge. Returns false if nothing was done. */
+bool viewer_minimap_seek(Viewer *v, float x, float y, bool start);
+
 /* --- Rotation --- */
 /* Rotate 90 degrees clockwise (true) or counter-clockwise (false).
    For animated images, rotation is ignored. */
 void viewer_rotate(Viewer *v, bool clockwise);
 
-/* --- Pan (drag) --- */
+/* Current rotation in degrees (0, 90, 180 or 270). It is reset whenever
+   another image is loaded. */
+int viewer_get_rotation(const Viewer *v);
+
+/* Mirror the image left to right (true) or top to bottom (false), after
+   the rotation; again to undo. Like rotation it is reset with another
+   image and ignored for animated ones. */
+void viewer_flip(Viewer *v, bool horizontal);
+void viewer_get_flip(const Viewer *v, bool *out_h, bool *out_v);
+
+/* Drop the rotation and flips and show the image upright again. */
+void viewer_reset_rotation(Viewer *v);
+
+/* Path of the image on screen, or NULL. */
+const char *viewer_get_path(const Viewer *v);
+
+/* The full-resolution image with the rotation and flips applied, for writing back to
+   disk. Borrowed; NULL while only a preview is shown or still loading. */
+SDL_Surface *viewer_transformed_image(const Viewer *v);
+
+/* --- View history --- */
+
+/* Step back or forward through rotate and zoom changes of the image on
+   screen (up to 32 steps, cleared when another image loads). A burst of
+   wheel zooming counts as one step. Return false if there is nothing to
+   undo or redo. */
+bool viewer_undo_view(Viewer *v);
+bool viewer_redo_view(Viewer *v);
+
+/* --- Pan (drag) ---
+   Dragging moves the image only along the axes where it is larger than
+   the viewport, and stops at its edges. */
 void viewer_begin_drag(Viewer *v);
 void viewer_do_drag(Viewer *v, float dx, float dy);
 void viewer_end_drag(Viewer *v);
 
+/* Keyboard pan: move the view one step (10% of the window) in the given
+   direction, e.g. (1, 0) shows more of the right side of the image. */
+void viewer_pan_step(Viewer *v, int dir_x, int dir_y);
+
 /* --- Info --- */
 /* Get the dimensions of the currently loaded image.
    Returns false if no image is loaded. */
@@ -76,13 +188,73 @@ bool viewer_is_animated(const Viewer *v);
    Returns true if the frame changed (caller should re-render). */
 bool viewer_animation_tick(Viewer *v);
 
+/* Current frame (0-based) and frame count of an animation; both 0 for
+   still images. */
+void viewer_anim_get_position(const Viewer *v, int *out_frame, int *out_count);
+
+/* Pause or resume playback. A paused animation shows a timeline scrubber
+   along the bottom of the window. Returns true if now paused. */
+bool viewer_anim_toggle_pause(Viewer *v);
+bool viewer_anim_is_paused(const Viewer *v);
+
+/* Pause and move delta frames forward or back, wrapping around. */
+void viewer_anim_step(Viewer *v, int delta);
+
+/* Seek to the frame under a click or drag at window position (x, y) if it
+   hits the scrubber. Returns false (doing nothing) otherwise. */
+bool viewer_anim_seek_at(Viewer *v, float x, float y);
+
+/* Get the surface currently on screen (the shown frame of an animation).
+   The surface is borrowed and only valid until the next viewer call. */
+SDL_Surface *viewer_current_frame(const Viewer *v);
+
 /* Check if the viewer needs active background ticking (for animation or thumbnail swap). */
 bool viewer_needs_tick(const Viewer *v);
 
 /* Get the thumbnail cache (for search grid to read cached thumbnails). */
 struct ImageCache *viewer_get_thumb_cache(const Viewer *v);
 
-/* Prefetch a specific list of image paths. */
-void viewer_prefetch_paths(Viewer *v, const char **paths, int count);
+/* Queue thumbnails for the search grid, most urgent first, replacing the
+   previous request (count 0 cancels it). Results land in the thumb cache. */
+void viewer_request_thumbnails(Viewer *v, const char **paths, int count);
+
+/* --- View filters --- */
+
+struct ViewFilter;
+
+/* Set the display-only filter (colour vision simulation, exposure, gamma). It applies to
+   every image shown until changed and never modifies files or the cache. */
+void viewer_set_filter(Viewer *v, const struct ViewFilter *filter);
+
+/* Get the active filter. */
+const struct ViewFilter *viewer_get_filter(const Viewer *v);
+
+/* Exposure warning: blink clipped highlights red and blocked shadows blue
+   over the image (after the view filter). Kept across images. */
+void viewer_set_clip_warning(Viewer *v, bool enabled);
+bool viewer_get_clip_warning(const Viewer *v);
+
+/* Fractions (0-1) of clipped highlights and shadows in the shown image.
+   Returns false when the warning is off or nothing is shown. */
+bool viewer_get_clip_stats(const Viewer *v, float *out_high, float *out_low);
+
+/* --- Memory --- */
+
+/* Memory held by the decoded-image and thumbnail caches. */
+typedef struct {
+    int image_count;
+    size_t image_bytes;
+    int thumb_count;
+    size_t thumb_bytes;
+} ViewerMemoryUsage;
+
+void viewer_get_memory_usage(const Viewer *v, ViewerMemoryUsage *out);
+
+/* Shrink the image cache to at most image_bytes (and the thumbnail cache to
+   a quarter of that). The displayed image is always kept. Pass 0 to clear. */
+void viewer_trim_caches(Viewer *v, size_t image_bytes);
+
+/* Set the image cache memory ceiling (0 = unlimited). */
+void viewer_set_cache_limit(Viewer *v, size_t max_bytes);
 
 #endif /* FRAME_VIEWER_H */
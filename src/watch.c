@@ -0,0 +1,115 @@
+#define _GNU_SOURCE
+#include "watch.h"
+#include "app.h"
+#include "utils.h"
+#include <errno.h>
+#include <limits.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/inotify.h>
+#include <unistd.h>
+
+/* Only finished files: written and closed, or renamed into place */
+#define WATCH_EVENTS (IN_CLOSE_WRITE | IN_MOVED_TO)
+
+static int inotify_fd = -1;
+static int watch_wd = -1;
+static char watch_dir[4096];
+
+/* Copy the directory part of path into buf. */
+static bool dir_of(const char *path, char *buf, size_t size)
+{
+    const char *slash = path ? strrchr(path, '/') : NULL;
+    if (!slash) return false;
+    size_t len = slash == path ? 1 : (size_t)(slash - path);
+    if (len >= size) return false;
+    memcpy(buf, path, len);
+    buf[len] = '\0';
+    return true;
+}
+
+/* Point the watch at dir (replacing any previous one). */
+static bool watch_dir_set(const char *dir)
+{
+    int wd = inotify_add_watch(inotify_fd, dir, WATCH_EVENTS | IN_ONLYDIR);
+    if (wd < 0) {
+        fprintf(stderr, "watch: cannot watch '%s': %s\n", dir, strerror(errno));
+        return false;
+    }
+    if (watch_wd >= 0 && watch_wd != wd) {
+        inotify_rm_watch(inotify_fd, watch_wd);
+    }
+    watch_wd = wd;
+    snprintf(watch_dir, sizeof(watch_dir), "%s", dir);
+    return true;
+}
+
+bool watch_start(const char *path)
+{
+    char dir[4096];
+    if (!dir_of(path, dir, sizeof(dir))) return false;
+
+    watch_stop();
+    inotify_fd = inotify_init1(IN_NONBLOCK | IN_CLOEXEC);
+    if (inotify_fd < 0) {
+        fprintf(stderr, "watch: inotify_init1: %s\n", strerror(errno));
+        return false;
+    }
+    if (!watch_dir_set(dir)) {
+        watch_stop();
+        return false;
+    }
+    return true;
+}
+
+void watch_stop(void)
+{
+    if (inotify_fd >= 0) {
+        close(inotify_fd); /* drops the watch as well */
+    }
+    inotify_fd = -1;
+    watch_wd = -1;
+    watch_dir[0] = '\0';
+}
+
+bool watch_is_active(void)
+{
+    return inotify_fd >= 0;
+}
+
+char *watch_poll(const char *current_path)
+{
+    if (inotify_fd < 0) return NULL;
+
+    /* Follow the viewer into another folder */
+    char dir[4096];
+    if (dir_of(current_path, dir, sizeof(dir)) && strcmp(dir, watch_dir) != 0) {
+        watch_dir_set(dir);
+    }
+
+    char *newest = NULL;
+    char buf[4096] __attribute__((aligned(__alignof__(struct inotify_event))));
+    for (;;) {
+        ssize_t len = read(inotify_fd, buf, sizeof(buf));
+        if (len <= 0) break; /* EAGAIN: nothing more queued */
+
+        for (char *p = buf; p < buf + len; ) {
+            const struct inotify_event *ev = (const struct inotify_event *)p;
+            p += sizeof(struct inotify_event) + ev->len;
+
+            /* Skip hidden files (partial downloads, editor temp files) */
+            if (ev->wd != watch_wd || ev->len == 0 || ev->name[0] == '.') continue;
+            if (!image_is_supported(ev->name) || !app_accepts_name(ev->name)) continue;
+
+            char full[4096];
+            int ret = snprintf(full, sizeof(full), "%s/%s",
+                               strcmp(watch_dir, "/") == 0 ? "" : watch_dir, ev->name);
+            if (ret < 0 || (size_t)ret >= sizeof(full)) continue;
+
+            free(newest);
+            newest = strdup(full);
+        }
+    }
+    return newest;
+}
@@ -0,0 +1,23 @@
+#ifndef FRAME_WATCH_H
+#define FRAME_WATCH_H
+
+#include <stdbool.h>
+
+/* Watch mode: follow new images as they appear in the folder being viewed
+   (tethered shooting, screenshot folders). Uses inotify. */
+
+/* Start watching the folder that contains `path`. Returns false on error. */
+bool watch_start(const char *path);
+
+/* Stop watching. Safe to call when not active. */
+void watch_stop(void);
+
+bool watch_is_active(void);
+
+/* Collect pending notifications. If the viewed folder changed (current_path
+   is in another directory) the watch moves there. Returns the path of the
+   newest supported image written or moved into the folder since the last
+   call, or NULL. The caller must free the returned string. */
+char *watch_poll(const char *current_path);
+
+#endif /* FRAME_WATCH_H */
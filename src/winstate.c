@@ -0,0 +1,120 @@
+#define _DEFAULT_SOURCE
+#include "winstate.h"
+#include "utils.h"
+#include <errno.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define DEFAULT_WIDTH 1200
+#define DEFAULT_HEIGHT 800
+#define MIN_SIZE 200
+#define MAX_SIZE 16384
+
+/* Last geometry seen while the window was neither maximized nor fullscreen */
+static WindowState normal = {
+    .width = DEFAULT_WIDTH,
+    .height = DEFAULT_HEIGHT,
+};
+
+bool winstate_load(WindowState *out)
+{
+    *out = normal;
+
+    char path[4096];
+    if (!state_path("window", path, sizeof(path))) return false;
+
+    FILE *fp = fopen(path, "r");
+    if (!fp) return false;
+
+    WindowState st = normal;
+    bool has_x = false, has_y = false;
+    char line[128];
+    while (fgets(line, sizeof(line), fp)) {
+        char key[32];
+        int value;
+        if (sscanf(line, " %31[a-z_] = %d", key, &value) != 2) continue;
+
+        if (strcmp(key, "width") == 0) st.width = value;
+        else if (strcmp(key, "height") == 0) st.height = value;
+        else if (strcmp(key, "x") == 0) { st.x = value; has_x = true; }
+        else if (strcmp(key, "y") == 0) { st.y = value; has_y = true; }
+        else if (strcmp(key, "maximized") == 0) st.maximized = value != 0;
+        else if (strcmp(key, "fullscreen") == 0) st.fullscreen = value != 0;
+    }
+    fclose(fp);
+
+    if (st.width < MIN_SIZE || st.width > MAX_SIZE ||
+        st.height < MIN_SIZE || st.height > MAX_SIZE) {
+        return false;
+    }
+    st.has_position = has_x && has_y;
+    *out = st;
+    normal = st;
+    return true;
+}
+
+void winstate_apply(SDL_Window *window, const WindowState *state)
+{
+    if (!window || !state) return;
+
+    /* Wayland does not let clients place windows; SDL ignores it there */
+    if (state->has_position) {
+        SDL_SetWindowPosition(window, state->x, state->y);
+    }
+    if (state->maximized) {
+        SDL_MaximizeWindow(window);
+    }
+    if (state->fullscreen) {
+        SDL_SetWindowFullscreen(window, true);
+    }
+}
+
+void winstate_track(SDL_Window *window)
+{
+    if (!window) return;
+    if (SDL_GetWindowFlags(window) & (SDL_WINDOW_MAXIMIZED | SDL_WINDOW_FULLSCREEN)) return;
+
+    int w, h, x, y;
+    if (SDL_GetWindowSize(window, &w, &h)) {
+        normal.width = w;
+        normal.height = h;
+    }
+    if (SDL_GetWindowPosition(window, &x, &y)) {
+        normal.x = x;
+        normal.y = y;
+        normal.has_position = true;
+    }
+}
+
+void winstate_save(SDL_Window *window)
+{
+    if (!window) return;
+    winstate_track(window);
+
+    SDL_WindowFlags flags = SDL_GetWindowFlags(window);
+
+    char dir[4096], path[4096];
+    if (!state_path("", dir, sizeof(dir)) ||
+        !state_path("window", path, sizeof(path))) {
+        return;
+    }
+    if (!make_dirs(dir)) {
+        fprintf(stderr, "winstate: cannot create '%s': %s\n", dir, strerror(errno));
+        return;
+    }
+
+    FILE *fp = fopen(path, "w");
+    if (!fp) {
+        fprintf(stderr, "winstate: cannot write '%s': %s\n", path, strerror(errno));
+        return;
+    }
+    fprintf(fp, "width = %d\nheight = %d\n", normal.width, normal.height);
+    if (normal.has_position) {
+        fprintf(fp, "x = %d\ny = %d\n", normal.x, normal.y);
+    }
+    fprintf(fp, "maximized = %d\nfullscreen = %d\n",
+            (flags & SDL_WINDOW_MAXIMIZED) ? 1 : 0,
+            (flags & SDL_WINDOW_FULLSCREEN) ? 1 : 0);
+    fclose(fp);
+}
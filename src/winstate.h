@@ -0,0 +1,32 @@
+#ifndef FRAME_WINSTATE_H
+#define FRAME_WINSTATE_H
+
+#include <SDL3/SDL.h>
+#include <stdbool.h>
+
+/* Window geometry remembered between runs */
+typedef struct {
+    int width, height;     /* size of the normal (not maximized) window */
+    bool has_position;
+    int x, y;
+    bool maximized;
+    bool fullscreen;
+} WindowState;
+
+/* Read the saved state from $XDG_STATE_HOME/frame/window (falling back to
+   ~/.local/state/frame/window). Fills out with defaults and returns false
+   if nothing usable was saved. */
+bool winstate_load(WindowState *out);
+
+/* Apply position, maximized and fullscreen state to a window created with
+   the saved size. */
+void winstate_apply(SDL_Window *window, const WindowState *state);
+
+/* Remember the normal window geometry. Call on move/resize events so that
+   the size from before maximizing or going fullscreen is what gets saved. */
+void winstate_track(SDL_Window *window);
+
+/* Save the current state. Errors are reported on stderr. */
+void winstate_save(SDL_Window *window);
+
+#endif /* FRAME_WINSTATE_H */
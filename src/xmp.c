@@ -0,0 +1,287 @@
+#define _GNU_SOURCE
+#include "xmp.h"
+#include <ctype.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <sys/stat.h>
+#include <unistd.h>
+
+/* Sidecars are small; embedded packets sit before the image data */
+#define XMP_SIDECAR_MAX (1024 * 1024)
+#define XMP_SCAN_BYTES (128 * 1024)
+
+/* Read up to `limit` bytes of a file into a NUL-terminated buffer */
+static char *read_head(const char *path, size_t limit, size_t *out_len)
+{
+    FILE *fp = fopen(path, "rb");
+    if (!fp) return NULL;
+
+    char *buf = malloc(limit + 1);
+    if (!buf) {
+        fclose(fp);
+        return NULL;
+    }
+    size_t len = fread(buf, 1, limit, fp);
+    fclose(fp);
+    buf[len] = '\0';
+    *out_len = len;
+    return buf;
+}
+
+/* Find the .xmp sidecar of path, in either naming style. Returns false if
+   there is none. */
+static bool find_sidecar(const char *path, char *buf, size_t size)
+{
+    static const char *exts[] = { ".xmp", ".XMP", NULL };
+    const char *slash = strrchr(path, '/');
+    const char *dot = strrchr(path, '.');
+    int stem = dot && (!slash || dot > slash + 1) ? (int)(dot - path) : (int)strlen(path);
+
+    struct stat st;
+    for (int i = 0; exts[i]; i++) {
+        int ret = snprintf(buf, size, "%s%s", path, exts[i]);
+        if (ret > 0 && (size_t)ret < size && stat(buf, &st) == 0) return true;
+        ret = snprintf(buf, size, "%.*s%s", stem, path, exts[i]);
+        if (ret > 0 && (size_t)ret < size && stat(buf, &st) == 0) return true;
+    }
+    return false;
+}
+
+/* Parse the integer value of a property written as an attribute
+   (xmp:Rating="3") or an element (<xmp:Rating>3</xmp:Rating>) */
+static bool property_int(const char *xmp, const char *name, int *out)
+{
+    const char *p = strstr(xmp, name);
+    while (p) {
+        const char *v = p + strlen(name);
+        while (*v == ' ') v++;
+        if (*v == '=') {
+            v++;
+            while (*v == ' ') v++;
+            if (*v == '"' || *v == '\'') v++;
+        } else if (*v == '>') {
+            v++;
+        } else {
+            p = strstr(v, name);
+            continue;
+        }
+        char *end = NULL;
+        long n = strtol(v, &end, 10);
+        if (end != v) {
+            *out = (int)n;
+            return true;
+        }
+        p = strstr(v, name);
+    }
+    return false;
+}
+
+/* Count the rdf:li entries of the dc:subject bag */
+static int count_subjects(const char *xmp)
+{
+    const char *start = strstr(xmp, "<dc:subject");
+    if (!start) return 0;
+    const char *end = strstr(start, "</dc:subject>");
+    if (!end) return 0;
+
+    int count = 0;
+    for (const char *p = strstr(start, "<rdf:li"); p && p < end; p = strstr(p + 7, "<rdf:li")) {
+        count++;
+    }
+    return count;
+}
+
+/* Find the value of a property written as an attribute (name="value") or
+   an element (<name>value</name>). Returns a pointer to it, or NULL. */
+static const char *property_value(const char *xmp, const char *name)
+{
+    size_t name_len = strlen(name);
+    for (const char *p = strstr(xmp, name); p; p = strstr(p + name_len, name)) {
+        const char *v = p + name_len;
+        if (p > xmp && p[-1] == '<' && *v == '>') return v + 1;
+        if (p > xmp && (p[-1] == ' ' || p[-1] == '\t' || p[-1] == '\n' || p[-1] == '\r') &&
+            v[0] == '=' && (v[1] == '"' || v[1] == '\'')) {
+            return v + 2;
+        }
+    }
+    return NULL;
+}
+
+/* Parse an XMP GPSCoordinate ("52,31.2004N" or "52,31,12N") into decimal
+   degrees, negative for S and W */
+static bool property_coord(const char *xmp, const char *name, double *out)
+{
+    const char *v = property_value(xmp, name);
+    if (!v) return false;
+
+    double parts[3] = {0};
+    int n = 0;
+    char *end = NULL;
+    while (n < 3) {
+        parts[n++] = strtod(v, &end);
+        if (end == v) return false;
+        v = end;
+        if (*v != ',') break;
+        v++;
+    }
+    if (*v != 'N' && *v != 'S' && *v != 'E' && *v != 'W') return false;
+
+    double value = parts[0] + parts[1] / 60.0 + parts[2] / 3600.0;
+    *out = (*v == 'S' || *v == 'W') ? -value : value;
+    return true;
+}
+
+bool xmp_read(const char *path, XmpInfo *out)
+{
+    memset(out, 0, sizeof(*out));
+    if (!path) return false;
+
+    char sidecar[4096];
+    size_t len = 0;
+    char *data = find_sidecar(path, sidecar, sizeof(sidecar))
+        ? read_head(sidecar, XMP_SIDECAR_MAX, &len)
+        : read_head(path, XMP_SCAN_BYTES, &len);
+    if (!data) return false;
+
+    /* Binary files may hold NULs before the packet, so search with memmem */
+    const char *packet = memmem(data, len, "<x:xmpmeta", 10);
+    if (!packet) {
+        free(data);
+        return false;
+    }
+
+    property_int(packet, "xmp:Rating", &out->rating);
+    if (out->rating > 5) out->rating = 5;
+    if (out->rating < -1) out->rating = -1;
+    out->tag_count = count_subjects(packet);
+    out->has_gps = property_coord(packet, "exif:GPSLatitude", &out->latitude) &&
+                   property_coord(packet, "exif:GPSLongitude", &out->longitude);
+    free(data);
+    return true;
+}
+
+/* Cut a property, attribute or element, out of xmp (in place) */
+static void remove_property(char *xmp, const char *name)
+{
+    const char *v;
+    while ((v = property_value(xmp, name)) != NULL) {
+        char *start = (char *)v - strlen(name) - 2;
+        char *end;
+        if (v[-1] != '>') start--;
+        /* Take the indentation with it */
+        while (start > xmp && isspace((unsigned char)start[-1])) start--;
+        if (v[-1] == '>') {
+            /* <name>value</name> */
+            char closing[64];
+            snprintf(closing, sizeof(closing), "</%s>", name);
+            end = strstr(v, closing);
+            if (!end) return;
+            end += strlen(closing);
+        } else {
+            /* name="value" */
+            end = strchr(v, v[-1]);
+            if (!end) return;
+            end++;
+        }
+        memmove(start, end, strlen(end) + 1);
+    }
+}
+
+/* Write text over path via a temporary file */
+static bool write_file(const char *path, const char *text)
+{
+    char tmp[4096];
+    int ret = snprintf(tmp, sizeof(tmp), "%s.frame-save", path);
+    if (ret < 0 || (size_t)ret >= sizeof(tmp)) return false;
+
+    FILE *fp = fopen(tmp, "w");
+    if (!fp) return false;
+    size_t len = strlen(text);
+    bool ok = fwrite(text, 1, len, fp) == len;
+    ok = fclose(fp) == 0 && ok;
+    if (!ok || rename(tmp, path) != 0) {
+        unlink(tmp);
+        return false;
+    }
+    return true;
+}
+
+/* Format decimal degrees as an XMP GPSCoordinate: "52,31.200480N" */
+static void format_coord(char *buf, size_t size, double value, char pos, char neg)
+{
+    double a = value < 0 ? -value : value;
+    int deg = (int)a;
+    snprintf(buf, size, "%d,%.6f%c", deg, (a - deg) * 60.0, value < 0 ? neg : pos);
+}
+
+bool xmp_write_gps(const char *path, bool set, double latitude, double longitude)
+{
+    if (!path) return false;
+    if (set && (latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180)) return false;
+
+    char lat[32], lon[32];
+    format_coord(lat, sizeof(lat), latitude, 'N', 'S');
+    format_coord(lon, sizeof(lon), longitude, 'E', 'W');
+
+    char sidecar[4096];
+    if (!find_sidecar(path, sidecar, sizeof(sidecar))) {
+        if (!set) return true;  /* nothing to remove */
+
+        /* New sidecar next to the image, named like Lightroom's (IMG_1.xmp) */
+        const char *slash = strrchr(path, '/');
+        const char *dot = strrchr(path, '.');
+        int stem = dot && (!slash || dot > slash + 1) ? (int)(dot - path) : (int)strlen(path);
+        int ret = snprintf(sidecar, sizeof(sidecar), "%.*s.xmp", stem, path);
+        if (ret < 0 || (size_t)ret >= sizeof(sidecar)) return false;
+
+        char text[1024];
+        snprintf(text, sizeof(text),
+                 "<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n"
+                 " <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n"
+                 "  <rdf:Description rdf:about=\"\"\n"
+                 "    xmlns:exif=\"http://ns.adobe.com/exif/1.0/\"\n"
+                 "   exif:GPSLatitude=\"%s\"\n"
+                 "   exif:GPSLongitude=\"%s\"/>\n"
+                 " </rdf:RDF>\n"
+                 "</x:xmpmeta>\n", lat, lon);
+        return write_file(sidecar, text);
+    }
+
+    size_t len = 0;
+    char *data = read_head(sidecar, XMP_SIDECAR_MAX, &len);
+    if (!data) return false;
+    remove_property(data, "exif:GPSLatitude");
+    remove_property(data, "exif:GPSLongitude");
+
+    bool ok = true;
+    if (set) {
+        /* New attributes go on the first rdf:Description */
+        char *desc = strstr(data, "<rdf:Description");
+        if (!desc) {
+            free(data);
+            return false;
+        }
+        char attrs[256];
+        snprintf(attrs, sizeof(attrs), "%s exif:GPSLatitude=\"%s\" exif:GPSLongitude=\"%s\"",
+                 strstr(data, "xmlns:exif=") ? "" : " xmlns:exif=\"http://ns.adobe.com/exif/1.0/\"",
+                 lat, lon);
+
+        size_t at = (size_t)(desc - data) + strlen("<rdf:Description");
+        size_t rest = strlen(data + at);
+        char *out = malloc(at + strlen(attrs) + rest + 1);
+        if (!out) {
+            free(data);
+            return false;
+        }
+        memcpy(out, data, at);
+        strcpy(out + at, attrs);
+        strcat(out + at, data + at);
+        ok = write_file(sidecar, out);
+        free(out);
+    } else {
+        ok = write_file(sidecar, data);
+    }
+    free(data);
+    return ok;
+}
@@ -0,0 +1,26 @@
+#ifndef FRAME_XMP_H
+#define FRAME_XMP_H
+
+#include <stdbool.h>
+
+/* The few XMP properties Frame shows, as written by photo managers
+   (Lightroom, darktable, digiKam) into a sidecar or the image itself. */
+typedef struct {
+    int rating;         /* xmp:Rating, 1-5; 0 if unrated, -1 if rejected */
+    int tag_count;      /* entries of dc:subject */
+    bool has_gps;       /* exif:GPSLatitude and exif:GPSLongitude are set */
+    double latitude;    /* decimal degrees, negative south */
+    double longitude;   /* decimal degrees, negative west */
+} XmpInfo;
+
+/* Read XMP from the .xmp sidecar of path (IMG_1.xmp or IMG_1.jpg.xmp) or,
+   without one, from the packet embedded near the start of the file.
+   Returns false if neither has XMP; *out is cleared either way. */
+bool xmp_read(const char *path, XmpInfo *out);
+
+/* Set the GPS coordinates (set = true) or remove them (set = false) in the
+   .xmp sidecar of path. A sidecar is created (IMG_1.xmp) when one is needed;
+   other properties of an existing one are kept. Returns false on error. */
+bool xmp_write_gps(const char *path, bool set, double latitude, double longitude);
+
+#endif /* FRAME_XMP_H */